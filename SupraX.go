@@ -1,8 +1,19 @@
 package suprax32
 
 import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/bits"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -172,6 +183,12 @@ const (
 	NumPhysRegs = 40 // INNOVATION #39: One physical register per window entry
 	NumArchRegs = 32 // What the programmer sees (r0-r31)
 
+	// Rename-stage move and zero-idiom elimination (see MOVE ELIMINATION)
+	MoveElimination = 0 // 1 = moves and zero idioms skip execution (opt-in)
+
+	// Mispredict recovery point (INNOVATION #48)
+	EarlyRecovery = 0 // 1 = repair at execute (opt-in), 0 = at commit
+
 	// INNOVATION #43: 6-wide issue (not 7)
 	//
 	// THE DECISION: We tested 5, 6, 7, and 8-wide issue:
@@ -216,6 +233,16 @@ const (
 	NumDIVs = 1 // INNOVATION #16: 4-cycle divide!
 	NumLSUs = 2 // Memory operations (load/store)
 
+	// Multiplier pool (see MULTIPLIER POOL)
+	MulLatency       = 1 // INNOVATION #12: Booth + Wallace in one cycle
+	MulIssueInterval = 1 // Cycles between ops on one unit (1 = pipelined)
+	MulResultPorts   = 1 // Multiply results written back per cycle
+
+	// Divider pool (see DIVIDER POOL)
+	DivLatency       = 4 // INNOVATION #16: Newton-Raphson in four cycles
+	DivIssueInterval = 4 // Cycles between ops on one unit (4 = not pipelined)
+	DivEarlyOut      = 0 // 1 = small quotients skip the second iteration (opt-in)
+
 	// ═══════════════════════════════════════════════════════════════════════
 	// L1I CACHE CONFIGURATION (INNOVATIONS #21-28)
 	// ═══════════════════════════════════════════════════════════════════════
@@ -341,6 +368,38 @@ const (
 
 	L1IMinScore = 0.05 // Minimum score to trigger prefetch
 
+	L1IThrashFilterSize = 256 // Recently evicted L1I lines (buffer thrash)
+
+	// Instruction line fills (see L1I FILL PATH)
+	L1IFillSlots     = 4 // Line fills in flight (demand + prefetch)
+	L1IFillsPerCycle = 1 // New fills started per cycle
+
+	// Fetch-directed prefetch (optional, see FETCH TARGET QUEUE)
+	FTQSize           = 32 // Fetch blocks the predictor may run ahead
+	FTQBlocksPerCycle = 2  // Blocks the run-ahead predictor adds per cycle
+	FTQPrefetchDepth  = 1  // L1I lines prefetched per cycle (same as coverage)
+
+	// Frontend pipeline depth (cycles per stage, see FRONTEND PIPELINE)
+	//
+	// Fetched in cycle N, decoded N+1, renamed N+2, in the window N+3:
+	//   a redirect leaves 3 empty cycles before the right path dispatches
+	FetchStages    = 1 // L1I read + next-PC select
+	DecodeStages   = 1 // INNOVATION #5: Single-cycle decode
+	RenameStages   = 1 // INNOVATION #36-39: RAT read + free list
+	DispatchStages = 1 // Window write (INNOVATION #44)
+
+	MaxFrontendDepth = 32 // Longest fetch-to-dispatch pipeline Validate accepts
+
+	// Fetch bandwidth (see FETCH BANDWIDTH)
+	//
+	// One aligned 32-byte block per I-cache read: a block entered at its
+	// last word yields one instruction, and a taken branch ends the cycle
+	FetchWidth            = 4  // Instructions fetched per cycle
+	FetchBlockBytes       = 32 // Aligned fetch block (8 instructions)
+	TakenBranchesPerCycle = 1  // Predicted-taken branches followed per cycle
+	FetchLinesPerCycle    = 1  // L1I lines one cycle may read from
+	FetchQueueSize        = 8  // Instructions buffered between fetch and decode
+
 	// ═══════════════════════════════════════════════════════════════════════
 	// L1D CACHE CONFIGURATION (INNOVATION #59)
 	// ═══════════════════════════════════════════════════════════════════════
@@ -512,6 +571,17 @@ const (
 
 	PrefetchQueueSize = 8 // INNOVATION #67: Queue predictions
 
+	// Load value prediction (optional, off by default)
+	ValuePredTableSize = 256 // Per-PC last-value / stride-value entries
+	ValueContextSize   = 512 // Value-history (context) table
+	ValuePredMinConf   = 12  // Out of 15: a wrong value costs a flush
+
+	// Prefetch feedback (see PREFETCH FEEDBACK AND THROTTLING)
+	PrefetchThrottling       = 0    // 1 = feedback throttling on (opt-in)
+	PrefetchThrottleInterval = 1024 // Cycles between throttle decisions
+	PrefetchMaxDegree        = 4    // Most lines fetched per prediction
+	PollutionFilterSize      = 1024 // Recently evicted demand lines
+
 	// ═══════════════════════════════════════════════════════════════════════
 	// TIMING PARAMETERS
 	// ═══════════════════════════════════════════════════════════════════════
@@ -533,14 +603,404 @@ const (
 	// This is BETTER than Intel's L2/L3 hierarchy! ✅
 	L1Latency   = 1   // Cache hit: instant (1 cycle)
 	DRAMLatency = 100 // Cache miss: slow (100 cycles)
+	MemPorts    = 1   // DRAM requests started per cycle (see MEMORY PORT ARBITER)
 
 	// ═══════════════════════════════════════════════════════════════════════
 	// SPECIAL VALUES
 	// ═══════════════════════════════════════════════════════════════════════
 
-	InvalidTag = 0xFF // Sentinel value for "no mapping" or "invalid"
+	InvalidTag  = 0xFF // Sentinel value for "no mapping" or "invalid"
+	ZeroPhysReg = 0    // r0's physical register: never renamed, always zero
 )
 
+// ═══════════════════════════════════════════════════════════════════════════════
+// RUNTIME CONFIGURATION
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: The constants above are the tuned design, but checking
+//              WHY they were chosen means rebuilding with new numbers
+//
+// THE SOLUTION: CoreConfig carries the sizing knobs at runtime
+//   DefaultCoreConfig(): The constants above (the shipped design)
+//   NewCoreWithConfig(): Sizes window, register files, units, caches,
+//                        RSB, frontend depth and DRAM timing from the config
+//   JSON:                Load/Save so a design point is one file
+//
+// WHAT STAYS CONSTANT: ISA-level facts (32 architectural registers,
+//   64-byte lines, 4-way sets) and predictor table sizes
+//
+// LIMITS (checked by Validate):
+//   Physical registers: 33-255 (byte tags, 0xFF = InvalidTag)
+//   Cache sizes:        Power-of-two number of 4-way, 64-byte sets
+//   Issue width:        No wider than the execution units behind it
+//   Frontend depth:     Fetch and dispatch 1+ cycles, decode and rename
+//                       0+ cycles, at most MaxFrontendDepth in total
+//   Fetch blocks:       Power of two, one word up to one line
+//
+// MINECRAFT ANALOGY: A world settings file - same game, different
+//   map size, saved and shared as one file
+
+// CoreConfig holds every runtime-sized parameter of a core
+type CoreConfig struct {
+	// Out-of-order engine (INNOVATIONS #34-45)
+	WindowSize    int `json:"window_size"`
+	NumPhysRegs   int `json:"num_phys_regs"`
+	IssueWidth    int `json:"issue_width"`
+	DispatchWidth int `json:"dispatch_width"`
+	CommitWidth   int `json:"commit_width"`
+
+	// Rename-stage elimination (see MOVE ELIMINATION)
+	MoveElimination int `json:"move_elimination"` // 0 = off, 1 = on
+
+	// Mispredict recovery (INNOVATION #48)
+	EarlyRecovery int `json:"early_recovery"` // 0 = at commit, 1 = at execute
+
+	// Execution units (INNOVATIONS #56-58, #69)
+	// num_muls and num_divs size the multiplier and divider pools: each
+	// unit issues on its own schedule (see MULTIPLIER POOL, DIVIDER POOL)
+	NumALUs int `json:"num_alus"`
+	NumMULs int `json:"num_muls"`
+	NumDIVs int `json:"num_divs"`
+	NumLSUs int `json:"num_lsus"`
+
+	// Multiplier pool (INNOVATION #57, see MULTIPLIER POOL)
+	MulLatency       int `json:"mul_latency"`        // Cycles issue to result
+	MulIssueInterval int `json:"mul_issue_interval"` // 1 = pipelined
+	MulResultPorts   int `json:"mul_result_ports"`   // Results per cycle
+
+	// Divider pool (INNOVATION #58, see DIVIDER POOL)
+	DivLatency       int `json:"div_latency"`        // Cycles issue to result
+	DivIssueInterval int `json:"div_issue_interval"` // 1 = pipelined
+	DivEarlyOut      int `json:"div_early_out"`      // 0 = off, 1 = on
+
+	// Caches (INNOVATIONS #18-28)
+	L1IBufferSize  int `json:"l1i_buffer_size"`  // Bytes per buffer
+	L1IBufferCount int `json:"l1i_buffer_count"` // Buffers
+	L1DCacheSize   int `json:"l1d_cache_size"`   // Bytes
+
+	// Prefetch feedback (see PREFETCH FEEDBACK AND THROTTLING)
+	PrefetchThrottle int `json:"prefetch_throttle"` // 0 = off, 1 = on
+
+	// Branch prediction (INNOVATION #31)
+	RSBSize int `json:"rsb_size"`
+
+	// Frontend pipeline depth in cycles (see FRONTEND PIPELINE)
+	FetchStages    int `json:"fetch_stages"`
+	DecodeStages   int `json:"decode_stages"` // 0 = decoded in fetch
+	RenameStages   int `json:"rename_stages"` // 0 = renamed in dispatch
+	DispatchStages int `json:"dispatch_stages"`
+
+	// Fetch bandwidth (see FETCH BANDWIDTH)
+	FetchWidth            int `json:"fetch_width"`
+	FetchBlockBytes       int `json:"fetch_block_bytes"`
+	TakenBranchesPerCycle int `json:"taken_branches_per_cycle"`
+	FetchLinesPerCycle    int `json:"fetch_lines_per_cycle"`
+	FetchQueueSize        int `json:"fetch_queue_size"`
+
+	// Memory timing
+	DRAMLatency int `json:"dram_latency"` // Cycles per line
+	MemPorts    int `json:"mem_ports"`    // Requests started per cycle
+}
+
+// DefaultCoreConfig returns the shipped design (the constants above)
+func DefaultCoreConfig() CoreConfig {
+	return CoreConfig{
+		WindowSize:            WindowSize,
+		NumPhysRegs:           NumPhysRegs,
+		IssueWidth:            IssueWidth,
+		DispatchWidth:         DispatchWidth,
+		CommitWidth:           CommitWidth,
+		MoveElimination:       MoveElimination,
+		EarlyRecovery:         EarlyRecovery,
+		NumALUs:               NumALUs,
+		NumMULs:               NumMULs,
+		NumDIVs:               NumDIVs,
+		NumLSUs:               NumLSUs,
+		MulLatency:            MulLatency,
+		MulIssueInterval:      MulIssueInterval,
+		MulResultPorts:        MulResultPorts,
+		DivLatency:            DivLatency,
+		DivIssueInterval:      DivIssueInterval,
+		DivEarlyOut:           DivEarlyOut,
+		L1IBufferSize:         L1IBufferSize,
+		L1IBufferCount:        L1IBufferCount,
+		L1DCacheSize:          L1DCacheSize,
+		PrefetchThrottle:      PrefetchThrottling,
+		RSBSize:               RSBSize,
+		FetchStages:           FetchStages,
+		DecodeStages:          DecodeStages,
+		RenameStages:          RenameStages,
+		DispatchStages:        DispatchStages,
+		FetchWidth:            FetchWidth,
+		FetchBlockBytes:       FetchBlockBytes,
+		TakenBranchesPerCycle: TakenBranchesPerCycle,
+		FetchLinesPerCycle:    FetchLinesPerCycle,
+		FetchQueueSize:        FetchQueueSize,
+		DRAMLatency:           DRAMLatency,
+		MemPorts:              MemPorts,
+	}
+}
+
+// l1iBufferSets returns sets per L1I buffer
+func (cfg CoreConfig) l1iBufferSets() int {
+	return cfg.L1IBufferSize / CacheLineSize / L1Associativity
+}
+
+// l1dNumSets returns L1D sets
+func (cfg CoreConfig) l1dNumSets() int {
+	return cfg.L1DCacheSize / CacheLineSize / L1Associativity
+}
+
+// frontendDepth returns cycles from fetch to dispatch, both included
+func (cfg CoreConfig) frontendDepth() int {
+	return cfg.FetchStages + cfg.DecodeStages + cfg.RenameStages + cfg.DispatchStages
+}
+
+// Validate checks every field and the combinations between them
+func (cfg CoreConfig) Validate() error {
+	positive := []struct {
+		name  string
+		value int
+	}{
+		{"window_size", cfg.WindowSize},
+		{"issue_width", cfg.IssueWidth},
+		{"dispatch_width", cfg.DispatchWidth},
+		{"commit_width", cfg.CommitWidth},
+		{"num_alus", cfg.NumALUs},
+		{"num_muls", cfg.NumMULs},
+		{"num_divs", cfg.NumDIVs},
+		{"num_lsus", cfg.NumLSUs},
+		{"mul_latency", cfg.MulLatency},
+		{"mul_issue_interval", cfg.MulIssueInterval},
+		{"mul_result_ports", cfg.MulResultPorts},
+		{"div_latency", cfg.DivLatency},
+		{"div_issue_interval", cfg.DivIssueInterval},
+		{"l1i_buffer_count", cfg.L1IBufferCount},
+		{"rsb_size", cfg.RSBSize},
+		{"fetch_stages", cfg.FetchStages},
+		{"dispatch_stages", cfg.DispatchStages},
+		{"fetch_width", cfg.FetchWidth},
+		{"taken_branches_per_cycle", cfg.TakenBranchesPerCycle},
+		{"fetch_lines_per_cycle", cfg.FetchLinesPerCycle},
+		{"dram_latency", cfg.DRAMLatency},
+		{"mem_ports", cfg.MemPorts},
+	}
+	for _, p := range positive {
+		if p.value < 1 {
+			return fmt.Errorf("config: %s must be at least 1, got %d", p.name, p.value)
+		}
+	}
+
+	// Register tags are bytes, and 0xFF is InvalidTag
+	if cfg.NumPhysRegs <= NumArchRegs || cfg.NumPhysRegs > int(InvalidTag) {
+		return fmt.Errorf("config: num_phys_regs must be %d-%d, got %d", NumArchRegs+1, InvalidTag, cfg.NumPhysRegs)
+	}
+
+	if cfg.EarlyRecovery != 0 && cfg.EarlyRecovery != 1 {
+		return fmt.Errorf("config: early_recovery must be 0 or 1, got %d", cfg.EarlyRecovery)
+	}
+	if cfg.PrefetchThrottle != 0 && cfg.PrefetchThrottle != 1 {
+		return fmt.Errorf("config: prefetch_throttle must be 0 or 1, got %d", cfg.PrefetchThrottle)
+	}
+
+	if cfg.DispatchWidth > cfg.WindowSize {
+		return fmt.Errorf("config: dispatch_width %d exceeds window_size %d", cfg.DispatchWidth, cfg.WindowSize)
+	}
+	if cfg.CommitWidth > cfg.WindowSize {
+		return fmt.Errorf("config: commit_width %d exceeds window_size %d", cfg.CommitWidth, cfg.WindowSize)
+	}
+
+	if cfg.MulIssueInterval > cfg.MulLatency {
+		return fmt.Errorf("config: mul_issue_interval %d exceeds mul_latency %d", cfg.MulIssueInterval, cfg.MulLatency)
+	}
+	if cfg.DivIssueInterval > cfg.DivLatency {
+		return fmt.Errorf("config: div_issue_interval %d exceeds div_latency %d", cfg.DivIssueInterval, cfg.DivLatency)
+	}
+	if cfg.MoveElimination != 0 && cfg.MoveElimination != 1 {
+		return fmt.Errorf("config: move_elimination must be 0 or 1, got %d", cfg.MoveElimination)
+	}
+	if cfg.DivEarlyOut != 0 && cfg.DivEarlyOut != 1 {
+		return fmt.Errorf("config: div_early_out must be 0 or 1, got %d", cfg.DivEarlyOut)
+	}
+
+	units := cfg.NumALUs + cfg.NumMULs + cfg.NumDIVs + cfg.NumLSUs
+	if cfg.IssueWidth > units {
+		return fmt.Errorf("config: issue_width %d exceeds the %d execution units", cfg.IssueWidth, units)
+	}
+
+	// Decode and rename may fold into their neighbours, not go negative
+	if cfg.DecodeStages < 0 || cfg.RenameStages < 0 {
+		return fmt.Errorf("config: decode_stages and rename_stages must be at least 0, got %d and %d",
+			cfg.DecodeStages, cfg.RenameStages)
+	}
+	if depth := cfg.frontendDepth(); depth > MaxFrontendDepth {
+		return fmt.Errorf("config: frontend depth %d exceeds %d cycles", depth, MaxFrontendDepth)
+	}
+
+	// Fetch blocks are aligned and never span two lines
+	b := cfg.FetchBlockBytes
+	if b < 4 || b > CacheLineSize || b&(b-1) != 0 {
+		return fmt.Errorf("config: fetch_block_bytes must be a power of two from 4 to %d, got %d",
+			CacheLineSize, b)
+	}
+	if cfg.FetchQueueSize < cfg.FetchWidth {
+		return fmt.Errorf("config: fetch_queue_size %d is smaller than fetch_width %d", cfg.FetchQueueSize, cfg.FetchWidth)
+	}
+
+	// Thrash filter records the evicting buffer in a byte
+	if cfg.L1IBufferCount > 255 {
+		return fmt.Errorf("config: l1i_buffer_count must be at most 255, got %d", cfg.L1IBufferCount)
+	}
+
+	caches := []struct {
+		name string
+		size int
+		sets int
+	}{
+		{"l1i_buffer_size", cfg.L1IBufferSize, cfg.l1iBufferSets()},
+		{"l1d_cache_size", cfg.L1DCacheSize, cfg.l1dNumSets()},
+	}
+	for _, c := range caches {
+		if c.sets < 1 || c.size != c.sets*CacheLineSize*L1Associativity || c.sets&(c.sets-1) != 0 {
+			return fmt.Errorf("config: %s %d is not a power-of-two number of %d-byte, %d-way sets",
+				c.name, c.size, CacheLineSize, L1Associativity)
+		}
+	}
+
+	return nil
+}
+
+// LoadCoreConfig reads a JSON config file
+//
+// Fields missing from the file keep their default values; unknown
+// fields are an error (catches typos). The result is validated.
+func LoadCoreConfig(path string) (CoreConfig, error) {
+	cfg := DefaultCoreConfig()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Save writes the config as indented JSON
+func (cfg CoreConfig) Save(path string) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// CoreConfigFields lists the JSON names of every CoreConfig field, in order
+//
+// USED BY: Set (sweep parameters by name) and the sweep CSV columns
+var CoreConfigFields = [...]string{
+	"window_size", "num_phys_regs", "issue_width", "dispatch_width", "commit_width",
+	"move_elimination", "early_recovery",
+	"num_alus", "num_muls", "num_divs", "num_lsus",
+	"mul_latency", "mul_issue_interval", "mul_result_ports",
+	"div_latency", "div_issue_interval", "div_early_out",
+	"l1i_buffer_size", "l1i_buffer_count", "l1d_cache_size", "prefetch_throttle",
+	"rsb_size", "fetch_stages", "decode_stages", "rename_stages", "dispatch_stages",
+	"fetch_width", "fetch_block_bytes", "taken_branches_per_cycle", "fetch_lines_per_cycle", "fetch_queue_size",
+	"dram_latency", "mem_ports",
+}
+
+// field returns the field with the given JSON name (nil if unknown)
+func (cfg *CoreConfig) field(name string) *int {
+	switch name {
+	case "window_size":
+		return &cfg.WindowSize
+	case "num_phys_regs":
+		return &cfg.NumPhysRegs
+	case "issue_width":
+		return &cfg.IssueWidth
+	case "dispatch_width":
+		return &cfg.DispatchWidth
+	case "commit_width":
+		return &cfg.CommitWidth
+	case "move_elimination":
+		return &cfg.MoveElimination
+	case "early_recovery":
+		return &cfg.EarlyRecovery
+	case "num_alus":
+		return &cfg.NumALUs
+	case "num_muls":
+		return &cfg.NumMULs
+	case "num_divs":
+		return &cfg.NumDIVs
+	case "num_lsus":
+		return &cfg.NumLSUs
+	case "mul_latency":
+		return &cfg.MulLatency
+	case "mul_issue_interval":
+		return &cfg.MulIssueInterval
+	case "mul_result_ports":
+		return &cfg.MulResultPorts
+	case "div_latency":
+		return &cfg.DivLatency
+	case "div_issue_interval":
+		return &cfg.DivIssueInterval
+	case "div_early_out":
+		return &cfg.DivEarlyOut
+	case "l1i_buffer_size":
+		return &cfg.L1IBufferSize
+	case "l1i_buffer_count":
+		return &cfg.L1IBufferCount
+	case "l1d_cache_size":
+		return &cfg.L1DCacheSize
+	case "prefetch_throttle":
+		return &cfg.PrefetchThrottle
+	case "rsb_size":
+		return &cfg.RSBSize
+	case "fetch_stages":
+		return &cfg.FetchStages
+	case "decode_stages":
+		return &cfg.DecodeStages
+	case "rename_stages":
+		return &cfg.RenameStages
+	case "dispatch_stages":
+		return &cfg.DispatchStages
+	case "fetch_width":
+		return &cfg.FetchWidth
+	case "fetch_block_bytes":
+		return &cfg.FetchBlockBytes
+	case "taken_branches_per_cycle":
+		return &cfg.TakenBranchesPerCycle
+	case "fetch_lines_per_cycle":
+		return &cfg.FetchLinesPerCycle
+	case "fetch_queue_size":
+		return &cfg.FetchQueueSize
+	case "dram_latency":
+		return &cfg.DRAMLatency
+	case "mem_ports":
+		return &cfg.MemPorts
+	}
+	return nil
+}
+
+// Set assigns a field by its JSON name (see CoreConfigFields)
+//
+// Does not validate; call Validate once all fields are set
+func (cfg *CoreConfig) Set(name string, value int) error {
+	f := cfg.field(name)
+	if f == nil {
+		return fmt.Errorf("config: unknown field %q", name)
+	}
+	*f = value
+	return nil
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // INSTRUCTION SET ARCHITECTURE (INNOVATIONS #1-6)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -586,8 +1046,8 @@ const (
 	// ═══════════════════════════════════════════════════════════════════
 	// R-FORMAT INSTRUCTIONS (Register-Register operations)
 	// ═══════════════════════════════════════════════════════════════════
-	// Format: [opcode:5][rd:5][rs1:5][rs2:5][unused:12]
-	// Meaning: rd = rs1 OP rs2
+	// Format: [opcode:5][rd:5][rs1:5][rs2:5][funct:12]
+	// Meaning: rd = rs1 OP rs2 (funct is zero except for FunctUnsigned)
 
 	OpADD  = 0x00 // Add: rd = rs1 + rs2
 	OpSUB  = 0x01 // Subtract: rd = rs1 - rs2
@@ -598,12 +1058,15 @@ const (
 	OpSRL  = 0x06 // Shift right logical: rd = rs1 >> rs2 (zero fill)
 	OpSRA  = 0x07 // Shift right arithmetic: rd = rs1 >> rs2 (sign fill)
 	OpMUL  = 0x08 // Multiply (low 32 bits): rd = (rs1 × rs2)[31:0]
-	OpMULH = 0x09 // Multiply (high 32 bits): rd = (rs1 × rs2)[63:32]
-	OpDIV  = 0x0A // Divide: rd = rs1 / rs2
-	OpREM  = 0x0B // Remainder: rd = rs1 % rs2
+	OpMULH = 0x09 // Multiply (high 32 bits): rd = (rs1 × rs2)[63:32], both signed
+	OpDIV  = 0x0A // Divide (signed, DIVU with FunctUnsigned): rd = rs1 / rs2
+	OpREM  = 0x0B // Remainder (signed, REMU with FunctUnsigned): rd = rs1 % rs2
 	OpSLT  = 0x0C // Set if less than (signed): rd = (rs1 < rs2) ? 1 : 0
 	OpSLTU = 0x0D // Set if less than (unsigned): rd = (rs1 < rs2) ? 1 : 0
 
+	OpMULHU  = 0x0E // Multiply high unsigned: rd = (rs1 × rs2)[63:32], both unsigned
+	OpMULHSU = 0x0F // Multiply high signed×unsigned: rd = (rs1 × rs2)[63:32], rs2 unsigned
+
 	// ═══════════════════════════════════════════════════════════════════
 	// I-FORMAT INSTRUCTIONS (Immediate operations)
 	// ═══════════════════════════════════════════════════════════════════
@@ -646,6 +1109,24 @@ const (
 	OpSYSTEM = 0x1F // System call (trap to OS)
 )
 
+// R-FORMAT FUNCTION BITS
+//
+// THE PROBLEM: All 16 R-format opcodes are taken (MULHU and MULHSU
+//              used the last two), but RISC-V has unsigned DIVU/REMU
+//
+// THE SOLUTION: R-format has 12 unused low bits - the funct field
+//   DIVU = DIV with FunctUnsigned set, REMU = REM with FunctUnsigned set
+//   Decode copies funct into Instruction.Funct; every other R-format
+//   instruction ignores it
+//
+// MINECRAFT ANALOGY: Same recipe, different fuel - the crafting table
+//                    is the same, one slot changes what comes out
+
+// R-format funct bits [11:0]
+const (
+	FunctUnsigned = 0x001 // DIV/REM: operands are unsigned (DIVU, REMU)
+)
+
 // INNOVATION #5: Single-cycle decode
 // INNOVATION #6: Pre-computed flags at decode time
 //
@@ -674,6 +1155,7 @@ type Instruction struct {
 	Rs2    uint8  // Source register 2 (0-31)
 	Imm    int32  // Immediate value (sign-extended to 32 bits)
 	PC     uint32 // Program counter (address of this instruction)
+	Funct  uint16 // R-format function bits [11:0] (FunctUnsigned)
 
 	// INNOVATION #6: Pre-computed convenience flags
 	// These are computed ONCE during decode, then used throughout pipeline
@@ -681,9 +1163,15 @@ type Instruction struct {
 	IsLoad   bool // Does this load from memory? (LW, LR)
 	IsStore  bool // Does this store to memory? (SW, SC)
 	IsJump   bool // Is this an unconditional jump? (JAL, JALR)
-	IsMul    bool // Is this a multiply? (MUL, MULH)
-	IsDiv    bool // Is this a divide? (DIV, REM)
+	IsMul    bool // Is this a multiply? (MUL, MULH, MULHU, MULHSU)
+	IsDiv    bool // Is this a divide? (DIV, DIVU, REM, REMU)
 	UsesImm  bool // Does this use the immediate field? (I-format and B-format)
+
+	// Fetch-time prediction (set on every branch and jump fetch follows)
+	HasPrediction bool          // PredTaken/PredTarget are valid
+	PredTaken     bool          // Predicted direction
+	PredTarget    uint32        // Predicted next PC
+	RSB           RSBCheckpoint // RSB just after this instruction was predicted
 }
 
 // DecodeInstruction implements INNOVATION #5: Single-cycle decode
@@ -712,10 +1200,11 @@ func DecodeInstruction(word uint32, pc uint32) Instruction {
 	// STEP 2-3: Decode based on format (determined by opcode range)
 	if inst.Opcode < 0x10 {
 		// R-FORMAT: Two register sources, one register destination
-		// Layout: [opcode:5][rd:5][rs1:5][rs2:5][unused:12]
+		// Layout: [opcode:5][rd:5][rs1:5][rs2:5][funct:12]
 		inst.Rd = uint8((word >> 22) & 0x1F)  // Bits [26:22]
 		inst.Rs1 = uint8((word >> 17) & 0x1F) // Bits [21:17]
 		inst.Rs2 = uint8((word >> 12) & 0x1F) // Bits [16:12]
+		inst.Funct = uint16(word & 0xFFF)     // Bits [11:0]
 		inst.Imm = 0
 		inst.UsesImm = false
 
@@ -760,7 +1249,7 @@ func DecodeInstruction(word uint32, pc uint32) Instruction {
 		inst.IsStore = true
 	case OpJAL, OpJALR:
 		inst.IsJump = true
-	case OpMUL, OpMULH:
+	case OpMUL, OpMULH, OpMULHU, OpMULHSU:
 		inst.IsMul = true
 	case OpDIV, OpREM:
 		inst.IsDiv = true
@@ -1188,7 +1677,8 @@ func init() {
 	//
 	// ALGORITHM:
 	//   FOR i = 0 to 511:
-	//     STEP 1: Compute x = 1.0 + i/512.0 (maps to range [1.0, 2.0))
+	//     STEP 1: Compute x = 1.0 + (i+0.5)/512.0, the middle of the
+	//             i-th slice of [1.0, 2.0) (halves the worst-case error)
 	//     STEP 2: Compute reciprocal: 1.0 / x
 	//     STEP 3: Convert to fixed-point: multiply by 2^32
 	//     STEP 4: Store in table
@@ -1201,7 +1691,7 @@ func init() {
 	//   Value = (integer value) / 2^32
 	//   Example: 0x80000000 = 2^31 / 2^32 = 0.5
 	for i := 0; i < 512; i++ {
-		x := 1.0 + float64(i)/512.0                       // Range: [1.0, 1.998)
+		x := 1.0 + (float64(i)+0.5)/512.0                 // Range: (1.0, 2.0)
 		recip := 1.0 / x                                  // Compute 1/x
 		reciprocalTable[i] = uint32(recip * 4294967296.0) // Convert to fixed-point
 	}
@@ -1444,72 +1934,97 @@ func Multiply(a, b uint32) (lower, upper uint32) {
 // MINECRAFT ANALOGY: Instead of repeatedly subtracting (slow counting),
 //                    use a multiplication table (instant lookup)!
 
-// Divider implements INNOVATIONS #13-16: Newton-Raphson division
+// DivideOp returns the result of a DIV, DIVU, REM or REMU
 //
-// This is a 4-cycle state machine:
+// funct selects the unsigned variants (FunctUnsigned). Every input
+// has a defined result - the RISC-V M extension rules:
 //
-//	State 0: Idle (waiting for new work)
-//	State 1: Normalize divisor and lookup reciprocal
-//	State 2: First Newton-Raphson iteration
-//	State 3: Second Newton-Raphson iteration
-//	State 4: Final multiply and correction
+//	                  DIV          DIVU         REM        REMU
+//	x / 0             0xFFFFFFFF   0xFFFFFFFF   x          x
+//	0x80000000 / -1   0x80000000   (no case)    0          (no case)
 //
-// HARDWARE NOTE: This would be a pipelined unit in real hardware
+// ALGORITHM:
+//
+//	STEP 1: Division by zero: all ones / the dividend
+//	STEP 2: Unsigned: divide directly
+//	STEP 3: Signed overflow (INT_MIN / -1): the quotient wraps
+//	STEP 4: Signed: divide the magnitudes, then fix the signs
+//	        Quotient is negative if the operand signs differ
+//	        Remainder takes the dividend's sign (truncating division)
 //
-//	Multiple divisions could be in-flight simultaneously
-type Divider struct {
-	// State machine
-	state uint8 // Current state (0-4)
+// HARDWARE NOTE: STEP 4 is a conditional negate on the way in and on
+//
+//	the way out - two adders around the unsigned datapath
+func DivideOp(op uint8, funct uint16, a, b uint32) uint32 {
+	rem := op == OpREM
 
-	// Input operands
-	dividend uint32 // Numerator (a in a/b)
-	divisor  uint32 // Denominator (b in a/b)
+	// STEP 1: Division by zero (no trap - RISC-V defines the result)
+	if b == 0 {
+		if rem {
+			return a
+		}
+		return 0xFFFFFFFF
+	}
 
-	// Working values
-	xApprox    uint32 // Current estimate of 1/divisor
-	normalized uint32 // Divisor normalized to range [1.0, 2.0)
-	shift      int    // How much we shifted for normalization
+	// STEP 2: Unsigned
+	if funct&FunctUnsigned != 0 {
+		q, r := DivideUnsigned(a, b)
+		if rem {
+			return r
+		}
+		return q
+	}
 
-	// Output results
-	quotient  uint32 // Result: dividend / divisor
-	remainder uint32 // Result: dividend % divisor
+	// STEP 3: Signed overflow - the true quotient 2^31 doesn't fit
+	if a == 0x80000000 && b == 0xFFFFFFFF {
+		if rem {
+			return 0
+		}
+		return a
+	}
 
-	// Control flags
-	Done     bool // True when result is ready
-	Busy     bool // True when computing
-	windowID int  // Which instruction this belongs to
-	isRem    bool // True for REM, false for DIV
+	// STEP 4: Signed - unsigned divide of the magnitudes
+	negA, negB := int32(a) < 0, int32(b) < 0
+	ma, mb := a, b
+	if negA {
+		ma = -a // Two's complement negate (INT_MIN stays 2^31)
+	}
+	if negB {
+		mb = -b
+	}
+	q, r := DivideUnsigned(ma, mb)
+	if negA != negB {
+		q = -q
+	}
+	if negA {
+		r = -r
+	}
+	if rem {
+		return r
+	}
+	return q
 }
 
-// StartDivision begins a new division operation
+// DivideUnsigned returns n / d and n % d for unsigned operands
 //
 // ALGORITHM:
 //
 //	STEP 1: Check for special cases (can compute instantly)
-//	STEP 2: If special case: Return result immediately
-//	STEP 3: Otherwise: Start 4-cycle state machine
+//	STEP 2: Otherwise: Newton-Raphson (INNOVATIONS #13-15)
 //
 // SPECIAL CASES:
-//  1. Division by zero: Return 0xFFFFFFFF (undefined behavior)
+//  1. Division by zero: quotient 0xFFFFFFFF, remainder = dividend
 //  2. Divisor is power of 2: Use shifts (instant!)
-func (d *Divider) StartDivision(dividend, divisor uint32, winID int, remainder bool) {
-	d.windowID = winID
-	d.isRem = remainder
-
+func DivideUnsigned(n, d uint32) (q, r uint32) {
 	// ═══════════════════════════════════════════════════════════════════
 	// SPECIAL CASE 1: Division by zero
 	// ═══════════════════════════════════════════════════════════════════
 	//
-	// Behavior: Return maximum value (architecture-defined)
+	// Behavior: Return maximum value (RISC-V defined, no trap)
 	//   Quotient: 0xFFFFFFFF (all bits set)
 	//   Remainder: Original dividend (unchanged)
-	if divisor == 0 {
-		d.quotient = 0xFFFFFFFF
-		d.remainder = dividend
-		d.Done = true
-		d.Busy = false
-		d.state = 0
-		return
+	if d == 0 {
+		return 0xFFFFFFFF, n
 	}
 
 	// ═══════════════════════════════════════════════════════════════════
@@ -1530,176 +2045,91 @@ func (d *Divider) StartDivision(dividend, divisor uint32, winID int, remainder b
 	//   7 = 0b00000111 (three bits) ❌
 	//
 	// We use a hardware instruction to count bits (very fast)
-	if bits.OnesCount32(divisor) == 1 {
-		// Find which bit is set (the power)
-		shift := bits.TrailingZeros32(divisor)
-		// Compute quotient and remainder using shifts
-		d.quotient = dividend >> shift         // Division by right shift
-		d.remainder = dividend & (divisor - 1) // Modulo by masking
-		d.Done = true
-		d.Busy = false
-		d.state = 0
-		return
+	if bits.OnesCount32(d) == 1 {
+		shift := bits.TrailingZeros32(d)
+		return n >> shift, n & (d - 1)
 	}
 
 	// ═══════════════════════════════════════════════════════════════════
-	// NORMAL CASE: Start 4-cycle Newton-Raphson algorithm
+	// NORMAL CASE: 4-cycle Newton-Raphson algorithm
 	// ═══════════════════════════════════════════════════════════════════
-	d.dividend = dividend
-	d.divisor = divisor
-	d.state = 1    // Start at cycle 1
-	d.Busy = true  // Mark as busy
-	d.Done = false // Result not ready yet
+	return newtonRaphsonDivide(n, d)
 }
 
-// Tick advances the divider by one clock cycle
+// newtonRaphsonDivide implements INNOVATIONS #13-15 (d not 0, not 2^k)
 //
-// ALGORITHM: State machine executes one stage per cycle
+// FIXED-POINT FORMATS:
 //
-// HARDWARE NOTE: In real hardware, this would be pipelined
+//	Divisor D:    d shifted so bit 31 is set, read as 1.31 → [1.0, 2.0)
+//	Reciprocal X: 0.32 → 1/D in (0.5, 1.0)
 //
-//	Multiple divisions could be active simultaneously
-func (d *Divider) Tick() {
-	switch d.state {
-	case 0:
-		// ═══════════════════════════════════════════════════════════════
-		// STATE 0: IDLE
-		// ═══════════════════════════════════════════════════════════════
-		// Nothing to do, waiting for work
-		d.Busy = false
-
-	case 1:
-		// ═══════════════════════════════════════════════════════════════
-		// CYCLE 1: NORMALIZE AND LOOKUP (INNOVATION #14)
-		// ═══════════════════════════════════════════════════════════════
-		//
-		// ALGORITHM:
-		//   STEP 1: Count leading zeros in divisor
-		//   STEP 2: Shift divisor left so leading 1 is at bit 31
-		//           This normalizes divisor to range [1.0, 2.0)
-		//   STEP 3: Extract top 9 bits (after leading 1) as table index
-		//   STEP 4: Look up initial reciprocal estimate from table
-		//
-		// WHY NORMALIZE: Table covers [1.0, 2.0) range
-		//   Any divisor can be shifted into this range
-		//   Then we denormalize the result after
-		//
-		// EXAMPLE: Divisor = 0x00001234
-		//   Leading zeros: 19 (bit 12 is first 1)
-		//   Normalized: 0x00001234 << 19 = 0x91A00000
-		//   Leading 1 now at bit 31 ✅
-		//   In range [0x80000000, 0xFFFFFFFF] = [1.0, 2.0) ✅
-		d.shift = bits.LeadingZeros32(d.divisor)
-		d.normalized = d.divisor << d.shift
-
-		// Extract top 9 bits for table index
-		// After normalizing, bit 31 is always 1
-		// We use bits [30:22] as index (9 bits = 512 values)
-		index := (d.normalized >> 23) & 0x1FF
-		d.xApprox = reciprocalTable[index]
-
-		d.state = 2 // Move to next cycle
-
-	case 2:
-		// ═══════════════════════════════════════════════════════════════
-		// CYCLE 2: FIRST NEWTON-RAPHSON ITERATION (INNOVATION #15)
-		// ═══════════════════════════════════════════════════════════════
-		//
-		// ALGORITHM: x' = x × (2 - b × x)
-		//   STEP 1: Compute b × x (multiply divisor by estimate)
-		//   STEP 2: Compute 2 - (b × x) in fixed-point
-		//   STEP 3: Compute x × (2 - b × x) (refine estimate)
-		//
-		// RESULT: Doubles correct bits (9 → 18 bits) ✅
-		//
-		// WHY IT WORKS: Newton-Raphson finds roots quadratically
-		//   We're finding root of f(x) = 1/x - b
-		//   Each iteration squares the error!
-		//
-		// FIXED-POINT MATH:
-		//   Our values are in 0.32 format (all bits are fraction)
-		//   2.0 in fixed-point = 0x100000000 = 0xFFFFFFFF + 1
-		//   We use modular arithmetic (wraps naturally)
-		_, bx := Multiply(d.normalized, d.xApprox)
-		twoMinusBX := 0xFFFFFFFF - bx + 1 // 2.0 - bx (wraps correctly)
-		_, d.xApprox = Multiply(d.xApprox, twoMinusBX)
-
-		d.state = 3 // Move to next cycle
-
-	case 3:
-		// ═══════════════════════════════════════════════════════════════
-		// CYCLE 3: SECOND NEWTON-RAPHSON ITERATION (INNOVATION #15)
-		// ═══════════════════════════════════════════════════════════════
-		//
-		// ALGORITHM: Same as cycle 2, but input is now 18-bit accurate
-		//
-		// RESULT: Doubles correct bits again (18 → 36 bits) ✅
-		//         36 > 32 needed, so we're done!
-		//
-		// WHY TWO ITERATIONS EXACTLY:
-		//   One iteration: 9 → 18 bits (not enough for 32-bit precision)
-		//   Two iterations: 18 → 36 bits (sufficient!) ✅
-		//   Three iterations: 36 → 72 bits (overkill, wasted cycle)
-		_, bx := Multiply(d.normalized, d.xApprox)
-		twoMinusBX := 0xFFFFFFFF - bx + 1
-		_, d.xApprox = Multiply(d.xApprox, twoMinusBX)
-
-		d.state = 4 // Move to final cycle
-
-	case 4:
-		// ═══════════════════════════════════════════════════════════════
-		// CYCLE 4: FINAL MULTIPLY AND CORRECTION
-		// ═══════════════════════════════════════════════════════════════
-		//
-		// ALGORITHM:
-		//   STEP 1: Multiply dividend × (1/divisor) to get quotient
-		//   STEP 2: Denormalize result (shift back by original shift)
-		//   STEP 3: Compute remainder = dividend - (quotient × divisor)
-		//   STEP 4: Check if remainder >= divisor (off-by-one error)
-		//   STEP 5: If so: increment quotient, adjust remainder
-		//
-		// WHY CORRECTION: Fixed-point rounding can be off by 1
-		//   The reciprocal might be slightly too small
-		//   Always check and fix if needed
-
-		// STEP 1-2: Multiply and denormalize
-		_, q := Multiply(d.dividend, d.xApprox)
-		d.quotient = q >> (32 - d.shift) // Shift back to denormalize
-
-		// STEP 3: Compute remainder to verify
-		prod, _ := Multiply(d.quotient, d.divisor)
-		d.remainder = d.dividend - prod
+// Each cycle below is one stage of the divider; the products are the
+// multiplier array's full 64-bit (and 32×64 → 96-bit) results
+func newtonRaphsonDivide(n, d uint32) (q, r uint32) {
+	// ═══════════════════════════════════════════════════════════════════
+	// CYCLE 1: NORMALIZE AND LOOKUP (INNOVATION #14)
+	// ═══════════════════════════════════════════════════════════════════
+	//
+	// ALGORITHM:
+	//   STEP 1: Count leading zeros in divisor
+	//   STEP 2: Shift divisor left so leading 1 is at bit 31
+	//           This normalizes divisor to range [1.0, 2.0)
+	//   STEP 3: Take the 9 bits after the leading 1 as table index
+	//   STEP 4: Look up initial reciprocal estimate from table
+	//
+	// EXAMPLE: Divisor = 0x00001234
+	//   Leading zeros: 19 (bit 12 is first 1)
+	//   Normalized: 0x00001234 << 19 = 0x91A00000
+	//   Leading 1 now at bit 31 ✅
+	//   Index: bits [30:22] = 0x046
+	shift := bits.LeadingZeros32(d)
+	D := uint64(d << shift)
+	x := uint64(reciprocalTable[(D>>22)&0x1FF])
 
-		// STEP 4-5: Correction if needed
-		// If remainder >= divisor, we underestimated by 1
-		if d.remainder >= d.divisor {
-			d.quotient++
-			d.remainder -= d.divisor
+	// ═══════════════════════════════════════════════════════════════════
+	// CYCLES 2-3: TWO NEWTON-RAPHSON ITERATIONS (INNOVATION #15)
+	// ═══════════════════════════════════════════════════════════════════
+	//
+	// ALGORITHM: x' = x × (2 - D × x)
+	//   STEP 1: D × x is 1.31 × 0.32 = 1.63 fixed-point, close to 1.0
+	//   STEP 2: 2 - D × x: 2.0 is 2^64 in 1.63, so the wrapping
+	//           negate of the product is exactly 2 - D × x
+	//   STEP 3: x × (2 - D × x) is 0.32 × 1.63 = 1.95; keep 32 bits
+	//
+	// RESULT: Each iteration doubles the correct bits (9 → 18 → 36)
+	for i := 0; i < 2; i++ {
+		twoMinusDX := -(D * x)
+		hi, lo := bits.Mul64(x, twoMinusDX)
+		x = hi<<1 | lo>>63
+		if x > 0xFFFFFFFF {
+			x = 0xFFFFFFFF // 1/D < 1.0 always fits in 0.32
 		}
-
-		// Mark result as complete
-		d.Done = true
-		d.Busy = false
-		d.state = 0 // Return to idle
 	}
-}
 
-// GetResult returns the completed division result
-//
-// RETURNS:
-//
-//	result: quotient (for DIV) or remainder (for REM)
-//	windowID: which instruction this result belongs to
-//	valid: true if result is ready
-func (d *Divider) GetResult() (result uint32, windowID int, valid bool) {
-	if d.Done {
-		d.Done = false // Clear flag (result consumed)
-		if d.isRem {
-			return d.remainder, d.windowID, true
-		}
-		return d.quotient, d.windowID, true
+	// ═══════════════════════════════════════════════════════════════════
+	// CYCLE 4: FINAL MULTIPLY AND CORRECTION
+	// ═══════════════════════════════════════════════════════════════════
+	//
+	// ALGORITHM:
+	//   STEP 1: n × x = n / D, scaled by 2^32
+	//   STEP 2: Denormalize: d = D × 2^(shift-31), so
+	//           n / d = (n × x) >> (63 - shift)
+	//   STEP 3: Compute remainder = n - q × d
+	//   STEP 4: Correct the quotient by one step at a time
+	//
+	// WHY CORRECTION: x is truncated, so q can be a little low (never
+	//   more than 2 steps); the remainder check finds and fixes it
+	q64 := (uint64(n) * x) >> (63 - shift)
+	r64 := int64(n) - int64(q64*uint64(d))
+	for r64 < 0 {
+		q64--
+		r64 += int64(d)
 	}
-	return 0, 0, false
+	for r64 >= int64(d) {
+		q64++
+		r64 -= int64(d)
+	}
+	return uint32(q64), uint32(r64)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -1725,14 +2155,26 @@ func (d *Divider) GetResult() (result uint32, windowID int, valid bool) {
 // BranchPredictor implements INNOVATIONS #29-32
 type BranchPredictor struct {
 	counters [BranchPredictorEntries]uint8 // INNOVATION #29: 4-bit counters
-	rsb      [RSBSize]uint32               // INNOVATION #31: Return Stack Buffer
+	rsb      []uint32                      // INNOVATION #31: Return Stack Buffer
 	rsbTop   int                           // RSB top of stack pointer
 
+	// Non-return JALR targets (nil = predict PC+4)
+	indirect IndirectTargetPredictor
+
 	// Statistics
 	predictions uint64
 	correct     uint64
 }
 
+// IndirectTargetPredictor supplies a target for an indirect jump
+//
+// USED BY: PredictTarget for JALR that isn't a return (the L1I
+//
+//	indirect table, INNOVATION #24, implements it)
+type IndirectTargetPredictor interface {
+	PredictIndirectTarget(pc uint32) (target uint32, valid bool)
+}
+
 // NewBranchPredictor creates an initialized branch predictor
 //
 // ALGORITHM:
@@ -1751,7 +2193,12 @@ type BranchPredictor struct {
 //	Starting at 8 (weakly taken) is correct 99% ✅
 //	Starting at 0 (not-taken) is wrong 99% ❌
 func NewBranchPredictor() *BranchPredictor {
-	bp := &BranchPredictor{}
+	return NewBranchPredictorWithConfig(DefaultCoreConfig())
+}
+
+// NewBranchPredictorWithConfig creates a predictor with cfg.RSBSize return slots
+func NewBranchPredictorWithConfig(cfg CoreConfig) *BranchPredictor {
+	bp := &BranchPredictor{rsb: make([]uint32, cfg.RSBSize)}
 	for i := range bp.counters {
 		bp.counters[i] = 8 // Weakly taken (slightly biased toward taken)
 	}
@@ -1891,18 +2338,16 @@ func (bp *BranchPredictor) Update(pc uint32, actualTaken bool) {
 //
 // MINECRAFT ANALOGY: Stack of portal locations you came through
 func (bp *BranchPredictor) PushRSB(returnAddr uint32) {
-	if bp.rsbTop < RSBSize {
+	if bp.rsbTop < len(bp.rsb) {
 		// Stack not full: simple push
 		bp.rsb[bp.rsbTop] = returnAddr
 		bp.rsbTop++
 	} else {
 		// Stack full: shift down and add at top
 		// Discard oldest entry (bottom of stack)
-		for i := 0; i < RSBSize-1; i++ {
-			bp.rsb[i] = bp.rsb[i+1]
-		}
-		bp.rsb[RSBSize-1] = returnAddr
-		// rsbTop stays at RSBSize
+		copy(bp.rsb, bp.rsb[1:])
+		bp.rsb[len(bp.rsb)-1] = returnAddr
+		// rsbTop stays at the RSB size
 	}
 }
 
@@ -1924,6 +2369,12 @@ func (bp *BranchPredictor) PopRSB() (addr uint32, valid bool) {
 	return 0, false // Stack empty
 }
 
+// SetIndirectPredictor sets where non-return JALR targets come from
+// (nil falls back to PC+4)
+func (bp *BranchPredictor) SetIndirectPredictor(p IndirectTargetPredictor) {
+	bp.indirect = p
+}
+
 // PeekRSB looks at top of RSB without popping
 //
 // USED BY: L1I cache for return target prefetching
@@ -1934,6 +2385,35 @@ func (bp *BranchPredictor) PeekRSB() (addr uint32, valid bool) {
 	return 0, false
 }
 
+// RSBCheckpoint is the RSB state a redirect restores
+//
+// WHY ONLY THE TOP: Wrong-path calls overwrite at most the entry above
+//
+//	the pointer and wrong-path returns only move the pointer, so the
+//	pointer plus the entry it names repairs the common cases
+//	(top-of-stack repair, as in most shipping cores)
+type RSBCheckpoint struct {
+	Top  int    // Top of stack pointer
+	Addr uint32 // Entry at Top-1 (0 if the stack was empty)
+}
+
+// CheckpointRSB captures the RSB state for a later RestoreRSB
+func (bp *BranchPredictor) CheckpointRSB() RSBCheckpoint {
+	cp := RSBCheckpoint{Top: bp.rsbTop}
+	if bp.rsbTop > 0 {
+		cp.Addr = bp.rsb[bp.rsbTop-1]
+	}
+	return cp
+}
+
+// RestoreRSB undoes wrong-path pushes and pops since cp was taken
+func (bp *BranchPredictor) RestoreRSB(cp RSBCheckpoint) {
+	bp.rsbTop = cp.Top
+	if cp.Top > 0 {
+		bp.rsb[cp.Top-1] = cp.Addr
+	}
+}
+
 // PredictTarget computes where a branch/jump will go
 //
 // ALGORITHM:
@@ -1944,8 +2424,10 @@ func (bp *BranchPredictor) PeekRSB() (addr uint32, valid bool) {
 //	ELIF indirect jump (JALR):
 //	  IF return (rs1=1, imm=0):
 //	    target = pop from RSB ✅
+//	  ELIF indirect table has history for this PC:
+//	    target = most frequent past target ✅
 //	  ELSE:
-//	    target = PC + 4 (can't predict well) ⚠️
+//	    target = PC + 4 (no idea) ⚠️
 //
 //	ELIF conditional branch (BEQ, BNE, etc):
 //	  Use direction predictor
@@ -1959,7 +2441,7 @@ func (bp *BranchPredictor) PeekRSB() (addr uint32, valid bool) {
 //
 //	We compute direct targets (free!)
 //	We use RSB for returns (small and accurate)
-//	We don't predict other indirect jumps well
+//	Other indirect jumps reuse the L1I indirect table (INNOVATION #24)
 //	Saves 98K transistors at cost of 0.15 IPC
 func (bp *BranchPredictor) PredictTarget(pc uint32, inst Instruction) uint32 {
 	switch inst.Opcode {
@@ -1978,8 +2460,13 @@ func (bp *BranchPredictor) PredictTarget(pc uint32, inst Instruction) uint32 {
 				return addr
 			}
 		}
-		// Other indirect jumps: can't predict well
-		// INNOVATION #33: No BTB, so just predict sequential
+		// Other indirect jumps: most frequent past target
+		// INNOVATION #33: No BTB - the L1I's indirect table stands in
+		if bp.indirect != nil {
+			if target, valid := bp.indirect.PredictIndirectTarget(pc); valid {
+				return target
+			}
+		}
 		return pc + 4
 
 	case OpBEQ, OpBNE, OpBLT, OpBGE:
@@ -2004,6 +2491,135 @@ func (bp *BranchPredictor) GetAccuracy() float64 {
 	return float64(bp.correct) / float64(bp.predictions)
 }
 
+// ═══════════════════════════════════════════════════════════════════════════════
+// BRANCH TRACE RECORDING
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY RECORD TRACES?
+//
+// The branch predictor can only be judged on real branch behavior.
+// Recording every retired branch lets us replay the exact same stream
+// through other predictors (TAGE, TAGE-SC-L, ...) and through this one
+// (bptrace.Core drives BranchPredictor itself), and compare them offline
+// with the trace harness in proto/bptrace.
+//
+// FORMAT: Identical to proto/bptrace (see that package for the full spec)
+//
+//	Text:   <pc> <target> <type> <T|N> <instructions>      one branch per line
+//	Binary: "BPTR" + version 1 + 3 zero bytes, then 21-byte records:
+//	        pc (8) + target (8) + instructions (4) + flags (1), little-endian
+//	        flags[2:0] = type, flags[7] = taken
+//
+// BRANCH TYPES (shared numbering with proto/bptrace):
+//
+//	0 cond: BEQ/BNE/BLT/BGE
+//	1 jump: JAL without link to ra
+//	2 call: JAL/JALR writing ra (x1)
+//	3 ret:  JALR x0, ra, 0
+//	4 ind:  any other JALR
+//
+// "instructions" is the number of instructions retired since the previous
+// recorded branch, including the branch itself (needed for MPKI).
+//
+// proto/bptrace imports this package, so it cannot import proto/bptrace
+// back. The format is pinned by the golden files in proto/bptrace/testdata:
+// SupraX_test.go checks this writer reproduces them and the bptrace suite
+// checks its Reader decodes them.
+const (
+	BranchTraceCond     = 0
+	BranchTraceJump     = 1
+	BranchTraceCall     = 2
+	BranchTraceReturn   = 3
+	BranchTraceIndirect = 4
+)
+
+var branchTraceTypeNames = [...]string{"cond", "jump", "call", "ret", "ind"}
+
+// BranchTraceWriter writes retired branches in the bptrace format
+type BranchTraceWriter struct {
+	w      *bufio.Writer
+	binary bool
+	header bool  // Binary header written?
+	err    error // First write error (sticky)
+}
+
+// NewBranchTraceWriter creates a trace writer (binary=false gives text)
+func NewBranchTraceWriter(w io.Writer, binary bool) *BranchTraceWriter {
+	return &BranchTraceWriter{w: bufio.NewWriter(w), binary: binary}
+}
+
+// BranchTraceType classifies a branch for the trace
+//
+// Uses the same return convention as PredictTarget (JALR with rs1=ra, imm=0)
+func BranchTraceType(opcode uint8, rd uint8, rs1 uint8, imm int32) uint8 {
+	switch opcode {
+	case OpJAL:
+		if rd == 1 {
+			return BranchTraceCall
+		}
+		return BranchTraceJump
+	case OpJALR:
+		if rs1 == 1 && imm == 0 && rd == 0 {
+			return BranchTraceReturn
+		}
+		if rd == 1 {
+			return BranchTraceCall
+		}
+		return BranchTraceIndirect
+	default:
+		return BranchTraceCond
+	}
+}
+
+// Record writes one branch (errors are sticky and reported by Flush)
+func (t *BranchTraceWriter) Record(pc, target uint32, branchType uint8, taken bool, instructions uint32) {
+	if t.err != nil {
+		return
+	}
+
+	if !t.binary {
+		dir := "N"
+		if taken {
+			dir = "T"
+		}
+		_, t.err = fmt.Fprintf(t.w, "%#x %#x %s %s %d\n",
+			pc, target, branchTraceTypeNames[branchType], dir, instructions)
+		return
+	}
+
+	t.writeHeader()
+	var rec [21]byte
+	binary.LittleEndian.PutUint64(rec[0:8], uint64(pc))
+	binary.LittleEndian.PutUint64(rec[8:16], uint64(target))
+	binary.LittleEndian.PutUint32(rec[16:20], instructions)
+	rec[20] = branchType
+	if taken {
+		rec[20] |= 0x80
+	}
+	if t.err == nil {
+		_, t.err = t.w.Write(rec[:])
+	}
+}
+
+func (t *BranchTraceWriter) writeHeader() {
+	if t.header || t.err != nil {
+		return
+	}
+	t.header = true
+	_, t.err = t.w.Write([]byte{'B', 'P', 'T', 'R', 1, 0, 0, 0})
+}
+
+// Flush writes any buffered records and returns the first error seen
+func (t *BranchTraceWriter) Flush() error {
+	if t.binary {
+		t.writeHeader()
+	}
+	if t.err != nil {
+		return t.err
+	}
+	return t.w.Flush()
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // L1D MEMORY ADDRESS PREDICTOR (INNOVATIONS #59-68)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -2123,8 +2739,43 @@ const (
 	PredictorConstant PredictorID = 3 // INNOVATION #62
 	PredictorDelta    PredictorID = 4 // INNOVATION #63
 	PredictorContext  PredictorID = 5 // INNOVATION #64
+
+	// Hardware prefetchers (see HARDWARE PREFETCHERS) share the ID space so
+	// prefetch queue entries and line tags can name them too
+	PrefetcherNextLine   PredictorID = 6
+	PrefetcherStream     PredictorID = 7
+	PrefetcherBestOffset PredictorID = 8
+	PrefetcherSMS        PredictorID = 9
+
+	NumPredictorIDs = 10 // Including PredictorNone (slot 0 of per-ID arrays)
 )
 
+var predictorNames = [NumPredictorIDs]string{
+	"none", "stride", "markov", "constant", "delta", "context",
+	"nextline", "stream", "bestoff", "sms",
+}
+
+// String returns the short name used in reports
+func (id PredictorID) String() string {
+	if int(id) < NumPredictorIDs {
+		return predictorNames[id]
+	}
+	return fmt.Sprintf("predictor(%d)", uint8(id))
+}
+
+// ClaimedCoverage is the design's per-component coverage budget
+// (share of loads × claimed accuracy, see TIMING PARAMETERS above)
+//
+// Used by the coverage report to compare what we promised against
+// what each benchmark actually measures.
+var ClaimedCoverage = [NumPredictorIDs]float64{
+	PredictorStride:   0.70 * 0.95, // 66.5%
+	PredictorMarkov:   0.15 * 0.90, // 13.5%
+	PredictorConstant: 0.05 * 1.00, // 5.0%
+	PredictorDelta:    0.03 * 0.85, // 2.6%
+	PredictorContext:  0.05 * 0.80, // 4.0%
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // STRIDE PREDICTOR (INNOVATION #60)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -2984,6 +3635,28 @@ func (mp *MetaPredictor) Update(pc uint32, predictorUsed PredictorID, correct bo
 // COMPLETE L1D PREDICTOR (INNOVATION #59 - THE ENSEMBLE)
 // ═══════════════════════════════════════════════════════════════════════════════
 
+// L1DComponentStats counts one component's contribution to the ensemble
+//
+// COUNTERS:
+//
+//	Offered:  Component had a valid prediction when the load was queried
+//	Selected: Meta-predictor picked it (INNOVATION #65)
+//	Resolved: Picked AND the load computed its address (not squashed)
+//	Correct:  Resolved AND the load went to the predicted address
+//	Useful:   Its prefetch filled a line that a demand load then hit
+//
+// DERIVED:
+//
+//	Accuracy = Correct / Resolved (how good it is when trusted)
+//	Coverage = Correct / Loads    (share of all loads it handled)
+type L1DComponentStats struct {
+	Offered  uint64
+	Selected uint64
+	Resolved uint64
+	Correct  uint64
+	Useful   uint64
+}
+
 // L1DPredictor combines all 5 predictors with meta-prediction
 type L1DPredictor struct {
 	stride   StridePredictor   // INNOVATION #60: Array access
@@ -2993,15 +3666,13 @@ type L1DPredictor struct {
 	context  ContextPredictor  // INNOVATION #64: Path-dependent
 	meta     MetaPredictor     // INNOVATION #65: Selector
 
-	// For feedback and statistics
-	lastPC        uint32
-	lastPredAddr  uint32
-	lastPredictor PredictorID
-	hasPrediction bool
-
 	// INNOVATION #66: Confidence tracking
 	totalPredictions   uint64
 	correctPredictions uint64
+
+	// Per-component breakdown (indexed by PredictorID, slot 0 unused)
+	components [NumPredictorIDs]L1DComponentStats
+	loads      uint64
 }
 
 // NewL1DPredictor creates the complete predictor ensemble
@@ -3015,13 +3686,37 @@ func NewL1DPredictor() *L1DPredictor {
 //
 //	STEP 1: Query all 5 predictors for this PC
 //	STEP 2: Meta-predictor chooses best prediction
-//	STEP 3: Remember prediction for later verification
-//	STEP 4: Return best prediction
+//	STEP 3: Return best prediction
+//
+// NO HIDDEN STATE: The caller carries (addr, predictor) with the load
+//
+//	(WindowEntry.PredictedMemAddr/MemPredictor) and hands it back to
+//	Resolve. Loads overlap - two LSUs, several iterations of one PC in
+//	the window - so a single "last prediction" register would check
+//	the wrong guess against the wrong address.
 //
 // MINECRAFT ANALOGY: Ask all 5 specialist villagers, then the master
 //
 //	villager picks whose advice to follow
 func (p *L1DPredictor) Predict(pc uint32) (addr uint32, predictor PredictorID, valid bool) {
+	addr, predictor, valid, offered := p.query(pc)
+
+	for i := range offered {
+		if offered[i] {
+			p.components[i+1].Offered++
+		}
+	}
+	if valid {
+		p.components[predictor].Selected++
+	}
+
+	return
+}
+
+// query asks every component and the meta-predictor, without touching stats
+//
+// Also used by L1DCache.triggerPrediction to pick a prefetch address.
+func (p *L1DPredictor) query(pc uint32) (addr uint32, predictor PredictorID, valid bool, offered [5]bool) {
 	// STEP 1: Get predictions from all 5 predictors
 	var predictions [5]struct {
 		addr       uint32
@@ -3035,52 +3730,66 @@ func (p *L1DPredictor) Predict(pc uint32) (addr uint32, predictor PredictorID, v
 	predictions[3].addr, predictions[3].confidence, predictions[3].valid = p.delta.Predict(pc)
 	predictions[4].addr, predictions[4].confidence, predictions[4].valid = p.context.Predict(pc)
 
-	// STEP 2: Meta-predictor chooses best
-	addr, predictor, valid = p.meta.SelectBest(pc, predictions)
-
-	// STEP 3: Remember for verification when load completes
-	p.lastPC = pc
-	p.lastPredAddr = addr
-	p.lastPredictor = predictor
-	p.hasPrediction = valid
-
-	if valid {
-		p.totalPredictions++
+	for i := range predictions {
+		offered[i] = predictions[i].valid
 	}
 
+	// STEP 2: Meta-predictor chooses best
+	addr, predictor, valid = p.meta.SelectBest(pc, predictions)
 	return
 }
 
-// RecordLoad is called when a load completes
-//
-// ALGORITHM:
+// Resolve checks one load's prediction against its computed address
 //
-//	STEP 1: Check if our prediction was correct
-//	STEP 2: Update meta-predictor with outcome
-//	STEP 3: Train all 5 predictors with observed address
+// Called once per load when its address is generated, with the
+// prediction that load was tagged with at dispatch.
 //
-// WHY TRAIN ALL: Each predictor learns its pattern
+// ALGORITHM:
 //
-//	Even if we didn't use it this time, it might be useful later
+//	STEP 1: Compare predicted vs actual address
+//	STEP 2: Update meta-predictor for the predictor that made it
 //
-// MINECRAFT ANALOGY: Tell all villagers what actually happened,
+// MINECRAFT ANALOGY: Check THIS villager's advice against what
 //
-//	so they can all learn for next time
-func (p *L1DPredictor) RecordLoad(pc uint32, addr uint32) {
-	// STEP 1: Check if prediction was correct
-	if p.hasPrediction && p.lastPC == pc {
-		correct := (p.lastPredAddr == addr)
+//	actually happened to THIS trip, not whoever spoke last
+func (p *L1DPredictor) Resolve(pc uint32, predAddr uint32, predictor PredictorID, addr uint32) {
+	if predictor == PredictorNone || int(predictor) >= NumPredictorIDs {
+		return
+	}
 
-		// STEP 2: Update meta-predictor
-		p.meta.Update(pc, p.lastPredictor, correct)
+	// STEP 1: Was it right?
+	correct := predAddr == addr
+	p.totalPredictions++
+	p.components[predictor].Resolved++
 
-		if correct {
-			p.correctPredictions++
-		}
+	// STEP 2: Update meta-predictor
+	p.meta.Update(pc, predictor, correct)
+
+	if correct {
+		p.correctPredictions++
+		p.components[predictor].Correct++
 	}
-	p.hasPrediction = false
+}
+
+// RecordLoad is called when a load accesses the cache
+//
+// ALGORITHM:
+//
+//	STEP 1: Train all 5 predictors with observed address
+//
+// WHY TRAIN ALL: Each predictor learns its pattern
+//
+//	Even if we didn't use it this time, it might be useful later
+//
+// NOTE: Verifying the load's own prediction is Resolve's job
+//
+// MINECRAFT ANALOGY: Tell all villagers what actually happened,
+//
+//	so they can all learn for next time
+func (p *L1DPredictor) RecordLoad(pc uint32, addr uint32) {
+	p.loads++
 
-	// STEP 3: Train all predictors with actual address
+	// STEP 1: Train all predictors with actual address
 	// Even unsuccessful predictors need to learn!
 	p.stride.Update(pc, addr)
 	p.markov.Update(addr)
@@ -3097,6 +3806,347 @@ func (p *L1DPredictor) GetAccuracy() float64 {
 	return float64(p.correctPredictions) / float64(p.totalPredictions)
 }
 
+// RecordUseful credits a component whose prefetch was hit by a demand load
+func (p *L1DPredictor) RecordUseful(predictor PredictorID) {
+	if predictor != PredictorNone && int(predictor) < NumPredictorIDs {
+		p.components[predictor].Useful++
+	}
+}
+
+// GetComponentStats returns the counters for one component
+func (p *L1DPredictor) GetComponentStats(predictor PredictorID) L1DComponentStats {
+	if int(predictor) >= NumPredictorIDs {
+		return L1DComponentStats{}
+	}
+	return p.components[predictor]
+}
+
+// GetLoadCount returns how many loads the predictor has been trained on
+func (p *L1DPredictor) GetLoadCount() uint64 {
+	return p.loads
+}
+
+// GetCoverage returns the share of all loads a component predicted correctly
+//
+// This is the number the INNOVATION #59 budget is stated in
+// (e.g. "Stride: 70% × 0.95 accuracy = 66.5% coverage").
+func (p *L1DPredictor) GetCoverage(predictor PredictorID) float64 {
+	if p.loads == 0 || int(predictor) >= NumPredictorIDs {
+		return 0
+	}
+	return float64(p.components[predictor].Correct) / float64(p.loads)
+}
+
+// CoverageReport compares measured per-component coverage to ClaimedCoverage
+//
+// COLUMNS:
+//
+//	Offered..Useful: raw L1DComponentStats counters
+//	Acc:     Correct / Resolved
+//	Cover:   Correct / Loads (measured)
+//	Claimed: ClaimedCoverage for that component
+//	Delta:   Cover - Claimed (negative = falling short of the budget)
+func (p *L1DPredictor) CoverageReport() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "L1D PREDICTOR COVERAGE (%d loads, %d predictions, %.2f%% accurate):\n",
+		p.loads, p.totalPredictions, p.GetAccuracy()*100)
+	fmt.Fprintf(&b, "  %-9s %9s %9s %9s %9s %9s %8s %8s %8s %8s\n",
+		"Component", "Offered", "Selected", "Resolved", "Correct", "Useful", "Acc", "Cover", "Claimed", "Delta")
+
+	var totalCover, totalClaimed float64
+	for id := PredictorStride; id <= PredictorContext; id++ {
+		st := p.components[id]
+		acc := 0.0
+		if st.Resolved > 0 {
+			acc = float64(st.Correct) / float64(st.Resolved)
+		}
+		cover := p.GetCoverage(id)
+		claimed := ClaimedCoverage[id]
+		totalCover += cover
+		totalClaimed += claimed
+
+		fmt.Fprintf(&b, "  %-9s %9d %9d %9d %9d %9d %7.2f%% %7.2f%% %7.2f%% %+7.2f%%\n",
+			id, st.Offered, st.Selected, st.Resolved, st.Correct, st.Useful,
+			acc*100, cover*100, claimed*100, (cover-claimed)*100)
+	}
+
+	fmt.Fprintf(&b, "  %-9s %9s %9s %9s %9s %9s %8s %7.2f%% %7.2f%% %+7.2f%%\n",
+		"Total", "", "", "", "", "", "", totalCover*100, totalClaimed*100, (totalCover-totalClaimed)*100)
+
+	return b.String()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// LOAD VALUE PREDICTOR (OPTIONAL)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: The L1D predictor guesses WHERE a load goes, but the
+//              instructions after it still wait for WHAT comes back
+//   r6 = load [r5]      // 1 cycle on a hit, 100 on a miss
+//   r1 = r1 + r6        // Stuck until the load returns
+//
+// THE SOLUTION: Predict the value itself, the same three ways the
+//               address predictors work
+//   Last-value:    Same value as last time (flags, loop bounds, globals)
+//   Stride-value:  Last value + constant (counters, induction variables)
+//   Context-value: Value that followed the last two values (FCM)
+//
+// THE FLOW:
+//   Dispatch: Confident? Write predicted value into the physical register
+//             and mark it ready - dependents issue immediately
+//   Complete: Real value arrives, compare against the prediction
+//   Commit:   Wrong AND a dependent already used it? Flush everything
+//             younger than the load and refetch (same recovery as a
+//             branch mispredict)
+//
+// WHY A HIGH THRESHOLD (12/15):
+//   Correct prediction saves a few cycles
+//   Wrong prediction costs a full pipeline flush
+//   Only predict when nearly certain ✅
+//
+// WHY OPTIONAL: Off by default so existing numbers don't move;
+//   Core.EnableValuePrediction turns it on for studies
+//
+// MINECRAFT ANALOGY: Start building with the block you're sure the
+//   chest holds - tear it down only if you opened it and were wrong
+
+// ValuePredKind identifies which component predicted a load value
+type ValuePredKind uint8
+
+const (
+	ValuePredNone    ValuePredKind = 0
+	ValuePredLast    ValuePredKind = 1 // Same as last value
+	ValuePredStride  ValuePredKind = 2 // Last value + stride
+	ValuePredContext ValuePredKind = 3 // Follows the last two values
+
+	NumValuePredKinds = 4
+)
+
+var valuePredNames = [NumValuePredKinds]string{"none", "last", "stride", "context"}
+
+// String returns the short name used in reports
+func (k ValuePredKind) String() string {
+	if int(k) < NumValuePredKinds {
+		return valuePredNames[k]
+	}
+	return fmt.Sprintf("valuepred(%d)", uint8(k))
+}
+
+// ValuePredEntry tracks one load PC's value history
+type ValuePredEntry struct {
+	Tag        uint16    // PC tag
+	LastValue  uint32    // Most recent value loaded
+	Stride     int32     // Difference between the last two values
+	LastConf   uint8     // Last-value confidence (0-15)
+	StrideConf uint8     // Stride-value confidence (0-15)
+	History    [2]uint32 // Last two values (context for FCM)
+	Valid      bool      // Is this entry in use?
+}
+
+// ValueContextEntry maps a value history to the value that followed it
+type ValueContextEntry struct {
+	Hash       uint32 // Full hash of PC + value history
+	Value      uint32 // Value seen after this history
+	Confidence uint8  // How confident (0-15)
+	Valid      bool   // Is this entry in use?
+}
+
+// ValuePredStats counts one component's predictions
+type ValuePredStats struct {
+	Predicted uint64 // Chosen at dispatch and later verified
+	Correct   uint64 // Prediction matched the loaded value
+}
+
+// LoadValuePredictor predicts load results at dispatch
+type LoadValuePredictor struct {
+	entries [ValuePredTableSize]ValuePredEntry
+	context [ValueContextSize]ValueContextEntry
+
+	// Statistics (indexed by ValuePredKind, slot 0 unused)
+	kinds   [NumValuePredKinds]ValuePredStats
+	loads   uint64 // Loads trained on
+	flushes uint64 // Wrong values that had already been consumed
+}
+
+// NewLoadValuePredictor creates an empty value predictor
+func NewLoadValuePredictor() *LoadValuePredictor {
+	return &LoadValuePredictor{}
+}
+
+func (vp *LoadValuePredictor) getIndex(pc uint32) int {
+	return int((pc >> 2) & (ValuePredTableSize - 1))
+}
+
+func (vp *LoadValuePredictor) getTag(pc uint32) uint16 {
+	return uint16(pc >> 10)
+}
+
+// hashContext mixes PC with the last two values (same scheme as INNOVATION #64)
+func (vp *LoadValuePredictor) hashContext(pc uint32, history [2]uint32) uint32 {
+	return pc ^ bits.RotateLeft32(history[0], 7) ^ bits.RotateLeft32(history[1], 14)
+}
+
+// Predict returns a value for the load at pc if a component is confident
+//
+// ALGORITHM:
+//
+//	STEP 1: Look up the PC's entry (tag must match)
+//	STEP 2: Gather last, stride and context candidates with confidence
+//	STEP 3: Return the most confident one at or above ValuePredMinConf
+//
+// TIE-BREAK: Context > stride > last (the more specific pattern wins)
+func (vp *LoadValuePredictor) Predict(pc uint32) (value uint32, kind ValuePredKind, valid bool) {
+	// STEP 1: Per-PC entry
+	entry := &vp.entries[vp.getIndex(pc)]
+	if !entry.Valid || entry.Tag != vp.getTag(pc) {
+		return 0, ValuePredNone, false
+	}
+
+	// STEP 2-3: Pick the most confident candidate
+	var best uint8
+	if entry.LastConf >= ValuePredMinConf && entry.LastConf >= best {
+		value, kind, valid, best = entry.LastValue, ValuePredLast, true, entry.LastConf
+	}
+	if entry.StrideConf >= ValuePredMinConf && entry.StrideConf >= best && entry.Stride != 0 {
+		value, kind, valid = uint32(int32(entry.LastValue)+entry.Stride), ValuePredStride, true
+		best = entry.StrideConf
+	}
+
+	hash := vp.hashContext(pc, entry.History)
+	ctx := &vp.context[hash&(ValueContextSize-1)]
+	if ctx.Valid && ctx.Hash == hash && ctx.Confidence >= ValuePredMinConf && ctx.Confidence >= best {
+		value, kind, valid = ctx.Value, ValuePredContext, true
+	}
+
+	return
+}
+
+// Update trains all three components with the value the load returned
+//
+// ALGORITHM:
+//
+//	IF entry invalid or different PC:
+//	  Initialize with this value
+//	ELSE:
+//	  Last:    value == last?          conf++ : conf = 0
+//	  Stride:  value - last == stride? conf++ : conf = 0, learn stride
+//	  Context: value == ctx[history]?  conf++ : conf--, learn at 0
+//	  Shift value into history
+//
+// WHY RESET TO 0 (not decrement) FOR LAST/STRIDE: A wrong value costs
+//
+//	a flush; one miss should silence the component until it re-earns
+//	ValuePredMinConf
+func (vp *LoadValuePredictor) Update(pc uint32, value uint32) {
+	vp.loads++
+
+	entry := &vp.entries[vp.getIndex(pc)]
+	tag := vp.getTag(pc)
+
+	// New entry or aliasing?
+	if !entry.Valid || entry.Tag != tag {
+		*entry = ValuePredEntry{
+			Tag:       tag,
+			LastValue: value,
+			History:   [2]uint32{value, 0},
+			Valid:     true,
+		}
+		return
+	}
+
+	// Last-value component
+	if value == entry.LastValue {
+		if entry.LastConf < 15 {
+			entry.LastConf++
+		}
+	} else {
+		entry.LastConf = 0
+	}
+
+	// Stride-value component
+	stride := int32(value) - int32(entry.LastValue)
+	if stride == entry.Stride {
+		if entry.StrideConf < 15 {
+			entry.StrideConf++
+		}
+	} else {
+		entry.StrideConf = 0
+		entry.Stride = stride
+	}
+
+	// Context-value component
+	hash := vp.hashContext(pc, entry.History)
+	ctx := &vp.context[hash&(ValueContextSize-1)]
+	if ctx.Valid && ctx.Hash == hash {
+		if ctx.Value == value {
+			if ctx.Confidence < 15 {
+				ctx.Confidence++
+			}
+		} else if ctx.Confidence > 0 {
+			ctx.Confidence--
+		} else {
+			ctx.Value = value
+		}
+	} else {
+		*ctx = ValueContextEntry{Hash: hash, Value: value, Confidence: 1, Valid: true}
+	}
+
+	// Shift history
+	entry.History[1] = entry.History[0]
+	entry.History[0] = value
+	entry.LastValue = value
+}
+
+// Resolve records whether a dispatched value prediction was right
+func (vp *LoadValuePredictor) Resolve(kind ValuePredKind, correct bool) {
+	if kind == ValuePredNone || int(kind) >= NumValuePredKinds {
+		return
+	}
+	vp.kinds[kind].Predicted++
+	if correct {
+		vp.kinds[kind].Correct++
+	}
+}
+
+// RecordFlush counts a wrong value that forced a pipeline flush
+func (vp *LoadValuePredictor) RecordFlush() {
+	vp.flushes++
+}
+
+// GetStats returns the counters for one component
+func (vp *LoadValuePredictor) GetStats(kind ValuePredKind) ValuePredStats {
+	if int(kind) >= NumValuePredKinds {
+		return ValuePredStats{}
+	}
+	return vp.kinds[kind]
+}
+
+// GetCoverage returns the share of loads whose value was predicted correctly
+func (vp *LoadValuePredictor) GetCoverage() float64 {
+	if vp.loads == 0 {
+		return 0
+	}
+	var correct uint64
+	for k := ValuePredLast; k < NumValuePredKinds; k++ {
+		correct += vp.kinds[k].Correct
+	}
+	return float64(correct) / float64(vp.loads)
+}
+
+// GetAccuracy returns the share of value predictions that were correct
+func (vp *LoadValuePredictor) GetAccuracy() float64 {
+	var predicted, correct uint64
+	for k := ValuePredLast; k < NumValuePredKinds; k++ {
+		predicted += vp.kinds[k].Predicted
+		correct += vp.kinds[k].Correct
+	}
+	if predicted == 0 {
+		return 0
+	}
+	return float64(correct) / float64(predicted)
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // PREFETCH QUEUE (INNOVATIONS #67-68)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -3146,7 +4196,8 @@ const (
 type PrefetchEntry struct {
 	Addr      uint32        // Memory address to prefetch
 	State     PrefetchState // Current state in lifecycle
-	Predictor PredictorID   // Which predictor made this prediction (for debug)
+	Predictor PredictorID   // Which predictor made this prediction
+	Late      bool          // A demand load missed on this line first
 }
 
 // PrefetchQueue manages pending prefetch requests (INNOVATION #67)
@@ -3183,8 +4234,8 @@ func (pq *PrefetchQueue) Enqueue(addr uint32, predictor PredictorID) bool {
 		idx := (pq.head + i) % PrefetchQueueSize
 		entry := &pq.entries[idx]
 
-		// If address already in queue and not complete yet
-		if entry.Addr == addr && entry.State != PrefetchEmpty {
+		// If line already in queue and not complete yet
+		if entry.Addr&^(CacheLineSize-1) == addr&^(CacheLineSize-1) && entry.State != PrefetchEmpty {
 			return false // Duplicate! Don't add
 		}
 	}
@@ -3235,18 +4286,29 @@ func (pq *PrefetchQueue) Dequeue() (addr uint32, valid bool) {
 //
 // ALGORITHM:
 //
-//	STEP 1: Find entry with matching address in InFlight state
+//	STEP 1: Find InFlight entry on the same cache line as addr
 //	STEP 2: Mark as Complete
 //	STEP 3: If it's at head: Remove from queue (advance head)
 //
+// WHY MATCH LINES: Entries hold the predicted (byte) address but fills
+//
+//	arrive line-aligned; comparing whole addresses would leave the
+//	head InFlight forever and stall every later prefetch
+//
 // WHY CHECK HEAD: We maintain queue order
 //
 //	Only remove from head to keep circular buffer consistent
-func (pq *PrefetchQueue) Complete(addr uint32) {
+//
+// RETURNS: the predictor that requested the line (PredictorNone if the
+//
+//	fill was not one of ours, e.g. a demand fill, or arrived late)
+func (pq *PrefetchQueue) Complete(addr uint32) PredictorID {
+	line := addr &^ (CacheLineSize - 1)
+
 	for i := 0; i < PrefetchQueueSize; i++ {
 		entry := &pq.entries[i]
 
-		if entry.Addr == addr && entry.State == PrefetchInFlight {
+		if entry.Addr&^(CacheLineSize-1) == line && entry.State == PrefetchInFlight {
 			entry.State = PrefetchComplete
 
 			// If this is at head, remove from queue
@@ -3254,9 +4316,31 @@ func (pq *PrefetchQueue) Complete(addr uint32) {
 				pq.head = (pq.head + 1) % PrefetchQueueSize
 				pq.count--
 			}
-			return
+			if entry.Late {
+				return PredictorNone // Demand already paid for it
+			}
+			return entry.Predictor
+		}
+	}
+	return PredictorNone
+}
+
+// MarkLate flags a queued prefetch whose line a demand load just missed on
+//
+// RETURNS: the requesting predictor, and whether such an entry existed
+func (pq *PrefetchQueue) MarkLate(addr uint32) (PredictorID, bool) {
+	line := addr &^ (CacheLineSize - 1)
+
+	for i := 0; i < pq.count; i++ {
+		entry := &pq.entries[(pq.head+i)%PrefetchQueueSize]
+
+		if entry.Addr&^(CacheLineSize-1) == line &&
+			(entry.State == PrefetchPending || entry.State == PrefetchInFlight) && !entry.Late {
+			entry.Late = true
+			return entry.Predictor, true
 		}
 	}
+	return PredictorNone, false
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -3348,27 +4432,100 @@ type IndirectTarget struct {
 
 // IndirectEntry tracks an indirect jump site (INNOVATION #24)
 type IndirectEntry struct {
-	PC      uint32                             // Address of indirect jump
-	Targets [L1IIndirectTargets]IndirectTarget // Up to 4 targets
-	Valid   bool                               // Is this entry valid?
+	PC       uint32                             // Address of indirect jump
+	Targets  [L1IIndirectTargets]IndirectTarget // Up to 4 targets
+	IsReturn bool                               // Trained by returns (INNOVATION #28)
+	Valid    bool                               // Is this entry valid?
+}
+
+// L1ISource identifies what asked for an L1I prefetch
+type L1ISource uint8
+
+const (
+	L1ISourceNone       L1ISource = 0 // Demand fill (not a prefetch)
+	L1ISourceSequential L1ISource = 1 // Next region (INNOVATION #26)
+	L1ISourceBranch     L1ISource = 2 // Tracked branch target (INNOVATION #23, #32)
+	L1ISourceIndirect   L1ISource = 3 // Indirect jump target (INNOVATION #24, #25)
+	L1ISourceReturn     L1ISource = 4 // Return target (INNOVATION #28)
+	L1ISourceFTQ        L1ISource = 5 // Fetch target queue (decoupled frontend)
+
+	NumL1ISources = 6
+)
+
+var l1iSourceNames = [NumL1ISources]string{"demand", "sequential", "branch", "indirect", "return", "ftq"}
+
+// String returns the short name used in reports
+func (s L1ISource) String() string {
+	if int(s) < NumL1ISources {
+		return l1iSourceNames[s]
+	}
+	return fmt.Sprintf("l1isource(%d)", uint8(s))
 }
 
 // CoverageRegion represents a region to potentially prefetch (INNOVATION #22)
 type CoverageRegion struct {
-	StartAddr  uint32  // Start of region
-	EndAddr    uint32  // End of region
-	Confidence float32 // How confident we are in this path
-	Urgency    float32 // How soon we'll need it (0.0=far, 1.0=soon)
-	Score      float32 // Combined score = confidence × urgency
-	Source     string  // What created this region (for debug)
+	StartAddr  uint32    // Start of region
+	EndAddr    uint32    // End of region
+	Confidence float32   // How confident we are in this path
+	Urgency    float32   // How soon we'll need it (0.0=far, 1.0=soon)
+	Score      float32   // Combined score = confidence × urgency
+	Source     L1ISource // What created this region
+}
+
+// L1ILineInfo remembers which prefetch brought a line in
+type L1ILineInfo struct {
+	Source    L1ISource // L1ISourceNone once fetched (or demand-filled)
+	FillCycle uint64    // When the prefetch landed
+}
+
+// L1ISourceStats counts one prefetch source's effectiveness
+type L1ISourceStats struct {
+	Issued     uint64 // Lines installed by this source
+	Useful     uint64 // ...fetched before eviction
+	Useless    uint64 // ...evicted without being fetched
+	Late       uint64 // Fetch reached the line while it was in flight
+	LeadCycles uint64 // Sum of fill → first fetch (useful lines)
+}
+
+// Accuracy returns useful / issued
+func (s L1ISourceStats) Accuracy() float64 {
+	if s.Issued == 0 {
+		return 0
+	}
+	return float64(s.Useful) / float64(s.Issued)
+}
+
+// AvgLead returns average cycles a useful line waited before fetch
+func (s L1ISourceStats) AvgLead() float64 {
+	if s.Useful == 0 {
+		return 0
+	}
+	return float64(s.LeadCycles) / float64(s.Useful)
+}
+
+// L1IBufferStats describes one of the four buffers (INNOVATION #21)
+type L1IBufferStats struct {
+	Active      bool   // Currently holding a live region
+	BaseAddr    uint32 // Region covered
+	EndAddr     uint32
+	ValidLines  int    // Lines currently valid
+	Activations uint64 // Times the buffer went from inactive to active
+	Fills       uint64 // Lines installed
+	Hits        uint64 // Fetches served (reuse)
+	Evictions   uint64 // Valid lines replaced
+	Thrash      uint64 // Evicted lines missed on again soon after
 }
 
 // L1IBuffer represents one of the 4 instruction cache buffers (INNOVATION #21)
 type L1IBuffer struct {
-	sets     [L1IBufferSets][L1Associativity]CacheLine
-	lru      [L1IBufferSets]uint8
+	sets     [][L1Associativity]CacheLine // L1IBufferSets by default
+	lru      []uint8
 	branches [L1IMaxBranches]BranchInfo // INNOVATION #23: Branch tracking
 
+	prefetchInfo [][L1Associativity]L1ILineInfo // Unfetched prefetches
+
+	stats L1IBufferStats // Activations, fills, hits, evictions, thrash
+
 	baseAddr   uint32 // Base address of this buffer's region
 	endAddr    uint32 // End address of this buffer's region
 	active     bool   // Is this buffer currently active?
@@ -3377,7 +4534,8 @@ type L1IBuffer struct {
 
 // L1ICache implements INNOVATIONS #21-28
 type L1ICache struct {
-	buffers [L1IBufferCount]L1IBuffer // INNOVATION #21: 4 buffers
+	buffers []L1IBuffer // INNOVATION #21: 4 buffers
+	numSets int         // Sets per buffer
 
 	// INNOVATION #24: Indirect jump predictor
 	indirectPredictor [L1IIndirectEntries]IndirectEntry
@@ -3393,11 +4551,29 @@ type L1ICache struct {
 	accesses uint64
 	hits     uint64
 	misses   uint64
+	cycle    uint64                        // Advanced by Tick (prefetch timeliness)
+	sources  [NumL1ISources]L1ISourceStats // Per prefetch source
+	evicted  [L1IThrashFilterSize]uint32   // Recently evicted line+1, by buffer
+	evictBuf [L1IThrashFilterSize]uint8    // ...and which buffer evicted it
 }
 
 // NewL1ICache creates an initialized instruction cache
 func NewL1ICache() *L1ICache {
-	return &L1ICache{}
+	return NewL1ICacheWithConfig(DefaultCoreConfig())
+}
+
+// NewL1ICacheWithConfig creates cfg.L1IBufferCount buffers of cfg.L1IBufferSize bytes
+func NewL1ICacheWithConfig(cfg CoreConfig) *L1ICache {
+	c := &L1ICache{
+		buffers: make([]L1IBuffer, cfg.L1IBufferCount),
+		numSets: cfg.l1iBufferSets(),
+	}
+	for i := range c.buffers {
+		c.buffers[i].sets = make([][L1Associativity]CacheLine, c.numSets)
+		c.buffers[i].lru = make([]uint8, c.numSets)
+		c.buffers[i].prefetchInfo = make([][L1Associativity]L1ILineInfo, c.numSets)
+	}
+	return c
 }
 
 // getSetIndex computes which set an address maps to
@@ -3408,12 +4584,12 @@ func NewL1ICache() *L1ICache {
 //	Use middle bits (set index)
 //	Upper bits become tag
 func (c *L1ICache) getSetIndex(addr uint32) int {
-	return int((addr >> 6) & uint32(L1IBufferSets-1))
+	return int((addr >> 6) & uint32(c.numSets-1))
 }
 
 // getTag extracts tag portion of address
 func (c *L1ICache) getTag(addr uint32) uint32 {
-	return addr >> (6 + bits.Len32(uint32(L1IBufferSets-1)))
+	return addr >> (6 + bits.Len32(uint32(c.numSets-1)))
 }
 
 // Read fetches an instruction from cache
@@ -3459,6 +4635,15 @@ func (c *L1ICache) Read(addr uint32) (data uint32, hit bool) {
 				c.updateLRU(bufIdx, setIdx, way)
 				buffer.lastAccess = c.accesses
 
+				buffer.stats.Hits++
+
+				// First fetch from a prefetched line: the prefetch paid off
+				if info := &buffer.prefetchInfo[setIdx][way]; info.Source != L1ISourceNone {
+					c.sources[info.Source].Useful++
+					c.sources[info.Source].LeadCycles += c.cycle - info.FillCycle
+					info.Source = L1ISourceNone
+				}
+
 				// Trigger coverage re-evaluation (INNOVATION #27)
 				c.evaluateCoverage(addr)
 
@@ -3469,10 +4654,28 @@ func (c *L1ICache) Read(addr uint32) (data uint32, hit bool) {
 
 	// MISS!
 	c.misses++
+
+	// Evicted not long ago? The buffer that dropped it is thrashing
+	line := addr &^ (CacheLineSize - 1)
+	if f := c.thrashIndex(line); c.evicted[f] == line+1 {
+		c.buffers[c.evictBuf[f]].stats.Thrash++
+		c.evicted[f] = 0
+	}
+
 	c.triggerPrefetch(addr)
 	return 0, false
 }
 
+// thrashIndex hashes a line into the recently-evicted filter
+func (c *L1ICache) thrashIndex(line uint32) int {
+	return int((line >> 6) & (L1IThrashFilterSize - 1))
+}
+
+// Tick advances the cycle count used for prefetch timeliness
+func (c *L1ICache) Tick() {
+	c.cycle++
+}
+
 // updateLRU marks a way as most recently used (INNOVATION #19)
 //
 // ALGORITHM: Simple 2-bit counter per set
@@ -3533,7 +4736,7 @@ func (c *L1ICache) evaluateCoverage(currentAddr uint32) {
 	// Clear old candidates
 	for i := range c.candidates {
 		c.candidates[i].Score = 0
-		c.candidates[i].Source = ""
+		c.candidates[i].Source = L1ISourceNone
 	}
 
 	candidateCount := 0
@@ -3547,7 +4750,7 @@ func (c *L1ICache) evaluateCoverage(currentAddr uint32) {
 		Confidence: 1.0, // 100% confident in sequential
 		Urgency:    1.0, // Immediate urgency
 		Score:      1.1, // Boosted! (1.0 × 1.0 + 0.1 boost)
-		Source:     "sequential",
+		Source:     L1ISourceSequential,
 	}
 	candidateCount++
 
@@ -3591,7 +4794,7 @@ func (c *L1ICache) evaluateCoverage(currentAddr uint32) {
 					Confidence: confidence,
 					Urgency:    urgency,
 					Score:      score,
-					Source:     "branch",
+					Source:     L1ISourceBranch,
 				}
 				candidateCount++
 			}
@@ -3600,6 +4803,10 @@ func (c *L1ICache) evaluateCoverage(currentAddr uint32) {
 
 	// Check indirect jump predictor (INNOVATION #24, #25)
 	indirectTargets := c.predictIndirect(currentAddr)
+	source := L1ISourceIndirect
+	if c.indirectPredictor[(currentAddr>>2)&(L1IIndirectEntries-1)].IsReturn {
+		source = L1ISourceReturn // Trained by NotifyReturn (INNOVATION #28)
+	}
 	for _, target := range indirectTargets {
 		if target.Score >= L1IMinScore && candidateCount < L1IMaxCandidates {
 			c.candidates[candidateCount] = CoverageRegion{
@@ -3608,7 +4815,7 @@ func (c *L1ICache) evaluateCoverage(currentAddr uint32) {
 				Confidence: 0.7,               // Moderate confidence
 				Urgency:    0.8,               // High urgency (unpredictable)
 				Score:      target.Score,
-				Source:     "indirect",
+				Source:     source,
 			}
 			candidateCount++
 		}
@@ -3724,6 +4931,23 @@ func (c *L1ICache) NotifyBranchResolved(pc uint32, taken bool, target uint32) {
 // Called when a return instruction completes
 // Updates indirect predictor with return address
 func (c *L1ICache) NotifyReturn(pc uint32, returnAddr uint32) {
+	c.NotifyIndirect(pc, returnAddr)
+
+	if entry := &c.indirectPredictor[(pc>>2)&(L1IIndirectEntries-1)]; entry.PC == pc {
+		entry.IsReturn = true
+	}
+}
+
+// NotifyIndirect trains the indirect predictor with a resolved JALR
+// target (INNOVATION #24)
+//
+// ALGORITHM:
+//
+//	STEP 1: Claim the entry for pc if it is free
+//	STEP 2: Target already tracked? Count it
+//	STEP 3: Free slot? Start tracking it
+//	STEP 4: List full: halve all counts (decay) and replace slot 0
+func (c *L1ICache) NotifyIndirect(pc uint32, target uint32) {
 	idx := int((pc >> 2) & (L1IIndirectEntries - 1))
 	entry := &c.indirectPredictor[idx]
 
@@ -3736,13 +4960,13 @@ func (c *L1ICache) NotifyReturn(pc uint32, returnAddr uint32) {
 	if entry.PC == pc {
 		// Find target in list or add new one
 		for i := 0; i < L1IIndirectTargets; i++ {
-			if entry.Targets[i].Addr == returnAddr {
+			if entry.Targets[i].Addr == target {
 				entry.Targets[i].Count++
 				return
 			}
 
 			if entry.Targets[i].Count == 0 {
-				entry.Targets[i].Addr = returnAddr
+				entry.Targets[i].Addr = target
 				entry.Targets[i].Count = 1
 				return
 			}
@@ -3752,11 +4976,27 @@ func (c *L1ICache) NotifyReturn(pc uint32, returnAddr uint32) {
 		for i := 0; i < L1IIndirectTargets; i++ {
 			entry.Targets[i].Count >>= 1 // Divide by 2 (decay)
 		}
-		entry.Targets[0].Addr = returnAddr
+		entry.Targets[0].Addr = target
 		entry.Targets[0].Count = 1
 	}
 }
 
+// PredictIndirectTarget returns the highest-scoring target for the
+// indirect jump at pc (INNOVATION #24)
+//
+// Ties go to the earlier slot. Returns false when pc has no history.
+func (c *L1ICache) PredictIndirectTarget(pc uint32) (target uint32, valid bool) {
+	best := float32(0)
+	for _, t := range c.predictIndirect(pc) {
+		if t.Score > best {
+			best = t.Score
+			target = t.Addr
+			valid = true
+		}
+	}
+	return target, valid
+}
+
 // TriggerBranchTargetPrefetch initiates prefetching of a branch target
 //
 // ALGORITHM:
@@ -3792,7 +5032,7 @@ func (c *L1ICache) TriggerBranchTargetPrefetch(target uint32, confidence float32
 				Confidence: confidence,
 				Urgency:    0.9, // High urgency for predicted branch
 				Score:      confidence * 0.9,
-				Source:     "branch_target",
+				Source:     L1ISourceBranch,
 			}
 			break
 		}
@@ -3824,11 +5064,16 @@ func (c *L1ICache) GetHitRate() float64 {
 //	STEP 3: Install line
 //	STEP 4: Update buffer metadata
 func (c *L1ICache) Fill(addr uint32, data []byte) {
+	c.fill(addr, data)
+}
+
+// fill installs a line and returns the buffer and way it went to
+func (c *L1ICache) fill(addr uint32, data []byte) (bufIdx, way int) {
 	// Find best buffer (prefer inactive, or LRU active)
 	bestBuf := 0
 	oldestAccess := c.buffers[0].lastAccess
 
-	for i := 1; i < L1IBufferCount; i++ {
+	for i := 1; i < len(c.buffers); i++ {
 		if !c.buffers[i].active {
 			bestBuf = i
 			break
@@ -3857,6 +5102,21 @@ func (c *L1ICache) Fill(addr uint32, data []byte) {
 
 	line := &set[victimWay]
 
+	// Account for what we are replacing
+	if line.Valid {
+		buffer.stats.Evictions++
+
+		victim := line.Tag<<(6+bits.Len32(uint32(c.numSets-1))) | uint32(setIdx)<<6
+		f := c.thrashIndex(victim)
+		c.evicted[f] = victim + 1
+		c.evictBuf[f] = uint8(bestBuf)
+
+		if info := &buffer.prefetchInfo[setIdx][victimWay]; info.Source != L1ISourceNone {
+			c.sources[info.Source].Useless++
+		}
+	}
+	buffer.prefetchInfo[setIdx][victimWay] = L1ILineInfo{}
+
 	// Install line
 	line.Tag = tag
 	line.Valid = true
@@ -3865,8 +5125,12 @@ func (c *L1ICache) Fill(addr uint32, data []byte) {
 
 	// Update metadata
 	c.updateLRU(bestBuf, setIdx, victimWay)
+	if !buffer.active {
+		buffer.stats.Activations++
+	}
 	buffer.active = true
 	buffer.lastAccess = c.accesses
+	buffer.stats.Fills++
 
 	// Update buffer region
 	lineAddr := addr &^ (CacheLineSize - 1)
@@ -3881,6 +5145,49 @@ func (c *L1ICache) Fill(addr uint32, data []byte) {
 	if addr == c.prefetchAddr {
 		c.prefetchActive = false
 	}
+
+	return bestBuf, victimWay
+}
+
+// Contains reports whether the line holding addr is in any buffer
+func (c *L1ICache) Contains(addr uint32) bool {
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+
+	for bufIdx := range c.buffers {
+		for way := 0; way < L1Associativity; way++ {
+			line := &c.buffers[bufIdx].sets[setIdx][way]
+			if line.Valid && line.Tag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Prefetch installs a line ahead of fetch
+//
+// Lines already present are skipped (returns false) so the issued
+// count only covers real memory traffic. The line is tagged with its
+// source so the first fetch that hits it (or its eviction unfetched)
+// is credited to the right place.
+func (c *L1ICache) Prefetch(addr uint32, data []byte, source L1ISource) bool {
+	if c.Contains(addr) {
+		return false
+	}
+
+	bufIdx, way := c.fill(addr, data)
+	c.buffers[bufIdx].prefetchInfo[c.getSetIndex(addr)][way] = L1ILineInfo{
+		Source:    source,
+		FillCycle: c.cycle,
+	}
+	c.sources[source].Issued++
+	return true
+}
+
+// MarkLate records that fetch caught up with a prefetch still in flight
+func (c *L1ICache) MarkLate(source L1ISource) {
+	c.sources[source].Late++
 }
 
 // Flush clears all buffers (on branch misprediction)
@@ -3899,20 +5206,21 @@ func (c *L1ICache) Flush() {
 	c.prefetchActive = false
 }
 
-// GetPrefetchAddr returns next address to prefetch
-func (c *L1ICache) GetPrefetchAddr() (addr uint32, valid bool) {
+// GetPrefetchAddr returns next address to prefetch and what asked
+// for it (L1ISourceNone for the line a miss is waiting on)
+func (c *L1ICache) GetPrefetchAddr() (addr uint32, source L1ISource, valid bool) {
 	if c.prefetchActive {
-		return c.prefetchAddr, true
+		return c.prefetchAddr, L1ISourceNone, true
 	}
 
 	// Check candidates for prefetch
 	for i := range c.candidates {
 		if c.candidates[i].Score >= 0.5 {
-			return c.candidates[i].StartAddr, true
+			return c.candidates[i].StartAddr, c.candidates[i].Source, true
 		}
 	}
 
-	return 0, false
+	return 0, L1ISourceNone, false
 }
 
 // GetStats returns cache statistics
@@ -3926,21 +5234,86 @@ func (c *L1ICache) GetStats() string {
 		c.hits, c.misses, hitRate)
 }
 
-// GetBufferStates returns buffer utilization info
-func (c *L1ICache) GetBufferStates() string {
-	active := 0
-	for i := range c.buffers {
-		if c.buffers[i].active {
-			active++
-		}
+// GetPrefetchCounts returns demand misses, lines prefetched and
+// prefetched lines that were later fetched (all sources)
+func (c *L1ICache) GetPrefetchCounts() (misses, issued, useful uint64) {
+	for src := L1ISourceSequential; src < NumL1ISources; src++ {
+		issued += c.sources[src].Issued
+		useful += c.sources[src].Useful
 	}
-	return fmt.Sprintf("%d/4 active", active)
+	return c.misses, issued, useful
 }
 
-// GetIndirectAccuracy returns indirect predictor accuracy
-func (c *L1ICache) GetIndirectAccuracy() float64 {
-	// Simplified: Count valid entries as proxy for accuracy
-	valid := 0
+// GetSourceStats returns the prefetch counters for one source
+func (c *L1ICache) GetSourceStats(source L1ISource) L1ISourceStats {
+	return c.sources[source]
+}
+
+// GetBufferStats returns the state and counters of every buffer
+func (c *L1ICache) GetBufferStats() []L1IBufferStats {
+	out := make([]L1IBufferStats, len(c.buffers))
+
+	for i := range c.buffers {
+		buffer := &c.buffers[i]
+		st := buffer.stats
+		st.Active = buffer.active
+		st.BaseAddr = buffer.baseAddr
+		st.EndAddr = buffer.endAddr
+
+		for set := range buffer.sets {
+			for way := 0; way < L1Associativity; way++ {
+				if buffer.sets[set][way].Valid {
+					st.ValidLines++
+				}
+			}
+		}
+		out[i] = st
+	}
+	return out
+}
+
+// TelemetryReport returns per-source prefetch and per-buffer tables
+func (c *L1ICache) TelemetryReport() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "  %-11s %8s %8s %8s %8s %8s %9s\n",
+		"Source", "Issued", "Useful", "Useless", "Late", "Acc", "AvgLead")
+	for src := L1ISourceSequential; src < NumL1ISources; src++ {
+		st := c.sources[src]
+		fmt.Fprintf(&b, "  %-11s %8d %8d %8d %8d %7.2f%% %9.1f\n",
+			src, st.Issued, st.Useful, st.Useless, st.Late, st.Accuracy()*100, st.AvgLead())
+	}
+
+	fmt.Fprintf(&b, "\n  %-6s %-6s %7s %8s %8s %8s %8s %8s\n",
+		"Buffer", "Active", "Lines", "Activ", "Fills", "Hits", "Evicts", "Thrash")
+	for i, st := range c.GetBufferStats() {
+		active := "no"
+		if st.Active {
+			active = "yes"
+		}
+		fmt.Fprintf(&b, "  %-6d %-6s %7d %8d %8d %8d %8d %8d\n",
+			i, active, st.ValidLines, st.Activations, st.Fills, st.Hits, st.Evictions, st.Thrash)
+	}
+	return b.String()
+}
+
+// GetBufferStates returns buffer utilization info
+//
+// Summary only; GetBufferStats has the per-buffer detail
+func (c *L1ICache) GetBufferStates() string {
+	active := 0
+	for i := range c.buffers {
+		if c.buffers[i].active {
+			active++
+		}
+	}
+	return fmt.Sprintf("%d/%d active", active, len(c.buffers))
+}
+
+// GetIndirectAccuracy returns indirect predictor accuracy
+func (c *L1ICache) GetIndirectAccuracy() float64 {
+	// Simplified: Count valid entries as proxy for accuracy
+	valid := 0
 	for i := range c.indirectPredictor {
 		if c.indirectPredictor[i].Valid {
 			valid++
@@ -3950,2535 +5323,6493 @@ func (c *L1ICache) GetIndirectAccuracy() float64 {
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
-// L1D CACHE (INNOVATIONS #18-20)
+// L1I FILL PATH
 // ═══════════════════════════════════════════════════════════════════════════════
 //
-// INNOVATION #18: 4-way set-associative
-// INNOVATION #19: LRU replacement
-// INNOVATION #20: 64-byte cache lines
+// THE PROBLEM: An instruction line that isn't cached comes from DRAM,
+//              exactly like a data line - 100 cycles away
+//   Filling it in the same cycle makes L1I misses (and prefetch) free
 //
-// Plus integration with the 5-way predictor (INNOVATION #59)
+// THE SOLUTION: Every L1I line fill, demand or prefetch, takes one of
+//   L1IFillSlots in-flight slots and goes to DRAM through the memory
+//   arbiter (the same path an LSU takes on a data miss)
+//   Bandwidth: At most L1IFillsPerCycle new fills start each cycle
+//   Priority:  Fetch asks first each cycle, prefetch gets what's left
+//   Merge:     Fetch missing on a line already being prefetched waits
+//              for that fill instead of starting another (late prefetch)
+//
+// MINECRAFT ANALOGY: A few minecarts shuttle between the mine and the
+//   workbench - the builder waits whenever the next block is still
+//   on its way
 
-// L1DCache is the data cache with 5-way predictor
-type L1DCache struct {
-	sets          [L1DNumSets][L1Associativity]CacheLine
-	lru           [L1DNumSets]uint8
-	predictor     *L1DPredictor // INNOVATION #59: 5-way predictor
-	prefetchQueue PrefetchQueue // INNOVATION #67: Prefetch queue
+// L1IFill is one instruction line in flight from memory
+type L1IFill struct {
+	Line     uint32      // Line address
+	Prefetch bool        // Started by prefetch
+	Source   L1ISource   // ...and which source asked
+	Demand   bool        // Fetch is waiting on it
+	req      *MemRequest // Read under way at the arbiter
+	Valid    bool        // Slot in use
+}
 
-	// For atomic operations (INNOVATION #71-72)
-	reservationValid bool
-	reservationAddr  uint32
+// L1IFillUnit moves instruction lines from memory into the L1I
+type L1IFillUnit struct {
+	icache  *L1ICache
+	arbiter *MemoryArbiter
+
+	slots   [L1IFillSlots]L1IFill
+	started int // Fills started this cycle
 
 	// Statistics
-	accesses uint64
-	hits     uint64
+	DemandFills    uint64 // Lines fetched because fetch missed
+	PrefetchFills  uint64 // Lines fetched by prefetch
+	LatePrefetches uint64 // Prefetches fetch caught up with
+	Rejected       uint64 // Requests turned away (no slot or bandwidth)
 }
 
-// NewL1DCache creates an initialized data cache
-func NewL1DCache() *L1DCache {
-	return &L1DCache{
-		predictor: NewL1DPredictor(),
+// NewL1IFillUnit creates an idle fill unit for icache
+func NewL1IFillUnit(icache *L1ICache, arbiter *MemoryArbiter) *L1IFillUnit {
+	return &L1IFillUnit{icache: icache, arbiter: arbiter}
+}
+
+// Tick installs the lines that arrived
+//
+// Called at the start of the cycle (after the arbiter), so fetch sees
+// a line the same cycle it lands.
+func (u *L1IFillUnit) Tick() {
+	u.started = 0
+
+	for i := range u.slots {
+		fill := &u.slots[i]
+		if !fill.Valid || !fill.req.Done {
+			continue
+		}
+
+		if fill.Prefetch {
+			u.icache.Prefetch(fill.Line, fill.req.Data[:], fill.Source)
+		} else if !u.icache.Contains(fill.Line) {
+			u.icache.Fill(fill.Line, fill.req.Data[:])
+		}
+		fill.Valid = false
+		fill.req = nil
 	}
 }
 
-func (c *L1DCache) getSetIndex(addr uint32) int {
-	return int((addr >> 6) & uint32(L1DNumSets-1))
+// Pending reports whether the line holding addr is already in flight
+func (u *L1IFillUnit) Pending(addr uint32) bool {
+	return u.find(addr&^(CacheLineSize-1)) != nil
 }
 
-func (c *L1DCache) getTag(addr uint32) uint32 {
-	return addr >> (6 + bits.Len32(uint32(L1DNumSets-1)))
+func (u *L1IFillUnit) find(line uint32) *L1IFill {
+	for i := range u.slots {
+		if u.slots[i].Valid && u.slots[i].Line == line {
+			return &u.slots[i]
+		}
+	}
+	return nil
 }
 
-// Read loads data from cache, training the predictor
-//
-// ALGORITHM:
-//
-//	STEP 1: Search cache for address
-//	STEP 2: If found: Return data, update LRU
-//	STEP 3: Train predictor with actual address
-//	STEP 4: Trigger prediction for next access
-func (c *L1DCache) Read(pc uint32, addr uint32) (data uint32, hit bool) {
-	c.accesses++
+// CanAccept reports whether a new fill could start this cycle
+func (u *L1IFillUnit) CanAccept() bool {
+	if u.started >= L1IFillsPerCycle {
+		return false
+	}
+	for i := range u.slots {
+		if !u.slots[i].Valid {
+			return true
+		}
+	}
+	return false
+}
 
-	setIdx := c.getSetIndex(addr)
-	tag := c.getTag(addr)
-	set := &c.sets[setIdx]
+// start claims a free slot for line (false if none this cycle)
+func (u *L1IFillUnit) start(line uint32, source L1ISource) bool {
+	if !u.CanAccept() {
+		u.Rejected++
+		return false
+	}
 
-	// STEP 1-2: Search cache
-	for way := 0; way < L1Associativity; way++ {
-		line := &set[way]
+	prefetch := source != L1ISourceNone
+	class := MemInstDemand
+	if prefetch {
+		class = MemInstPrefetch
+	}
 
-		if line.Valid && line.Tag == tag {
-			// HIT!
-			c.hits++
-			offset := int(addr & (CacheLineSize - 1))
+	for i := range u.slots {
+		if !u.slots[i].Valid {
+			u.slots[i] = L1IFill{
+				Line:     line,
+				Prefetch: prefetch,
+				Source:   source,
+				Demand:   !prefetch,
+				req:      u.arbiter.Submit(line, class),
+				Valid:    true,
+			}
+			u.started++
+			return true
+		}
+	}
+	return false
+}
 
-			data = uint32(line.Data[offset]) |
-				uint32(line.Data[offset+1])<<8 |
-				uint32(line.Data[offset+2])<<16 |
-				uint32(line.Data[offset+3])<<24
+// RequestDemand asks for the line fetch just missed on
+//
+// Merges into a fill already in flight; returns false only when the
+// request could not be started this cycle (fetch retries next cycle).
+func (u *L1IFillUnit) RequestDemand(addr uint32) bool {
+	line := addr &^ (CacheLineSize - 1)
 
-			c.updateLRU(setIdx, way)
+	if fill := u.find(line); fill != nil {
+		if fill.Prefetch && !fill.Demand {
+			u.LatePrefetches++
+			u.icache.MarkLate(fill.Source)
+			u.arbiter.Promote(fill.req, MemInstDemand)
+		}
+		fill.Demand = true
+		return true
+	}
 
-			// STEP 3: Train predictor (INNOVATION #59)
-			c.predictor.RecordLoad(pc, addr)
+	if !u.start(line, L1ISourceNone) {
+		return false
+	}
+	u.DemandFills++
+	return true
+}
 
-			// STEP 4: Trigger prediction
-			c.triggerPrediction(pc)
+// RequestPrefetch starts a prefetch fill for addr's line on behalf
+// of source
+//
+// Returns false when the line is cached, already in flight, or no
+// slot is free this cycle. A line a miss is waiting on (source None)
+// is left to RequestDemand.
+func (u *L1IFillUnit) RequestPrefetch(addr uint32, source L1ISource) bool {
+	line := addr &^ (CacheLineSize - 1)
+	if source == L1ISourceNone || u.icache.Contains(line) || u.find(line) != nil {
+		return false
+	}
 
-			return data, true
-		}
+	if !u.start(line, source) {
+		return false
 	}
+	u.PrefetchFills++
+	return true
+}
 
-	// MISS
-	c.predictor.RecordLoad(pc, addr)
-	return 0, false
+// GetStats returns fill path statistics
+func (u *L1IFillUnit) GetStats() string {
+	return fmt.Sprintf("Demand fills: %d, Prefetch fills: %d, Late prefetches: %d, Rejected: %d",
+		u.DemandFills, u.PrefetchFills, u.LatePrefetches, u.Rejected)
 }
 
-// triggerPrediction asks predictor what we'll need next
+// ═══════════════════════════════════════════════════════════════════════════════
+// FETCH TARGET QUEUE (OPTIONAL)
+// ═══════════════════════════════════════════════════════════════════════════════
 //
-// ALGORITHM:
+// THE PROBLEM: Coverage scoring (INNOVATION #22, #27) GUESSES which
+//              regions fetch will need from branches it has seen
+//   It re-scores on every fetch, and only looks near the current PC
+//   The branch predictor already knows the path fetch will follow
 //
-//	STEP 1: Get prediction from 5-way predictor
-//	STEP 2: Check if already in cache
-//	STEP 3: If not: Add to prefetch queue (INNOVATION #67)
-func (c *L1DCache) triggerPrediction(pc uint32) {
-	predAddr, predictor, valid := c.predictor.Predict(pc)
+// THE SOLUTION: Decouple prediction from fetch (fetch-directed prefetch)
+//   Predictor runs ahead:  Walks the predicted path one fetch block at
+//                          a time and queues each block in the FTQ
+//   Fetch follows the FTQ: No prediction at fetch, just the next block
+//   Prefetch scans the FTQ: Every queued block's line is a line fetch
+//                           WILL ask for (unless a branch mispredicts)
+//
+// FETCH BLOCK: Instructions from a start PC up to the first branch or
+//   jump, or to the end of the cache line - one line, one prediction
+//
+// PRE-DECODE: The run-ahead walk reads instruction words straight from
+//   memory to find branches. Hardware keeps this in the per-buffer
+//   branch info (INNOVATION #23); the simulator takes the shortcut.
+//
+// RSB: The walk owns the RSB - predicted calls push, predicted returns
+//   pop. Each block records the RSB state around its branch; fetch
+//   hands that to every instruction, and a redirect restores the RSB
+//   to the redirecting instruction's checkpoint.
+//
+// RECOVERY: Mispredict or value flush redirects the run-ahead PC,
+//   restores the RSB and empties the queue - everything in it was
+//   down the wrong path
+//
+// WHY OPTIONAL: Off by default so existing numbers don't move;
+//   Core.EnableFDIP switches the frontend for comparison studies
+//
+// MINECRAFT ANALOGY: A scout walks the route ahead and posts a list of
+//   chests to open - the builder just follows the list
 
-	if !valid {
-		return
-	}
+// FetchBlock is one predicted run of sequential instructions
+type FetchBlock struct {
+	Start      uint32 // First instruction
+	End        uint32 // Address after the last instruction
+	Next       uint32 // Predicted start of the following block
+	BranchPC   uint32 // Branch or jump ending the block (if HasBranch)
+	PredTaken  bool   // Predicted direction of that branch
+	HasBranch  bool   // Block ends in a branch or jump
+	Prefetched bool   // Line already handed to prefetch
 
-	// Check if already in cache
-	setIdx := c.getSetIndex(predAddr)
-	tag := c.getTag(predAddr)
-	set := &c.sets[setIdx]
+	// RSB checkpoints: before and after the ending branch's push/pop
+	RSBBefore RSBCheckpoint
+	RSBAfter  RSBCheckpoint
+}
 
-	for way := 0; way < L1Associativity; way++ {
-		if set[way].Valid && set[way].Tag == tag {
-			return // Already cached, no need to prefetch
-		}
-	}
+// FetchTargetQueue holds the predicted fetch path ahead of fetch
+type FetchTargetQueue struct {
+	blocks [FTQSize]FetchBlock
+	head   int // Oldest block (next to fetch)
+	count  int // Blocks queued
+
+	runPC uint32 // Where the run-ahead walk continues
+
+	// Statistics
+	Blocks     uint64 // Blocks predicted
+	Prefetches uint64 // Lines handed to prefetch
+	Redirects  uint64 // Queue flushes (mispredicts)
+	FullCycles uint64 // Cycles the walk stalled on a full queue
+	cycles     uint64 // Cycles the walk ran
+	occupancy  uint64 // Sum of queued blocks per cycle
+}
 
-	// INNOVATION #67-68: Queue prefetch with deduplication
-	c.prefetchQueue.Enqueue(predAddr, predictor)
+// NewFetchTargetQueue creates an empty FTQ starting at pc
+func NewFetchTargetQueue(pc uint32) *FetchTargetQueue {
+	return &FetchTargetQueue{runPC: pc}
 }
 
-// Write stores data to cache
+// RunAhead extends the predicted path by up to FTQBlocksPerCycle blocks
 //
 // ALGORITHM:
 //
-//	STEP 1: Find line in cache
-//	STEP 2: Write data to line, mark dirty
-//	STEP 3: Invalidate any reservations (for atomics)
-func (c *L1DCache) Write(addr uint32, data uint32) bool {
-	setIdx := c.getSetIndex(addr)
-	tag := c.getTag(addr)
-	set := &c.sets[setIdx]
-
-	for way := 0; way < L1Associativity; way++ {
-		line := &set[way]
-
-		if line.Valid && line.Tag == tag {
-			offset := int(addr & (CacheLineSize - 1))
-
-			line.Data[offset] = byte(data)
-			line.Data[offset+1] = byte(data >> 8)
-			line.Data[offset+2] = byte(data >> 16)
-			line.Data[offset+3] = byte(data >> 24)
-			line.Dirty = true
+//	FOR each block this cycle (while the queue has room):
+//	  STEP 1: Pre-decode from runPC to the first branch/jump or line end
+//	  STEP 2: Branch? Predict direction and target (same predictor
+//	          and RSB the fetch stage uses); a call pushes its
+//	          return address, a return pops it
+//	  STEP 3: Queue the block, continue at its predicted successor
+func (q *FetchTargetQueue) RunAhead(memory []byte, bp *BranchPredictor) {
+	q.cycles++
+	q.occupancy += uint64(q.count)
+
+	for n := 0; n < FTQBlocksPerCycle; n++ {
+		if q.count == FTQSize {
+			q.FullCycles++
+			return
+		}
 
-			c.updateLRU(setIdx, way)
+		// STEP 1: Scan to the end of the line
+		block := FetchBlock{Start: q.runPC, RSBBefore: bp.CheckpointRSB()}
+		lineEnd := (q.runPC | (CacheLineSize - 1)) + 1
+		block.End = lineEnd
+		block.Next = lineEnd
+
+		for pc := q.runPC; pc < lineEnd; pc += 4 {
+			word := uint32(0)
+			if int(pc)+3 < len(memory) {
+				word = uint32(memory[pc]) | uint32(memory[pc+1])<<8 |
+					uint32(memory[pc+2])<<16 | uint32(memory[pc+3])<<24
+			}
 
-			// INNOVATION #72: Invalidate reservation if writing to reserved line
-			if c.reservationValid && (addr&^63) == (c.reservationAddr&^63) {
-				c.reservationValid = false
+			inst := DecodeInstruction(word, pc)
+			if !inst.IsBranch && !inst.IsJump {
+				continue
 			}
 
-			return true
+			// STEP 2: Predict where the block goes
+			block.End = pc + 4
+			block.BranchPC = pc
+			block.HasBranch = true
+			if inst.IsJump {
+				block.PredTaken = true
+				block.Next = bp.PredictTarget(pc, inst)
+				if inst.Rd == 1 {
+					bp.PushRSB(pc + 4) // Call: its return comes back here
+				}
+			} else {
+				block.PredTaken, _ = bp.Predict(pc)
+				block.Next = pc + 4
+				if block.PredTaken {
+					block.Next = uint32(int32(pc) + inst.Imm)
+				}
+			}
+			break
 		}
+
+		// STEP 3: Queue it
+		block.RSBAfter = bp.CheckpointRSB()
+		q.blocks[(q.head+q.count)%FTQSize] = block
+		q.count++
+		q.Blocks++
+		q.runPC = block.Next
 	}
+}
 
-	return false // Not in cache
+// Head returns the oldest queued block (the one fetch is working on)
+func (q *FetchTargetQueue) Head() (*FetchBlock, bool) {
+	if q.count == 0 {
+		return nil, false
+	}
+	return &q.blocks[q.head], true
 }
 
-// Fill installs a cache line from memory
-func (c *L1DCache) Fill(addr uint32, data []byte) {
-	setIdx := c.getSetIndex(addr)
-	tag := c.getTag(addr)
-	set := &c.sets[setIdx]
+// Pop retires the head block once fetch has passed its end
+func (q *FetchTargetQueue) Pop() {
+	if q.count == 0 {
+		return
+	}
+	q.head = (q.head + 1) % FTQSize
+	q.count--
+}
 
-	victimWay := c.findVictim(setIdx)
-	line := &set[victimWay]
+// NextPrefetch returns the line of the oldest block not yet prefetched
+//
+// Consecutive blocks in the same line share one prefetch.
+func (q *FetchTargetQueue) NextPrefetch() (lineAddr uint32, valid bool) {
+	for i := 0; i < q.count; i++ {
+		block := &q.blocks[(q.head+i)%FTQSize]
+		if block.Prefetched {
+			continue
+		}
 
-	line.Tag = tag
-	line.Valid = true
-	line.Dirty = false
-	copy(line.Data[:], data)
+		// Different line: leave it for the next call
+		line := block.Start &^ (CacheLineSize - 1)
+		if valid && line != lineAddr {
+			break
+		}
 
-	c.updateLRU(setIdx, victimWay)
-	c.prefetchQueue.Complete(addr)
+		block.Prefetched = true
+		lineAddr, valid = line, true
+	}
+
+	if valid {
+		q.Prefetches++
+	}
+	return lineAddr, valid
 }
 
-// findVictim selects a line to evict (INNOVATION #19: LRU)
-func (c *L1DCache) findVictim(setIdx int) int {
-	set := &c.sets[setIdx]
+// Redirect empties the queue, restarts the walk at pc and restores the
+// RSB to the redirecting instruction's checkpoint
+func (q *FetchTargetQueue) Redirect(pc uint32, bp *BranchPredictor, rsb RSBCheckpoint) {
+	bp.RestoreRSB(rsb)
+	q.head = 0
+	q.count = 0
+	q.runPC = pc
+	q.Redirects++
+}
 
-	// First try to find invalid line
-	for way := 0; way < L1Associativity; way++ {
-		if !set[way].Valid {
-			return way
-		}
+// GetStats returns FTQ statistics
+func (q *FetchTargetQueue) GetStats() string {
+	avg := 0.0
+	if q.cycles > 0 {
+		avg = float64(q.occupancy) / float64(q.cycles)
 	}
 
-	// All valid: evict LRU
-	return int(c.lru[setIdx] & 0x3)
+	return fmt.Sprintf("Blocks: %d, Prefetches: %d, Redirects: %d, Full: %d cycles, Avg occupancy: %.1f/%d",
+		q.Blocks, q.Prefetches, q.Redirects, q.FullCycles, avg, FTQSize)
 }
 
-// updateLRU marks a way as most recently used (INNOVATION #19)
-func (c *L1DCache) updateLRU(setIdx int, usedWay int) {
-	c.lru[setIdx] = uint8(usedWay)
-}
+// ═══════════════════════════════════════════════════════════════════════════════
+// PREFETCH FEEDBACK AND THROTTLING
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: A prefetch can help, arrive too late, or hurt
+//   Useful:    Line arrived, then a demand load hit it ✅
+//   Late:      Demand load missed while the prefetch was still queued
+//   Useless:   Line evicted before anyone used it (wasted bandwidth)
+//   Polluting: Line evicted a demand line that was then missed on 😱
+//
+// Without counting these, a bad predictor keeps flooding the cache
+//
+// THE SOLUTION: Feedback-directed prefetching
+//   Every prefetched line is tagged with its PredictorID and fill cycle
+//   Every PrefetchThrottleInterval cycles, look at the last interval:
+//     Predictor accuracy < 40%       → Disable its prefetches for 4 intervals
+//     Overall accuracy >= 75%, late  → Raise degree (fetch more lines ahead)
+//     Overall accuracy < 40%, or
+//     pollution high                 → Lower degree
+//
+// OPT-IN: Counting always runs; the controller only acts when
+//   prefetch_throttle is 1 (or SetPrefetchThrottling(true) is called)
+//
+// DEGREE: Lines fetched per prediction (predicted line + next degree-1)
+//   Starts at 1 (one line, the original behaviour), max PrefetchMaxDegree
+//
+// POLLUTION FILTER: Direct-mapped table of demand lines that a prefetch
+//   evicted, remembering which predictor did it. A demand miss that
+//   finds its line there is a pollution event.
+//
+// MINECRAFT ANALOGY: Stop listening to the villager whose deliveries
+//   keep getting thrown out unopened
 
-// LoadReserved performs LR (INNOVATION #71: Load reserved)
-func (c *L1DCache) LoadReserved(pc uint32, addr uint32) (data uint32, hit bool) {
-	data, hit = c.Read(pc, addr)
+const (
+	ThrottleHighAccuracy   = 0.75 // Above: prefetches are worth more of
+	ThrottleLowAccuracy    = 0.40 // Below: prefetches are hurting
+	ThrottleLateRatio      = 0.10 // Late/useful above this: go further ahead
+	ThrottlePollutionRatio = 0.05 // Polluting/issued above this: back off
+	ThrottleMinSamples     = 8    // Resolved prefetches before judging
+	ThrottleProbation      = 4    // Intervals a disabled predictor sits out
+)
 
-	if hit {
-		// INNOVATION #72: Set reservation
-		c.reservationValid = true
-		c.reservationAddr = addr
+// PrefetchStats counts one predictor's prefetches (indexed by PredictorID)
+type PrefetchStats struct {
+	Issued     uint64 // Enqueued in the prefetch queue
+	Throttled  uint64 // Dropped because the predictor was disabled
+	Useful     uint64 // Filled, then hit by a demand load
+	Late       uint64 // Demand load missed while still queued
+	Useless    uint64 // Evicted without a demand hit
+	Polluting  uint64 // Evicted a line a demand load then missed on
+	LeadCycles uint64 // Sum of (first demand hit - fill cycle) over Useful
+}
+
+// sub returns s - o, field by field (interval deltas)
+func (s PrefetchStats) sub(o PrefetchStats) PrefetchStats {
+	return PrefetchStats{
+		Issued:     s.Issued - o.Issued,
+		Throttled:  s.Throttled - o.Throttled,
+		Useful:     s.Useful - o.Useful,
+		Late:       s.Late - o.Late,
+		Useless:    s.Useless - o.Useless,
+		Polluting:  s.Polluting - o.Polluting,
+		LeadCycles: s.LeadCycles - o.LeadCycles,
+	}
+}
+
+// Accuracy returns Useful / (Useful + Useless), the resolved prefetches
+func (s PrefetchStats) Accuracy() float64 {
+	if s.Useful+s.Useless == 0 {
+		return 0
 	}
+	return float64(s.Useful) / float64(s.Useful+s.Useless)
+}
 
-	return
+// PrefetchLineInfo tags one cache line with where it came from
+type PrefetchLineInfo struct {
+	By        PredictorID // Requesting predictor (None: demand or already used)
+	FillCycle uint64      // When the line arrived
 }
 
-// StoreConditional performs SC (INNOVATION #71: Store conditional)
-func (c *L1DCache) StoreConditional(addr uint32, data uint32) (success bool, cacheHit bool) {
-	// Check reservation (INNOVATION #72)
-	if !c.reservationValid || c.reservationAddr != addr {
-		c.reservationValid = false
-		return false, true // SC failed, but cache "hit"
-	}
+// pollutionEntry remembers a demand line evicted by a prefetch
+type pollutionEntry struct {
+	Line uint32
+	By   PredictorID // PredictorNone: slot empty
+}
 
-	// Reservation valid: perform store
-	cacheHit = c.Write(addr, data)
-	c.reservationValid = false
+// PrefetchThrottle is the feedback controller
+type PrefetchThrottle struct {
+	Enabled bool
+	Degree  int // Lines per prediction (1..PrefetchMaxDegree)
 
-	return cacheHit, cacheHit
+	disabledFor [NumPredictorIDs]int           // Intervals left on probation
+	snapshot    [NumPredictorIDs]PrefetchStats // Totals at last decision
+
+	// Statistics
+	Decisions   uint64
+	DegreeUps   uint64
+	DegreeDowns uint64
+	Disables    uint64
 }
 
-// GetNextPrefetch returns next prefetch request (INNOVATION #67)
-func (c *L1DCache) GetNextPrefetch() (addr uint32, valid bool) {
-	return c.prefetchQueue.Dequeue()
+// Allows reports whether predictor may issue prefetches right now
+func (t *PrefetchThrottle) Allows(predictor PredictorID) bool {
+	return !t.Enabled || t.disabledFor[predictor] == 0
 }
 
-func (c *L1DCache) GetHitRate() float64 {
-	if c.accesses == 0 {
-		return 0
+// Evaluate makes one throttling decision from the interval's counters
+//
+// ALGORITHM:
+//
+//	STEP 1: Delta = totals - snapshot for every predictor
+//	STEP 2: Per predictor: on probation? count down. Else if enough
+//	        samples and accuracy < low: put on probation
+//	STEP 3: Overall: accurate and late → degree++
+//	                 inaccurate or polluting → degree--
+//	STEP 4: Snapshot totals for the next interval
+func (t *PrefetchThrottle) Evaluate(totals *[NumPredictorIDs]PrefetchStats) {
+	t.Decisions++
+
+	var all PrefetchStats
+	for id := PredictorStride; id < NumPredictorIDs; id++ {
+		// STEP 1
+		d := totals[id].sub(t.snapshot[id])
+
+		// STEP 2
+		if t.disabledFor[id] > 0 {
+			t.disabledFor[id]--
+			continue
+		}
+		if d.Useful+d.Useless >= ThrottleMinSamples && d.Accuracy() < ThrottleLowAccuracy {
+			t.disabledFor[id] = ThrottleProbation
+			t.Disables++
+		}
+
+		all.Issued += d.Issued
+		all.Useful += d.Useful
+		all.Late += d.Late
+		all.Useless += d.Useless
+		all.Polluting += d.Polluting
 	}
-	return float64(c.hits) / float64(c.accesses)
-}
 
-func (c *L1DCache) GetPredictorAccuracy() float64 {
-	return c.predictor.GetAccuracy()
+	// STEP 3
+	if all.Useful+all.Useless >= ThrottleMinSamples {
+		acc := all.Accuracy()
+		late := float64(all.Late) / float64(all.Useful+all.Late)
+		polluting := 0.0
+		if all.Issued > 0 {
+			polluting = float64(all.Polluting) / float64(all.Issued)
+		}
+
+		switch {
+		case acc < ThrottleLowAccuracy || polluting > ThrottlePollutionRatio:
+			if t.Degree > 1 {
+				t.Degree--
+				t.DegreeDowns++
+			}
+		case acc >= ThrottleHighAccuracy && late > ThrottleLateRatio:
+			if t.Degree < PrefetchMaxDegree {
+				t.Degree++
+				t.DegreeUps++
+			}
+		}
+	}
+
+	// STEP 4
+	t.snapshot = *totals
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
-// LOAD/STORE UNIT (INNOVATIONS #69-73)
+// HARDWARE PREFETCHERS
 // ═══════════════════════════════════════════════════════════════════════════════
 //
-// INNOVATION #69: 2 independent LSUs
-//
-// THE PROBLEM: Memory operations are common (30% of instructions)
-//              Single LSU becomes bottleneck
-//
-// THE SOLUTION: Two independent LSUs
-//              Can process 2 memory operations simultaneously
-//
-// THE MATH: With 30% memory ops and 2 LSUs:
-//   1 LSU: 30% utilization, becomes bottleneck at 3.3 IPC
-//   2 LSUs: 15% utilization each, no bottleneck until 6.6 IPC ✅
-//
-// INNOVATION #70: Load speculation
-//
-// THE PROBLEM: Loads take variable time (1-100 cycles)
-//              Don't know if it will hit cache until we try
-//
-// THE SOLUTION: Optimistically assume cache hit (1 cycle)
-//              If wrong (miss): Squash dependent ops, restart
-//
-// BENEFIT: 95% of loads hit cache → 95% correct speculation ✅
-//
-// INNOVATION #71: Atomic operations (LR/SC)
-//
-// THE PROBLEM: Multi-threaded code needs atomic read-modify-write
-//              Example: atomic increment of counter
-//
-// THE SOLUTION: Load-Reserved / Store-Conditional pair
-//   LR: Load value, remember address
-//   SC: Store if no one else wrote to address
-//
-// EXAMPLE: Atomic increment
-//   LR r1, [counter]     // Load counter, reserve address
-//   ADDI r1, r1, 1       // Increment
-//   SC r2, [counter], r1 // Store if still reserved
-//   BNE r2, x0, retry    // If SC failed (r2=1), retry
-//
-// INNOVATION #72: Reservation tracking
-//
-// THE ALGORITHM: Track one reserved address per core
-//   LR: Set reservation to address
-//   SC: Check reservation, clear if match
-//   Any write to cache line: Clear reservation
+// THE QUESTION: Is the 5-way predictor actually better than the standard
+//               prefetchers everyone else ships?
 //
-// WHY ONE RESERVATION: Sufficient for lock-free algorithms
-//                       Multiple reservations = complex hardware
+// The 5-way predictor sees one address per load PC. Conventional
+// prefetchers look at the stream of demand accesses instead:
 //
-// INNOVATION #73: Variable latency handling
+//   Next-line:   Miss on line L → fetch L+1 (the baseline everyone beats)
+//   Stream:      Two misses in a row heading the same way → run ahead
+//   Best-Offset: Learn the single offset D that would have covered the
+//                most recent accesses, fetch X+D (Michaud, HPCA 2016)
+//   SMS:         Remember which lines of a 2KB region were touched last
+//                time this PC+offset started it, fetch them all next time
+//                (Somogyi et al., ISCA 2006)
 //
-// THE PROBLEM: Cache hit = 1 cycle, miss = 100 cycles
-//              LSU must handle both gracefully
+// THE FRAMEWORK:
+//   L1DCache.Read → DemandAccess{PC, Addr, Hit, PrefetchHit}
+//                 → every attached Prefetcher
+//                 → cache.IssuePrefetch(addr, its PredictorID)
+//   Same queue, same throttle, same useful/late/useless accounting
+//   as the 5-way predictor, so PrefetchReport compares them directly
 //
-// THE SOLUTION: State machine with cycle counter
-//   Start operation: Set cycles_remaining
-//   Each cycle: Decrement counter
-//   When zero: Check cache again (might still miss!)
-//   On hit: Return result
-//   On miss: Wait more cycles
+// MINECRAFT ANALOGY: Hire the usual delivery services next to our five
+//   villagers and see whose packages actually get opened
+
+const (
+	StreamTableSize    = 8  // Concurrent streams tracked
+	StreamWindow       = 4  // Lines from the last miss that still count
+	StreamDistance     = 4  // Lines ahead once a stream is confirmed
+	StreamConfirmCount = 2  // Misses in one direction before prefetching
+	BOOffsetCount      = 26 // Candidate offsets (Michaud's list up to 64)
+	BORRSize           = 256
+	BOScoreMax         = 31  // A score this high ends the phase early
+	BORoundMax         = 100 // Rounds per learning phase
+	BOBadScore         = 1   // Best score at or below: stop prefetching
+	SMSRegionLines     = 32  // 2KB regions of 64-byte lines
+	SMSAGTSize         = 16  // Active generations (regions being recorded)
+	SMSPHTSize         = 256 // Pattern history (PC+offset → footprint)
+)
+
+// DemandAccess is one demand load as seen by a prefetcher
+type DemandAccess struct {
+	PC          uint32
+	Addr        uint32
+	Hit         bool // Line was in the cache
+	PrefetchHit bool // ...and this was the first use of a prefetched line
+}
+
+// Prefetcher observes demand loads and may queue prefetches
 //
-// MINECRAFT ANALOGY: Two workers fetching items from storage
-//                    Usually instant (hotbar), sometimes slow (storage room)
+// Implementations call cache.IssuePrefetch(addr, ID()) for each line
+// they want; the cache handles dedup, throttling and accounting.
+type Prefetcher interface {
+	ID() PredictorID
+	Access(cache *L1DCache, access DemandAccess)
+}
 
-// MemoryOperation describes a pending memory access
-type MemoryOperation struct {
-	PC       uint32 // For predictor training
-	Addr     uint32 // Memory address
-	Data     uint32 // Data to store (for stores)
-	Rd       uint8  // Destination register (for loads)
-	WindowID int    // Which window entry this belongs to
-	IsStore  bool   // Store (true) or load (false)
-	IsAtomic bool   // Is this SC (store conditional)?
-	IsLR     bool   // Is this LR (load reserved)?
+// lineOf returns the cache line number of addr
+func lineOf(addr uint32) uint32 {
+	return addr / CacheLineSize
 }
 
-// LSU handles one memory operation at a time (INNOVATION #69)
-type LSU struct {
-	busy      bool            // Is this LSU processing an operation?
-	op        MemoryOperation // Current operation
-	cyclesRem int             // Cycles remaining (INNOVATION #73)
-	dcache    *L1DCache       // Data cache reference
+// ─────────────────────────────────────────────────────────────────────────────
+// Next-line
+// ─────────────────────────────────────────────────────────────────────────────
 
-	// Result communication
-	resultValid bool   // Is result ready?
-	resultData  uint32 // Result value
-	resultRd    uint8  // Destination register
-	resultWinID int    // Window ID for result
+// NextLinePrefetcher fetches the following line on every miss
+type NextLinePrefetcher struct{}
+
+// NewNextLinePrefetcher creates a next-line prefetcher
+func NewNextLinePrefetcher() *NextLinePrefetcher {
+	return &NextLinePrefetcher{}
 }
 
-// NewLSU creates a load/store unit
-func NewLSU(dcache *L1DCache) *LSU {
-	return &LSU{dcache: dcache}
+// ID identifies this prefetcher in stats and line tags
+func (p *NextLinePrefetcher) ID() PredictorID { return PrefetcherNextLine }
+
+// Access prefetches L+1 on a miss (or first use of a prefetched line,
+// so a sequential walk keeps one line ahead)
+func (p *NextLinePrefetcher) Access(cache *L1DCache, a DemandAccess) {
+	if a.Hit && !a.PrefetchHit {
+		return
+	}
+	cache.IssuePrefetch((lineOf(a.Addr)+1)*CacheLineSize, p.ID())
 }
 
-// Issue starts a new memory operation (INNOVATION #70: speculative)
+// ─────────────────────────────────────────────────────────────────────────────
+// Stream
+// ─────────────────────────────────────────────────────────────────────────────
+
+// streamEntry tracks one sequential stream of misses
+type streamEntry struct {
+	LastLine uint32 // Most recent line in the stream
+	Dir      int32  // +1 ascending, -1 descending, 0 not yet known
+	Confirm  uint8  // Misses seen moving in Dir
+	LastUse  uint64 // For LRU replacement
+	Valid    bool
+}
+
+// StreamPrefetcher detects ascending/descending miss streams and runs ahead
+type StreamPrefetcher struct {
+	streams [StreamTableSize]streamEntry
+	clock   uint64
+}
+
+// NewStreamPrefetcher creates a stream prefetcher
+func NewStreamPrefetcher() *StreamPrefetcher {
+	return &StreamPrefetcher{}
+}
+
+// ID identifies this prefetcher in stats and line tags
+func (p *StreamPrefetcher) ID() PredictorID { return PrefetcherStream }
+
+// Access trains on misses and first uses of prefetched lines
 //
 // ALGORITHM:
 //
-//	STEP 1: Check if LSU is busy
-//	STEP 2: If free: Accept operation
-//	STEP 3: Set initial latency (optimistic: 1 cycle for cache hit)
-//	STEP 4: Start processing
-//
-// SPECULATION: We assume cache hit (1 cycle)
-//
-//	If wrong, we'll discover it and wait longer
-func (lsu *LSU) Issue(op MemoryOperation) bool {
-	if lsu.busy {
-		return false // LSU busy, can't accept
+//	STEP 1: Find a stream whose last line is within StreamWindow
+//	STEP 2: Found: set/confirm direction, advance; once confirmed,
+//	        prefetch StreamDistance lines ahead in that direction
+//	STEP 3: Not found: start a new stream in the LRU slot
+func (p *StreamPrefetcher) Access(cache *L1DCache, a DemandAccess) {
+	if a.Hit && !a.PrefetchHit {
+		return
 	}
+	p.clock++
+	line := lineOf(a.Addr)
 
-	// Accept operation
-	lsu.busy = true
-	lsu.op = op
-	lsu.cyclesRem = L1Latency // INNOVATION #70: Optimistic 1 cycle
-	lsu.resultValid = false
+	// STEP 1: Match
+	for i := range p.streams {
+		st := &p.streams[i]
+		if !st.Valid {
+			continue
+		}
+		delta := int32(line - st.LastLine)
+		if delta == 0 || delta > StreamWindow || delta < -StreamWindow {
+			continue
+		}
 
-	return true
+		// STEP 2: Advance stream
+		dir := int32(1)
+		if delta < 0 {
+			dir = -1
+		}
+		if dir == st.Dir {
+			if st.Confirm < StreamConfirmCount {
+				st.Confirm++
+			}
+		} else {
+			st.Dir = dir
+			st.Confirm = 1
+		}
+		st.LastLine = line
+		st.LastUse = p.clock
+
+		if st.Confirm >= StreamConfirmCount {
+			for k := int32(1); k <= StreamDistance; k++ {
+				cache.IssuePrefetch(uint32(int32(line)+k*st.Dir)*CacheLineSize, p.ID())
+			}
+		}
+		return
+	}
+
+	// STEP 3: Allocate (invalid first, else LRU)
+	victim := 0
+	for i := range p.streams {
+		if !p.streams[i].Valid {
+			victim = i
+			break
+		}
+		if p.streams[i].LastUse < p.streams[victim].LastUse {
+			victim = i
+		}
+	}
+	p.streams[victim] = streamEntry{LastLine: line, LastUse: p.clock, Valid: true}
 }
 
-// Tick advances the LSU by one clock cycle (INNOVATION #73)
+// ─────────────────────────────────────────────────────────────────────────────
+// Best-Offset
+// ─────────────────────────────────────────────────────────────────────────────
+
+// boOffsets are Michaud's candidates: 2^i·3^j·5^k up to 64
+var boOffsets = [BOOffsetCount]int32{
+	1, 2, 3, 4, 5, 6, 8, 9, 10, 12, 15, 16, 18, 20, 24, 25, 27, 30,
+	32, 36, 40, 45, 48, 50, 54, 60,
+}
+
+// BestOffsetPrefetcher learns one global prefetch offset
 //
-// ALGORITHM:
+// SIMPLIFICATION: The recent-requests (RR) table holds recent demand
 //
-//	STEP 1: Decrement cycle counter
-//	STEP 2: If cycles remain: Wait
-//	STEP 3: If cycles done: Try cache access
-//	STEP 4: On hit: Return result
-//	STEP 5: On miss: Wait more cycles (DRAM latency)
+//	trigger lines rather than completed-prefetch base addresses, so
+//	"offset d would have worked" means "X-d was itself a recent trigger"
+type BestOffsetPrefetcher struct {
+	rr     [BORRSize]uint32 // Recent trigger lines (tag = line+1, 0 empty)
+	scores [BOOffsetCount]uint8
+	test   int // Next offset to test
+	round  int // Rounds completed in this phase
+
+	offset  int32 // Current prefetch offset
+	enabled bool  // Best score was good enough to prefetch
+}
+
+// NewBestOffsetPrefetcher creates a BO prefetcher (offset 1 until learned)
+func NewBestOffsetPrefetcher() *BestOffsetPrefetcher {
+	return &BestOffsetPrefetcher{offset: 1, enabled: true}
+}
+
+// ID identifies this prefetcher in stats and line tags
+func (p *BestOffsetPrefetcher) ID() PredictorID { return PrefetcherBestOffset }
+
+func (p *BestOffsetPrefetcher) rrIndex(line uint32) int {
+	return int((line ^ line>>8) & (BORRSize - 1))
+}
+
+// Access learns from and prefetches on misses and prefetched hits
 //
-// VARIABLE LATENCY: Cache hit = 1 cycle, miss = 100 cycles
+// ALGORITHM:
 //
-//	State machine handles both seamlessly
-func (lsu *LSU) Tick() {
-	if !lsu.busy {
+//	STEP 1: Test one offset: X-d in RR? score[d]++
+//	STEP 2: After a full round, or a score hits BOScoreMax, or
+//	        BORoundMax rounds: adopt the best offset, reset scores
+//	STEP 3: Record X in RR, prefetch X+offset if enabled
+func (p *BestOffsetPrefetcher) Access(cache *L1DCache, a DemandAccess) {
+	if a.Hit && !a.PrefetchHit {
 		return
 	}
+	line := lineOf(a.Addr)
 
-	// STEP 1: Count down
-	lsu.cyclesRem--
-	if lsu.cyclesRem > 0 {
-		return // Still waiting
+	// STEP 1: Test
+	d := boOffsets[p.test]
+	base := line - uint32(d)
+	if p.rr[p.rrIndex(base)] == base+1 {
+		p.scores[p.test]++
 	}
 
-	// STEP 3: Time to try cache access
-	if lsu.op.IsStore {
-		// ═══════════════════════════════════════════════════════════════
-		// STORE OPERATION
-		// ═══════════════════════════════════════════════════════════════
-
-		if lsu.op.IsAtomic {
-			// INNOVATION #71: Store conditional (SC)
-			success, _ := lsu.dcache.StoreConditional(lsu.op.Addr, lsu.op.Data)
-
-			// SC returns success/failure in destination register
-			lsu.resultData = 0
-			if !success {
-				lsu.resultData = 1 // SC failed
+	// STEP 2: End of round / phase
+	phaseOver := p.scores[p.test] >= BOScoreMax
+	p.test++
+	if p.test == BOOffsetCount {
+		p.test = 0
+		p.round++
+		phaseOver = phaseOver || p.round >= BORoundMax
+	}
+	if phaseOver {
+		best := 0
+		for i := range p.scores {
+			if p.scores[i] > p.scores[best] {
+				best = i
 			}
-		} else {
-			// Regular store
-			lsu.dcache.Write(lsu.op.Addr, lsu.op.Data)
-			lsu.resultData = 0
 		}
+		p.offset = boOffsets[best]
+		p.enabled = p.scores[best] > BOBadScore
+		p.scores = [BOOffsetCount]uint8{}
+		p.test, p.round = 0, 0
+	}
 
-		lsu.resultRd = lsu.op.Rd
-		lsu.resultWinID = lsu.op.WindowID
-		lsu.resultValid = true
-		lsu.busy = false
-
-	} else {
-		// ═══════════════════════════════════════════════════════════════
-		// LOAD OPERATION
-		// ═══════════════════════════════════════════════════════════════
-
-		var data uint32
-		var hit bool
+	// STEP 3: Remember and prefetch
+	p.rr[p.rrIndex(line)] = line + 1
+	if p.enabled {
+		cache.IssuePrefetch((line+uint32(p.offset))*CacheLineSize, p.ID())
+	}
+}
 
-		if lsu.op.IsLR {
-			// INNOVATION #71: Load reserved (LR)
-			data, hit = lsu.dcache.LoadReserved(lsu.op.PC, lsu.op.Addr)
-		} else {
-			// Regular load
-			data, hit = lsu.dcache.Read(lsu.op.PC, lsu.op.Addr)
-		}
+// ─────────────────────────────────────────────────────────────────────────────
+// Spatial Memory Streaming
+// ─────────────────────────────────────────────────────────────────────────────
 
-		if hit {
-			// CACHE HIT! (INNOVATION #70: speculation was correct)
-			lsu.resultData = data
-			lsu.resultRd = lsu.op.Rd
-			lsu.resultWinID = lsu.op.WindowID
-			lsu.resultValid = true
-			lsu.busy = false
-		} else {
-			// CACHE MISS! (INNOVATION #73: variable latency)
-			// Need to wait for DRAM
-			lsu.cyclesRem = DRAMLatency
-		}
-	}
+// smsGeneration records the footprint of one active region
+type smsGeneration struct {
+	Region    uint32 // Region number (line / SMSRegionLines)
+	Key       uint32 // PC + trigger offset that started it
+	Footprint uint32 // Bit i: line i of the region was touched
+	LastUse   uint64
+	Valid     bool
 }
 
-// IsBusy returns true if LSU is processing
-func (lsu *LSU) IsBusy() bool {
-	return lsu.busy
+// smsPattern is a learned footprint for one PC+offset
+type smsPattern struct {
+	Key       uint32
+	Footprint uint32
+	Valid     bool
 }
 
-// GetResult returns completed operation result
+// SMSPrefetcher predicts whole-region footprints from the trigger access
 //
-// RETURNS:
+// SIMPLIFICATION: A generation ends when its AGT slot is reclaimed
 //
-//	data: loaded value or SC result
-//	rd: destination register
-//	windowID: which instruction this is for
-//	valid: true if result available
-func (lsu *LSU) GetResult() (data uint32, rd uint8, windowID int, valid bool) {
-	if lsu.resultValid {
-		lsu.resultValid = false // Clear flag (consumed)
-		return lsu.resultData, lsu.resultRd, lsu.resultWinID, true
-	}
-	return 0, 0, 0, false
+//	(LRU), not when one of its lines leaves the cache
+type SMSPrefetcher struct {
+	agt   [SMSAGTSize]smsGeneration
+	pht   [SMSPHTSize]smsPattern
+	clock uint64
 }
 
-// ═══════════════════════════════════════════════════════════════════════════════
-// OUT-OF-ORDER ENGINE (INNOVATIONS #34-58)
-// ═══════════════════════════════════════════════════════════════════════════════
-//
-// This is the HEART of the CPU! Enables executing instructions out of order.
-//
-// WHY OUT-OF-ORDER?
-//
-// Consider this code:
-//   r1 = load [slow_address]    // Takes 100 cycles! 😱
-//   r2 = r1 + 5                 // Must wait for r1
-//   r3 = 10 * 20                // Could run immediately! ✅
-//   r4 = r3 + 1                 // Could run after r3! ✅
-//
-// IN-ORDER EXECUTION:
-//   Cycle 1-100: Wait for r1 load
-//   Cycle 101: Compute r2 = r1 + 5
-//   Cycle 102: Compute r3 = 10 * 20
-//   Cycle 103: Compute r4 = r3 + 1
-//   Total: 103 cycles 😱
-//
-// OUT-OF-ORDER EXECUTION:
-//   Cycle 1: Start r1 load, compute r3 = 10 * 20
-//   Cycle 2: Compute r4 = r3 + 1
-//   Cycle 100: Load completes
-//   Cycle 101: Compute r2 = r1 + 5
-//   Total: 101 cycles ✅ (saved 2 cycles!)
-//
-// With more independent work: Even bigger savings!
-//
-// THE ARCHITECTURE: Three key components
-//
-// 1. INSTRUCTION WINDOW (INNOVATION #35)
-//    - Holds instructions "in flight"
-//    - Acts as scheduler + ROB + instruction queue
-//    - Tracks dependencies between instructions
-//
-// 2. REGISTER RENAMING (INNOVATIONS #36-39)
-//    - Eliminates false dependencies
-//    - Maps architectural registers → physical registers
-//    - Allows multiple versions of same register simultaneously
+// NewSMSPrefetcher creates a spatial memory streaming prefetcher
+func NewSMSPrefetcher() *SMSPrefetcher {
+	return &SMSPrefetcher{}
+}
+
+// ID identifies this prefetcher in stats and line tags
+func (p *SMSPrefetcher) ID() PredictorID { return PrefetcherSMS }
+
+func (p *SMSPrefetcher) phtIndex(key uint32) int {
+	return int((key ^ key>>8 ^ key>>16) & (SMSPHTSize - 1))
+}
+
+// Access records footprints and replays them on trigger accesses
 //
-// 3. WAKEUP/SELECT (INNOVATIONS #40-42)
-//    - Wakeup: Tell waiting instructions a value is ready
-//    - Select: Pick ready instructions to execute
-//    - Age-based priority: Older instructions go first
+// ALGORITHM:
 //
-// MINECRAFT ANALOGY: A kitchen with multiple chefs
-//   Window = Recipe board (what's being cooked)
-//   Renaming = Multiple versions of same ingredient
-//   Wakeup = "Eggs are ready!" (notify recipes waiting for eggs)
-//   Select = "Which recipes can we cook now?"
+//	STEP 1: Region active? Set the line's footprint bit, done
+//	STEP 2: Trigger access: end the LRU generation (store its
+//	        footprint in the PHT), start a new one keyed by PC+offset
+//	STEP 3: PHT has a footprint for that key? Prefetch every line in it
+func (p *SMSPrefetcher) Access(cache *L1DCache, a DemandAccess) {
+	p.clock++
+	line := lineOf(a.Addr)
+	region := line / SMSRegionLines
+	offset := line % SMSRegionLines
+
+	// STEP 1: Active generation
+	for i := range p.agt {
+		g := &p.agt[i]
+		if g.Valid && g.Region == region {
+			g.Footprint |= 1 << offset
+			g.LastUse = p.clock
+			return
+		}
+	}
+
+	// STEP 2: New generation (evict LRU into the PHT)
+	victim := 0
+	for i := range p.agt {
+		if !p.agt[i].Valid {
+			victim = i
+			break
+		}
+		if p.agt[i].LastUse < p.agt[victim].LastUse {
+			victim = i
+		}
+	}
+	if old := &p.agt[victim]; old.Valid {
+		p.pht[p.phtIndex(old.Key)] = smsPattern{Key: old.Key, Footprint: old.Footprint, Valid: true}
+	}
+
+	key := a.PC<<5 | offset
+	p.agt[victim] = smsGeneration{Region: region, Key: key, Footprint: 1 << offset, LastUse: p.clock, Valid: true}
+
+	// STEP 3: Replay
+	pat := &p.pht[p.phtIndex(key)]
+	if !pat.Valid || pat.Key != key {
+		return
+	}
+	for i := uint32(0); i < SMSRegionLines; i++ {
+		if i != offset && pat.Footprint&(1<<i) != 0 {
+			cache.IssuePrefetch((region*SMSRegionLines+i)*CacheLineSize, p.ID())
+		}
+	}
+}
 
 // ═══════════════════════════════════════════════════════════════════════════════
-// REGISTER RENAMING (INNOVATIONS #36-39)
+// L1D CACHE (INNOVATIONS #18-20)
 // ═══════════════════════════════════════════════════════════════════════════════
 //
-// INNOVATION #36: Register renaming with RAT
-//
-// THE PROBLEM: False dependencies (WAW, WAR hazards)
-//
-// EXAMPLE:
-//   r5 = r1 + r2    // Version 1 of r5
-//   r6 = r5 + r3    // Uses version 1
-//   r5 = r7 + r8    // Version 2 of r5 (overwrites!)
-//   r9 = r5 + r10   // Uses version 2
-//
-// These two r5 assignments don't actually conflict!
-// But in-order execution must wait (false dependency)
-//
-// INNOVATION #37: Bitmap-based RAT (not traditional)
-//
-// TRADITIONAL RAT: Array mapping arch_reg → phys_reg
-//   RAT[5] = 33 means "r5 is currently in physical register 33"
-//
-// OUR RAT: Bitmap per architectural register
-//   RAT[5] = 0x00000200 means "r5 is in physical register 9" (bit 9 set)
-//
-// WHY BITMAP:
-//   Faster hardware implementation (parallel lookup)
-//   Easier to handle multiple mappings during speculation
-//   More efficient for VLSI layout
-//
-// INNOVATION #38: Free list for physical registers
-//
-// PROBLEM: When we rename, we need a free physical register
-//
-// SOLUTION: Bitmap of free registers
-//   Bit N = 1 means physical register N is free
-//   To allocate: Find first set bit, clear it
-//   To free: Set the bit
-//
-// INNOVATION #39: 40 physical registers (one per window slot)
-//
-// WHY 40: One physical register per window entry
-//   Window has 40 entries max
-//   Each can produce one new value
-//   Need 40 physical registers to hold them all ✅
-//
-// ARCHITECTURAL DECISION: Simple and sufficient
-//   More registers = more renaming flexibility
-//   But 40 is enough for our window size
+// INNOVATION #18: 4-way set-associative
+// INNOVATION #19: LRU replacement
+// INNOVATION #20: 64-byte cache lines
 //
-// MINECRAFT ANALOGY: Extra chests for multiple versions of items
-//   Architectural registers = labeled chests (r5 = "diamond chest")
-//   Physical registers = actual chests (chest #33, chest #34, etc.)
-//   RAT = Map showing which physical chest holds each item
-//   Free list = List of empty chests
-
-// WindowEntry holds state for one in-flight instruction (INNOVATION #35)
-type WindowEntry struct {
-	// Instruction information
-	PC      uint32 // Address of this instruction
-	Opcode  uint8  // What operation
-	Rd      uint8  // Architectural destination register
-	PhysRd  uint8  // Physical destination register
-	Rs1     uint8  // Architectural source 1
-	Rs2     uint8  // Architectural source 2
-	PhysRs1 uint8  // Physical source 1
-	PhysRs2 uint8  // Physical source 2
-	Imm     int32  // Immediate value
+// Plus integration with the 5-way predictor (INNOVATION #59)
 
-	// INNOVATION #53: Dependency tracking
-	Src1Ready bool // Is source 1 value available?
-	Src2Ready bool // Is source 2 value available?
+// L1DCache is the data cache with 5-way predictor
+type L1DCache struct {
+	sets          [][L1Associativity]CacheLine // L1DNumSets by default
+	lru           []uint8
+	numSets       int
+	predictor     *L1DPredictor // INNOVATION #59: 5-way predictor
+	prefetchQueue PrefetchQueue // INNOVATION #67: Prefetch queue
 
-	// INNOVATION #54: State tracking
-	Valid    bool // Is this entry in use?
-	Issued   bool // Has this been sent to execute?
-	Executed bool // Has execution completed?
+	// Prefetch feedback (see PREFETCH FEEDBACK AND THROTTLING)
+	prefetchInfo  [][L1Associativity]PrefetchLineInfo
+	pollution     [PollutionFilterSize]pollutionEntry
+	prefetchStats [NumPredictorIDs]PrefetchStats
+	throttle      PrefetchThrottle
+	cycle         uint64
 
-	// INNOVATION #55: Result forwarding
-	Result      uint32 // Computed result
-	ResultValid bool   // Is result ready?
+	// Prefetch sources: the 5-way predictor plus any attached hardware
+	// prefetchers (see HARDWARE PREFETCHERS)
+	predictorPrefetch bool
+	prefetchers       []Prefetcher
 
-	// Memory operation state
-	IsLoad       bool
-	IsStore      bool
-	MemAddr      uint32
-	MemAddrValid bool
-	StoreData    uint32
+	// For atomic operations (INNOVATION #71-72)
+	reservationValid bool
+	reservationAddr  uint32
 
-	// Branch handling
-	IsBranch      bool
-	BranchTaken   bool
-	BranchTarget  uint32
-	Predicted     bool   // What did we predict?
-	PredictedAddr uint32 // Where did we predict?
+	// Statistics
+	accesses uint64
+	hits     uint64
+}
 
-	// Memory prediction (from L1D predictor)
-	PredictedMemAddr uint32
-	MemPredictor     PredictorID
-	HasMemPrediction bool
+// NewL1DCache creates an initialized data cache
+func NewL1DCache() *L1DCache {
+	return NewL1DCacheWithConfig(DefaultCoreConfig())
 }
 
-// RAT (Register Alias Table) implements INNOVATION #37
-//
-// Maps architectural registers to physical registers using bitmaps
-type RAT struct {
-	bitmaps [NumArchRegs]uint64 // 32 bitmaps, one per architectural register
+// NewL1DCacheWithConfig creates a cfg.L1DCacheSize-byte data cache
+func NewL1DCacheWithConfig(cfg CoreConfig) *L1DCache {
+	numSets := cfg.l1dNumSets()
+	return &L1DCache{
+		sets:              make([][L1Associativity]CacheLine, numSets),
+		lru:               make([]uint8, numSets),
+		numSets:           numSets,
+		prefetchInfo:      make([][L1Associativity]PrefetchLineInfo, numSets),
+		predictor:         NewL1DPredictor(),
+		throttle:          PrefetchThrottle{Enabled: cfg.PrefetchThrottle != 0, Degree: 1},
+		predictorPrefetch: true,
+	}
 }
 
-// NewRAT creates an initialized RAT
-func NewRAT() *RAT {
-	return &RAT{}
+// AddPrefetcher attaches a hardware prefetcher; several may run together
+func (c *L1DCache) AddPrefetcher(p Prefetcher) {
+	c.prefetchers = append(c.prefetchers, p)
 }
 
-// Lookup returns physical register holding an architectural register
-//
-// ALGORITHM:
+// SetPredictorPrefetch turns prefetching from the 5-way predictor on or off
 //
-//	STEP 1: Check if register is r0 (always zero, never renamed)
-//	STEP 2: Get bitmap for this architectural register
-//	STEP 3: Find highest set bit (most recent mapping)
-//	STEP 4: Return physical register number
-//
-// WHY HIGHEST BIT: Multiple mappings might exist during speculation
-//
-//	Highest bit = most recent = correct one
-//
-// EXAMPLE: r5 mapped to physical registers 9 and 33
-//
-//	Bitmap: 0x0000_0000_0002_0200 (bits 9 and 33 set)
-//	Highest bit: 33
-//	Return: 33 (most recent mapping) ✅
-func (rat *RAT) Lookup(archReg uint8) uint8 {
-	// r0 is special: always zero, never renamed
-	if archReg == 0 || archReg >= NumArchRegs {
-		return InvalidTag
-	}
+// The predictor keeps training and predicting addresses either way; this
+// only stops it filling the prefetch queue, so hardware prefetchers can
+// be measured on their own.
+func (c *L1DCache) SetPredictorPrefetch(on bool) {
+	c.predictorPrefetch = on
+}
 
-	bitmap := rat.bitmaps[archReg]
-	if bitmap == 0 {
-		return InvalidTag // No mapping exists
+// notifyPrefetchers feeds one demand load to every attached prefetcher
+func (c *L1DCache) notifyPrefetchers(access DemandAccess) {
+	for _, p := range c.prefetchers {
+		p.Access(c, access)
 	}
+}
 
-	// Find highest set bit (most recent mapping)
-	// LeadingZeros counts from left, we want position from right
-	return uint8(63 - bits.LeadingZeros64(bitmap))
+// Tick advances the cache's clock and runs the prefetch throttle
+func (c *L1DCache) Tick() {
+	c.cycle++
+	if c.throttle.Enabled && c.cycle%PrefetchThrottleInterval == 0 {
+		c.throttle.Evaluate(&c.prefetchStats)
+	}
 }
 
-// Allocate creates a new mapping (INNOVATION #37)
-//
-// ALGORITHM:
-//
-//	STEP 1: Set bit for physical register in bitmap
-//	STEP 2: Don't clear old bits (kept for speculation recovery)
+// SetPrefetchThrottling turns feedback-directed throttling on or off
 //
-// WHY KEEP OLD BITS: During speculation, we might need to revert
-//
-//	Old mappings help with recovery
-func (rat *RAT) Allocate(archReg, physReg uint8) {
-	if archReg == 0 || archReg >= NumArchRegs || physReg >= NumPhysRegs {
-		return
-	}
+// Off means degree 1 and no predictor is ever disabled.
+func (c *L1DCache) SetPrefetchThrottling(on bool) {
+	c.throttle = PrefetchThrottle{Enabled: on, Degree: 1}
+}
+
+func (c *L1DCache) pollutionIndex(line uint32) int {
+	return int((line >> 6) & (PollutionFilterSize - 1))
+}
 
-	// Set bit for this physical register
-	rat.bitmaps[archReg] |= (1 << physReg)
+// contains reports whether addr's line is cached (no side effects)
+func (c *L1DCache) contains(addr uint32) bool {
+	set := &c.sets[c.getSetIndex(addr)]
+	tag := c.getTag(addr)
+	for way := 0; way < L1Associativity; way++ {
+		if set[way].Valid && set[way].Tag == tag {
+			return true
+		}
+	}
+	return false
 }
 
-// Free removes a mapping when instruction commits
+// IssuePrefetch queues a predictor's address, subject to the throttle
 //
 // ALGORITHM:
 //
-//	STEP 1: Clear bit for physical register
-//	STEP 2: Physical register can now be reused
-func (rat *RAT) Free(archReg, physReg uint8) {
-	if archReg >= NumArchRegs || physReg >= NumPhysRegs {
+//	STEP 1: Predictor on probation? Drop it
+//	STEP 2: For each of degree lines starting at addr's line:
+//	          Skip if cached, else enqueue (deduplicated)
+func (c *L1DCache) IssuePrefetch(addr uint32, predictor PredictorID) {
+	// STEP 1: Throttled?
+	if !c.throttle.Allows(predictor) {
+		c.prefetchStats[predictor].Throttled++
 		return
 	}
 
-	// Clear bit for this physical register
-	rat.bitmaps[archReg] &^= (1 << physReg)
+	// STEP 2: Queue degree lines
+	degree := 1
+	if c.throttle.Enabled {
+		degree = c.throttle.Degree
+	}
+	for k := 0; k < degree; k++ {
+		lineAddr := addr + uint32(k*CacheLineSize)
+		if k > 0 {
+			lineAddr &^= CacheLineSize - 1
+		}
+		if c.contains(lineAddr) {
+			continue
+		}
+		if c.prefetchQueue.Enqueue(lineAddr, predictor) {
+			c.prefetchStats[predictor].Issued++
+		}
+	}
 }
 
-// FreeList tracks available physical registers (INNOVATION #38)
-type FreeList struct {
-	bitmap    uint64 // Bit N = 1 means physical register N is free
-	freeCount int    // Number of free registers
+func (c *L1DCache) getSetIndex(addr uint32) int {
+	return int((addr >> 6) & uint32(c.numSets-1))
 }
 
-// NewFreeList creates an initialized free list
-//
-// ALGORITHM:
-//
-//	Physical registers 0-31: Reserved for architectural state
-//	Physical registers 32-39: Available for renaming
-func NewFreeList() *FreeList {
-	fl := &FreeList{}
-
-	// Mark registers 32-39 as free
-	// Create mask: bits 32-39 set, others clear
-	fl.bitmap = ((uint64(1) << NumPhysRegs) - 1) &^ ((uint64(1) << NumArchRegs) - 1)
-	fl.freeCount = NumPhysRegs - NumArchRegs
-
-	return fl
+func (c *L1DCache) getTag(addr uint32) uint32 {
+	return addr >> (6 + bits.Len32(uint32(c.numSets-1)))
 }
 
-// Allocate returns a free physical register (INNOVATION #38)
+// Read loads data from cache, training the predictor
 //
 // ALGORITHM:
 //
-//	STEP 1: Check if any registers free
-//	STEP 2: Find first free register (trailing zeros)
-//	STEP 3: Mark as used (clear bit)
-//	STEP 4: Return register number
-//
-// HARDWARE NOTE: Finding trailing zeros is ONE gate delay
-//
-//	Priority encoder circuit (very fast!)
-func (fl *FreeList) Allocate() uint8 {
-	if fl.bitmap == 0 {
-		return InvalidTag // None free!
-	}
+//	STEP 1: Search cache for address
+//	STEP 2: If found: Return data, update LRU
+//	STEP 3: Train predictor with actual address
+//	STEP 4: Trigger prediction for next access
+func (c *L1DCache) Read(pc uint32, addr uint32) (data uint32, hit bool) {
+	c.accesses++
 
-	// Find first free register (rightmost set bit)
-	freeReg := bits.TrailingZeros64(fl.bitmap)
-	if freeReg >= NumPhysRegs {
-		return InvalidTag
-	}
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+	set := &c.sets[setIdx]
 
-	// Mark as used
-	fl.bitmap &^= (1 << freeReg)
-	fl.freeCount--
+	// STEP 1-2: Search cache
+	for way := 0; way < L1Associativity; way++ {
+		line := &set[way]
 
-	return uint8(freeReg)
-}
+		if line.Valid && line.Tag == tag {
+			// HIT!
+			c.hits++
+			offset := int(addr & (CacheLineSize - 1))
 
-// Free returns a physical register to the pool (INNOVATION #38)
-func (fl *FreeList) Free(physReg uint8) {
-	if physReg >= NumPhysRegs || physReg < NumArchRegs {
-		return // Don't free architectural registers (0-31)
-	}
+			data = uint32(line.Data[offset]) |
+				uint32(line.Data[offset+1])<<8 |
+				uint32(line.Data[offset+2])<<16 |
+				uint32(line.Data[offset+3])<<24
 
-	// Mark as free
-	fl.bitmap |= (1 << physReg)
-	fl.freeCount++
-}
+			c.updateLRU(setIdx, way)
 
-// HasFree returns true if registers available
-func (fl *FreeList) HasFree() bool {
-	return fl.bitmap != 0
+			// First demand use of a prefetched line: credit the predictor
+			prefetchHit := false
+			if info := &c.prefetchInfo[setIdx][way]; info.By != PredictorNone {
+				if info.By <= PredictorContext {
+					c.predictor.RecordUseful(info.By)
+				}
+				prefetchHit = true
+				c.prefetchStats[info.By].Useful++
+				c.prefetchStats[info.By].LeadCycles += c.cycle - info.FillCycle
+				info.By = PredictorNone
+			}
+
+			// STEP 3: Train predictor (INNOVATION #59)
+			c.predictor.RecordLoad(pc, addr)
+
+			// STEP 4: Trigger prediction
+			c.triggerPrediction(pc)
+			c.notifyPrefetchers(DemandAccess{PC: pc, Addr: addr, Hit: true, PrefetchHit: prefetchHit})
+
+			return data, true
+		}
+	}
+
+	// MISS: was a prefetch for this line still on its way?
+	line := addr &^ (CacheLineSize - 1)
+	if by, late := c.prefetchQueue.MarkLate(line); late {
+		c.prefetchStats[by].Late++
+	}
+
+	// Did a prefetch throw this line out?
+	if p := &c.pollution[c.pollutionIndex(line)]; p.By != PredictorNone && p.Line == line {
+		c.prefetchStats[p.By].Polluting++
+		p.By = PredictorNone
+	}
+
+	c.predictor.RecordLoad(pc, addr)
+	c.notifyPrefetchers(DemandAccess{PC: pc, Addr: addr})
+	return 0, false
 }
 
-// ═══════════════════════════════════════════════════════════════════════════════
-// INSTRUCTION WINDOW (INNOVATION #35: Unified scheduler + ROB + IQ)
-// ═══════════════════════════════════════════════════════════════════════════════
-//
-// INNOVATION #35: Unified window = scheduler + ROB + IQ
-//
-// TRADITIONAL APPROACH:
-//   - Instruction Queue (IQ): Holds decoded instructions
-//   - Reservation Stations (RS): Wait for operands
-//   - Reorder Buffer (ROB): Tracks program order for commit
-//   Total: 3 separate structures, complex coordination
-//
-// OUR APPROACH: One unified window!
-//   - Each entry holds: instruction, dependencies, result, state
-//   - Acts as IQ (decoded instructions)
-//   - Acts as RS (wait for operands)
-//   - Acts as ROB (program order maintained by circular buffer)
-//
-// THE BENEFIT:
-//   Simpler hardware (one structure, not three)
-//   Less data movement (everything in one place)
-//   Easier dependency tracking (local to entry)
-//
-// INNOVATION #40: Bitmap wakeup (not CAM)
+// ReadFilled returns a word from a line a demand fill just installed
 //
-// TRADITIONAL: Content Addressable Memory (CAM)
-//   When value ready: Broadcast physical register number
-//   Every entry compares: "Is this my source?"
-//   CAM = expensive! (44× cost of regular memory)
-//
-// OUR APPROACH: Bitmap wakeup
-//   When value ready: Set bit in physical register file
-//   Each entry checks: "Are my source bits set?"
-//   Regular logic, not CAM (44× cheaper!) ✅
+// WHY NOT Read AGAIN: The missing access already counted and already
 //
-// INNOVATION #41: Single-cycle wakeup
+//	trained the predictor; training twice on one load would teach the
+//	stride predictor a stride of zero
+func (c *L1DCache) ReadFilled(addr uint32) (data uint32, hit bool) {
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+	set := &c.sets[setIdx]
+
+	for way := 0; way < L1Associativity; way++ {
+		line := &set[way]
+
+		if line.Valid && line.Tag == tag {
+			offset := int(addr & (CacheLineSize - 1))
+
+			data = uint32(line.Data[offset]) |
+				uint32(line.Data[offset+1])<<8 |
+				uint32(line.Data[offset+2])<<16 |
+				uint32(line.Data[offset+3])<<24
+
+			c.updateLRU(setIdx, way)
+			return data, true
+		}
+	}
+
+	return 0, false
+}
+
+// triggerPrediction asks predictor what we'll need next
 //
 // ALGORITHM:
-//   Instruction completes → Set ready bit → Wakeup happens
-//   All happens in ONE cycle (combinational logic)
-//
-// INNOVATION #42: Age-based selection priority
-//
-// PROBLEM: Multiple instructions ready, which to execute first?
 //
-// SOLUTION: Prioritize by age (program order)
-//   Scan window from head to tail (oldest to youngest)
-//   Execute oldest ready instructions first
+//	STEP 1: Get prediction from 5-way predictor
+//	STEP 2: Hand it to IssuePrefetch (cache check, throttle, queue)
+func (c *L1DCache) triggerPrediction(pc uint32) {
+	if !c.predictorPrefetch {
+		return
+	}
+
+	predAddr, predictor, valid, _ := c.predictor.query(pc)
+
+	if !valid {
+		return
+	}
+
+	// INNOVATION #67-68: Queue prefetch (skips cached lines, deduplicates)
+	c.IssuePrefetch(predAddr, predictor)
+}
+
+// Write stores data to cache
 //
-// WHY: Guarantees forward progress
-//      Prevents starvation
-//      Helps branch misprediction recovery
+// ALGORITHM:
 //
-// MINECRAFT ANALOGY: One big crafting board with all recipes
-//   Each recipe card shows: ingredients needed, status, result
-//   When ingredient arrives: Update all cards instantly (wakeup)
-//   When choosing recipes: Pick oldest ready ones first
+//	STEP 1: Find line in cache
+//	STEP 2: Write data to line, mark dirty
+//	STEP 3: Invalidate any reservations (for atomics)
+func (c *L1DCache) Write(addr uint32, data uint32) bool {
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+	set := &c.sets[setIdx]
 
-// Window is the instruction window (INNOVATION #35)
-type Window struct {
-	entries [WindowSize]WindowEntry // The 40 instruction slots
+	for way := 0; way < L1Associativity; way++ {
+		line := &set[way]
 
-	head  int // Oldest instruction (for commit)
-	tail  int // Next free slot (for dispatch)
-	count int // Number of valid entries
+		if line.Valid && line.Tag == tag {
+			offset := int(addr & (CacheLineSize - 1))
 
-	// INNOVATIONS #36-39: Register renaming
-	rat      *RAT
-	freeList *FreeList
-	regFile  [NumArchRegs]uint32 // Architectural register file
+			line.Data[offset] = byte(data)
+			line.Data[offset+1] = byte(data >> 8)
+			line.Data[offset+2] = byte(data >> 16)
+			line.Data[offset+3] = byte(data >> 24)
+			line.Dirty = true
 
-	// INNOVATION #51: Architectural + physical register files
-	physRegFile  [NumPhysRegs]uint32 // Physical register values
-	physRegReady [NumPhysRegs]bool   // Which registers have valid data
+			c.updateLRU(setIdx, way)
 
-	// Statistics
-	dispatched uint64
-	issued     uint64
-	committed  uint64
-}
+			// INNOVATION #72: Invalidate reservation if writing to reserved line
+			if c.reservationValid && (addr&^63) == (c.reservationAddr&^63) {
+				c.reservationValid = false
+			}
 
-// NewWindow creates an initialized instruction window
-func NewWindow() *Window {
-	w := &Window{
-		rat:      NewRAT(),
-		freeList: NewFreeList(),
+			return true
+		}
 	}
 
-	// Architectural registers start ready (initialized to zero)
-	for i := 0; i < NumArchRegs; i++ {
-		w.physRegReady[i] = true
-	}
+	return false // Not in cache
+}
 
-	return w
+// Fill installs a prefetched cache line from memory
+//
+// The line remembers which predictor asked for it and when it arrived,
+// so the first demand hit can be credited as useful (see PrefetchStats).
+func (c *L1DCache) Fill(addr uint32, data []byte) {
+	c.install(addr, data, c.prefetchQueue.Complete(addr))
 }
 
-// CanDispatch returns true if window has space (INNOVATION #44)
-func (w *Window) CanDispatch() bool {
-	return w.count < WindowSize && w.freeList.HasFree()
+// FillDemand installs a line fetched for a missing load
+func (c *L1DCache) FillDemand(addr uint32, data []byte) {
+	c.install(addr, data, PredictorNone)
 }
 
-// Dispatch adds a new instruction to the window (INNOVATION #44)
+// install places a line in its set, tagged with the requesting predictor
 //
 // ALGORITHM:
 //
-//	STEP 1: Check if window has space
-//	STEP 2: Allocate physical register for destination (INNOVATION #36)
-//	STEP 3: Look up physical registers for sources (INNOVATION #37)
-//	STEP 4: Determine if sources are ready (INNOVATION #53)
-//	STEP 5: Create window entry with all info (INNOVATION #54)
-//	STEP 6: Update RAT with new mapping (INNOVATION #37)
-//
-// INNOVATION #52: Dependency tracking per entry
-//
-//	Each entry knows exactly what it needs
-//	No separate dependency matrix
-//
-// MINECRAFT ANALOGY: Add new recipe card to the board
-//
-//	Note which ingredients are available
-func (w *Window) Dispatch(inst Instruction) (windowID int, ok bool) {
-	// STEP 1: Check capacity
-	if !w.CanDispatch() {
-		return -1, false
-	}
+//	STEP 1: Line already present? Leave it alone (it may be dirty)
+//	STEP 2: Pick LRU victim; account for what it held:
+//	          Unused prefetch   → Useless for its predictor
+//	          Demand line, and we are a prefetch → pollution filter
+//	STEP 3: Install and tag
+func (c *L1DCache) install(addr uint32, data []byte, by PredictorID) {
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+	set := &c.sets[setIdx]
 
-	// STEP 2: Allocate physical register for destination
-	var physRd uint8 = InvalidTag
-	if inst.Rd != 0 {
-		physRd = w.freeList.Allocate()
-		if physRd == InvalidTag {
-			return -1, false // No free registers!
+	// STEP 1: Already cached (e.g. prefetch and demand raced)
+	for way := 0; way < L1Associativity; way++ {
+		if set[way].Valid && set[way].Tag == tag {
+			return
 		}
 	}
 
-	// STEP 3: Look up physical registers for sources
-	physRs1 := w.rat.Lookup(inst.Rs1)
-	physRs2 := w.rat.Lookup(inst.Rs2)
+	// STEP 2: Evict
+	victimWay := c.findVictim(setIdx)
+	line := &set[victimWay]
+	info := &c.prefetchInfo[setIdx][victimWay]
+
+	if line.Valid {
+		if info.By != PredictorNone {
+			c.prefetchStats[info.By].Useless++
+		} else if by != PredictorNone {
+			victim := line.Tag<<(6+bits.Len32(uint32(c.numSets-1))) | uint32(setIdx)<<6
+			c.pollution[c.pollutionIndex(victim)] = pollutionEntry{Line: victim, By: by}
+		}
+	}
 
-	// STEP 4: Determine if sources are ready (INNOVATION #53)
-	src1Ready := inst.Rs1 == 0 || physRs1 == InvalidTag || w.physRegReady[physRs1]
-	src2Ready := inst.Rs2 == 0 || physRs2 == InvalidTag || w.physRegReady[physRs2]
+	// STEP 3: Install
+	line.Tag = tag
+	line.Valid = true
+	line.Dirty = false
+	copy(line.Data[:], data)
+	*info = PrefetchLineInfo{By: by, FillCycle: c.cycle}
 
-	// Special case: I-format instructions use immediate for rs2
-	if inst.UsesImm && !inst.IsBranch && inst.Opcode != OpSW && inst.Opcode != OpSC {
-		src2Ready = true
-		physRs2 = InvalidTag
-	}
+	c.updateLRU(setIdx, victimWay)
+}
 
-	// STEP 5: Create window entry (INNOVATION #35, #52, #53, #54)
-	entry := &w.entries[w.tail]
-	*entry = WindowEntry{
-		PC:        inst.PC,
-		Opcode:    inst.Opcode,
-		Rd:        inst.Rd,
-		PhysRd:    physRd,
-		Rs1:       inst.Rs1,
-		Rs2:       inst.Rs2,
-		PhysRs1:   physRs1,
-		PhysRs2:   physRs2,
-		Imm:       inst.Imm,
-		Src1Ready: src1Ready,
-		Src2Ready: src2Ready,
-		Valid:     true,
-		IsLoad:    inst.IsLoad,
-		IsStore:   inst.IsStore,
-		IsBranch:  inst.IsBranch,
-	}
+// findVictim selects a line to evict (INNOVATION #19: LRU)
+func (c *L1DCache) findVictim(setIdx int) int {
+	set := &c.sets[setIdx]
 
-	// STEP 6: Update RAT with new mapping
-	if physRd != InvalidTag {
-		w.rat.Allocate(inst.Rd, physRd)
-		w.physRegReady[physRd] = false // Result not ready yet
+	// First try to find invalid line
+	for way := 0; way < L1Associativity; way++ {
+		if !set[way].Valid {
+			return way
+		}
 	}
 
-	windowID = w.tail
-	w.tail = (w.tail + 1) % WindowSize
-	w.count++
-	w.dispatched++
+	// All valid: evict LRU
+	return int(c.lru[setIdx] & 0x3)
+}
 
-	return windowID, true
+// updateLRU marks a way as most recently used (INNOVATION #19)
+func (c *L1DCache) updateLRU(setIdx int, usedWay int) {
+	c.lru[setIdx] = uint8(usedWay)
 }
 
-// Wakeup tells waiting instructions a value is ready (INNOVATION #40-41)
-//
-// ALGORITHM:
-//
-//	STEP 1: Store value in physical register file
-//	STEP 2: Mark physical register as ready
-//	STEP 3: Scan ALL window entries
-//	STEP 4: For each entry: If waiting for this register, mark ready
-//
-// INNOVATION #40: Bitmap wakeup (not CAM)
-//
-//	We don't use expensive CAM
-//	Just check if physReg matches (simple comparison)
-//
-// INNOVATION #41: Single-cycle wakeup
-//
-//	All comparisons happen in parallel (combinational)
-//	Result available same cycle
-//
-// HARDWARE NOTE: This is ONE gate delay in real hardware
-//
-//	All entries check simultaneously
-//
-// MINECRAFT ANALOGY: Announce "eggs are ready!"
-//
-//	All chefs check their recipes simultaneously
-func (w *Window) Wakeup(physReg uint8, value uint32) {
-	if physReg >= NumPhysRegs || physReg == InvalidTag {
-		return
+// LoadReserved performs LR (INNOVATION #71: Load reserved)
+func (c *L1DCache) LoadReserved(pc uint32, addr uint32) (data uint32, hit bool) {
+	data, hit = c.Read(pc, addr)
+
+	if hit {
+		// INNOVATION #72: Set reservation
+		c.reservationValid = true
+		c.reservationAddr = addr
 	}
 
-	// STEP 1-2: Store value and mark ready
-	w.physRegFile[physReg] = value
-	w.physRegReady[physReg] = true
+	return
+}
 
-	// STEP 3-4: Wake up waiting instructions (INNOVATION #40)
-	for i := 0; i < WindowSize; i++ {
-		entry := &w.entries[i]
+// StoreConditional performs SC (INNOVATION #71: Store conditional)
+func (c *L1DCache) StoreConditional(addr uint32, data uint32) (success bool, cacheHit bool) {
+	// Check reservation (INNOVATION #72)
+	if !c.reservationValid || c.reservationAddr != addr {
+		c.reservationValid = false
+		return false, true // SC failed, but cache "hit"
+	}
 
-		if !entry.Valid || entry.Issued {
-			continue
-		}
+	// Reservation valid: perform store
+	cacheHit = c.Write(addr, data)
+	c.reservationValid = false
 
-		// Check if this entry was waiting for this register
-		if entry.PhysRs1 == physReg {
-			entry.Src1Ready = true
-		}
-		if entry.PhysRs2 == physReg {
-			entry.Src2Ready = true
-		}
+	return cacheHit, cacheHit
+}
+
+// GetNextPrefetch returns next prefetch request (INNOVATION #67)
+func (c *L1DCache) GetNextPrefetch() (addr uint32, valid bool) {
+	return c.prefetchQueue.Dequeue()
+}
+
+func (c *L1DCache) GetHitRate() float64 {
+	if c.accesses == 0 {
+		return 0
 	}
+	return float64(c.hits) / float64(c.accesses)
 }
 
-// SelectReady finds ready instructions (INNOVATION #42-43)
-//
-// ALGORITHM:
+func (c *L1DCache) GetPredictorAccuracy() float64 {
+	return c.predictor.GetAccuracy()
+}
+
+// GetPredictorCoverageReport returns the per-component coverage table
+func (c *L1DCache) GetPredictorCoverageReport() string {
+	return c.predictor.CoverageReport()
+}
+
+// GetPrefetchStats returns the prefetch counters for one predictor
+func (c *L1DCache) GetPrefetchStats(predictor PredictorID) PrefetchStats {
+	if int(predictor) >= NumPredictorIDs {
+		return PrefetchStats{}
+	}
+	return c.prefetchStats[predictor]
+}
+
+// PrefetchReport tabulates prefetch effectiveness per predictor
 //
-//	STEP 1: Scan window from head to tail (oldest first)
-//	STEP 2: For each instruction:
-//	          Check if ready (sources available)
-//	          Check if appropriate execution unit available
-//	          If yes: Add to ready list
-//	STEP 3: Return up to IssueWidth instructions
+// COLUMNS:
 //
-// INNOVATION #42: Age-based selection
+//	Issued..Polluting: raw PrefetchStats counters
+//	Acc:  Useful / (Useful + Useless)
+//	Lead: Average cycles from fill to first demand hit
+func (c *L1DCache) PrefetchReport() string {
+	var b strings.Builder
+	t := &c.throttle
+
+	state := "off"
+	if t.Enabled {
+		state = "on"
+	}
+	fmt.Fprintf(&b, "L1D PREFETCH EFFECTIVENESS (throttle %s, degree %d, %d decisions, %d up, %d down, %d disables):\n",
+		state, t.Degree, t.Decisions, t.DegreeUps, t.DegreeDowns, t.Disables)
+	fmt.Fprintf(&b, "  %-9s %8s %9s %8s %8s %8s %9s %8s %8s\n",
+		"Component", "Issued", "Throttled", "Useful", "Late", "Useless", "Polluting", "Acc", "Lead")
+
+	for id := PredictorStride; id < NumPredictorIDs; id++ {
+		st := c.prefetchStats[id]
+		if id > PredictorContext && st.Issued == 0 && st.Throttled == 0 {
+			continue // Hardware prefetcher not attached
+		}
+		lead := 0.0
+		if st.Useful > 0 {
+			lead = float64(st.LeadCycles) / float64(st.Useful)
+		}
+		fmt.Fprintf(&b, "  %-9s %8d %9d %8d %8d %8d %9d %7.2f%% %8.1f\n",
+			id, st.Issued, st.Throttled, st.Useful, st.Late, st.Useless, st.Polluting, st.Accuracy()*100, lead)
+	}
+
+	return b.String()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// MEMORY PORT ARBITER
+// ═══════════════════════════════════════════════════════════════════════════════
 //
-//	Scan from head (oldest) to tail (youngest)
-//	This gives priority to older instructions ✅
+// THE PROBLEM: Four kinds of traffic want DRAM, and none of them knows
+//              about the others
+//   L1I demand:    Fetch missed, frontend stalled
+//   L1D demand:    Load missed, dependents waiting
+//   L1I prefetch:  Coverage scoring or the FTQ
+//   L1D prefetch:  5-way predictor and hardware prefetchers
+//   Aggressive prefetch on one side can starve demand on the other
 //
-// INNOVATION #43: 6-wide issue
+// THE SOLUTION: One queue per kind, one arbiter in front of DRAM
+//   Each cycle: Up to Ports requests start (Latency cycles each,
+//               pipelined - a port is free again next cycle)
+//   Priority:   Demand first (data, then instruction), then prefetch
+//               (data, then instruction) - or plain round-robin
+//   Stats:      Per-kind queue occupancy and wait, to show who is
+//               waiting behind whom
 //
-//	Return up to 6 instructions per cycle
-//	Balanced for our execution unit configuration
+// COMPLETION: The arbiter copies the line out of memory when the
+//   request finishes; requesters poll Done and install Data
 //
-// MINECRAFT ANALOGY: Pick oldest recipes that have all ingredients ready
-func (w *Window) SelectReady() []int {
-	ready := make([]int, 0, IssueWidth)
+// MINECRAFT ANALOGY: One mine entrance, several teams with carts - the
+//   foreman decides whose cart goes in next
 
-	// Count execution units used (ensure we don't over-issue)
-	aluCount := 0
-	mulCount := 0
-	divCount := 0
-	lsuCount := 0
+// MemClass identifies the kind of memory traffic
+type MemClass uint8
 
-	// INNOVATION #42: Scan in age order (head to tail)
-	for i := 0; i < w.count && len(ready) < IssueWidth; i++ {
-		idx := (w.head + i) % WindowSize
-		entry := &w.entries[idx]
+const (
+	MemInstDemand   MemClass = 0 // L1I miss (fetch stalled)
+	MemDataDemand   MemClass = 1 // L1D miss (load waiting)
+	MemInstPrefetch MemClass = 2 // L1I prefetch
+	MemDataPrefetch MemClass = 3 // L1D prefetch
 
-		// Check if ready
-		if !entry.Valid || entry.Issued || !entry.Src1Ready || !entry.Src2Ready {
-			continue
-		}
+	NumMemClasses = 4
+)
 
-		// Check if appropriate execution unit available
-		canIssue := false
-		switch entry.Opcode {
-		case OpMUL, OpMULH:
-			if mulCount < NumMULs {
-				mulCount++
-				canIssue = true
-			}
-		case OpDIV, OpREM:
-			if divCount < NumDIVs {
-				divCount++
-				canIssue = true
-			}
-		case OpLW, OpSW, OpLR, OpSC:
-			if lsuCount < NumLSUs {
-				lsuCount++
-				canIssue = true
-			}
-		default:
-			if aluCount < NumALUs {
-				aluCount++
-				canIssue = true
-			}
-		}
+var memClassNames = [NumMemClasses]string{"i-demand", "d-demand", "i-prefetch", "d-prefetch"}
 
-		if canIssue {
-			ready = append(ready, idx)
-		}
+// String returns the short name used in reports
+func (k MemClass) String() string {
+	if int(k) < NumMemClasses {
+		return memClassNames[k]
 	}
-
-	return ready
+	return fmt.Sprintf("memclass(%d)", uint8(k))
 }
 
-// MarkIssued marks instruction as sent to execution
-func (w *Window) MarkIssued(windowID int) {
-	if windowID >= 0 && windowID < WindowSize {
-		w.entries[windowID].Issued = true
-		w.issued++
+// ArbiterPolicy picks which queue gets the next free port
+type ArbiterPolicy uint8
+
+const (
+	ArbiterDemandFirst ArbiterPolicy = 0 // Demand over prefetch, data over instruction
+	ArbiterRoundRobin  ArbiterPolicy = 1 // Kinds take turns
+)
+
+// String returns the short name used in reports
+func (p ArbiterPolicy) String() string {
+	if p == ArbiterRoundRobin {
+		return "round-robin"
 	}
+	return "demand-first"
 }
 
-// Complete marks instruction as finished (INNOVATION #55)
-//
-// ALGORITHM:
-//
-//	STEP 1: Store result in window entry
-//	STEP 2: Mark as executed
-//	STEP 3: Wakeup dependent instructions (INNOVATION #41)
-//
-// INNOVATION #55: Result forwarding
-//
-//	Result immediately available to dependent instructions
-//	Don't wait for commit to forward result
-func (w *Window) Complete(windowID int, result uint32) {
-	if windowID < 0 || windowID >= WindowSize {
-		return
-	}
+// demandFirstOrder is the ArbiterDemandFirst priority, highest first
+var demandFirstOrder = [NumMemClasses]MemClass{
+	MemDataDemand, MemInstDemand, MemDataPrefetch, MemInstPrefetch,
+}
 
-	entry := &w.entries[windowID]
-	entry.Result = result
-	entry.ResultValid = true
-	entry.Executed = true
+// MemRequest is one line read from memory
+type MemRequest struct {
+	Line    uint32              // Line address
+	Class   MemClass            // Which queue it waits in
+	Data    [CacheLineSize]byte // Line contents (valid once Done)
+	Granted bool                // Has a port, DRAM access under way
+	Done    bool                // Data has arrived
 
-	// INNOVATION #55: Wakeup dependent instructions
-	if entry.PhysRd != InvalidTag {
-		w.Wakeup(entry.PhysRd, result)
-	}
+	submitted uint64 // Cycle it was queued
+	cyclesRem int    // DRAM cycles left once granted
 }
 
-// GetEntry returns a window entry (for reading state)
-func (w *Window) GetEntry(windowID int) *WindowEntry {
-	if windowID >= 0 && windowID < WindowSize {
-		return &w.entries[windowID]
-	}
-	return nil
+// MemClassStats counts one kind of traffic at the arbiter
+type MemClassStats struct {
+	Requests   uint64 // Submitted
+	Granted    uint64 // Given a port
+	WaitCycles uint64 // Cycles spent queued before a port (granted only)
+	Occupancy  uint64 // Sum of queue length per cycle
+	MaxQueue   int    // Longest queue seen
 }
 
-// ReadReg reads a register value (architectural or physical)
-func (w *Window) ReadReg(archReg, physReg uint8) uint32 {
-	// r0 is always zero
-	if archReg == 0 {
+// AvgWait returns average cycles from submit to grant
+func (s MemClassStats) AvgWait() float64 {
+	if s.Granted == 0 {
 		return 0
 	}
+	return float64(s.WaitCycles) / float64(s.Granted)
+}
 
-	// Try physical register first
-	if physReg != InvalidTag && physReg < NumPhysRegs && w.physRegReady[physReg] {
-		return w.physRegFile[physReg]
+// MemoryArbiter shares DRAM ports between all memory traffic
+type MemoryArbiter struct {
+	Ports   int           // Requests started per cycle
+	Policy  ArbiterPolicy // How queues are picked
+	Latency int           // Cycles from grant to data (DRAMLatency by default)
+
+	memory   []byte
+	queues   [NumMemClasses][]*MemRequest
+	inFlight []*MemRequest
+	rrNext   int    // Round-robin: first queue to try next
+	cycle    uint64 // Cycles ticked
+
+	// Statistics
+	stats     [NumMemClasses]MemClassStats
+	portsUsed uint64 // Grants over all cycles (port utilization)
+}
+
+// NewMemoryArbiter creates an arbiter in front of memory
+func NewMemoryArbiter(memory []byte, ports int, policy ArbiterPolicy) *MemoryArbiter {
+	return &MemoryArbiter{Ports: ports, Policy: policy, Latency: DRAMLatency, memory: memory}
+}
+
+// Submit queues a read of addr's line
+func (a *MemoryArbiter) Submit(addr uint32, class MemClass) *MemRequest {
+	req := &MemRequest{
+		Line:      addr &^ (CacheLineSize - 1),
+		Class:     class,
+		submitted: a.cycle,
 	}
+	a.queues[class] = append(a.queues[class], req)
+	a.stats[class].Requests++
+	return req
+}
 
-	// Fall back to architectural register
-	if archReg < NumArchRegs {
-		return w.regFile[archReg]
+// Promote moves a still-queued request to a higher-priority kind
+//
+// USED BY: Fetch catching up with its line's prefetch - the prefetch
+//
+//	is now demand and should be treated as such
+func (a *MemoryArbiter) Promote(req *MemRequest, class MemClass) {
+	if req.Granted || req.Class == class {
+		return
 	}
 
-	return 0
+	queue := a.queues[req.Class]
+	for i, r := range queue {
+		if r == req {
+			a.queues[req.Class] = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+
+	a.stats[req.Class].Requests--
+	req.Class = class
+	a.queues[class] = append(a.queues[class], req)
+	a.stats[class].Requests++
 }
 
-// Commit retires the oldest instruction (INNOVATION #45, #47)
+// Tick advances DRAM accesses, then hands free ports to queued requests
 //
 // ALGORITHM:
 //
-//	STEP 1: Check if oldest instruction is ready to commit
-//	STEP 2: If yes: Write result to architectural state
-//	STEP 3: Free physical register (INNOVATION #38)
-//	STEP 4: Advance head pointer
-//
-// INNOVATION #45: 4-wide commit
-//
-//	Can commit up to 4 instructions per cycle
-//
-// INNOVATION #47: Program-order commit
-//
-//	Always commit from head (oldest first)
-//	Guarantees precise exceptions
-//	Makes speculation recovery simple
-//
-// MINECRAFT ANALOGY: Serve completed dishes in order they were ordered
-func (w *Window) Commit() *WindowEntry {
-	if w.count == 0 {
-		return nil
+//	STEP 1: Count down in-flight requests; finished ones copy their line
+//	STEP 2: Grant up to Ports requests, queue order set by Policy
+//	STEP 3: Record queue occupancy
+func (a *MemoryArbiter) Tick() {
+	a.cycle++
+
+	// STEP 1: DRAM progress
+	remaining := a.inFlight[:0]
+	for _, req := range a.inFlight {
+		req.cyclesRem--
+		if req.cyclesRem > 0 {
+			remaining = append(remaining, req)
+			continue
+		}
+
+		for j := 0; j < CacheLineSize; j++ {
+			if int(req.Line)+j < len(a.memory) {
+				req.Data[j] = a.memory[req.Line+uint32(j)]
+			}
+		}
+		req.Done = true
 	}
+	a.inFlight = remaining
 
-	entry := &w.entries[w.head]
+	// STEP 2: Grant ports
+	for port := 0; port < a.Ports; port++ {
+		class, ok := a.pick()
+		if !ok {
+			break
+		}
 
-	// Can only commit if executed
-	if !entry.Valid || !entry.Executed {
-		return nil
+		req := a.queues[class][0]
+		a.queues[class] = a.queues[class][1:]
+
+		req.Granted = true
+		req.cyclesRem = a.Latency
+		a.inFlight = append(a.inFlight, req)
+
+		a.stats[class].Granted++
+		a.stats[class].WaitCycles += a.cycle - req.submitted
+		a.portsUsed++
 	}
 
-	// STEP 2: Write result to architectural state
-	if entry.Rd != 0 && entry.ResultValid {
-		w.regFile[entry.Rd] = entry.Result
+	// STEP 3: Occupancy
+	for k := range a.queues {
+		n := len(a.queues[k])
+		a.stats[k].Occupancy += uint64(n)
+		if n > a.stats[k].MaxQueue {
+			a.stats[k].MaxQueue = n
+		}
 	}
+}
 
-	// STEP 3: Free physical register (INNOVATION #38)
-	if entry.PhysRd != InvalidTag {
-		w.rat.Free(entry.Rd, entry.PhysRd)
-		w.freeList.Free(entry.PhysRd)
+// pick chooses the queue that gets the next port
+func (a *MemoryArbiter) pick() (MemClass, bool) {
+	if a.Policy == ArbiterRoundRobin {
+		for i := 0; i < NumMemClasses; i++ {
+			k := (a.rrNext + i) % NumMemClasses
+			if len(a.queues[k]) > 0 {
+				a.rrNext = k + 1
+				return MemClass(k), true
+			}
+		}
+		return 0, false
 	}
 
-	// Save entry info before clearing
-	committed := *entry
+	for _, k := range demandFirstOrder {
+		if len(a.queues[k]) > 0 {
+			return k, true
+		}
+	}
+	return 0, false
+}
 
-	// Clear entry
-	entry.Valid = false
+// GetStats returns the counters for one kind of traffic
+func (a *MemoryArbiter) GetStats(class MemClass) MemClassStats {
+	return a.stats[class]
+}
 
-	// STEP 4: Advance head
-	w.head = (w.head + 1) % WindowSize
-	w.count--
-	w.committed++
+// GetAvgQueue returns the average queue length for one kind
+func (a *MemoryArbiter) GetAvgQueue(class MemClass) float64 {
+	if a.cycle == 0 {
+		return 0
+	}
+	return float64(a.stats[class].Occupancy) / float64(a.cycle)
+}
 
-	return &committed
+// GetPortUtilization returns the fraction of port-cycles used
+func (a *MemoryArbiter) GetPortUtilization() float64 {
+	if a.cycle == 0 || a.Ports == 0 {
+		return 0
+	}
+	return float64(a.portsUsed) / float64(a.cycle*uint64(a.Ports))
 }
 
-// Flush clears all entries (INNOVATION #48: mispredict recovery)
-//
-// ALGORITHM:
-//
-//	STEP 1: For each entry: Free physical register
-//	STEP 2: Clear all entries
-//	STEP 3: Reset pointers
-//	STEP 4: Reset RAT (start fresh)
-//
-// INNOVATION #48: Branch mispredict recovery
-//
-//	On mispredict: Throw away ALL speculative work
-//	Restart from correct path
-//
-// WHY: Simpler than selective recovery
-//
-//	Works correctly for nested mispredictions
-//	Fast enough (mispredictions are rare)
-func (w *Window) Flush() {
-	// STEP 1: Free all allocated physical registers
-	for i := 0; i < WindowSize; i++ {
-		entry := &w.entries[i]
-		if entry.Valid && entry.PhysRd != InvalidTag {
-			w.freeList.Free(entry.PhysRd)
-		}
-		entry.Valid = false
-	}
+// Report returns a per-kind table of arbiter traffic
+func (a *MemoryArbiter) Report() string {
+	var b strings.Builder
 
-	// STEP 2-3: Reset state
-	w.head = 0
-	w.tail = 0
-	w.count = 0
+	fmt.Fprintf(&b, "  Ports: %d (%s), Utilization: %.1f%%\n",
+		a.Ports, a.Policy, a.GetPortUtilization()*100)
+	fmt.Fprintf(&b, "  %-11s %9s %9s %9s %9s %9s\n",
+		"Kind", "Requests", "Granted", "AvgWait", "AvgQueue", "MaxQueue")
 
-	// STEP 4: Reset RAT (INNOVATION #48)
-	w.rat = NewRAT()
-}
-
-// GetCount returns number of in-flight instructions
-func (w *Window) GetCount() int {
-	return w.count
+	for k := MemClass(0); k < NumMemClasses; k++ {
+		st := a.stats[k]
+		fmt.Fprintf(&b, "  %-11s %9d %9d %9.1f %9.2f %9d\n",
+			k, st.Requests, st.Granted, st.AvgWait(), a.GetAvgQueue(k), st.MaxQueue)
+	}
+	return b.String()
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
-// EXECUTION UNITS (INNOVATIONS #56-58)
+// LOAD/STORE UNIT (INNOVATIONS #69-73)
 // ═══════════════════════════════════════════════════════════════════════════════
 //
-// INNOVATION #56: 2 ALUs
-// INNOVATION #57: 1 Multiplier
-// INNOVATION #58: 1 Divider
+// INNOVATION #69: 2 independent LSUs
 //
-// THE DECISION: Match unit count to workload
+// THE PROBLEM: Memory operations are common (30% of instructions)
+//              Single LSU becomes bottleneck
 //
-// Workload analysis shows:
-//   ALU operations: 60% (add, sub, shift, compare, logic)
-//   Multiply: 8%
-//   Divide: 2%
-//   Load/Store: 30%
+// THE SOLUTION: Two independent LSUs
+//              Can process 2 memory operations simultaneously
 //
-// Our configuration:
-//   2 ALUs: Handle 60% workload with margin
-//   1 MUL: Handle 8% workload (1-cycle, so sufficient)
-//   1 DIV: Handle 2% workload (4-cycle, so sufficient)
-//   2 LSUs: Handle 30% workload with margin
+// THE MATH: With 30% memory ops and 2 LSUs:
+//   1 LSU: 30% utilization, becomes bottleneck at 3.3 IPC
+//   2 LSUs: 15% utilization each, no bottleneck until 6.6 IPC ✅
 //
-// Total: 6 execution units (matches IssueWidth of 6)
+// INNOVATION #70: Load speculation
 //
-// MINECRAFT ANALOGY: Different workstations for different tasks
-//   2 crafting tables (general purpose)
-//   1 furnace (slow but specialized)
-//   1 anvil (even slower, rarely needed)
-//   2 chests for storage/retrieval
-
-// ALUExecute performs single-cycle ALU operations (INNOVATION #56)
+// THE PROBLEM: Loads take variable time (1-100 cycles)
+//              Don't know if it will hit cache until we try
 //
-// ALGORITHM:
+// THE SOLUTION: Optimistically assume cache hit (1 cycle)
+//              If wrong (miss): Squash dependent ops, restart
 //
-//	Based on opcode, perform appropriate operation
-//	All operations complete in ONE cycle
+// BENEFIT: 95% of loads hit cache → 95% correct speculation ✅
 //
-// OPERATIONS:
+// INNOVATION #71: Atomic operations (LR/SC)
 //
-//	Arithmetic: ADD, SUB (using our carry-select adder)
-//	Logic: AND, OR, XOR
-//	Shifts: SLL, SRL, SRA (using our barrel shifter)
-//	Compare: SLT, SLTU (using subtraction + sign check)
+// THE PROBLEM: Multi-threaded code needs atomic read-modify-write
+//              Example: atomic increment of counter
 //
-// HARDWARE NOTE: These are all combinational logic
+// THE SOLUTION: Load-Reserved / Store-Conditional pair
+//   LR: Load value, remember address
+//   SC: Store if no one else wrote to address
 //
-//	All complete in <1 cycle at modern clock rates
-func ALUExecute(op uint8, a, b uint32) uint32 {
-	switch op {
-	case OpADD, OpADDI:
-		// INNOVATION #7: Carry-select adder (fast!)
-		return Add32(a, b)
-
-	case OpSUB:
-		// INNOVATION #8: Two's complement (reuse adder!)
-		return Sub32(a, b)
-
-	case OpAND, OpANDI:
-		return a & b
-
-	case OpOR, OpORI:
-		return a | b
-
-	case OpXOR, OpXORI:
-		return a ^ b
-
-	case OpSLL:
-		// INNOVATION #9: Barrel shifter (1 cycle!)
-		return BarrelShift(a, uint8(b), true, false)
-
-	case OpSRL:
-		return BarrelShift(a, uint8(b), false, false)
-
-	case OpSRA:
-		return BarrelShift(a, uint8(b), false, true)
+// EXAMPLE: Atomic increment
+//   LR r1, [counter]     // Load counter, reserve address
+//   ADDI r1, r1, 1       // Increment
+//   SC r2, [counter], r1 // Store if still reserved
+//   BNE r2, x0, retry    // If SC failed (r2=1), retry
+//
+// INNOVATION #72: Reservation tracking
+//
+// THE ALGORITHM: Track one reserved address per core
+//   LR: Set reservation to address
+//   SC: Check reservation, clear if match
+//   Any write to cache line: Clear reservation
+//
+// WHY ONE RESERVATION: Sufficient for lock-free algorithms
+//                       Multiple reservations = complex hardware
+//
+// INNOVATION #73: Variable latency handling
+//
+// THE PROBLEM: Cache hit = 1 cycle, miss = 100 cycles
+//              LSU must handle both gracefully
+//
+// THE SOLUTION: State machine with cycle counter
+//   Start operation: Set cycles_remaining
+//   Each cycle: Decrement counter
+//   When zero: Check cache again (might still miss!)
+//   On hit: Return result
+//   On miss: Wait more cycles
+//
+// MINECRAFT ANALOGY: Two workers fetching items from storage
+//                    Usually instant (hotbar), sometimes slow (storage room)
 
-	case OpSLT:
-		// Set if less than (signed)
-		// Subtract and check sign bit
-		if int32(a) < int32(b) {
-			return 1
-		}
-		return 0
+// MemoryOperation describes a pending memory access
+type MemoryOperation struct {
+	PC       uint32 // For predictor training
+	Addr     uint32 // Memory address
+	Data     uint32 // Data to store (for stores)
+	Rd       uint8  // Destination register (for loads)
+	WindowID int    // Which window entry this belongs to
+	IsStore  bool   // Store (true) or load (false)
+	IsAtomic bool   // Is this SC (store conditional)?
+	IsLR     bool   // Is this LR (load reserved)?
+}
 
-	case OpSLTU:
-		// Set if less than (unsigned)
-		if a < b {
-			return 1
-		}
-		return 0
+// LSU handles one memory operation at a time (INNOVATION #69)
+type LSU struct {
+	busy      bool            // Is this LSU processing an operation?
+	op        MemoryOperation // Current operation
+	cyclesRem int             // Cycles remaining (INNOVATION #73)
+	dcache    *L1DCache       // Data cache reference
+	arbiter   *MemoryArbiter  // DRAM access for demand misses
+	fill      *MemRequest     // op's line, requested on a miss
+	missing   bool            // Waiting on DRAM for op's line
 
-	case OpLUI:
-		// Load upper immediate: shift left by 15 bits
-		return uint32(int32(b) << 15)
+	// Result communication
+	resultValid bool   // Is result ready?
+	resultData  uint32 // Result value
+	resultRd    uint8  // Destination register
+	resultWinID int    // Window ID for result
+}
 
-	default:
-		return 0
-	}
+// NewLSU creates a load/store unit
+func NewLSU(dcache *L1DCache, arbiter *MemoryArbiter) *LSU {
+	return &LSU{dcache: dcache, arbiter: arbiter}
 }
 
-// EvaluateBranch determines if a branch condition is true
+// Issue starts a new memory operation (INNOVATION #70: speculative)
 //
 // ALGORITHM:
 //
-//	Compare two values according to branch type
-//	Return true if branch should be taken
-//
-// BRANCH TYPES:
-//
-//	BEQ: Equal (a == b)
-//	BNE: Not equal (a != b)
-//	BLT: Less than signed (a < b as signed)
-//	BGE: Greater or equal signed (a >= b as signed)
+//	STEP 1: Check if LSU is busy
+//	STEP 2: If free: Accept operation
+//	STEP 3: Set initial latency (optimistic: 1 cycle for cache hit)
+//	STEP 4: Start processing
 //
-// HARDWARE NOTE: Uses subtraction and flag checking
+// SPECULATION: We assume cache hit (1 cycle)
 //
-//	Completes in 1 cycle
-func EvaluateBranch(op uint8, a, b uint32) bool {
-	switch op {
-	case OpBEQ:
-		return a == b
-	case OpBNE:
-		return a != b
-	case OpBLT:
-		return int32(a) < int32(b)
-	case OpBGE:
-		return int32(a) >= int32(b)
-	default:
-		return false
+//	If wrong, we'll discover it and wait longer
+func (lsu *LSU) Issue(op MemoryOperation) bool {
+	if lsu.busy {
+		return false // LSU busy, can't accept
 	}
-}
 
-// Multiplier wraps the 1-cycle Wallace tree multiply (INNOVATION #57)
-type Multiplier struct {
-	busy      bool   // Is multiplier in use?
-	windowID  int    // Which instruction this is for
-	resultLo  uint32 // Low 32 bits of result
-	resultHi  uint32 // High 32 bits of result
-	isHigh    bool   // MUL (false) or MULH (true)?
-	completed bool   // Is result ready?
+	// Accept operation
+	lsu.busy = true
+	lsu.op = op
+	lsu.cyclesRem = L1Latency // INNOVATION #70: Optimistic 1 cycle
+	lsu.resultValid = false
+
+	return true
 }
 
-// Issue starts a new multiply (INNOVATION #12: completes in 1 cycle!)
+// Tick advances the LSU by one clock cycle (INNOVATION #73)
 //
 // ALGORITHM:
 //
-//	STEP 1: Mark as busy
-//	STEP 2: Call multiply function (INNOVATION #10-11: Booth + Wallace)
-//	STEP 3: Mark as completed (same cycle!)
+//	STEP 1: Decrement cycle counter
+//	STEP 2: If cycles remain: Wait
+//	STEP 3: If cycles done: Try cache access
+//	STEP 4: On hit: Return result
+//	STEP 5: On miss: Wait more cycles (DRAM latency)
 //
-// INNOVATION #12: 1-cycle multiply
+// VARIABLE LATENCY: Cache hit = 1 cycle, miss = 100 cycles
 //
-//	Intel takes 3-4 cycles
-//	We complete in 1 cycle! 🔥
-func (m *Multiplier) Issue(windowID int, a, b uint32, high bool) {
-	m.busy = true
-	m.windowID = windowID
-
-	// INNOVATION #10-12: Booth + Wallace tree = 1 cycle!
-	m.resultLo, m.resultHi = Multiply(a, b)
-
-	m.isHigh = high
-	m.completed = true // Done immediately!
-}
-
-// GetResult returns the multiply result
-func (m *Multiplier) GetResult() (uint32, int, bool) {
-	if m.completed {
-		m.busy = false
-		m.completed = false
+//	State machine handles both seamlessly
+func (lsu *LSU) Tick() {
+	if !lsu.busy {
+		return
+	}
 
-		if m.isHigh {
-			return m.resultHi, m.windowID, true
+	// STEP 1: Count down (a miss waits on the arbiter instead)
+	if lsu.missing {
+		if !lsu.fill.Done {
+			return // Line still on its way
+		}
+	} else {
+		lsu.cyclesRem--
+		if lsu.cyclesRem > 0 {
+			return // Still waiting
 		}
-		return m.resultLo, m.windowID, true
 	}
-	return 0, 0, false
-}
 
-// IsBusy returns true if multiplier is in use
-func (m *Multiplier) IsBusy() bool {
-	return m.busy
-}
+	// STEP 3: Time to try cache access
+	if lsu.op.IsStore {
+		// ═══════════════════════════════════════════════════════════════
+		// STORE OPERATION
+		// ═══════════════════════════════════════════════════════════════
 
-// ═══════════════════════════════════════════════════════════════════════════════
-// THE COMPLETE CPU (INTEGRATION OF ALL INNOVATIONS)
-// ═══════════════════════════════════════════════════════════════════════════════
-//
-// This is where everything comes together!
-//
-// THE ARCHITECTURE: 7-stage pipeline
+		if lsu.op.IsAtomic {
+			// INNOVATION #71: Store conditional (SC)
+			success, _ := lsu.dcache.StoreConditional(lsu.op.Addr, lsu.op.Data)
+
+			// SC returns success/failure in destination register
+			lsu.resultData = 0
+			if !success {
+				lsu.resultData = 1 // SC failed
+			}
+		} else {
+			// Regular store
+			lsu.dcache.Write(lsu.op.Addr, lsu.op.Data)
+			lsu.resultData = 0
+		}
+
+		lsu.resultRd = lsu.op.Rd
+		lsu.resultWinID = lsu.op.WindowID
+		lsu.resultValid = true
+		lsu.busy = false
+
+	} else {
+		// ═══════════════════════════════════════════════════════════════
+		// LOAD OPERATION
+		// ═══════════════════════════════════════════════════════════════
+
+		var data uint32
+		var hit bool
+
+		if lsu.missing {
+			// DRAM has answered our earlier miss: install the line and
+			// take the word (the miss itself already trained the predictor)
+			lsu.dcache.FillDemand(lsu.fill.Line, lsu.fill.Data[:])
+			lsu.fill = nil
+			lsu.missing = false
+			data, hit = lsu.dcache.ReadFilled(lsu.op.Addr)
+
+			if hit && lsu.op.IsLR {
+				// INNOVATION #72: Set reservation
+				lsu.dcache.reservationValid = true
+				lsu.dcache.reservationAddr = lsu.op.Addr
+			}
+		} else if lsu.op.IsLR {
+			// INNOVATION #71: Load reserved (LR)
+			data, hit = lsu.dcache.LoadReserved(lsu.op.PC, lsu.op.Addr)
+		} else {
+			// Regular load
+			data, hit = lsu.dcache.Read(lsu.op.PC, lsu.op.Addr)
+		}
+
+		if hit {
+			// CACHE HIT! (INNOVATION #70: speculation was correct)
+			lsu.resultData = data
+			lsu.resultRd = lsu.op.Rd
+			lsu.resultWinID = lsu.op.WindowID
+			lsu.resultValid = true
+			lsu.busy = false
+		} else {
+			// CACHE MISS! (INNOVATION #73: variable latency)
+			// Need to wait for DRAM (queued behind other traffic)
+			lsu.fill = lsu.arbiter.Submit(lsu.op.Addr, MemDataDemand)
+			lsu.missing = true
+		}
+	}
+}
+
+// IsBusy returns true if LSU is processing
 //
-//   STAGE 1: COMMIT
-//     - Retire completed instructions (INNOVATION #45: 4-wide)
-//     - Check for branch mispredictions (INNOVATION #48)
-//     - Update architectural state
+// An unconsumed result also counts: Issue clears resultValid, so
+// accepting a new op before COMPLETE collects the last one would
+// lose that load and leave its window entry waiting forever.
+func (lsu *LSU) IsBusy() bool {
+	return lsu.busy || lsu.resultValid
+}
+
+// GetResult returns completed operation result
 //
-//   STAGE 2: COMPLETE
-//     - Collect results from execution units
-//     - Forward results to waiting instructions (INNOVATION #55)
+// RETURNS:
 //
-//   STAGE 3: EXECUTE
-//     - Advance multi-cycle operations (divide, loads)
-//     - Divider ticks through Newton-Raphson (INNOVATION #13-16)
-//     - LSUs handle memory operations (INNOVATION #69-73)
+//	data: loaded value or SC result
+//	rd: destination register
+//	windowID: which instruction this is for
+//	valid: true if result available
+func (lsu *LSU) GetResult() (data uint32, rd uint8, windowID int, valid bool) {
+	if lsu.resultValid {
+		lsu.resultValid = false // Clear flag (consumed)
+		return lsu.resultData, lsu.resultRd, lsu.resultWinID, true
+	}
+	return 0, 0, 0, false
+}
+
+// WindowID returns the window entry the LSU is working for (-1 if idle)
+func (lsu *LSU) WindowID() int {
+	switch {
+	case lsu.busy:
+		return lsu.op.WindowID
+	case lsu.resultValid:
+		return lsu.resultWinID
+	}
+	return -1
+}
+
+// Cancel drops the operation in progress (its instruction was squashed)
 //
-//   STAGE 4: ISSUE
-//     - Send ready instructions to execution units
-//     - Select up to 6 instructions (INNOVATION #43)
-//     - ALU ops execute immediately (INNOVATION #56)
-//     - Start multi-cycle ops (INNOVATION #57-58)
+// A store that has not reached the cache yet never writes; an
+// outstanding miss still completes at the arbiter but is ignored
+func (lsu *LSU) Cancel() {
+	lsu.busy = false
+	lsu.resultValid = false
+	lsu.missing = false
+	lsu.fill = nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// OUT-OF-ORDER ENGINE (INNOVATIONS #34-58)
+// ═══════════════════════════════════════════════════════════════════════════════
 //
-//   STAGE 5: DISPATCH
-//     - Move decoded instructions to window
-//     - Allocate physical registers (INNOVATION #36-39)
-//     - Track dependencies (INNOVATION #52-53)
-//     - Up to 4 instructions (INNOVATION #44)
+// This is the HEART of the CPU! Enables executing instructions out of order.
 //
-//   STAGE 6: FETCH
-//     - Get instructions from L1I cache (INNOVATION #21-28)
-//     - Decode instructions (INNOVATION #5-6)
-//     - Predict branches (INNOVATION #29-33)
-//     - Fill fetch buffer
+// WHY OUT-OF-ORDER?
 //
-//   STAGE 7: PREFETCH
-//     - Handle background prefetch requests
-//     - L1I prefetch (INNOVATION #22, #27)
-//     - L1D prefetch (INNOVATION #59, #67)
+// Consider this code:
+//   r1 = load [slow_address]    // Takes 100 cycles! 😱
+//   r2 = r1 + 5                 // Must wait for r1
+//   r3 = 10 * 20                // Could run immediately! ✅
+//   r4 = r3 + 1                 // Could run after r3! ✅
 //
-// ALL STAGES HAPPEN SIMULTANEOUSLY EVERY CYCLE!
+// IN-ORDER EXECUTION:
+//   Cycle 1-100: Wait for r1 load
+//   Cycle 101: Compute r2 = r1 + 5
+//   Cycle 102: Compute r3 = 10 * 20
+//   Cycle 103: Compute r4 = r3 + 1
+//   Total: 103 cycles 😱
 //
-// MINECRAFT ANALOGY: Assembly line with 7 stations
-//   All stations work at the same time on different items
-//   Items move through stations in order
-//   But can overtake each other in middle stations (out-of-order!)
+// OUT-OF-ORDER EXECUTION:
+//   Cycle 1: Start r1 load, compute r3 = 10 * 20
+//   Cycle 2: Compute r4 = r3 + 1
+//   Cycle 100: Load completes
+//   Cycle 101: Compute r2 = r1 + 5
+//   Total: 101 cycles ✅ (saved 2 cycles!)
+//
+// With more independent work: Even bigger savings!
+//
+// THE ARCHITECTURE: Three key components
+//
+// 1. INSTRUCTION WINDOW (INNOVATION #35)
+//    - Holds instructions "in flight"
+//    - Acts as scheduler + ROB + instruction queue
+//    - Tracks dependencies between instructions
+//
+// 2. REGISTER RENAMING (INNOVATIONS #36-39)
+//    - Eliminates false dependencies
+//    - Maps architectural registers → physical registers
+//    - Allows multiple versions of same register simultaneously
+//
+// 3. WAKEUP/SELECT (INNOVATIONS #40-42)
+//    - Wakeup: Tell waiting instructions a value is ready
+//    - Select: Pick ready instructions to execute
+//    - Age-based priority: Older instructions go first
+//
+// MINECRAFT ANALOGY: A kitchen with multiple chefs
+//   Window = Recipe board (what's being cooked)
+//   Renaming = Multiple versions of same ingredient
+//   Wakeup = "Eggs are ready!" (notify recipes waiting for eggs)
+//   Select = "Which recipes can we cook now?"
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// REGISTER RENAMING (INNOVATIONS #36-39)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// INNOVATION #36: Register renaming with RAT
+//
+// THE PROBLEM: False dependencies (WAW, WAR hazards)
+//
+// EXAMPLE:
+//   r5 = r1 + r2    // Version 1 of r5
+//   r6 = r5 + r3    // Uses version 1
+//   r5 = r7 + r8    // Version 2 of r5 (overwrites!)
+//   r9 = r5 + r10   // Uses version 2
+//
+// These two r5 assignments don't actually conflict!
+// But in-order execution must wait (false dependency)
+//
+// INNOVATION #37: Bitmap-based RAT (not traditional)
+//
+// RAT: Array mapping arch_reg → phys_reg
+//   RAT[5] = 33 means "r5 is currently in physical register 33"
+//
+// TWO COPIES:
+//   Speculative RAT: Updated at dispatch (newest mapping, used for lookup)
+//   Committed RAT:   Updated at commit (architectural state)
+//   Recovery:        Speculative RAT = committed RAT (one copy, one cycle)
+//
+// ONE REGISTER FILE: Architectural state lives in the physical registers
+//   the committed RAT points at - no separate copy to write back
+//
+// INNOVATION #38: Free list for physical registers
+//
+// PROBLEM: When we rename, we need a free physical register
+//
+// SOLUTION: Bitmap of free registers
+//   Bit N = 1 means physical register N is free
+//   To allocate: Find first set bit, clear it
+//   To free: Set the bit
+//
+// WHEN TO FREE: At commit, free the PREVIOUS mapping of rd
+//   r5 = ... (p33)   // p33 becomes r5
+//   ...    = r5      // Younger readers may still be reading p33
+//   r5 = ... (p40)   // Commits: no older instruction can need p33 now
+//   So p33 (not p40) goes back on the free list ✅
+//
+// INNOVATION #39: 40 physical registers (one per window slot)
+//
+// WHY 40: One physical register per window entry
+//   Window has 40 entries max
+//   Each can produce one new value
+//   Need 40 physical registers to hold them all ✅
+//
+// ARCHITECTURAL DECISION: Simple and sufficient
+//   More registers = more renaming flexibility
+//   But 40 is enough for our window size
+//   (num_phys_regs raises it; GetDispatchStalls shows free-list stalls)
+//
+// MINECRAFT ANALOGY: Extra chests for multiple versions of items
+//   Architectural registers = labeled chests (r5 = "diamond chest")
+//   Physical registers = actual chests (chest #33, chest #34, etc.)
+//   RAT = Map showing which physical chest holds each item
+//   Free list = List of empty chests
+
+// WindowEntry holds state for one in-flight instruction (INNOVATION #35)
+type WindowEntry struct {
+	// Instruction information
+	PC      uint32 // Address of this instruction
+	Opcode  uint8  // What operation
+	Funct   uint16 // R-format function bits (FunctUnsigned)
+	Rd      uint8  // Architectural destination register
+	PhysRd  uint8  // Physical destination register
+	PrevRd  uint8  // Rd's previous mapping, freed at commit
+	Rs1     uint8  // Architectural source 1
+	Rs2     uint8  // Architectural source 2
+	PhysRs1 uint8  // Physical source 1
+	PhysRs2 uint8  // Physical source 2
+	Imm     int32  // Immediate value
+
+	// INNOVATION #53: Dependency tracking
+	Src1Ready bool // Is source 1 value available?
+	Src2Ready bool // Is source 2 value available?
+
+	// INNOVATION #54: State tracking
+	Valid      bool     // Is this entry in use?
+	Issued     bool     // Has this been sent to execute?
+	Executed   bool     // Has execution completed?
+	Eliminated ElimKind // Finished at rename (see MOVE ELIMINATION)
+
+	// INNOVATION #55: Result forwarding
+	Result      uint32 // Computed result
+	ResultValid bool   // Is result ready?
+
+	// Memory operation state
+	IsLoad       bool
+	IsStore      bool
+	MemAddr      uint32
+	MemAddrValid bool
+	StoreData    uint32
+
+	// Branch handling
+	IsBranch      bool
+	BranchTaken   bool
+	BranchTarget  uint32
+	Predicted     bool   // What did we predict?
+	PredictedAddr uint32 // Where did we predict?
+	ResolveCycle  uint64 // Cycle the outcome was computed (execute)
+	Recovered     bool   // Mispredict already repaired before commit
+
+	// FTQ frontend: RSB state after this instruction (redirect restores it)
+	RSB RSBCheckpoint
+
+	// Speculative RAT right after this branch renamed (recovery point)
+	Checkpoint    [NumArchRegs]uint8
+	HasCheckpoint bool
+
+	// Memory prediction (from L1D predictor)
+	PredictedMemAddr uint32
+	MemPredictor     PredictorID
+	HasMemPrediction bool
+
+	// Value prediction (from LoadValuePredictor, when enabled)
+	PredictedValue    uint32
+	ValueKind         ValuePredKind
+	ValuePredicted    bool
+	ValueMispredicted bool // Wrong AND already consumed: flush (or squash)
+}
+
+// RAT (Register Alias Table) implements INNOVATION #37
+//
+// Speculative and committed mappings from architectural to physical
+// registers. Both start as the identity (r0-r31 in p0-p31).
+type RAT struct {
+	speculative [NumArchRegs]uint8 // Newest mapping (dispatch)
+	committed   [NumArchRegs]uint8 // Architectural mapping (commit)
+	numPhys     uint8              // Physical registers
+}
+
+// NewRAT creates an initialized RAT
+func NewRAT() *RAT {
+	return NewRATWithConfig(DefaultCoreConfig())
+}
+
+// NewRATWithConfig creates a RAT over cfg.NumPhysRegs physical registers
+func NewRATWithConfig(cfg CoreConfig) *RAT {
+	rat := &RAT{numPhys: uint8(cfg.NumPhysRegs)}
+	for i := range rat.speculative {
+		rat.speculative[i] = uint8(i)
+		rat.committed[i] = uint8(i)
+	}
+	return rat
+}
+
+// Lookup returns the physical register holding an architectural register
+//
+// r0 is never renamed (always zero): returns InvalidTag
+func (rat *RAT) Lookup(archReg uint8) uint8 {
+	if archReg == 0 || archReg >= NumArchRegs {
+		return InvalidTag
+	}
+	return rat.speculative[archReg]
+}
+
+// Rename points archReg at a new physical register (INNOVATION #37)
+//
+// Returns the previous mapping, which the instruction frees when it
+// commits (InvalidTag if nothing was renamed)
+func (rat *RAT) Rename(archReg, physReg uint8) (prev uint8) {
+	if archReg == 0 || archReg >= NumArchRegs || physReg >= rat.numPhys {
+		return InvalidTag
+	}
+	prev = rat.speculative[archReg]
+	rat.speculative[archReg] = physReg
+	return prev
+}
+
+// Commit makes physReg the architectural home of archReg
+func (rat *RAT) Commit(archReg, physReg uint8) {
+	if archReg == 0 || archReg >= NumArchRegs || physReg >= rat.numPhys {
+		return
+	}
+	rat.committed[archReg] = physReg
+}
+
+// Committed returns the architectural mapping of archReg
+func (rat *RAT) Committed(archReg uint8) uint8 {
+	if archReg >= NumArchRegs {
+		return InvalidTag
+	}
+	return rat.committed[archReg]
+}
+
+// Restore discards speculative mappings (INNOVATION #48)
+func (rat *RAT) Restore() {
+	rat.speculative = rat.committed
+}
+
+// Checkpoint returns a copy of the speculative mappings
+func (rat *RAT) Checkpoint() [NumArchRegs]uint8 {
+	return rat.speculative
+}
+
+// RestoreCheckpoint discards mappings made after the checkpoint
+func (rat *RAT) RestoreCheckpoint(cp [NumArchRegs]uint8) {
+	rat.speculative = cp
+}
+
+// FreeList tracks available physical registers (INNOVATION #38)
+//
+// Eliminated moves (see MOVE ELIMINATION) map two architectural
+// registers to one physical register, so each allocated register has
+// a reference count: Share adds a holder, Free drops one, and the
+// register goes back on the bitmap when the last holder lets go
+type FreeList struct {
+	bitmap    []uint64 // Bit N = 1 means physical register N is free
+	refs      []uint16 // Holders of each allocated register
+	freeCount int      // Number of free registers
+	numPhys   int      // Physical registers
+}
+
+// NewFreeList creates an initialized free list
+//
+// ALGORITHM:
+//
+//	Physical registers 0-31: Initial architectural state
+//	Physical registers 32..NumPhysRegs-1: Available for renaming
+func NewFreeList() *FreeList {
+	return NewFreeListWithConfig(DefaultCoreConfig())
+}
+
+// NewFreeListWithConfig creates a free list over cfg.NumPhysRegs registers
+//
+// Registers 32 through NumPhysRegs-1 start free
+func NewFreeListWithConfig(cfg CoreConfig) *FreeList {
+	fl := &FreeList{
+		bitmap:  make([]uint64, (cfg.NumPhysRegs+63)/64),
+		refs:    make([]uint16, cfg.NumPhysRegs),
+		numPhys: cfg.NumPhysRegs,
+	}
+	for reg := 0; reg < NumArchRegs; reg++ {
+		fl.refs[reg] = 1 // Held by the committed RAT
+	}
+	for reg := NumArchRegs; reg < fl.numPhys; reg++ {
+		fl.bitmap[reg/64] |= 1 << (reg % 64)
+	}
+	fl.freeCount = fl.numPhys - NumArchRegs
+
+	return fl
+}
+
+// Allocate returns a free physical register (INNOVATION #38)
+//
+// ALGORITHM:
+//
+//	STEP 1: Find the first non-empty bitmap word
+//	STEP 2: Find first free register in it (trailing zeros)
+//	STEP 3: Mark as used (clear bit)
+//	STEP 4: Return register number
+//
+// HARDWARE NOTE: Finding trailing zeros is ONE gate delay
+//
+//	Priority encoder circuit (very fast!)
+func (fl *FreeList) Allocate() uint8 {
+	for word := range fl.bitmap {
+		if fl.bitmap[word] == 0 {
+			continue
+		}
+
+		bit := bits.TrailingZeros64(fl.bitmap[word])
+		fl.bitmap[word] &^= 1 << bit
+		fl.freeCount--
+		fl.refs[word*64+bit] = 1
+		return uint8(word*64 + bit)
+	}
+	return InvalidTag // None free!
+}
+
+// Share adds a holder to an allocated physical register
+//
+// USED BY: Move elimination - the move's destination maps to its
+//
+//	source's register instead of a new one
+func (fl *FreeList) Share(physReg uint8) {
+	if int(physReg) >= fl.numPhys || physReg == ZeroPhysReg {
+		return
+	}
+	fl.refs[physReg]++
+}
+
+// Free drops one holder of a physical register (INNOVATION #38)
+//
+// The register returns to the pool when no holder is left. The zero
+// register is never freed.
+func (fl *FreeList) Free(physReg uint8) {
+	if int(physReg) >= fl.numPhys || physReg == ZeroPhysReg {
+		return
+	}
+	mask := uint64(1) << (physReg % 64)
+	if fl.bitmap[physReg/64]&mask != 0 {
+		return // Already free
+	}
+	if fl.refs[physReg] > 1 {
+		fl.refs[physReg]--
+		return // Still shared
+	}
+
+	// Mark as free
+	fl.refs[physReg] = 0
+	fl.bitmap[physReg/64] |= mask
+	fl.freeCount++
+}
+
+// HasFree returns true if registers available
+func (fl *FreeList) HasFree() bool {
+	return fl.freeCount > 0
+}
+
+// GetFreeCount returns the number of free physical registers
+func (fl *FreeList) GetFreeCount() int {
+	return fl.freeCount
+}
+
+// GetRefCount returns how many holders a physical register has
+// (0 = free)
+func (fl *FreeList) GetRefCount(physReg uint8) int {
+	if int(physReg) >= fl.numPhys {
+		return 0
+	}
+	return int(fl.refs[physReg])
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// MOVE ELIMINATION (INNOVATION #37)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: A register copy or a zeroing instruction computes nothing
+//   ADDI r5, r3, 0 still waits for r3, takes an ALU, and takes a
+//   physical register to hold a value another register already holds
+//   XOR r5, r5, r5 is zero whatever r5 was, yet waits for r5 anyway
+//
+// THE SOLUTION: Finish them in the RAT, at rename
+//   Move:       RAT[rd] = RAT[rs] - rd shares rs's physical register
+//               (FreeList reference count goes up by one)
+//   Zero idiom: RAT[rd] = ZeroPhysReg - r0's register, always zero
+//   The entry is born executed: it keeps its window slot (commit is
+//   still in program order) but never issues or uses an ALU, and
+//   readers of rd wait on whatever rs was waiting on - nothing more
+//
+// RECOGNIZED:
+//
+//	Moves:       ADDI/ORI/XORI rd, rs, 0
+//	             ADD/OR/XOR rd, rs, r0 (either operand order)
+//	             SUB rd, rs, r0
+//	Zero idioms: XOR/SUB rd, rs, rs
+//	             Any move whose source is r0
+//
+// FREEING SHARED REGISTERS: The same rule as INNOVATION #38 - a holder
+//   lets go when the instruction overwriting its mapping commits, or
+//   when its own instruction is squashed; the register is free only
+//   when the last holder lets go
+//
+// OPT-IN: move_elimination = 1; off, moves and zero idioms rename and
+//   execute like any other instruction (the shipped timing)
+//
+// MINECRAFT ANALOGY: Relabeling a chest instead of moving its contents
+//   into a new one - and "empty chest" is the one that is always empty
+
+// ElimKind is how rename handled an instruction's destination
+type ElimKind uint8
+
+const (
+	ElimNone ElimKind = iota // Executes normally
+	ElimMove                 // rd shares the source's physical register
+	ElimZero                 // rd maps to ZeroPhysReg
+	NumElimKinds
+)
+
+func (k ElimKind) String() string {
+	switch k {
+	case ElimNone:
+		return "none"
+	case ElimMove:
+		return "move"
+	case ElimZero:
+		return "zero idiom"
+	}
+	return "unknown"
+}
+
+// ClassifyElimination reports whether rename can eliminate inst
+//
+// For ElimMove, src is the architectural register being copied
+func ClassifyElimination(inst Instruction) (kind ElimKind, src uint8) {
+	// Nothing to rename: writes to r0 are discarded anyway
+	if inst.Rd == 0 {
+		return ElimNone, 0
+	}
+
+	switch inst.Opcode {
+	case OpADDI, OpORI, OpXORI:
+		if inst.Imm != 0 {
+			return ElimNone, 0
+		}
+		src = inst.Rs1
+
+	case OpADD, OpOR, OpXOR, OpSUB:
+		switch {
+		case inst.Rs2 == 0:
+			src = inst.Rs1
+		case inst.Rs1 == 0 && inst.Opcode != OpSUB:
+			src = inst.Rs2 // 0 - rs is not a move
+		case inst.Rs1 == inst.Rs2 && (inst.Opcode == OpXOR || inst.Opcode == OpSUB):
+			return ElimZero, 0
+		default:
+			return ElimNone, 0
+		}
+
+	default:
+		return ElimNone, 0
+	}
+
+	if src == 0 {
+		return ElimZero, 0
+	}
+	return ElimMove, src
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// INSTRUCTION WINDOW (INNOVATION #35: Unified scheduler + ROB + IQ)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// INNOVATION #35: Unified window = scheduler + ROB + IQ
+//
+// TRADITIONAL APPROACH:
+//   - Instruction Queue (IQ): Holds decoded instructions
+//   - Reservation Stations (RS): Wait for operands
+//   - Reorder Buffer (ROB): Tracks program order for commit
+//   Total: 3 separate structures, complex coordination
+//
+// OUR APPROACH: One unified window!
+//   - Each entry holds: instruction, dependencies, result, state
+//   - Acts as IQ (decoded instructions)
+//   - Acts as RS (wait for operands)
+//   - Acts as ROB (program order maintained by circular buffer)
+//
+// THE BENEFIT:
+//   Simpler hardware (one structure, not three)
+//   Less data movement (everything in one place)
+//   Easier dependency tracking (local to entry)
+//
+// INNOVATION #40: Bitmap wakeup (not CAM)
+//
+// TRADITIONAL: Content Addressable Memory (CAM)
+//   When value ready: Broadcast physical register number
+//   Every entry compares: "Is this my source?"
+//   CAM = expensive! (44× cost of regular memory)
+//
+// OUR APPROACH: Bitmap wakeup
+//   When value ready: Set bit in physical register file
+//   Each entry checks: "Are my source bits set?"
+//   Regular logic, not CAM (44× cheaper!) ✅
+//
+// INNOVATION #41: Single-cycle wakeup
+//
+// ALGORITHM:
+//   Instruction completes → Set ready bit → Wakeup happens
+//   All happens in ONE cycle (combinational logic)
+//
+// INNOVATION #42: Age-based selection priority
+//
+// PROBLEM: Multiple instructions ready, which to execute first?
+//
+// SOLUTION: Prioritize by age (program order)
+//   Scan window from head to tail (oldest to youngest)
+//   Execute oldest ready instructions first
+//
+// WHY: Guarantees forward progress
+//      Prevents starvation
+//      Helps branch misprediction recovery
+//
+// MINECRAFT ANALOGY: One big crafting board with all recipes
+//   Each recipe card shows: ingredients needed, status, result
+//   When ingredient arrives: Update all cards instantly (wakeup)
+//   When choosing recipes: Pick oldest ready ones first
+
+// Window is the instruction window (INNOVATION #35)
+type Window struct {
+	entries []WindowEntry // The 40 instruction slots (cfg.WindowSize)
+	cfg     CoreConfig    // Window size, issue width and unit counts
+
+	head  int // Oldest instruction (for commit)
+	tail  int // Next free slot (for dispatch)
+	count int // Number of valid entries
+
+	// INNOVATIONS #36-39: Register renaming
+	rat      *RAT
+	freeList *FreeList
+
+	// INNOVATION #51: Physical register file (holds architectural state too)
+	physRegFile  []uint32 // Physical register values
+	physRegReady []bool   // Which registers have valid data
+
+	// INNOVATION #42: Issue order (see ISSUE SCHEDULING POLICY)
+	sched Scheduler
+
+	// In-flight entry writing each physical register (-1 = none)
+	// Hardware: the rename stage's producer tag, read by the scheduler
+	producer []int
+
+	// Statistics
+	dispatched     uint64
+	issued         uint64
+	committed      uint64
+	windowStalls   uint64 // Dispatch blocked: every entry in use
+	freeListStalls uint64 // Dispatch blocked: no physical register for rd
+	squashed       uint64 // Entries discarded by Flush or SquashAfter
+	eliminated     [NumElimKinds]uint64
+}
+
+// NewWindow creates an initialized instruction window
+func NewWindow() *Window {
+	return NewWindowWithConfig(DefaultCoreConfig())
+}
+
+// NewWindowWithConfig sizes the window and register files from cfg
+func NewWindowWithConfig(cfg CoreConfig) *Window {
+	w := &Window{
+		entries:      make([]WindowEntry, cfg.WindowSize),
+		cfg:          cfg,
+		rat:          NewRATWithConfig(cfg),
+		freeList:     NewFreeListWithConfig(cfg),
+		physRegFile:  make([]uint32, cfg.NumPhysRegs),
+		physRegReady: make([]bool, cfg.NumPhysRegs),
+		sched:        NewOldestFirstScheduler(),
+		producer:     make([]int, cfg.NumPhysRegs),
+	}
+	for i := range w.producer {
+		w.producer[i] = -1
+	}
+
+	// Architectural registers start ready (initialized to zero)
+	for i := 0; i < NumArchRegs; i++ {
+		w.physRegReady[i] = true
+	}
+
+	return w
+}
+
+// SetScheduler replaces the issue policy
+//
+// The window must be empty: the new policy never saw the entries in
+// flight, so it could not order or track them
+func (w *Window) SetScheduler(s Scheduler) error {
+	if w.count != 0 {
+		return fmt.Errorf("window: cannot change scheduler with %d instructions in flight", w.count)
+	}
+	w.sched = s
+	return nil
+}
+
+// GetScheduler returns the issue policy
+func (w *Window) GetScheduler() Scheduler {
+	return w.sched
+}
+
+// CanDispatch returns true if window has space (INNOVATION #44)
+func (w *Window) CanDispatch() bool {
+	return w.count < len(w.entries)
+}
+
+// Dispatch adds a new instruction to the window (INNOVATION #44)
+//
+// ALGORITHM:
+//
+//	STEP 1: Check if window has space
+//	STEP 2: Allocate physical register for destination (INNOVATION #36)
+//	        Eliminated moves share the source's register instead, zero
+//	        idioms use ZeroPhysReg (see MOVE ELIMINATION)
+//	STEP 3: Look up physical registers for sources (INNOVATION #37)
+//	STEP 4: Determine if sources are ready (INNOVATION #53)
+//	STEP 5: Create window entry with all info (INNOVATION #54)
+//	STEP 6: Update RAT with new mapping (INNOVATION #37)
+//
+// INNOVATION #52: Dependency tracking per entry
+//
+//	Each entry knows exactly what it needs
+//	No separate dependency matrix (CriticalPathScheduler keeps one,
+//	for issue priority only)
+//
+// MINECRAFT ANALOGY: Add new recipe card to the board
+//
+//	Note which ingredients are available
+func (w *Window) Dispatch(inst Instruction) (windowID int, ok bool) {
+	// STEP 1: Check capacity
+	if !w.CanDispatch() {
+		w.windowStalls++
+		return -1, false
+	}
+
+	// STEP 2: Allocate physical register for destination
+	elim, elimSrc := ElimNone, uint8(0)
+	if w.cfg.MoveElimination != 0 {
+		elim, elimSrc = ClassifyElimination(inst)
+	}
+
+	var physRd uint8 = InvalidTag
+	switch {
+	case elim == ElimMove:
+		physRd = w.rat.Lookup(elimSrc) // Same register, one more holder
+		w.freeList.Share(physRd)
+	case elim == ElimZero:
+		physRd = ZeroPhysReg
+	case inst.Rd != 0:
+		physRd = w.freeList.Allocate()
+		if physRd == InvalidTag {
+			w.freeListStalls++
+			return -1, false // No free registers!
+		}
+	}
+
+	// STEP 3: Look up physical registers for sources
+	physRs1 := w.rat.Lookup(inst.Rs1)
+	physRs2 := w.rat.Lookup(inst.Rs2)
+
+	// STEP 4: Determine if sources are ready (INNOVATION #53)
+	src1Ready := inst.Rs1 == 0 || physRs1 == InvalidTag || w.physRegReady[physRs1]
+	src2Ready := inst.Rs2 == 0 || physRs2 == InvalidTag || w.physRegReady[physRs2]
+
+	// Special case: I-format instructions use immediate for rs2
+	if inst.UsesImm && !inst.IsBranch && inst.Opcode != OpSW && inst.Opcode != OpSC {
+		src2Ready = true
+		physRs2 = InvalidTag
+	}
+
+	// Eliminated: reads nothing (the RAT did the work)
+	if elim != ElimNone {
+		physRs1, physRs2 = InvalidTag, InvalidTag
+		src1Ready, src2Ready = true, true
+	}
+
+	// STEP 5: Create window entry (INNOVATION #35, #52, #53, #54)
+	entry := &w.entries[w.tail]
+	*entry = WindowEntry{
+		PC:        inst.PC,
+		Opcode:    inst.Opcode,
+		Funct:     inst.Funct,
+		Rd:        inst.Rd,
+		PhysRd:    physRd,
+		PrevRd:    InvalidTag,
+		Rs1:       inst.Rs1,
+		Rs2:       inst.Rs2,
+		PhysRs1:   physRs1,
+		PhysRs2:   physRs2,
+		Imm:       inst.Imm,
+		Src1Ready: src1Ready,
+		Src2Ready: src2Ready,
+		Valid:     true,
+		IsLoad:    inst.IsLoad,
+		IsStore:   inst.IsStore,
+		IsBranch:  inst.IsBranch,
+	}
+
+	// STEP 6: Update RAT with new mapping
+	if physRd != InvalidTag {
+		entry.PrevRd = w.rat.Rename(inst.Rd, physRd)
+		if elim == ElimNone {
+			w.physRegReady[physRd] = false // Result not ready yet
+		}
+	}
+
+	// Eliminated: done at rename, waits only to commit
+	if elim != ElimNone {
+		entry.Eliminated = elim
+		entry.Issued = true
+		entry.Executed = true
+		w.eliminated[elim]++
+	}
+
+	// STEP 7: Branches remember the RAT for selective recovery
+	if inst.IsBranch || inst.IsJump {
+		entry.Checkpoint = w.rat.Checkpoint()
+		entry.HasCheckpoint = true
+	}
+
+	windowID = w.tail
+	if elim == ElimNone && physRd != InvalidTag {
+		w.producer[physRd] = windowID
+	}
+	w.tail = (w.tail + 1) % len(w.entries)
+	w.count++
+	w.dispatched++
+
+	w.sched.Insert(w, windowID)
+
+	return windowID, true
+}
+
+// producerOf returns the in-flight entry (other than consumer) that
+// writes physReg, or -1 if its value comes from a retired instruction
+//
+// One lookup in the producer index. Eliminated moves share their
+// source's register but write nothing, so they are never recorded
+func (w *Window) producerOf(physReg uint8, consumer int) int {
+	if physReg == InvalidTag || physReg == ZeroPhysReg {
+		return -1
+	}
+	if p := w.producer[physReg]; p != consumer {
+		return p
+	}
+	return -1
+}
+
+// clearProducer drops windowID from the producer index as it leaves
+func (w *Window) clearProducer(windowID int) {
+	if physRd := w.entries[windowID].PhysRd; physRd != InvalidTag && w.producer[physRd] == windowID {
+		w.producer[physRd] = -1
+	}
+}
+
+// Wakeup tells waiting instructions a value is ready (INNOVATION #40-41)
+//
+// ALGORITHM:
+//
+//	STEP 1: Store value in physical register file
+//	STEP 2: Mark physical register as ready
+//	STEP 3: Scan ALL window entries
+//	STEP 4: For each entry: If waiting for this register, mark ready
+//
+// INNOVATION #40: Bitmap wakeup (not CAM)
+//
+//	We don't use expensive CAM
+//	Just check if physReg matches (simple comparison)
+//
+// INNOVATION #41: Single-cycle wakeup
+//
+//	All comparisons happen in parallel (combinational)
+//	Result available same cycle
+//
+// HARDWARE NOTE: This is ONE gate delay in real hardware
+//
+//	All entries check simultaneously
+//
+// MINECRAFT ANALOGY: Announce "eggs are ready!"
+//
+//	All chefs check their recipes simultaneously
+func (w *Window) Wakeup(physReg uint8, value uint32) {
+	if int(physReg) >= len(w.physRegFile) || physReg == InvalidTag {
+		return
+	}
+
+	// STEP 1-2: Store value and mark ready
+	w.physRegFile[physReg] = value
+	w.physRegReady[physReg] = true
+
+	// STEP 3-4: Wake up waiting instructions (INNOVATION #40)
+	for i := 0; i < len(w.entries); i++ {
+		entry := &w.entries[i]
+
+		if !entry.Valid || entry.Issued {
+			continue
+		}
+
+		// Check if this entry was waiting for this register
+		if entry.PhysRs1 == physReg {
+			entry.Src1Ready = true
+		}
+		if entry.PhysRs2 == physReg {
+			entry.Src2Ready = true
+		}
+	}
+}
+
+// SelectReady finds ready instructions (INNOVATION #42-43)
+//
+// ALGORITHM:
+//
+//	STEP 1: Scan window from head to tail (oldest first)
+//	        Collect every ready instruction (sources available)
+//	STEP 2: Scheduler puts them in issue order
+//	STEP 3: For each instruction in that order:
+//	          Check if appropriate execution unit available
+//	          If yes: Add to ready list
+//	STEP 4: Return up to IssueWidth instructions
+//
+// INNOVATION #42: Age-based selection
+//
+//	Scan from head (oldest) to tail (youngest)
+//	The default scheduler keeps that order: older instructions first ✅
+//
+// INNOVATION #43: 6-wide issue
+//
+//	Return up to 6 instructions per cycle
+//	Balanced for our execution unit configuration
+//
+// MINECRAFT ANALOGY: Pick oldest recipes that have all ingredients ready
+func (w *Window) SelectReady() []int {
+	// STEP 1: INNOVATION #42: Scan in age order (head to tail)
+	candidates := make([]int, 0, w.count)
+	for i := 0; i < w.count; i++ {
+		idx := (w.head + i) % len(w.entries)
+		entry := &w.entries[idx]
+		if entry.Valid && !entry.Issued && entry.Src1Ready && entry.Src2Ready {
+			candidates = append(candidates, idx)
+		}
+	}
+
+	// STEP 2: Issue policy
+	candidates = w.sched.Order(w, candidates)
+
+	ready := make([]int, 0, w.cfg.IssueWidth)
+
+	// Count execution units used (ensure we don't over-issue)
+	aluCount := 0
+	mulCount := 0
+	divCount := 0
+	lsuCount := 0
+
+	// STEP 3: Unit limits, in the scheduler's order
+	for _, idx := range candidates {
+		if len(ready) == w.cfg.IssueWidth {
+			break
+		}
+		entry := &w.entries[idx]
+
+		// Check if appropriate execution unit available
+		canIssue := false
+		switch entry.Opcode {
+		case OpMUL, OpMULH, OpMULHU, OpMULHSU:
+			if mulCount < w.cfg.NumMULs {
+				mulCount++
+				canIssue = true
+			}
+		case OpDIV, OpREM:
+			if divCount < w.cfg.NumDIVs {
+				divCount++
+				canIssue = true
+			}
+		case OpLW, OpSW, OpLR, OpSC:
+			if lsuCount < w.cfg.NumLSUs {
+				lsuCount++
+				canIssue = true
+			}
+		default:
+			if aluCount < w.cfg.NumALUs {
+				aluCount++
+				canIssue = true
+			}
+		}
+
+		if canIssue {
+			ready = append(ready, idx)
+		}
+	}
+
+	return ready
+}
+
+// MarkIssued marks instruction as sent to execution
+func (w *Window) MarkIssued(windowID int) {
+	if windowID >= 0 && windowID < len(w.entries) {
+		w.entries[windowID].Issued = true
+		w.issued++
+	}
+}
+
+// Complete marks instruction as finished (INNOVATION #55)
+//
+// ALGORITHM:
+//
+//	STEP 1: Store result in window entry
+//	STEP 2: Mark as executed
+//	STEP 3: Wakeup dependent instructions (INNOVATION #41)
+//
+// INNOVATION #55: Result forwarding
+//
+//	Result immediately available to dependent instructions
+//	Don't wait for commit to forward result
+func (w *Window) Complete(windowID int, result uint32) {
+	if windowID < 0 || windowID >= len(w.entries) {
+		return
+	}
+
+	entry := &w.entries[windowID]
+	entry.Result = result
+	entry.ResultValid = true
+	entry.Executed = true
+
+	// INNOVATION #55: Wakeup dependent instructions
+	if entry.PhysRd != InvalidTag {
+		w.Wakeup(entry.PhysRd, result)
+	}
+}
+
+// ConsumedEarly reports whether any younger instruction has already
+// issued with windowID's destination register as a source
+//
+// USED BY: Value prediction verify - a wrong predicted value only needs
+//
+//	recovery if something actually read it; later readers will pick
+//	up the real value from Complete's wakeup
+func (w *Window) ConsumedEarly(windowID int) bool {
+	if windowID < 0 || windowID >= len(w.entries) {
+		return false
+	}
+	physRd := w.entries[windowID].PhysRd
+	if physRd == InvalidTag {
+		return false
+	}
+
+	for i := (windowID + 1) % len(w.entries); i != w.tail; i = (i + 1) % len(w.entries) {
+		entry := &w.entries[i]
+		if entry.Valid && entry.Issued && (entry.PhysRs1 == physRd || entry.PhysRs2 == physRd) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEntry returns a window entry (for reading state)
+func (w *Window) GetEntry(windowID int) *WindowEntry {
+	if windowID >= 0 && windowID < len(w.entries) {
+		return &w.entries[windowID]
+	}
+	return nil
+}
+
+// ReadReg reads a source operand through its renamed physical register
+func (w *Window) ReadReg(archReg, physReg uint8) uint32 {
+	// r0 is always zero
+	if archReg == 0 {
+		return 0
+	}
+
+	if physReg != InvalidTag && int(physReg) < len(w.physRegFile) {
+		return w.physRegFile[physReg]
+	}
+	return 0
+}
+
+// ReadArchReg returns the committed value of an architectural register
+func (w *Window) ReadArchReg(archReg uint8) uint32 {
+	if archReg == 0 || archReg >= NumArchRegs {
+		return 0
+	}
+	return w.physRegFile[w.rat.Committed(archReg)]
+}
+
+// Commit retires the oldest instruction (INNOVATION #45, #47)
+//
+// ALGORITHM:
+//
+//	STEP 1: Check if oldest instruction is ready to commit
+//	STEP 2: If yes: Its physical register becomes architectural state
+//	STEP 3: Free rd's previous physical register (INNOVATION #38)
+//	STEP 4: Advance head pointer
+//
+// INNOVATION #45: 4-wide commit
+//
+//	Can commit up to 4 instructions per cycle
+//
+// INNOVATION #47: Program-order commit
+//
+//	Always commit from head (oldest first)
+//	Guarantees precise exceptions
+//	Makes speculation recovery simple
+//
+// MINECRAFT ANALOGY: Serve completed dishes in order they were ordered
+func (w *Window) Commit() *WindowEntry {
+	if w.count == 0 {
+		return nil
+	}
+
+	entry := &w.entries[w.head]
+
+	// Can only commit if executed
+	if !entry.Valid || !entry.Executed {
+		return nil
+	}
+
+	// STEP 2-3: Commit the mapping, free the one it replaces (INNOVATION #38)
+	if entry.PhysRd != InvalidTag {
+		w.rat.Commit(entry.Rd, entry.PhysRd)
+		if entry.PrevRd != InvalidTag {
+			w.freeList.Free(entry.PrevRd)
+		}
+	}
+
+	// Save entry info before clearing
+	committed := *entry
+
+	// Clear entry
+	w.clearProducer(w.head)
+	entry.Valid = false
+	w.sched.Remove(w.head)
+
+	// STEP 4: Advance head
+	w.head = (w.head + 1) % len(w.entries)
+	w.count--
+	w.committed++
+
+	return &committed
+}
+
+// Flush clears all entries (INNOVATION #48: mispredict recovery)
+//
+// ALGORITHM:
+//
+//	STEP 1: For each entry: Free its (never committed) physical register
+//	STEP 2: Clear all entries
+//	STEP 3: Reset pointers
+//	STEP 4: Restore speculative RAT from committed RAT
+//
+// INNOVATION #48: Branch mispredict recovery
+//
+//	On mispredict: Throw away ALL speculative work
+//	Restart from correct path
+//
+// USED BY: Commit-time recovery and value mispredicts (the faulting
+//
+//	instruction has retired, so nothing left is on the right path);
+//	execute-time recovery uses SquashAfter instead
+func (w *Window) Flush() {
+	// STEP 1: Free all allocated physical registers
+	w.squashed += uint64(w.count)
+	for i := 0; i < len(w.entries); i++ {
+		entry := &w.entries[i]
+		if entry.Valid && entry.PhysRd != InvalidTag {
+			w.freeList.Free(entry.PhysRd)
+		}
+		if entry.Valid {
+			w.clearProducer(i)
+			w.sched.Remove(i)
+		}
+		entry.Valid = false
+	}
+
+	// STEP 2-3: Reset state
+	w.head = 0
+	w.tail = 0
+	w.count = 0
+
+	// STEP 4: Back to the architectural mappings (INNOVATION #48)
+	w.rat.Restore()
+}
+
+// SquashAfter discards every entry younger than windowID (INNOVATION #48)
+//
+// ALGORITHM:
+//
+//	STEP 1: Walk from windowID+1 to tail (program order = age order)
+//	STEP 2: Free each squashed entry's physical register
+//	STEP 3: Pull tail back to just after windowID
+//	STEP 4: Restore the speculative RAT from windowID's checkpoint
+//
+// WHY SELECTIVE: Older work (and windowID itself) is on the right
+//
+//	path - Flush would throw it away and refetch it for nothing
+//
+// Returns the number of entries squashed
+func (w *Window) SquashAfter(windowID int) int {
+	if windowID < 0 || windowID >= len(w.entries) || !w.entries[windowID].Valid {
+		return 0
+	}
+
+	// STEP 1-2: Squash younger entries
+	n := 0
+	for i := (windowID + 1) % len(w.entries); i != w.tail; i = (i + 1) % len(w.entries) {
+		entry := &w.entries[i]
+		if entry.Valid && entry.PhysRd != InvalidTag {
+			w.freeList.Free(entry.PhysRd)
+		}
+		w.clearProducer(i)
+		entry.Valid = false
+		w.sched.Remove(i)
+		n++
+	}
+
+	// STEP 3: Younger slots are free again
+	w.tail = (windowID + 1) % len(w.entries)
+	w.count -= n
+	w.squashed += uint64(n)
+
+	// STEP 4: Mappings as of the branch (INNOVATION #37)
+	if entry := &w.entries[windowID]; entry.HasCheckpoint {
+		w.rat.RestoreCheckpoint(entry.Checkpoint)
+	}
+	return n
+}
+
+// Checkpoint saves the current RAT as windowID's recovery point
+//
+// Call right after Dispatch: value-predicted loads need one so
+// SquashAfter can undo younger renames (branches take theirs at dispatch)
+func (w *Window) Checkpoint(windowID int) {
+	entry := &w.entries[windowID]
+	entry.Checkpoint = w.rat.Checkpoint()
+	entry.HasCheckpoint = true
+}
+
+// IsValid reports whether windowID holds an in-flight instruction
+func (w *Window) IsValid(windowID int) bool {
+	return windowID >= 0 && windowID < len(w.entries) && w.entries[windowID].Valid
+}
+
+// GetCount returns number of in-flight instructions
+func (w *Window) GetCount() int {
+	return w.count
+}
+
+// GetSquashed returns how many entries recovery has discarded
+func (w *Window) GetSquashed() uint64 {
+	return w.squashed
+}
+
+// GetEliminated returns how many instructions rename eliminated as
+// moves and as zero idioms (squashed ones included)
+func (w *Window) GetEliminated() (moves, zeros uint64) {
+	return w.eliminated[ElimMove], w.eliminated[ElimZero]
+}
+
+// GetDispatchStalls returns how many dispatch attempts stopped because
+// the window was full and because no physical register was free
+func (w *Window) GetDispatchStalls() (windowFull, freeList uint64) {
+	return w.windowStalls, w.freeListStalls
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// ISSUE SCHEDULING POLICY (INNOVATION #42)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: Oldest-first (INNOVATION #42) is one choice among many
+//   proto/ooo argues for critical-path-first: issue what other work
+//   waits on before leaves nothing waits on
+//   Both can't be compared while select is hard-wired into the window
+//
+// THE SOLUTION: SelectReady asks a Scheduler for the issue order
+//   SelectReady: Finds ready entries (oldest first) and applies issue
+//                width and execution unit limits
+//   Scheduler:   Reorders the ready entries; sees every entry enter
+//                and leave the window so it can keep its own state
+//
+// POLICIES:
+//   OldestFirstScheduler:  Age order (the shipped design, default)
+//   CriticalPathScheduler: proto/ooo's dependency matrix - ready ops
+//                          with dependents first, then leaves
+//   RandomScheduler:       Shuffled (baseline: how much does order matter?)
+//
+// DIFFERENCES FROM proto/ooo: Physical registers are renamed here, so
+//   two ops in one bundle never share a destination and the bundle
+//   conflict check has nothing to do. Window slots are not a fixed 32,
+//   so matrix rows are bitsets of cfg.WindowSize bits.
+//
+// MINECRAFT ANALOGY: Which recipe to cook first - the oldest order,
+//   the one other recipes need as an ingredient, or whatever comes up
+
+// Scheduler orders ready instructions for issue
+type Scheduler interface {
+	// Name identifies the policy in reports
+	Name() string
+
+	// Insert is called after windowID enters the window
+	Insert(w *Window, windowID int)
+
+	// Remove is called when windowID leaves (commit or squash)
+	Remove(windowID int)
+
+	// Order returns ready (oldest first on entry) in issue priority;
+	// it may reorder ready in place. Entries left out wait a cycle.
+	Order(w *Window, ready []int) []int
+}
+
+// OldestFirstScheduler issues in age order (INNOVATION #42)
+type OldestFirstScheduler struct{}
+
+// NewOldestFirstScheduler creates the default age-ordered policy
+func NewOldestFirstScheduler() *OldestFirstScheduler {
+	return &OldestFirstScheduler{}
+}
+
+func (s *OldestFirstScheduler) Name() string                       { return "oldest-first" }
+func (s *OldestFirstScheduler) Insert(w *Window, id int)           {}
+func (s *OldestFirstScheduler) Remove(windowID int)                {}
+func (s *OldestFirstScheduler) Order(w *Window, ready []int) []int { return ready }
+
+// DependencyMatrix records which window slots read which slots' results
+//
+// Row i, bit j set: slot j waits on slot i's destination register.
+// Filled at dispatch and cleared at commit or squash, so it is state
+// the scheduler reads, not logic it evaluates (proto/ooo DependencyMatrix).
+type DependencyMatrix struct {
+	rows  [][]uint64 // One bitset per slot
+	words int        // uint64 words per row
+}
+
+// NewDependencyMatrix creates an empty matrix for n window slots
+func NewDependencyMatrix(n int) *DependencyMatrix {
+	m := &DependencyMatrix{
+		rows:  make([][]uint64, n),
+		words: (n + 63) / 64,
+	}
+	for i := range m.rows {
+		m.rows[i] = make([]uint64, m.words)
+	}
+	return m
+}
+
+// Set records that consumer reads producer's result
+func (m *DependencyMatrix) Set(producer, consumer int) {
+	m.rows[producer][consumer/64] |= 1 << (consumer % 64)
+}
+
+// Clear removes slot's row and column (the slot left the window)
+func (m *DependencyMatrix) Clear(slot int) {
+	clear(m.rows[slot])
+	for i := range m.rows {
+		m.rows[i][slot/64] &^= 1 << (slot % 64)
+	}
+}
+
+// HasDependents reports whether any slot waits on slot
+// (proto/ooo ComputeHasDependents: OR-reduce the row)
+func (m *DependencyMatrix) HasDependents(slot int) bool {
+	for _, word := range m.rows[slot] {
+		if word != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CriticalPathScheduler issues ops with dependents before leaves
+//
+// ALGORITHM (proto/ooo ClassifyPriority + SelectIssueBundle):
+//
+//	STEP 1: HasDependents for each ready op (row of the matrix non-zero)
+//	STEP 2: High priority = ready AND has dependents
+//	        Low priority  = ready AND NOT has dependents
+//	STEP 3: Oldest first within each tier
+//	STEP 4: TierMux: high tier only, if it has anything (the prototype's
+//	        MUX); otherwise high tier then low tier
+//
+// WHY: An op with dependents is probably on the critical path - issuing
+//
+//	it first wakes its consumers a cycle sooner. proto/ooo claims ~90%
+//	correlation with true critical-path depth.
+type CriticalPathScheduler struct {
+	deps    *DependencyMatrix
+	TierMux bool // Leaves wait while any op with dependents is ready
+
+	high, low []int // Reused tier buffers
+}
+
+// NewCriticalPathScheduler creates the proto/ooo policy for a window of
+// windowSize slots; tierMux selects the prototype's strict tier MUX
+func NewCriticalPathScheduler(windowSize int, tierMux bool) *CriticalPathScheduler {
+	return &CriticalPathScheduler{
+		deps:    NewDependencyMatrix(windowSize),
+		TierMux: tierMux,
+	}
+}
+
+func (s *CriticalPathScheduler) Name() string {
+	if s.TierMux {
+		return "critical-path (tier mux)"
+	}
+	return "critical-path"
+}
+
+// Insert records windowID as a dependent of the in-flight producers of
+// the sources it is still waiting for
+func (s *CriticalPathScheduler) Insert(w *Window, windowID int) {
+	entry := &w.entries[windowID]
+	if !entry.Src1Ready {
+		if p := w.producerOf(entry.PhysRs1, windowID); p >= 0 {
+			s.deps.Set(p, windowID)
+		}
+	}
+	if !entry.Src2Ready {
+		if p := w.producerOf(entry.PhysRs2, windowID); p >= 0 {
+			s.deps.Set(p, windowID)
+		}
+	}
+}
+
+// Remove clears windowID from the matrix
+func (s *CriticalPathScheduler) Remove(windowID int) {
+	s.deps.Clear(windowID)
+}
+
+// Order puts ready ops with dependents ahead of leaves (see ALGORITHM)
+func (s *CriticalPathScheduler) Order(w *Window, ready []int) []int {
+	// STEP 1-3: Split into tiers, keeping age order
+	s.high, s.low = s.high[:0], s.low[:0]
+	for _, id := range ready {
+		if s.deps.HasDependents(id) {
+			s.high = append(s.high, id)
+		} else {
+			s.low = append(s.low, id)
+		}
+	}
+
+	// STEP 4: Tier MUX
+	if s.TierMux && len(s.high) > 0 {
+		return s.high
+	}
+	return append(append(ready[:0], s.high...), s.low...)
+}
+
+// RandomScheduler issues ready ops in a random order
+//
+// A floor for the other policies: whatever they gain over random is
+// what their ordering is worth
+type RandomScheduler struct {
+	rng *rand.Rand
+}
+
+// NewRandomScheduler creates a shuffling policy (same seed, same run)
+func NewRandomScheduler(seed int64) *RandomScheduler {
+	return &RandomScheduler{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (s *RandomScheduler) Name() string             { return "random" }
+func (s *RandomScheduler) Insert(w *Window, id int) {}
+func (s *RandomScheduler) Remove(windowID int)      {}
+
+// Order shuffles ready in place
+func (s *RandomScheduler) Order(w *Window, ready []int) []int {
+	s.rng.Shuffle(len(ready), func(i, j int) { ready[i], ready[j] = ready[j], ready[i] })
+	return ready
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// EXECUTION UNITS (INNOVATIONS #56-58)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// INNOVATION #56: 2 ALUs
+// INNOVATION #57: 1 Multiplier
+// INNOVATION #58: 1 Divider
+//
+// THE DECISION: Match unit count to workload
+//
+// Workload analysis shows:
+//   ALU operations: 60% (add, sub, shift, compare, logic)
+//   Multiply: 8%
+//   Divide: 2%
+//   Load/Store: 30%
+//
+// Our configuration:
+//   2 ALUs: Handle 60% workload with margin
+//   1 MUL: Handle 8% workload (1-cycle, so sufficient)
+//   1 DIV: Handle 2% workload (4-cycle, so sufficient)
+//   2 LSUs: Handle 30% workload with margin
+//
+// Total: 6 execution units (matches IssueWidth of 6)
+//
+// MINECRAFT ANALOGY: Different workstations for different tasks
+//   2 crafting tables (general purpose)
+//   1 furnace (slow but specialized)
+//   1 anvil (even slower, rarely needed)
+//   2 chests for storage/retrieval
+
+// ALUExecute performs single-cycle ALU operations (INNOVATION #56)
+//
+// ALGORITHM:
+//
+//	Based on opcode, perform appropriate operation
+//	All operations complete in ONE cycle
+//
+// OPERATIONS:
+//
+//	Arithmetic: ADD, SUB (using our carry-select adder)
+//	Logic: AND, OR, XOR
+//	Shifts: SLL, SRL, SRA (using our barrel shifter)
+//	Compare: SLT, SLTU (using subtraction + sign check)
+//
+// HARDWARE NOTE: These are all combinational logic
+//
+//	All complete in <1 cycle at modern clock rates
+func ALUExecute(op uint8, a, b uint32) uint32 {
+	switch op {
+	case OpADD, OpADDI:
+		// INNOVATION #7: Carry-select adder (fast!)
+		return Add32(a, b)
+
+	case OpSUB:
+		// INNOVATION #8: Two's complement (reuse adder!)
+		return Sub32(a, b)
+
+	case OpAND, OpANDI:
+		return a & b
+
+	case OpOR, OpORI:
+		return a | b
+
+	case OpXOR, OpXORI:
+		return a ^ b
+
+	case OpSLL:
+		// INNOVATION #9: Barrel shifter (1 cycle!)
+		return BarrelShift(a, uint8(b), true, false)
+
+	case OpSRL:
+		return BarrelShift(a, uint8(b), false, false)
+
+	case OpSRA:
+		return BarrelShift(a, uint8(b), false, true)
+
+	case OpSLT:
+		// Set if less than (signed)
+		// Subtract and check sign bit
+		if int32(a) < int32(b) {
+			return 1
+		}
+		return 0
+
+	case OpSLTU:
+		// Set if less than (unsigned)
+		if a < b {
+			return 1
+		}
+		return 0
+
+	case OpLUI:
+		// Load upper immediate: shift left by 15 bits
+		return uint32(int32(b) << 15)
+
+	default:
+		return 0
+	}
+}
+
+// EvaluateBranch determines if a branch condition is true
+//
+// ALGORITHM:
+//
+//	Compare two values according to branch type
+//	Return true if branch should be taken
+//
+// BRANCH TYPES:
+//
+//	BEQ: Equal (a == b)
+//	BNE: Not equal (a != b)
+//	BLT: Less than signed (a < b as signed)
+//	BGE: Greater or equal signed (a >= b as signed)
+//
+// HARDWARE NOTE: Uses subtraction and flag checking
+//
+//	Completes in 1 cycle
+func EvaluateBranch(op uint8, a, b uint32) bool {
+	switch op {
+	case OpBEQ:
+		return a == b
+	case OpBNE:
+		return a != b
+	case OpBLT:
+		return int32(a) < int32(b)
+	case OpBGE:
+		return int32(a) >= int32(b)
+	default:
+		return false
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// MULTIPLIER POOL (INNOVATION #57)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: One multiplier that holds its op until the result is
+//              collected blocks every other multiply for that time
+//   The Booth/Wallace datapath (INNOVATION #10-12) is combinational -
+//   nothing stops it from taking a new op every cycle
+//
+// THE SOLUTION: A pool of NumMULs units sharing a result bus
+//   Latency:        Cycles from issue to result (MulLatency)
+//   Issue interval: Cycles before a unit takes its next op
+//                   1 = fully pipelined, MulLatency = not pipelined
+//   Result bus:     Up to MulResultPorts results written back per
+//                   cycle; extra results wait (oldest first)
+//
+// TIMING (latency 3, interval 1, one unit):
+//
+//	Cycle:   1      2      3      4      5      6
+//	MUL a:   issue  ·      ·      result
+//	MUL b:          issue  ·      ·      result
+//	MUL c:                 issue  ·      ·      result
+//
+// VARIANTS (RISC-V M extension):
+//   MUL:    Low 32 bits (same for signed and unsigned)
+//   MULH:   High 32 bits, signed × signed
+//   MULHU:  High 32 bits, unsigned × unsigned
+//   MULHSU: High 32 bits, signed rs1 × unsigned rs2
+//
+// MINECRAFT ANALOGY: A row of furnaces feeding one output hopper
+
+// MultiplyOp returns the result of a MUL, MULH, MULHU or MULHSU
+//
+// ALGORITHM:
+//
+//	STEP 1: Booth + Wallace product (INNOVATION #10-11)
+//	        The radix-4 Booth recoding reads the top bit of b as a
+//	        sign, so Multiply returns unsigned(a) × signed(b)
+//	STEP 2: Correct the high word for the signedness op asks for
+//	        b unsigned: add a << 32 when b's sign bit is set
+//	        a signed:   subtract b << 32 when a's sign bit is set
+//
+// HARDWARE NOTE: The corrections are one conditional add into the
+//
+//	Wallace tree's final adder, not extra cycles
+func MultiplyOp(op uint8, a, b uint32) uint32 {
+	// STEP 1: unsigned(a) × signed(b)
+	lo, hi := Multiply(a, b)
+	if op == OpMUL {
+		return lo
+	}
+
+	// STEP 2: Signedness corrections (high word only)
+	if op == OpMULHU || op == OpMULHSU {
+		if int32(b) < 0 {
+			hi += a // b was read as b - 2^32
+		}
+	}
+	if op == OpMULH || op == OpMULHSU {
+		if int32(a) < 0 {
+			hi -= b // a is a - 2^32
+		}
+	}
+	return hi
+}
+
+// mulOp is one multiply in flight
+type mulOp struct {
+	windowID int
+	result   uint32
+	ready    uint64 // First cycle the result can be written back
+}
+
+// UnitResult is one result written back by a unit pool
+type UnitResult struct {
+	WindowID int
+	Value    uint32
+}
+
+// MultiplierPool holds the multiply units and their shared result bus
+type MultiplierPool struct {
+	nextIssue []uint64 // Per unit: first cycle it accepts a new op
+	unitOps   []uint64 // Per unit: ops issued
+	inflight  []mulOp  // Oldest issue first
+	results   []UnitResult
+
+	latency  int
+	interval int
+	ports    int
+
+	// Statistics
+	Issued    uint64 // Multiplies started
+	BusWaits  uint64 // Result-cycles spent waiting for a result port
+	Cancelled uint64 // Squashed before writeback
+}
+
+// NewMultiplierPool creates cfg.NumMULs units
+func NewMultiplierPool(cfg CoreConfig) *MultiplierPool {
+	return &MultiplierPool{
+		nextIssue: make([]uint64, cfg.NumMULs),
+		unitOps:   make([]uint64, cfg.NumMULs),
+		latency:   cfg.MulLatency,
+		interval:  cfg.MulIssueInterval,
+		ports:     cfg.MulResultPorts,
+	}
+}
+
+// freeUnit returns a unit that can start an op at cycle now, or -1
+func (p *MultiplierPool) freeUnit(now uint64) int {
+	for u, next := range p.nextIssue {
+		if next <= now {
+			return u
+		}
+	}
+	return -1
+}
+
+// CanIssue reports whether any unit can start a multiply at cycle now
+func (p *MultiplierPool) CanIssue(now uint64) bool {
+	return p.freeUnit(now) >= 0
+}
+
+// Issue starts a multiply at cycle now (INNOVATION #12)
+//
+// The value is computed here; the pool only models when it appears.
+// Returns false if no unit is free.
+func (p *MultiplierPool) Issue(now uint64, windowID int, op uint8, a, b uint32) bool {
+	u := p.freeUnit(now)
+	if u < 0 {
+		return false
+	}
+
+	p.nextIssue[u] = now + uint64(p.interval)
+	p.unitOps[u]++
+	p.Issued++
+	p.inflight = append(p.inflight, mulOp{
+		windowID: windowID,
+		result:   MultiplyOp(op, a, b),
+		ready:    now + uint64(p.latency),
+	})
+	return true
+}
+
+// Results returns the results written back at cycle now
+//
+// ALGORITHM:
+//
+//	STEP 1: Walk in-flight ops oldest first
+//	STEP 2: Finished and a port left: write back, remove
+//	        Finished, no port left:   wait for next cycle (BusWaits)
+//
+// The returned slice is reused by the next call
+func (p *MultiplierPool) Results(now uint64) []UnitResult {
+	p.results = p.results[:0]
+
+	kept := p.inflight[:0]
+	for _, op := range p.inflight {
+		if op.ready > now {
+			kept = append(kept, op)
+			continue
+		}
+		if len(p.results) == p.ports {
+			p.BusWaits++
+			kept = append(kept, op)
+			continue
+		}
+		p.results = append(p.results, UnitResult{WindowID: op.windowID, Value: op.result})
+	}
+	p.inflight = kept
+
+	return p.results
+}
+
+// Squash drops every in-flight multiply whose window entry is gone
+func (p *MultiplierPool) Squash(valid func(windowID int) bool) {
+	kept := p.inflight[:0]
+	for _, op := range p.inflight {
+		if valid(op.windowID) {
+			kept = append(kept, op)
+		} else {
+			p.Cancelled++
+		}
+	}
+	p.inflight = kept
+}
+
+// InFlight returns the multiplies not yet written back
+func (p *MultiplierPool) InFlight() int {
+	return len(p.inflight)
+}
+
+// GetUtilization returns, per unit, the fraction of cycles it was
+// occupied (an op holds its unit for the issue interval)
+func (p *MultiplierPool) GetUtilization(cycles uint64) []float64 {
+	util := make([]float64, len(p.unitOps))
+	if cycles == 0 {
+		return util
+	}
+	for u, n := range p.unitOps {
+		util[u] = float64(n*uint64(p.interval)) / float64(cycles)
+	}
+	return util
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// DIVIDER POOL (INNOVATION #58)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: One divider that holds the whole operation blocks every
+//              other divide for four cycles, and every divide pays the
+//              full Newton-Raphson latency - even 7 / 9
+//
+// THE SOLUTION: A pool of NumDIVs units with per-operation latency
+//   Latency:        Cycles from issue to result (DivLatency)
+//   Issue interval: Cycles before a unit takes its next op
+//                   1 = fully pipelined, DivLatency = not pipelined
+//   Fast cases:     Division by zero, INT_MIN / -1 and power-of-two
+//                   divisors need no iterations - 1 cycle, always
+//   Early-out:      With DivEarlyOut, a quotient known to be 0 takes
+//                   1 cycle, and one known to fit in 16 bits skips the
+//                   second iteration (18 correct bits are enough)
+//                   OPT-IN: div_early_out = 1; off, every divide that
+//                   misses the fast cases takes DivLatency
+//
+// DETECTING SMALL QUOTIENTS: Leading-zero counts, already computed
+//   for normalization. With z = clz(|divisor|) - clz(|dividend|):
+//     |dividend| < |divisor|   → quotient 0
+//     z < 15                   → quotient < 2^16
+//
+// TIMING (latency 4, interval 4, one unit):
+//
+//	Cycle:   1      2      3      4      5      6      7      8      9
+//	DIV a:   issue  ·      ·      ·      result
+//	DIV b:          (busy) (busy) (busy) issue  ·      ·      ·      result
+//
+// MINECRAFT ANALOGY: Anvils - slow, but a simple repair is quick, and
+//                    a second anvil means the queue moves twice as fast
+
+// divOp is one divide in flight
+type divOp struct {
+	windowID int
+	result   uint32
+	ready    uint64 // First cycle the result can be written back
+}
+
+// DividerPool holds the divide units
+type DividerPool struct {
+	nextIssue []uint64 // Per unit: first cycle it accepts a new op
+	busy      []uint64 // Per unit: cycles occupied
+	inflight  []divOp  // Oldest issue first
+	results   []UnitResult
+
+	latency  int
+	interval int
+	earlyOut bool
+
+	// Statistics
+	Issued    uint64 // Divides started
+	Fast      uint64 // Zero divisor, overflow or power-of-two divisor
+	EarlyOuts uint64 // Finished early on a small quotient
+	Cancelled uint64 // Squashed before writeback
+}
+
+// NewDividerPool creates cfg.NumDIVs units
+func NewDividerPool(cfg CoreConfig) *DividerPool {
+	return &DividerPool{
+		nextIssue: make([]uint64, cfg.NumDIVs),
+		busy:      make([]uint64, cfg.NumDIVs),
+		latency:   cfg.DivLatency,
+		interval:  cfg.DivIssueInterval,
+		earlyOut:  cfg.DivEarlyOut != 0,
+	}
+}
+
+// freeUnit returns a unit that can start an op at cycle now, or -1
+func (p *DividerPool) freeUnit(now uint64) int {
+	for u, next := range p.nextIssue {
+		if next <= now {
+			return u
+		}
+	}
+	return -1
+}
+
+// CanIssue reports whether any unit can start a divide at cycle now
+func (p *DividerPool) CanIssue(now uint64) bool {
+	return p.freeUnit(now) >= 0
+}
+
+// latencyOf returns how many cycles dividing a by b takes
+//
+// ALGORITHM:
+//
+//	STEP 1: Zero divisor or signed overflow: 1 cycle
+//	STEP 2: Take magnitudes (signed ops), power-of-two divisor: 1 cycle
+//	STEP 3: Early-out on the leading-zero counts (if enabled)
+//	STEP 4: Otherwise the full DivLatency
+func (p *DividerPool) latencyOf(funct uint16, a, b uint32) (cycles int, fast, early bool) {
+	signed := funct&FunctUnsigned == 0
+
+	// STEP 1: Results defined without dividing
+	if b == 0 || (signed && a == 0x80000000 && b == 0xFFFFFFFF) {
+		return 1, true, false
+	}
+
+	// STEP 2: Magnitudes
+	if signed && int32(a) < 0 {
+		a = -a
+	}
+	if signed && int32(b) < 0 {
+		b = -b
+	}
+	if bits.OnesCount32(b) == 1 {
+		return 1, true, false
+	}
+
+	// STEP 3: Small quotients
+	if p.earlyOut {
+		if a < b {
+			return 1, false, true
+		}
+		if bits.LeadingZeros32(b)-bits.LeadingZeros32(a) < 15 && p.latency > 1 {
+			return p.latency - 1, false, true
+		}
+	}
+
+	// STEP 4: Full Newton-Raphson
+	return p.latency, false, false
+}
+
+// Issue starts a divide at cycle now (INNOVATION #16)
+//
+// The value is computed here; the pool only models when it appears.
+// A unit is held for the issue interval, or the op's own latency if
+// that is shorter. Returns false if no unit is free.
+func (p *DividerPool) Issue(now uint64, windowID int, op uint8, funct uint16, a, b uint32) bool {
+	u := p.freeUnit(now)
+	if u < 0 {
+		return false
+	}
+
+	lat, fast, early := p.latencyOf(funct, a, b)
+	hold := min(p.interval, lat)
+	p.nextIssue[u] = now + uint64(hold)
+	p.busy[u] += uint64(hold)
+
+	p.Issued++
+	if fast {
+		p.Fast++
+	}
+	if early {
+		p.EarlyOuts++
+	}
+	p.inflight = append(p.inflight, divOp{
+		windowID: windowID,
+		result:   DivideOp(op, funct, a, b),
+		ready:    now + uint64(lat),
+	})
+	return true
+}
+
+// Results returns the results written back at cycle now
+//
+// Every unit has its own write port, so all finished divides write
+// back. The returned slice is reused by the next call.
+func (p *DividerPool) Results(now uint64) []UnitResult {
+	p.results = p.results[:0]
+
+	kept := p.inflight[:0]
+	for _, op := range p.inflight {
+		if op.ready > now {
+			kept = append(kept, op)
+			continue
+		}
+		p.results = append(p.results, UnitResult{WindowID: op.windowID, Value: op.result})
+	}
+	p.inflight = kept
+
+	return p.results
+}
+
+// Squash drops every in-flight divide whose window entry is gone
+//
+// The unit stays occupied until its issue interval ends - the
+// iteration hardware can't stop mid-cycle
+func (p *DividerPool) Squash(valid func(windowID int) bool) {
+	kept := p.inflight[:0]
+	for _, op := range p.inflight {
+		if valid(op.windowID) {
+			kept = append(kept, op)
+		} else {
+			p.Cancelled++
+		}
+	}
+	p.inflight = kept
+}
+
+// InFlight returns the divides not yet written back
+func (p *DividerPool) InFlight() int {
+	return len(p.inflight)
+}
+
+// GetUtilization returns, per unit, the fraction of cycles it was
+// occupied
+func (p *DividerPool) GetUtilization(cycles uint64) []float64 {
+	util := make([]float64, len(p.busy))
+	if cycles == 0 {
+		return util
+	}
+	for u, n := range p.busy {
+		util[u] = float64(n) / float64(cycles)
+	}
+	return util
+}
+
+// GetEarlyOutRate returns the fraction of divides that finished early
+// or took a fast path
+func (p *DividerPool) GetEarlyOutRate() float64 {
+	if p.Issued == 0 {
+		return 0
+	}
+	return float64(p.EarlyOuts+p.Fast) / float64(p.Issued)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// FETCH BANDWIDTH
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: An I-cache reads one aligned block per access, and a
+//              taken branch means a second access at the target
+//   Following every predicted-taken branch in one cycle, across any
+//   number of lines, fetches a full group where hardware gets a few
+//
+// THE SOLUTION: Fetch works in aligned fetch blocks
+//   Sequential: Fetch stops at the end of the aligned block
+//   Taken:      Each predicted-taken branch ends its block; fetch
+//               continues at the target while the taken-branch budget
+//               (TakenBranchesPerCycle) lasts
+//   Lines:      A target in another L1I line needs another line read;
+//               at most FetchLinesPerCycle lines per cycle
+//   Queue:      Fetched instructions wait in the fetch queue until
+//               decode takes them (DispatchWidth per cycle)
+//
+// EXAMPLE (32-byte blocks, 4-wide, 1 taken branch per cycle):
+//
+//	PC 0x101C: last word of its block → 1 instruction, stop (block end)
+//	PC 0x1020: 4 instructions, stop (width)
+//	PC 0x1040: BEQ taken at 0x1044 → 2 instructions, stop (taken)
+//
+// STOP REASONS: Every cycle ends for exactly one reason, so the
+//   reasons add up to 100% and show what limits fetch bandwidth
+//
+// MINECRAFT ANALOGY: Grabbing items from a chest row - one row per
+//   trip, and every detour to another chest costs a trip
+
+// FetchStop is why a fetch cycle ended
+type FetchStop uint8
+
+const (
+	FetchStopWidth     FetchStop = iota // FetchWidth instructions fetched
+	FetchStopBlockEnd                   // Ran off the end of the aligned block
+	FetchStopTaken                      // Taken-branch budget used up
+	FetchStopLine                       // Target in a line beyond FetchLinesPerCycle
+	FetchStopQueueFull                  // Fetch queue full (backpressure)
+	FetchStopMiss                       // L1I miss or line fill pending
+	FetchStopFTQ                        // FTQ empty (decoupled frontend)
+	NumFetchStops
+)
+
+func (s FetchStop) String() string {
+	switch s {
+	case FetchStopWidth:
+		return "width"
+	case FetchStopBlockEnd:
+		return "block end"
+	case FetchStopTaken:
+		return "taken branch"
+	case FetchStopLine:
+		return "line crossing"
+	case FetchStopQueueFull:
+		return "queue full"
+	case FetchStopMiss:
+		return "I-cache miss"
+	case FetchStopFTQ:
+		return "FTQ empty"
+	}
+	return "unknown"
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// FRONTEND PIPELINE
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: Fetch, decode, rename and dispatch are one step in a
+//              model, but several cycles in silicon
+//   Fetched in cycle N, in the window in cycle N+1: a redirect costs
+//   one cycle of refill no matter how deep a real frontend is, so a
+//   "tiny mispredict penalty" can't be checked against anything
+//
+// THE SOLUTION: A pipeline latch at the end of every frontend cycle
+//   Fetch fills the fetch queue (see FETCH BANDWIDTH), latch 0 takes
+//   one group from it, dispatch reads the last latch
+//   Each cycle, every group of instructions moves one latch forward
+//   Depth per stage comes from CoreConfig (FetchStages, DecodeStages,
+//   RenameStages, DispatchStages)
+//
+// THE LATCHES:
+//
+//	depth = fetch + decode + rename + dispatch cycles
+//	latches = depth - 1 (dispatch's last cycle writes the window)
+//
+//	Default (1+1+1+1):  FETCH ─▶ [F/D] ─▶ DECODE ─▶ [D/R] ─▶ RENAME ─▶ [R/P] ─▶ DISPATCH
+//	Folded  (1+0+0+1):  FETCH ─▶ [F/P] ─▶ DISPATCH  (the old 1-cycle model)
+//
+// BACKPRESSURE: A latch holds one group (up to DispatchWidth
+//   instructions) and only moves when the next latch is empty. If
+//   dispatch stalls, the stall walks back one latch a cycle to the
+//   fetch queue, and fetch stops once the queue is full.
+//
+// REDIRECT: Every latch and the fetch queue are on the wrong path -
+//   all are emptied, and the
+//   right path needs depth-1 cycles to reach dispatch. That refill is
+//   what GetMispredictPenalty reports.
+//
+// TIMING ONLY: Decode still happens at fetch and renaming at dispatch
+//   (Window.Dispatch) - the latches delay instructions, nothing more
+//
+// MINECRAFT ANALOGY: Minecarts on a track between stations - each cart
+//   moves one segment per tick, and a derailment empties the whole track
+
+// FrontendStage names a frontend pipeline stage
+type FrontendStage uint8
+
+const (
+	StageFetch FrontendStage = iota
+	StageDecode
+	StageRename
+	StageDispatch
+	NumFrontendStages
+)
+
+func (s FrontendStage) String() string {
+	switch s {
+	case StageFetch:
+		return "fetch"
+	case StageDecode:
+		return "decode"
+	case StageRename:
+		return "rename"
+	case StageDispatch:
+		return "dispatch"
+	}
+	return "unknown"
+}
+
+// FrontendPipeline holds the instructions between fetch and dispatch
+type FrontendPipeline struct {
+	queue     []Instruction   // Fetch queue (oldest first)
+	queueSize int             // Fetch queue capacity
+	latches   [][]Instruction // [0] = loaded from the queue, last = read by dispatch
+	stageOf   []FrontendStage // Stage that fills each latch
+	depth     int             // Cycles from fetch to dispatch, both included
+	width     int             // Instructions per latch
+
+	// Statistics
+	Fetched   uint64                    // Instructions fetched
+	Stops     [NumFetchStops]uint64     // Why each fetch cycle ended
+	Bubbles   [NumFrontendStages]uint64 // Empty latch-cycles per stage
+	Latches   [NumFrontendStages]int    // Latches per stage
+	Starved   uint64                    // Cycles dispatch found nothing
+	Flushes   uint64                    // Redirects
+	Discarded uint64                    // Instructions thrown away by redirects
+	cycles    uint64
+}
+
+// NewFrontendPipeline creates the latches for cfg's stage depths
+func NewFrontendPipeline(cfg CoreConfig) *FrontendPipeline {
+	f := &FrontendPipeline{
+		queue:     make([]Instruction, 0, cfg.FetchQueueSize),
+		queueSize: cfg.FetchQueueSize,
+		depth:     cfg.frontendDepth(),
+		width:     cfg.DispatchWidth,
+	}
+
+	stages := [NumFrontendStages]int{cfg.FetchStages, cfg.DecodeStages, cfg.RenameStages, cfg.DispatchStages}
+	stages[StageDispatch]-- // Its last cycle writes the window, not a latch
+	for s, n := range stages {
+		for i := 0; i < n; i++ {
+			f.latches = append(f.latches, make([]Instruction, 0, f.width))
+			f.stageOf = append(f.stageOf, FrontendStage(s))
+		}
+		f.Latches[s] = n
+	}
+
+	return f
+}
+
+// Depth returns cycles from fetch to dispatch, both included
+func (f *FrontendPipeline) Depth() int {
+	return f.depth
+}
+
+// QueueFull reports whether the fetch queue has no room left
+func (f *FrontendPipeline) QueueFull() bool {
+	return len(f.queue) >= f.queueSize
+}
+
+// Fetch appends one instruction to the fetch queue
+func (f *FrontendPipeline) Fetch(inst Instruction) {
+	f.queue = append(f.queue, inst)
+	f.Fetched++
+}
+
+// Peek returns the oldest instruction waiting for dispatch
+func (f *FrontendPipeline) Peek() (Instruction, bool) {
+	last := f.latches[len(f.latches)-1]
+	if len(last) == 0 {
+		return Instruction{}, false
+	}
+	return last[0], true
+}
+
+// Pop removes the instruction Peek returned (it dispatched)
+func (f *FrontendPipeline) Pop() {
+	n := len(f.latches) - 1
+	last := f.latches[n]
+	copy(last, last[1:])
+	f.latches[n] = last[:len(last)-1]
+}
+
+// Advance moves every group one latch toward dispatch
+//
+// ALGORITHM:
+//
+//	STEP 1: Nothing for dispatch this cycle? Count it as starved
+//	STEP 2: Oldest first - each latch moves into the next if it is empty
+//	        (the group behind a stalled latch stays put)
+//	STEP 3: Count the latches left empty (bubbles) per stage
+//
+// Call after dispatch and before fetch: dispatch frees the last latch
+// and EndFetch refills latch 0 in the same cycle
+func (f *FrontendPipeline) Advance(dispatched int) {
+	f.cycles++
+
+	// STEP 1: Frontend bubble reached dispatch
+	if dispatched == 0 {
+		f.Starved++
+	}
+
+	// STEP 2: Move groups forward (swap buffers to keep both allocations)
+	for i := len(f.latches) - 1; i > 0; i-- {
+		if len(f.latches[i]) == 0 && len(f.latches[i-1]) > 0 {
+			f.latches[i], f.latches[i-1] = f.latches[i-1], f.latches[i][:0]
+		}
+	}
+
+	// STEP 3: Bubbles (latch 0 is counted once fetch has had its turn)
+	for i := 1; i < len(f.latches); i++ {
+		if len(f.latches[i]) == 0 {
+			f.Bubbles[f.stageOf[i]]++
+		}
+	}
+}
+
+// EndFetch records why fetch stopped and loads latch 0 from the queue
+//
+// An instruction fetched this cycle can enter latch 0 this cycle, so
+// the queue only adds delay when the frontend is backed up
+func (f *FrontendPipeline) EndFetch(stop FetchStop) {
+	f.Stops[stop]++
+
+	if len(f.latches[0]) == 0 && len(f.queue) > 0 {
+		n := min(f.width, len(f.queue))
+		f.latches[0] = append(f.latches[0], f.queue[:n]...)
+		f.queue = f.queue[:copy(f.queue, f.queue[n:])]
+	}
+
+	// Fetch bubble: nothing reached latch 0 this cycle
+	if len(f.latches[0]) == 0 {
+		f.Bubbles[f.stageOf[0]]++
+	}
+}
+
+// Flush empties every latch (INNOVATION #48: all of it is wrong path)
+func (f *FrontendPipeline) Flush() {
+	f.Flushes++
+	f.Discarded += uint64(len(f.queue))
+	f.queue = f.queue[:0]
+	for i := range f.latches {
+		f.Discarded += uint64(len(f.latches[i]))
+		f.latches[i] = f.latches[i][:0]
+	}
+}
+
+// Len returns the instructions currently in the frontend
+func (f *FrontendPipeline) Len() int {
+	n := len(f.queue)
+	for _, l := range f.latches {
+		n += len(l)
+	}
+	return n
+}
+
+// GetBubbleRate returns the fraction of stage's latch-cycles that held
+// nothing (0 for a stage with no latches of its own)
+func (f *FrontendPipeline) GetBubbleRate(stage FrontendStage) float64 {
+	if f.cycles == 0 || f.Latches[stage] == 0 {
+		return 0
+	}
+	return float64(f.Bubbles[stage]) / float64(f.cycles*uint64(f.Latches[stage]))
+}
+
+// GetFetchRate returns the average instructions fetched per cycle
+func (f *FrontendPipeline) GetFetchRate() float64 {
+	if f.cycles == 0 {
+		return 0
+	}
+	return float64(f.Fetched) / float64(f.cycles)
+}
+
+// GetStopRate returns the fraction of fetch cycles that ended for reason
+func (f *FrontendPipeline) GetStopRate(reason FetchStop) float64 {
+	if f.cycles == 0 {
+		return 0
+	}
+	return float64(f.Stops[reason]) / float64(f.cycles)
+}
+
+// GetStarvedRate returns the fraction of cycles dispatch had nothing
+func (f *FrontendPipeline) GetStarvedRate() float64 {
+	if f.cycles == 0 {
+		return 0
+	}
+	return float64(f.Starved) / float64(f.cycles)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// THE COMPLETE CPU (INTEGRATION OF ALL INNOVATIONS)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// This is where everything comes together!
+//
+// THE ARCHITECTURE: 7-stage pipeline
+//
+//   STAGE 1: COMMIT
+//     - Retire completed instructions (INNOVATION #45: 4-wide)
+//     - Check for branch mispredictions (INNOVATION #48)
+//     - Update architectural state
+//
+//   STAGE 2: COMPLETE
+//     - Collect results from execution units
+//     - Forward results to waiting instructions (INNOVATION #55)
+//
+//   STAGE 3: EXECUTE
+//     - Advance multi-cycle operations (loads)
+//     - Multiplier and divider pools finish on their own cycle counts
+//     - LSUs handle memory operations (INNOVATION #69-73)
+//
+//   STAGE 4: ISSUE
+//     - Send ready instructions to execution units
+//     - Select up to 6 instructions (INNOVATION #43)
+//     - ALU ops execute immediately (INNOVATION #56)
+//     - Start multi-cycle ops (INNOVATION #57-58)
+//
+//   STAGE 5: DISPATCH
+//     - Move decoded instructions from the last frontend latch to window
+//     - Allocate physical registers (INNOVATION #36-39)
+//     - Track dependencies (INNOVATION #52-53)
+//     - Up to 4 instructions (INNOVATION #44)
+//
+//   STAGE 6: FETCH
+//     - Advance the frontend latches (decode, rename - see FRONTEND PIPELINE)
+//     - Get instructions from L1I cache (INNOVATION #21-28)
+//     - Decode instructions (INNOVATION #5-6)
+//     - Predict branches (INNOVATION #29-33)
+//     - Fill frontend latch 0
+//
+//   STAGE 7: PREFETCH
+//     - Handle background prefetch requests
+//     - L1I prefetch (INNOVATION #22, #27)
+//     - L1D prefetch (INNOVATION #59, #67)
+//
+// ALL STAGES HAPPEN SIMULTANEOUSLY EVERY CYCLE!
+//
+// MINECRAFT ANALOGY: Assembly line with 7 stations
+//   All stations work at the same time on different items
+//   Items move through stations in order
+//   But can overtake each other in middle stations (out-of-order!)
+
+// Core is the complete SUPRAX-32 processor
+type Core struct {
+	pc  uint32     // Program counter (next instruction to fetch)
+	cfg CoreConfig // Structure sizes and widths (see RUNTIME CONFIGURATION)
+
+	// Cache hierarchy (INNOVATIONS #17-28, #59-68)
+	icache     *L1ICache        // INNOVATION #21-28: Quad-buffered L1I
+	ifill      *L1IFillUnit     // L1I line fills from memory (DRAM latency)
+	arbiter    *MemoryArbiter   // Shared DRAM ports for all misses and prefetch
+	dprefetch  *MemRequest      // L1D prefetch in flight (nil = none)
+	dcache     *L1DCache        // INNOVATION #18-20, #59-68: L1D + predictor
+	branchPred *BranchPredictor // INNOVATION #29-33: 4-bit counters + RSB
+
+	// Out-of-order engine (INNOVATIONS #34-58)
+	window *Window // INNOVATION #35: Unified scheduler + ROB + IQ
+
+	// Execution units (INNOVATIONS #56-58)
+	multiplier *MultiplierPool // INNOVATION #57: Pipelined multiply units
+	divider    *DividerPool    // INNOVATION #58: Newton-Raphson divide units
+	lsus       []*LSU          // INNOVATION #69: 2 LSUs
+
+	// Fetch-to-dispatch latches (see FRONTEND PIPELINE)
+	frontend *FrontendPipeline
+
+	// Main memory (simplified - in reality this is DRAM)
+	memory []byte
+
+	// Statistics
+	cycles            uint64
+	instructions      uint64
+	branches          uint64
+	branchMispredicts uint64
+	loads             uint64
+	stores            uint64
+	fetchStallCycles  uint64 // Fetch waiting on an L1I line fill
+	indirectJumps     uint64 // JALRs committed (returns included)
+	indirectMispreds  uint64 // ... whose predicted target was wrong
+
+	// Branch mispredict recovery (INNOVATION #48)
+	earlyRecovery bool   // Recover at execute (false = at commit)
+	recoveries    uint64 // Fetch redirects after a mispredict
+	resolveWait   uint64 // Cycles from outcome known to redirect
+	refillCycles  uint64 // Cycles from redirect to next dispatch
+	refillPending bool   // Waiting for the first dispatch after a redirect
+	redirectCycle uint64 // When the pending redirect happened
+
+	// Branch trace recording (nil = off)
+	branchTrace      *BranchTraceWriter
+	lastTracedInstrs uint64 // c.instructions at the previous traced branch
+
+	// Load value prediction (nil = off)
+	valuePred *LoadValuePredictor
+
+	// Fetch-directed frontend (nil = coverage prefetch, INNOVATION #22)
+	ftq *FetchTargetQueue
+}
+
+// NewCore creates an initialized SUPRAX-32 processor
+//
+// ALGORITHM:
+//
+//	STEP 1: Initialize all components
+//	STEP 2: Create caches with predictors
+//	STEP 3: Set up execution units
+//	STEP 4: Allocate memory
+func NewCore(memorySize int) *Core {
+	// The default preset always validates
+	c, _ := NewCoreWithConfig(DefaultCoreConfig(), memorySize)
+	return c
+}
+
+// NewCoreWithConfig creates a processor sized by cfg
+//
+// Every runtime-sized structure (window, register files, LSUs, caches,
+// RSB, DRAM ports and latency) comes from cfg. Returns an error if cfg
+// does not validate.
+func NewCoreWithConfig(cfg CoreConfig, memorySize int) (*Core, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &Core{
+		pc:            0x1000, // Start at 0x1000 (standard)
+		cfg:           cfg,
+		icache:        NewL1ICacheWithConfig(cfg),
+		dcache:        NewL1DCacheWithConfig(cfg),
+		branchPred:    NewBranchPredictorWithConfig(cfg),
+		window:        NewWindowWithConfig(cfg),
+		multiplier:    NewMultiplierPool(cfg),
+		divider:       NewDividerPool(cfg),
+		lsus:          make([]*LSU, cfg.NumLSUs),
+		frontend:      NewFrontendPipeline(cfg),
+		memory:        make([]byte, memorySize),
+		earlyRecovery: cfg.EarlyRecovery != 0, // INNOVATION #48: Repair at execute?
+	}
+
+	// INNOVATION #24: JALR targets from the L1I indirect table
+	c.branchPred.SetIndirectPredictor(c.icache)
+
+	c.arbiter = NewMemoryArbiter(c.memory, cfg.MemPorts, ArbiterDemandFirst)
+	c.arbiter.Latency = cfg.DRAMLatency
+	c.ifill = NewL1IFillUnit(c.icache, c.arbiter)
+
+	// Initialize LSUs (INNOVATION #69: 2 independent units)
+	for i := range c.lsus {
+		c.lsus[i] = NewLSU(c.dcache, c.arbiter)
+	}
+
+	return c, nil
+}
+
+// GetConfig returns the configuration the core was built with
+func (c *Core) GetConfig() CoreConfig {
+	return c.cfg
+}
+
+// LoadProgram loads instructions into memory
+//
+// ALGORITHM:
+//
+//	FOR each instruction word:
+//	  Write to memory at address (little-endian)
+//	Set PC to start address
+func (c *Core) LoadProgram(program []uint32, startAddr uint32) {
+	for i, word := range program {
+		addr := startAddr + uint32(i*4)
+
+		// Write 32-bit word as 4 bytes (little-endian)
+		c.memory[addr] = byte(word)
+		c.memory[addr+1] = byte(word >> 8)
+		c.memory[addr+2] = byte(word >> 16)
+		c.memory[addr+3] = byte(word >> 24)
+	}
+
+	c.pc = startAddr
+	if c.ftq != nil {
+		c.ftq = NewFetchTargetQueue(startAddr)
+	}
+}
+
+// ReadMemWord reads a 32-bit word from memory
+func (c *Core) ReadMemWord(addr uint32) uint32 {
+	if int(addr+3) >= len(c.memory) {
+		return 0
+	}
+
+	return uint32(c.memory[addr]) |
+		uint32(c.memory[addr+1])<<8 |
+		uint32(c.memory[addr+2])<<16 |
+		uint32(c.memory[addr+3])<<24
+}
+
+// WriteMemWord writes a 32-bit word to memory
+func (c *Core) WriteMemWord(addr uint32, data uint32) {
+	if int(addr+3) >= len(c.memory) {
+		return
+	}
+
+	c.memory[addr] = byte(data)
+	c.memory[addr+1] = byte(data >> 8)
+	c.memory[addr+2] = byte(data >> 16)
+	c.memory[addr+3] = byte(data >> 24)
+}
+
+// Cycle executes one clock cycle (THE MAIN EXECUTION LOOP!)
+//
+// ALGORITHM: 7 stages execute simultaneously
+//
+// CRITICAL NOTE: All stages run in PARALLEL in real hardware!
+//
+//	In this simulation, we run them sequentially
+//	but the logic is designed for parallel execution
+//
+// MINECRAFT ANALOGY: All 7 crafting stations work simultaneously
+func (c *Core) Cycle() {
+	c.cycles++
+	c.dcache.Tick()
+	c.icache.Tick()
+	c.arbiter.Tick()
+	c.ifill.Tick()
+
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 1: COMMIT (INNOVATION #45, #47, #48)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Retire completed instructions in program order
+	// This makes speculative work permanent
+	// Check for branch mispredictions and recover
+	//
+	// INNOVATION #45: 4-wide commit (retire up to 4 per cycle)
+	// INNOVATION #47: Program-order commit (precise exceptions)
+	// INNOVATION #48: Branch mispredict recovery (flush on wrong prediction)
+
+	for i := 0; i < c.cfg.CommitWidth; i++ {
+		committed := c.window.Commit()
+		if committed == nil {
+			break // No more ready to commit
+		}
+
+		c.instructions++
+
+		// Wrong predicted value already used: refetch everything after the load
+		if committed.ValueMispredicted {
+			c.valuePred.RecordFlush()
+
+			// Already repaired when the value arrived
+			if committed.Recovered {
+				continue
+			}
+
+			c.window.Flush()
+			c.squashUnits()
+			c.redirect(committed.PC+4, committed.ResolveCycle, committed.RSB)
+
+			return // Restart pipeline
+		}
+
+		// Check branches for misprediction (INNOVATION #48)
+		if committed.IsBranch || committed.Opcode == OpJAL || committed.Opcode == OpJALR {
+			c.branches++
+
+			actualTaken := committed.BranchTaken
+			actualTarget := committed.BranchTarget
+
+			if c.branchTrace != nil {
+				c.branchTrace.Record(committed.PC, actualTarget,
+					BranchTraceType(committed.Opcode, committed.Rd, committed.Rs1, committed.Imm),
+					actualTaken, uint32(c.instructions-c.lastTracedInstrs))
+				c.lastTracedInstrs = c.instructions
+			}
+
+			// Train the indirect table on every JALR (INNOVATION #24, #28)
+			if committed.Opcode == OpJALR {
+				c.indirectJumps++
+				if actualTarget != committed.PredictedAddr {
+					c.indirectMispreds++
+				}
+
+				if committed.Rs1 == 1 {
+					c.icache.NotifyReturn(committed.PC, actualTarget)
+				} else {
+					c.icache.NotifyIndirect(committed.PC, actualTarget)
+				}
+			}
+
+			// Compare prediction to reality
+			if actualTaken != committed.Predicted ||
+				(actualTaken && actualTarget != committed.PredictedAddr) {
+
+				// MISPREDICT! (INNOVATION #48: Recovery)
+				c.branchMispredicts++
+
+				// Update branch predictor (learn from mistake)
+				c.branchPred.Update(committed.PC, actualTaken)
+
+				// Notify L1I about branch resolution (INNOVATION #23)
+				c.icache.NotifyBranchResolved(committed.PC, actualTaken, actualTarget)
+
+				// Already repaired at execute: younger work is on the right path
+				if committed.Recovered {
+					continue
+				}
+
+				// Flush all speculative work
+				c.window.Flush()
+				c.squashUnits()
+
+				// Restart from correct path
+				target := committed.PC + 4
+				if actualTaken {
+					target = actualTarget
+				}
+				c.redirect(target, committed.ResolveCycle, committed.RSB)
+
+				return // Restart pipeline
+			}
+
+			// Correct prediction! Update predictor (reinforce learning)
+			c.branchPred.Update(committed.PC, actualTaken)
+
+			// Notify L1I (INNOVATION #23, #28)
+			c.icache.NotifyBranchResolved(committed.PC, actualTaken, actualTarget)
+		}
+	}
+
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 2: COMPLETE (INNOVATION #55: Result forwarding)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Collect results from all execution units
+	// Forward results to waiting instructions immediately
+	//
+	// INNOVATION #55: Results forwarded as soon as available
+	//                 Don't wait for commit!
+
+	// Check multipliers (INNOVATION #12: results due this cycle, up to
+	// MulResultPorts of them)
+	for _, r := range c.multiplier.Results(c.cycles) {
+		c.window.Complete(r.WindowID, r.Value)
+	}
+
+	// Check dividers (INNOVATION #16: results due this cycle)
+	for _, r := range c.divider.Results(c.cycles) {
+		c.window.Complete(r.WindowID, r.Value)
+	}
+
+	// Check LSUs (INNOVATION #69: 2 independent LSUs)
+	for _, lsu := range c.lsus {
+		if data, _, winID, valid := lsu.GetResult(); valid {
+			if c.valuePred != nil {
+				c.verifyValuePrediction(winID, data)
+			}
+			c.window.Complete(winID, data)
+		}
+	}
+
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 3: EXECUTE (INNOVATION #73: Variable latency)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Advance multi-cycle operations
+	// LSUs: Cache access or DRAM wait (INNOVATION #70, #73)
+	// (The multiplier and divider pools time themselves from c.cycles)
+
+	for _, lsu := range c.lsus {
+		lsu.Tick()
+	}
+
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 4: ISSUE (INNOVATION #43: 6-wide issue)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Send ready instructions to execution units
+	// Select up to 6 instructions per cycle
+	//
+	// INNOVATION #40-42: Wakeup and select
+	//   Wakeup: Bitmap-based (44× cheaper than CAM)
+	//   Select: Scheduler order (oldest first by default)
+	//   Issue: Up to 6 per cycle
+
+	readyList := c.window.SelectReady() // INNOVATION #42: Scheduler order
+	lsuIdx := 0                         // Track which LSU to use
+
+	for _, winID := range readyList {
+		entry := c.window.GetEntry(winID)
+		if entry == nil {
+			continue
+		}
+
+		// Read operands from register file
+		op1 := c.window.ReadReg(entry.Rs1, entry.PhysRs1)
+		op2 := c.window.ReadReg(entry.Rs2, entry.PhysRs2)
+
+		// For I-format, use immediate as second operand
+		if entry.Opcode >= 0x10 && entry.Opcode != OpBEQ && entry.Opcode != OpBNE &&
+			entry.Opcode != OpBLT && entry.Opcode != OpBGE {
+			op2 = uint32(entry.Imm)
+		}
+
+		issued := false
+
+		// Dispatch to appropriate execution unit
+		switch entry.Opcode {
+		case OpMUL, OpMULH, OpMULHU, OpMULHSU:
+			// INNOVATION #57: Pipelined multiply (any free unit)
+			issued = c.multiplier.Issue(c.cycles, winID, entry.Opcode, op1, op2)
+
+		case OpDIV, OpREM:
+			// INNOVATION #58: Divide or remainder (any free unit)
+			issued = c.divider.Issue(c.cycles, winID, entry.Opcode, entry.Funct, op1, op2)
+
+		case OpLW, OpLR:
+			// INNOVATION #69-73: Load operation
+			if lsuIdx < len(c.lsus) && !c.lsus[lsuIdx].IsBusy() {
+				// INNOVATION #7: Carry-select adder for address
+				addr := Add32(op1, uint32(entry.Imm))
+
+				// Check the prediction this load carried since dispatch
+				if entry.HasMemPrediction {
+					c.dcache.predictor.Resolve(entry.PC, entry.PredictedMemAddr, entry.MemPredictor, addr)
+				}
+
+				c.lsus[lsuIdx].Issue(MemoryOperation{
+					PC:       entry.PC,
+					Addr:     addr,
+					Rd:       entry.Rd,
+					WindowID: winID,
+					IsStore:  false,
+					IsLR:     entry.Opcode == OpLR, // INNOVATION #71
+				})
+				lsuIdx++
+				issued = true
+				c.loads++
+			}
+
+		case OpSW, OpSC:
+			// INNOVATION #69-73: Store operation
+			if lsuIdx < len(c.lsus) && !c.lsus[lsuIdx].IsBusy() {
+				addr := Add32(op1, uint32(entry.Imm))
+				storeData := c.window.ReadReg(entry.Rs2, entry.PhysRs2)
+
+				c.lsus[lsuIdx].Issue(MemoryOperation{
+					PC:       entry.PC,
+					Addr:     addr,
+					Data:     storeData,
+					Rd:       entry.Rd,
+					WindowID: winID,
+					IsStore:  true,
+					IsAtomic: entry.Opcode == OpSC, // INNOVATION #71
+				})
+				lsuIdx++
+				issued = true
+				c.stores++
+			}
+
+		case OpBEQ, OpBNE, OpBLT, OpBGE:
+			// Branch evaluation
+			taken := EvaluateBranch(entry.Opcode, op1, op2)
+			target := uint32(int32(entry.PC) + entry.Imm)
+			entry.BranchTaken = taken
+			entry.BranchTarget = target
+			entry.ResolveCycle = c.cycles
+			c.window.Complete(winID, 0) // Complete with dummy result
+			issued = true
+
+		case OpJAL:
+			// Jump and link (function call)
+			result := entry.PC + 4 // Return address
+			target := uint32(int32(entry.PC) + entry.Imm)
+
+			// INNOVATION #31: Push return address to RSB
+			// (the FTQ walk already pushed it when it predicted the call)
+			if c.ftq == nil {
+				c.branchPred.PushRSB(result)
+			}
+
+			entry.BranchTaken = true
+			entry.BranchTarget = target
+			entry.ResolveCycle = c.cycles
+			c.window.Complete(winID, result)
+			issued = true
+
+		case OpJALR:
+			// Jump and link register (return or indirect jump)
+			result := entry.PC + 4
+			target := Add32(op1, uint32(entry.Imm)) &^ 1 // Clear LSB
+
+			entry.BranchTaken = true
+			entry.BranchTarget = target
+			entry.ResolveCycle = c.cycles
+			c.window.Complete(winID, result)
+			issued = true
+
+		default:
+			// INNOVATION #56: ALU operations (single-cycle)
+			result := ALUExecute(entry.Opcode, op1, op2)
+			c.window.Complete(winID, result)
+			issued = true
+		}
 
-// Core is the complete SUPRAX-32 processor
-type Core struct {
-	pc uint32 // Program counter (next instruction to fetch)
+		if issued {
+			c.window.MarkIssued(winID)
+		}
 
-	// Cache hierarchy (INNOVATIONS #17-28, #59-68)
-	icache     *L1ICache        // INNOVATION #21-28: Quad-buffered L1I
-	dcache     *L1DCache        // INNOVATION #18-20, #59-68: L1D + predictor
-	branchPred *BranchPredictor // INNOVATION #29-33: 4-bit counters + RSB
+		// INNOVATION #48: Early recovery - stop issuing this cycle (oldest
+		// first: the rest of readyList is younger and gone; other policies:
+		// the survivors wait a cycle)
+		if issued && c.earlyRecovery && c.resolveAtExecute(winID, entry) {
+			break
+		}
+	}
 
-	// Out-of-order engine (INNOVATIONS #34-58)
-	window *Window // INNOVATION #35: Unified scheduler + ROB + IQ
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 5: DISPATCH (INNOVATION #44: 4-wide dispatch)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Move decoded instructions from the last frontend latch to window
+	// Allocate physical registers (INNOVATION #36-39)
+	// Track dependencies (INNOVATION #52-53)
 
-	// Execution units (INNOVATIONS #56-58)
-	multiplier *Multiplier   // INNOVATION #57: 1-cycle multiply
-	divider    *Divider      // INNOVATION #58: 4-cycle divide
-	lsus       [NumLSUs]*LSU // INNOVATION #69: 2 LSUs
+	dispatched := 0
+	for dispatched < c.cfg.DispatchWidth {
+		inst, ok := c.frontend.Peek()
+		if !ok {
+			break // Frontend bubble
+		}
 
-	// Fetch buffer
-	fetchBuffer    []Instruction
-	fetchBufferMax int
+		// INNOVATION #36-39: Register renaming
+		winID, ok := c.window.Dispatch(inst)
+		if !ok {
+			// Failed to dispatch (window full or no free register -
+			// counted by the window). Stays in the latch
+			break
+		}
+		c.frontend.Pop()
 
-	// Main memory (simplified - in reality this is DRAM)
-	memory []byte
+		if c.refillPending {
+			c.refillCycles += c.cycles - c.redirectCycle
+			c.refillPending = false
+		}
 
-	// Statistics
-	cycles            uint64
-	instructions      uint64
-	branches          uint64
-	branchMispredicts uint64
-	loads             uint64
-	stores            uint64
-}
+		entry := c.window.GetEntry(winID)
+		if entry != nil {
+			// Store branch predictions: the path fetch actually followed
+			// (asking the predictor again here could disagree once it
+			// has trained on branches that committed in the meantime)
+			entry.RSB = inst.RSB
+			if inst.HasPrediction {
+				entry.Predicted = inst.PredTaken
+				entry.PredictedAddr = inst.PredTarget
+			}
 
-// NewCore creates an initialized SUPRAX-32 processor
-//
-// ALGORITHM:
-//
-//	STEP 1: Initialize all components
-//	STEP 2: Create caches with predictors
-//	STEP 3: Set up execution units
-//	STEP 4: Allocate memory
-func NewCore(memorySize int) *Core {
-	c := &Core{
-		pc:             0x1000, // Start at 0x1000 (standard)
-		icache:         NewL1ICache(),
-		dcache:         NewL1DCache(),
-		branchPred:     NewBranchPredictor(),
-		window:         NewWindow(),
-		multiplier:     &Multiplier{},
-		divider:        &Divider{},
-		fetchBuffer:    make([]Instruction, 0, DispatchWidth),
-		fetchBufferMax: DispatchWidth * 2,
-		memory:         make([]byte, memorySize),
-	}
+			// Query L1D predictor for loads (INNOVATION #59)
+			if inst.IsLoad {
+				predAddr, predictor, valid := c.dcache.predictor.Predict(inst.PC)
+				if valid {
+					entry.PredictedMemAddr = predAddr
+					entry.MemPredictor = predictor
+					entry.HasMemPrediction = true
 
-	// Initialize LSUs (INNOVATION #69: 2 independent units)
-	for i := range c.lsus {
-		c.lsus[i] = NewLSU(c.dcache)
+					// INNOVATION #67-68: Queue prefetch with deduplication
+					if c.dcache.predictorPrefetch {
+						c.dcache.IssuePrefetch(predAddr, predictor)
+					}
+				}
+			}
+
+			// Predict the loaded value: dependents may issue right away
+			if inst.IsLoad && c.valuePred != nil && entry.PhysRd != InvalidTag {
+				if value, kind, ok := c.valuePred.Predict(inst.PC); ok {
+					entry.PredictedValue = value
+					entry.ValueKind = kind
+					entry.ValuePredicted = true
+					c.window.Checkpoint(winID) // Recovery point if the value is wrong
+					c.window.Wakeup(entry.PhysRd, value)
+				}
+			}
+		}
+
+		dispatched++
 	}
 
-	return c
-}
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 6: FETCH (INNOVATION #21-28, #5-6)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Move every frontend latch one stage forward (decode, rename)
+	// Get one aligned fetch block (or more, past taken branches) from L1I
+	// Decode instructions (INNOVATION #5)
+	// Predict branches (INNOVATION #29-33)
+	// Fill the fetch queue, then frontend latch 0 from it
 
-// LoadProgram loads instructions into memory
-//
-// ALGORITHM:
-//
-//	FOR each instruction word:
-//	  Write to memory at address (little-endian)
-//	Set PC to start address
-func (c *Core) LoadProgram(program []uint32, startAddr uint32) {
-	for i, word := range program {
-		addr := startAddr + uint32(i*4)
+	c.frontend.Advance(dispatched)
+	c.frontend.EndFetch(c.fetch())
 
-		// Write 32-bit word as 4 bytes (little-endian)
-		c.memory[addr] = byte(word)
-		c.memory[addr+1] = byte(word >> 8)
-		c.memory[addr+2] = byte(word >> 16)
-		c.memory[addr+3] = byte(word >> 24)
+	// Predictor runs ahead of fetch (blocks are fetchable next cycle)
+	if c.ftq != nil {
+		c.ftq.RunAhead(c.memory, c.branchPred)
 	}
 
-	c.pc = startAddr
-}
+	// ═══════════════════════════════════════════════════════════════════════
+	// STAGE 7: PREFETCH (INNOVATION #17, #22, #27, #59, #67)
+	// ═══════════════════════════════════════════════════════════════════════
+	//
+	// Handle background prefetch requests
+	// This is KEY to our no-L2/L3 strategy!
+	//
+	// INNOVATION #17: No L2/L3 caches
+	//   We rely on intelligent prefetching instead
+	//   Saves 530M transistors! 🎯
 
-// ReadMemWord reads a 32-bit word from memory
-func (c *Core) ReadMemWord(addr uint32) uint32 {
-	if int(addr+3) >= len(c.memory) {
-		return 0
+	// L1I prefetch: FTQ lines when decoupled (lines already cached
+	// cost only a tag check), else coverage scoring (INNOVATION #22, #27)
+	// Both go through the fill path and only get the bandwidth fetch left
+	if c.ftq != nil {
+		for issued := 0; issued < FTQPrefetchDepth && c.ifill.CanAccept(); {
+			lineAddr, valid := c.ftq.NextPrefetch()
+			if !valid {
+				break
+			}
+			if c.ifill.RequestPrefetch(lineAddr, L1ISourceFTQ) {
+				issued++
+			}
+		}
+	} else if prefetchAddr, source, valid := c.icache.GetPrefetchAddr(); valid && c.ifill.CanAccept() {
+		c.ifill.RequestPrefetch(prefetchAddr, source)
 	}
 
-	return uint32(c.memory[addr]) |
-		uint32(c.memory[addr+1])<<8 |
-		uint32(c.memory[addr+2])<<16 |
-		uint32(c.memory[addr+3])<<24
-}
+	// L1D prefetch (INNOVATION #59, #67): install the line that arrived
+	if c.dprefetch != nil && c.dprefetch.Done {
+		c.dcache.Fill(c.dprefetch.Line, c.dprefetch.Data[:])
+		c.dprefetch = nil
+	}
 
-// WriteMemWord writes a 32-bit word to memory
-func (c *Core) WriteMemWord(addr uint32, data uint32) {
-	if int(addr+3) >= len(c.memory) {
+	// One L1D prefetch at a time (the queue head stays InFlight until Fill)
+	if c.dprefetch != nil {
 		return
 	}
+	if prefetchAddr, valid := c.dcache.GetNextPrefetch(); valid {
+		// Check if already in cache
+		setIdx := c.dcache.getSetIndex(prefetchAddr)
+		tag := c.dcache.getTag(prefetchAddr)
+		inCache := false
 
-	c.memory[addr] = byte(data)
-	c.memory[addr+1] = byte(data >> 8)
-	c.memory[addr+2] = byte(data >> 16)
-	c.memory[addr+3] = byte(data >> 24)
+		for way := 0; way < L1Associativity; way++ {
+			if c.dcache.sets[setIdx][way].Valid && c.dcache.sets[setIdx][way].Tag == tag {
+				inCache = true
+				break
+			}
+		}
+
+		// Already cached: retire the request so the queue keeps draining
+		if inCache {
+			c.dcache.prefetchQueue.Complete(prefetchAddr)
+		}
+
+		// Fetch if not in cache
+		if !inCache {
+			c.dprefetch = c.arbiter.Submit(prefetchAddr, MemDataPrefetch)
+		}
+	}
 }
 
-// Cycle executes one clock cycle (THE MAIN EXECUTION LOOP!)
+// EnableIndirectPrediction turns JALR target prediction from the L1I
+// indirect table on (the default) or off (non-return JALR predicts PC+4)
+func (c *Core) EnableIndirectPrediction(on bool) {
+	if on {
+		c.branchPred.SetIndirectPredictor(c.icache)
+	} else {
+		c.branchPred.SetIndirectPredictor(nil)
+	}
+}
+
+// GetIndirectMispredictRate returns the fraction of committed JALRs
+// whose target was mispredicted
+func (c *Core) GetIndirectMispredictRate() float64 {
+	if c.indirectJumps == 0 {
+		return 0
+	}
+	return float64(c.indirectMispreds) / float64(c.indirectJumps)
+}
+
+// resolveAtExecute repairs a mispredicted branch the cycle it executes
 //
-// ALGORITHM: 7 stages execute simultaneously
+// ALGORITHM:
 //
-// CRITICAL NOTE: All stages run in PARALLEL in real hardware!
+//	STEP 1: Compare the computed outcome with the prediction
+//	STEP 2: If wrong: Squash only younger entries (age = window order)
+//	        and restore the RAT from the branch's checkpoint
+//	STEP 3: Cancel execution-unit work for squashed entries
+//	STEP 4: Redirect fetch to the correct path immediately
+//
+// Commit still counts the mispredict and trains the predictor, so
+// statistics match commit-time recovery. Returns true on a squash.
+func (c *Core) resolveAtExecute(winID int, entry *WindowEntry) bool {
+	// STEP 1: Was the prediction right?
+	if entry.BranchTaken == entry.Predicted &&
+		(!entry.BranchTaken || entry.BranchTarget == entry.PredictedAddr) {
+		return false
+	}
+
+	// STEP 2-3: Selective squash (INNOVATION #48)
+	entry.Recovered = true
+	c.window.SquashAfter(winID)
+	c.squashUnits()
+
+	// STEP 4: Correct path
+	target := entry.PC + 4
+	if entry.BranchTaken {
+		target = entry.BranchTarget
+	}
+	c.redirect(target, entry.ResolveCycle, entry.RSB)
+	return true
+}
+
+// fetch reads up to FetchWidth instructions into the fetch queue and
+// returns why it stopped (see FETCH BANDWIDTH)
 //
-//	In this simulation, we run them sequentially
-//	but the logic is designed for parallel execution
+// ALGORITHM:
 //
-// MINECRAFT ANALOGY: All 7 crafting stations work simultaneously
-func (c *Core) Cycle() {
-	c.cycles++
+//	FOR each instruction (up to FetchWidth):
+//	  STEP 1: Room in the fetch queue? FTQ block ready (decoupled)?
+//	  STEP 2: Read the L1I (miss: request the line and stop)
+//	  STEP 3: Decode, queue, pick the next PC (FTQ block or predictor)
+//	  STEP 4: Taken? Charge the taken-branch budget, and a line read if
+//	          the target is in another line. Sequential? Stop at the
+//	          end of the aligned block
+func (c *Core) fetch() FetchStop {
+	blockMask := uint32(c.cfg.FetchBlockBytes - 1)
+	line := c.pc / CacheLineSize
+	lines, taken := 1, 0
+
+	for n := 0; n < c.cfg.FetchWidth; n++ {
+		// STEP 1: Somewhere to put it, and (decoupled) a block to follow
+		if c.frontend.QueueFull() {
+			return FetchStopQueueFull
+		}
+		var block *FetchBlock
+		if c.ftq != nil {
+			var ok bool
+			if block, ok = c.ftq.Head(); !ok {
+				return FetchStopFTQ // Just redirected
+			}
+		}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 1: COMMIT (INNOVATION #45, #47, #48)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Retire completed instructions in program order
-	// This makes speculative work permanent
-	// Check for branch mispredictions and recover
-	//
-	// INNOVATION #45: 4-wide commit (retire up to 4 per cycle)
-	// INNOVATION #47: Program-order commit (precise exceptions)
-	// INNOVATION #48: Branch mispredict recovery (flush on wrong prediction)
+		// STEP 2: Line already on its way from memory: keep waiting
+		// (catching up with a prefetch counts it as late)
+		if c.ifill.Pending(c.pc) {
+			c.ifill.RequestDemand(c.pc)
+			c.fetchStallCycles++
+			return FetchStopMiss
+		}
 
-	for i := 0; i < CommitWidth; i++ {
-		committed := c.window.Commit()
-		if committed == nil {
-			break // No more ready to commit
+		// INNOVATION #21-28: Quad-buffered L1I with smart prefetch
+		word, hit := c.icache.Read(c.pc)
+		if !hit {
+			// Cache miss - request the line (DRAMLatency cycles away)
+			// and stall fetch until it lands
+			c.ifill.RequestDemand(c.pc)
+			c.fetchStallCycles++
+			return FetchStopMiss
 		}
 
-		c.instructions++
+		// STEP 3: INNOVATION #5: Single-cycle decode
+		pc := c.pc
+		inst := DecodeInstruction(word, pc)
+		next := pc + 4
+
+		if block != nil {
+			// Decoupled frontend: follow the block the FTQ predicted
+			inst.RSB = block.RSBBefore
+			if block.HasBranch && pc == block.BranchPC {
+				inst.HasPrediction = true
+				inst.PredTaken = block.PredTaken
+				inst.PredTarget = block.Next
+				inst.RSB = block.RSBAfter
+			}
+			if pc+4 >= block.End {
+				next = block.Next
+				c.ftq.Pop()
+			}
+		} else if inst.IsBranch || inst.IsJump {
+			// INNOVATION #29-33: Predict branch/jump target
+			next = c.branchPred.PredictTarget(pc, inst)
+
+			// INNOVATION #22, #32: Confidence-based prefetch
+			predTaken, conf := c.branchPred.Predict(pc)
+			// Convert 4-bit confidence (0-15) to float32 (0.0-1.0)
+			confFloat := float32(conf) / 15.0
+			c.icache.TriggerBranchTargetPrefetch(next, confFloat)
+
+			// Dispatch records this prediction (jumps are always taken)
+			inst.HasPrediction = true
+			inst.PredTaken = predTaken || inst.IsJump
+			inst.PredTarget = next
+		}
 
-		// Check branches for misprediction (INNOVATION #48)
-		if committed.IsBranch || committed.Opcode == OpJAL || committed.Opcode == OpJALR {
-			c.branches++
+		c.frontend.Fetch(inst)
+		c.pc = next
 
-			actualTaken := committed.BranchTaken
-			actualTarget := committed.BranchTarget
+		// STEP 4: Block boundaries
+		if next != pc+4 {
+			taken++
+			if taken >= c.cfg.TakenBranchesPerCycle {
+				return FetchStopTaken
+			}
+			if next/CacheLineSize != line {
+				if lines >= c.cfg.FetchLinesPerCycle {
+					return FetchStopLine
+				}
+				lines++
+				line = next / CacheLineSize
+			}
+		} else if next&blockMask == 0 {
+			return FetchStopBlockEnd
+		}
+	}
 
-			// Compare prediction to reality
-			if actualTaken != committed.Predicted ||
-				(actualTaken && actualTarget != committed.PredictedAddr) {
+	return FetchStopWidth
+}
 
-				// MISPREDICT! (INNOVATION #48: Recovery)
-				c.branchMispredicts++
+// squashUnits cancels execution-unit work whose window entry is gone
+//
+// Must run before dispatch can reuse the freed slots, or a late
+// result would complete the new occupant
+func (c *Core) squashUnits() {
+	c.multiplier.Squash(c.window.IsValid)
+	c.divider.Squash(c.window.IsValid)
+	for _, lsu := range c.lsus {
+		if id := lsu.WindowID(); id >= 0 && !c.window.IsValid(id) {
+			lsu.Cancel()
+		}
+	}
+}
 
-				// Flush all speculative work
-				c.window.Flush()
-				c.fetchBuffer = c.fetchBuffer[:0]
-				c.icache.Flush()
+// redirect restarts fetch at pc after a mispredict resolved at resolveCycle
+// (rsb is the mispredicted instruction's RSB checkpoint, FTQ frontend only)
+func (c *Core) redirect(pc uint32, resolveCycle uint64, rsb RSBCheckpoint) {
+	c.frontend.Flush()
+	c.icache.Flush()
+	c.pc = pc
+	if c.ftq != nil {
+		c.ftq.Redirect(pc, c.branchPred, rsb)
+	}
 
-				// Restart from correct path
-				if actualTaken {
-					c.pc = actualTarget
-				} else {
-					c.pc = committed.PC + 4
-				}
+	// Penalty = wait for recovery + refill until the next dispatch
+	if c.refillPending {
+		c.refillCycles += c.cycles - c.redirectCycle // Redirected again before refilling
+	}
+	c.recoveries++
+	c.resolveWait += c.cycles - resolveCycle
+	c.refillPending = true
+	c.redirectCycle = c.cycles
+}
 
-				// Update branch predictor (learn from mistake)
-				c.branchPred.Update(committed.PC, actualTaken)
+// SetScheduler replaces the issue policy (see ISSUE SCHEDULING POLICY)
+//
+// Set before Run: the policy must see every instruction enter the window
+func (c *Core) SetScheduler(s Scheduler) error {
+	return c.window.SetScheduler(s)
+}
 
-				// Notify L1I about branch resolution (INNOVATION #23)
-				c.icache.NotifyBranchResolved(committed.PC, actualTaken, actualTarget)
+// EnableEarlyRecovery switches mispredict recovery between commit time
+// (full flush when the branch retires) and execute time (selective
+// squash and redirect as soon as the branch computes its outcome)
+//
+// Commit time is the default; early_recovery = 1 selects execute time
+func (c *Core) EnableEarlyRecovery(on bool) {
+	c.earlyRecovery = on
+}
 
-				return // Restart pipeline
-			}
+// GetMispredictPenalty returns the average cycles per mispredict spent
+// waiting for recovery and refilling the pipeline afterwards
+func (c *Core) GetMispredictPenalty() (resolveWait, refill float64) {
+	if c.recoveries == 0 {
+		return 0, 0
+	}
+	n := float64(c.recoveries)
+	return float64(c.resolveWait) / n, float64(c.refillCycles) / n
+}
+
+// SetMemoryPorts configures the shared memory arbiter
+//
+// ports is the number of DRAM requests started per cycle; policy
+// decides whose request goes first. Set before Run.
+func (c *Core) SetMemoryPorts(ports int, policy ArbiterPolicy) {
+	c.arbiter.Ports = ports
+	c.arbiter.Policy = policy
+}
+
+// EnableFDIP switches the frontend between coverage prefetch and a
+// fetch target queue driven by the run-ahead branch predictor
+//
+// Set before LoadProgram or Run; the queue starts at the current PC.
+func (c *Core) EnableFDIP(on bool) {
+	if on {
+		c.ftq = NewFetchTargetQueue(c.pc)
+	} else {
+		c.ftq = nil
+	}
+}
+
+// EnableValuePrediction turns load value prediction on or off
+//
+// Turning it on starts with an empty LoadValuePredictor; turning it
+// off drops it. Set before Run so every load is trained.
+func (c *Core) EnableValuePrediction(on bool) {
+	if on {
+		c.valuePred = NewLoadValuePredictor()
+	} else {
+		c.valuePred = nil
+	}
+}
+
+// verifyValuePrediction checks a finished load against its predicted value
+//
+// ALGORITHM:
+//
+//	STEP 1: Score the prediction (if the load had one)
+//	STEP 2: Wrong and already consumed? Mark for flush at commit, or
+//	        (early recovery) squash younger entries and refetch now
+//	STEP 3: Train the value predictor with the real value
+func (c *Core) verifyValuePrediction(winID int, data uint32) {
+	entry := c.window.GetEntry(winID)
+	if entry == nil || !entry.Valid || !entry.IsLoad {
+		return
+	}
 
-			// Correct prediction! Update predictor (reinforce learning)
-			c.branchPred.Update(committed.PC, actualTaken)
+	// STEP 1-2: Verify
+	if entry.ValuePredicted {
+		correct := data == entry.PredictedValue
+		c.valuePred.Resolve(entry.ValueKind, correct)
 
-			// Notify L1I (INNOVATION #23, #28)
-			c.icache.NotifyBranchResolved(committed.PC, actualTaken, actualTarget)
+		if !correct && c.window.ConsumedEarly(winID) {
+			entry.ValueMispredicted = true
+			entry.ResolveCycle = c.cycles
 
-			// If this was a return, notify for RSB integration (INNOVATION #28)
-			if committed.Opcode == OpJALR && committed.Rs1 == 1 {
-				c.icache.NotifyReturn(committed.PC, actualTarget)
+			// Same repair as resolveAtExecute: the load itself is right
+			if c.earlyRecovery {
+				entry.Recovered = true
+				c.window.SquashAfter(winID)
+				c.squashUnits()
+				c.redirect(entry.PC+4, entry.ResolveCycle, entry.RSB)
 			}
 		}
 	}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 2: COMPLETE (INNOVATION #55: Result forwarding)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Collect results from all execution units
-	// Forward results to waiting instructions immediately
-	//
-	// INNOVATION #55: Results forwarded as soon as available
-	//                 Don't wait for commit!
+	// STEP 3: Train
+	c.valuePred.Update(entry.PC, data)
+}
+
+// SetBranchTrace starts recording every retired branch to t (nil stops)
+//
+// Records are written at commit, in program order, for correct-path
+// branches only. Call t.Flush() after the run.
+//
+// USED BY: Predictor studies (replay with proto/bptrace)
+func (c *Core) SetBranchTrace(t *BranchTraceWriter) {
+	c.branchTrace = t
+	c.lastTracedInstrs = c.instructions
+}
 
-	// Check multiplier (INNOVATION #12: 1-cycle multiply)
-	if result, winID, valid := c.multiplier.GetResult(); valid {
-		c.window.Complete(winID, result)
+// Run executes for the specified number of cycles
+//
+// ALGORITHM:
+//
+//	FOR each cycle until limit:
+//	  Execute one cycle
+//
+// USED BY: Benchmark and test programs
+func (c *Core) Run(maxCycles uint64) {
+	for c.cycles < maxCycles {
+		c.Cycle()
 	}
+}
 
-	// Check divider (INNOVATION #16: 4-cycle divide)
-	if result, winID, valid := c.divider.GetResult(); valid {
-		c.window.Complete(winID, result)
+// GetIPC returns instructions per cycle (key performance metric)
+//
+// IPC (Instructions Per Cycle):
+//
+//	Higher is better
+//	1.0 = one instruction per cycle (baseline)
+//	4.0 = four instructions per cycle (superscalar)
+//	Our target: ~4.15 IPC (competitive with Intel)
+func (c *Core) GetIPC() float64 {
+	if c.cycles == 0 {
+		return 0
 	}
+	return float64(c.instructions) / float64(c.cycles)
+}
 
-	// Check LSUs (INNOVATION #69: 2 independent LSUs)
-	for _, lsu := range c.lsus {
-		if data, _, winID, valid := lsu.GetResult(); valid {
-			c.window.Complete(winID, data)
-		}
+// GetFetchStallRate returns the fraction of cycles fetch spent waiting
+// on an instruction line fill
+func (c *Core) GetFetchStallRate() float64 {
+	if c.cycles == 0 {
+		return 0
 	}
+	return float64(c.fetchStallCycles) / float64(c.cycles)
+}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 3: EXECUTE (INNOVATION #73: Variable latency)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Advance multi-cycle operations
-	// Divider: Newton-Raphson iterations (INNOVATION #13-15)
-	// LSUs: Cache access or DRAM wait (INNOVATION #70, #73)
+// GetDispatchStallRates returns the fraction of cycles dispatch stopped
+// on a full window and on an empty free list
+//
+// Free-list stalls with window room to spare mean too few physical
+// registers for the window (INNOVATION #39)
+func (c *Core) GetDispatchStallRates() (windowFull, freeList float64) {
+	if c.cycles == 0 {
+		return 0, 0
+	}
+	w, f := c.window.GetDispatchStalls()
+	return float64(w) / float64(c.cycles), float64(f) / float64(c.cycles)
+}
 
-	c.divider.Tick()
-	for _, lsu := range c.lsus {
-		lsu.Tick()
+// GetValuePredictionStats summarizes load value prediction (empty if off)
+func (c *Core) GetValuePredictionStats() string {
+	vp := c.valuePred
+	if vp == nil {
+		return ""
 	}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 4: ISSUE (INNOVATION #43: 6-wide issue)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Send ready instructions to execution units
-	// Select up to 6 instructions per cycle
-	//
-	// INNOVATION #40-42: Wakeup and select
-	//   Wakeup: Bitmap-based (44× cheaper than CAM)
-	//   Select: Age-based priority (oldest first)
-	//   Issue: Up to 6 per cycle
+	var b strings.Builder
+	fmt.Fprintf(&b, "LOAD VALUE PREDICTION (%d loads):\n", vp.loads)
+	fmt.Fprintf(&b, "  Coverage:            %.2f%% of loads predicted correctly\n", vp.GetCoverage()*100)
+	fmt.Fprintf(&b, "  Accuracy:            %.2f%% of predictions correct\n", vp.GetAccuracy()*100)
+	fmt.Fprintf(&b, "  Flushes:             %d (wrong value already consumed)\n", vp.flushes)
+	for k := ValuePredLast; k < NumValuePredKinds; k++ {
+		st := vp.kinds[k]
+		fmt.Fprintf(&b, "  %-8s %9d predicted %9d correct\n", k, st.Predicted, st.Correct)
+	}
+	return b.String()
+}
 
-	readyList := c.window.SelectReady() // INNOVATION #42: Age-based
-	lsuIdx := 0                         // Track which LSU to use
+// GetStats returns comprehensive performance statistics
+//
+// Shows all key metrics:
+//   - IPC (instructions per cycle)
+//   - Branch prediction accuracy
+//   - Cache hit rates
+//   - Predictor accuracy
+//   - Resource utilization
+func (c *Core) GetStats() string {
+	ipc := c.GetIPC()
 
-	for _, winID := range readyList {
-		entry := c.window.GetEntry(winID)
-		if entry == nil {
-			continue
-		}
+	branchAccuracy := float64(0)
+	if c.branches > 0 {
+		branchAccuracy = float64(c.branches-c.branchMispredicts) / float64(c.branches) * 100
+	}
 
-		// Read operands from register file
-		op1 := c.window.ReadReg(entry.Rs1, entry.PhysRs1)
-		op2 := c.window.ReadReg(entry.Rs2, entry.PhysRs2)
+	windowStalls, freeListStalls := c.window.GetDispatchStalls()
+	windowStallRate, freeListStallRate := c.GetDispatchStallRates()
+	elimMoves, elimZeros := c.window.GetEliminated()
 
-		// For I-format, use immediate as second operand
-		if entry.Opcode >= 0x10 && entry.Opcode != OpBEQ && entry.Opcode != OpBNE &&
-			entry.Opcode != OpBLT && entry.Opcode != OpBGE {
-			op2 = uint32(entry.Imm)
+	percents := func(fracs []float64) string {
+		var b strings.Builder
+		for u, f := range fracs {
+			if u > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%.1f%%", f*100)
 		}
+		return b.String()
+	}
 
-		issued := false
+	var fetchStops strings.Builder
+	for r := FetchStop(0); r < NumFetchStops; r++ {
+		fmt.Fprintf(&fetchStops, "    %-17s %5.1f%%\n", r.String()+":", c.frontend.GetStopRate(r)*100)
+	}
 
-		// Dispatch to appropriate execution unit
-		switch entry.Opcode {
-		case OpMUL:
-			// INNOVATION #57: 1-cycle multiply
-			if !c.multiplier.IsBusy() {
-				c.multiplier.Issue(winID, op1, op2, false)
-				issued = true
-			}
+	return fmt.Sprintf(`
+╔═══════════════════════════════════════════════════════════════════════════╗
+║                    SUPRAX-32 PERFORMANCE STATISTICS                       ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		case OpMULH:
-			// INNOVATION #57: 1-cycle multiply (high bits)
-			if !c.multiplier.IsBusy() {
-				c.multiplier.Issue(winID, op1, op2, true)
-				issued = true
-			}
+EXECUTION METRICS:
+  Cycles:              %d
+  Instructions:        %d
+  IPC:                 %.3f (Target: 4.15)
 
-		case OpDIV:
-			// INNOVATION #58: 4-cycle divide
-			if !c.divider.Busy {
-				c.divider.StartDivision(op1, op2, winID, false)
-				issued = true
-			}
+BRANCH PREDICTION:
+  Total Branches:      %d
+  Mispredictions:      %d
+  Accuracy:            %.2f%% (INNOVATION #29-33)
 
-		case OpREM:
-			// INNOVATION #58: 4-cycle remainder
-			if !c.divider.Busy {
-				c.divider.StartDivision(op1, op2, winID, true)
-				issued = true
-			}
+MEMORY OPERATIONS:
+  Loads:               %d (30%% of instructions)
+  Stores:              %d
 
-		case OpLW, OpLR:
-			// INNOVATION #69-73: Load operation
-			if lsuIdx < NumLSUs && !c.lsus[lsuIdx].IsBusy() {
-				// INNOVATION #7: Carry-select adder for address
-				addr := Add32(op1, uint32(entry.Imm))
+FETCH BANDWIDTH:
+  Fetched per Cycle:   %.2f (width %d, %d-byte blocks, %d taken/cycle)
+  Fetch Stopped By:
+%s
+CACHE PERFORMANCE:
+  L1I Hit Rate:        %.2f%% (INNOVATION #21-28: Quad-buffer)
+  I-Miss Stalls:       %d cycles (%.1f%% of cycles)
+  L1D Hit Rate:        %.2f%% (INNOVATION #18-20)
+  L1D Predictor Acc:   %.2f%% (INNOVATION #59: 5-way predictor)
 
-				c.lsus[lsuIdx].Issue(MemoryOperation{
-					PC:       entry.PC,
-					Addr:     addr,
-					Rd:       entry.Rd,
-					WindowID: winID,
-					IsStore:  false,
-					IsLR:     entry.Opcode == OpLR, // INNOVATION #71
-				})
-				lsuIdx++
-				issued = true
-				c.loads++
-			}
+RESOURCE UTILIZATION:
+  Window Fill:         %.1f%% (%d/%d entries) (INNOVATION #34)
+  Out-of-Order Depth:  %d instructions
+  Window-Full Stalls:  %d cycles (%.1f%% of cycles)
+  Free-List Stalls:    %d cycles (%.1f%% of cycles) (INNOVATION #38-39)
+  Eliminated:          %d moves, %d zero idioms at rename (INNOVATION #37)
+  MUL Utilization:     %s per unit (INNOVATION #57)
+  MUL Result Waits:    %d (result bus: %d per cycle)
+  DIV Utilization:     %s per unit (INNOVATION #58)
+  DIV Early-Out:       %.1f%% of divides (latency %d)
+  Frontend Depth:      %d cycles fetch to dispatch
+  Dispatch Starved:    %d cycles (%.1f%% of cycles)
 
-		case OpSW, OpSC:
-			// INNOVATION #69-73: Store operation
-			if lsuIdx < NumLSUs && !c.lsus[lsuIdx].IsBusy() {
-				addr := Add32(op1, uint32(entry.Imm))
-				storeData := c.window.ReadReg(entry.Rs2, entry.PhysRs2)
+INNOVATION SUMMARY:
+  Total Innovations:   73 (across 7 tiers)
+  Transistors:         22.1M (Intel: 26,000M)
+  Simplicity Factor:   1,175× simpler than Intel
+  Efficiency:          %.3f IPC per million transistors
+  Intel Efficiency:    %.6f IPC per million transistors
+  Our Advantage:       %.0f× more efficient! 🎯
 
-				c.lsus[lsuIdx].Issue(MemoryOperation{
-					PC:       entry.PC,
-					Addr:     addr,
-					Data:     storeData,
-					Rd:       entry.Rd,
-					WindowID: winID,
-					IsStore:  true,
-					IsAtomic: entry.Opcode == OpSC, // INNOVATION #71
-				})
-				lsuIdx++
-				issued = true
-				c.stores++
-			}
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  "Maximum Courage, Minimum Bloat" - Simple, Fast, Efficient              ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+`,
+		c.cycles,
+		c.instructions,
+		ipc,
+		c.branches,
+		c.branchMispredicts,
+		branchAccuracy,
+		c.loads,
+		c.stores,
+		c.frontend.GetFetchRate(),
+		c.cfg.FetchWidth,
+		c.cfg.FetchBlockBytes,
+		c.cfg.TakenBranchesPerCycle,
+		fetchStops.String(),
+		c.icache.GetHitRate()*100,
+		c.fetchStallCycles,
+		c.GetFetchStallRate()*100,
+		c.dcache.GetHitRate()*100,
+		c.dcache.GetPredictorAccuracy()*100,
+		float64(c.window.GetCount())/float64(c.cfg.WindowSize)*100,
+		c.window.GetCount(),
+		c.cfg.WindowSize,
+		c.window.GetCount(),
+		windowStalls,
+		windowStallRate*100,
+		freeListStalls,
+		freeListStallRate*100,
+		elimMoves,
+		elimZeros,
+		percents(c.multiplier.GetUtilization(c.cycles)),
+		c.multiplier.BusWaits,
+		c.cfg.MulResultPorts,
+		percents(c.divider.GetUtilization(c.cycles)),
+		c.divider.GetEarlyOutRate()*100,
+		c.cfg.DivLatency,
+		c.frontend.Depth(),
+		c.frontend.Starved,
+		c.frontend.GetStarvedRate()*100,
+		ipc/22.1,                 // Our efficiency
+		4.3/26000.0,              // Intel efficiency
+		(ipc/22.1)/(4.3/26000.0), // Advantage
+	)
+}
 
-		case OpBEQ, OpBNE, OpBLT, OpBGE:
-			// Branch evaluation
-			taken := EvaluateBranch(entry.Opcode, op1, op2)
-			target := uint32(int32(entry.PC) + entry.Imm)
-			entry.BranchTaken = taken
-			entry.BranchTarget = target
-			c.window.Complete(winID, 0) // Complete with dummy result
-			issued = true
+// ═══════════════════════════════════════════════════════════════════════════════
+// HELPER FUNCTIONS FOR TESTING AND BENCHMARKING
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// EncodeRFormat creates an R-format instruction
+//
+// R-FORMAT: [opcode:5][rd:5][rs1:5][rs2:5][funct:12]
+func EncodeRFormat(opcode, rd, rs1, rs2 uint8) uint32 {
+	return (uint32(opcode) << 27) |
+		(uint32(rd) << 22) |
+		(uint32(rs1) << 17) |
+		(uint32(rs2) << 12)
+}
+
+// EncodeRFormatFunct creates an R-format instruction with funct bits
+//
+// Example: EncodeRFormatFunct(OpDIV, rd, rs1, rs2, FunctUnsigned) is DIVU
+func EncodeRFormatFunct(opcode, rd, rs1, rs2 uint8, funct uint16) uint32 {
+	return EncodeRFormat(opcode, rd, rs1, rs2) | uint32(funct&0xFFF)
+}
+
+// EncodeIFormat creates an I-format instruction
+//
+// I-FORMAT: [opcode:5][rd:5][rs1:5][immediate:17]
+func EncodeIFormat(opcode, rd, rs1 uint8, imm int32) uint32 {
+	return (uint32(opcode) << 27) |
+		(uint32(rd) << 22) |
+		(uint32(rs1) << 17) |
+		(uint32(imm) & 0x1FFFF)
+}
+
+// EncodeBFormat creates a B-format instruction
+//
+// B-FORMAT: [opcode:5][rs2:5][rs1:5][immediate:17]
+// Note: rd field is reused for rs2 (INNOVATION #4)
+func EncodeBFormat(opcode, rs1, rs2 uint8, imm int32) uint32 {
+	return (uint32(opcode) << 27) |
+		(uint32(rs2) << 22) |
+		(uint32(rs1) << 17) |
+		(uint32(imm) & 0x1FFFF)
+}
+
+// CreateSimpleProgram creates a test program
+//
+// Simple program that exercises all components:
+//   - ALU operations (INNOVATION #56)
+//   - Multiply (INNOVATION #57)
+//   - Divide (INNOVATION #58)
+//   - Loads/Stores (INNOVATION #69)
+//   - Branches (INNOVATION #29-33)
+func CreateSimpleProgram() []uint32 {
+	return []uint32{
+		// Initialize registers
+		EncodeIFormat(OpADDI, 1, 0, 10), // r1 = 10
+		EncodeIFormat(OpADDI, 2, 0, 20), // r2 = 20
 
-		case OpJAL:
-			// Jump and link (function call)
-			result := entry.PC + 4 // Return address
-			target := uint32(int32(entry.PC) + entry.Imm)
+		// ALU operations (INNOVATION #56)
+		EncodeRFormat(OpADD, 3, 1, 2), // r3 = r1 + r2 (30)
+		EncodeRFormat(OpSUB, 4, 2, 1), // r4 = r2 - r1 (10)
 
-			// INNOVATION #31: Push return address to RSB
-			c.branchPred.PushRSB(result)
+		// Multiply (INNOVATION #57: 1-cycle)
+		EncodeRFormat(OpMUL, 5, 1, 2), // r5 = r1 * r2 (200)
 
-			entry.BranchTaken = true
-			entry.BranchTarget = target
-			c.window.Complete(winID, result)
-			issued = true
+		// Divide (INNOVATION #58: 4-cycle)
+		EncodeRFormat(OpDIV, 6, 2, 1), // r6 = r2 / r1 (2)
 
-		case OpJALR:
-			// Jump and link register (return or indirect jump)
-			result := entry.PC + 4
-			target := Add32(op1, uint32(entry.Imm)) &^ 1 // Clear LSB
+		// Load/Store (INNOVATION #69)
+		EncodeIFormat(OpSW, 0, 1, 0x2000), // Store r1 to [0x2000]
+		EncodeIFormat(OpLW, 7, 0, 0x2000), // Load r7 from [0x2000]
 
-			entry.BranchTaken = true
-			entry.BranchTarget = target
-			c.window.Complete(winID, result)
-			issued = true
+		// Branch (INNOVATION #29-33)
+		EncodeBFormat(OpBEQ, 1, 7, 8),   // if r1 == r7, skip ahead
+		EncodeIFormat(OpADDI, 8, 0, 99), // r8 = 99 (skipped)
+		EncodeIFormat(OpADDI, 9, 0, 1),  // r9 = 1 (executed)
 
-		default:
-			// INNOVATION #56: ALU operations (single-cycle)
-			result := ALUExecute(entry.Opcode, op1, op2)
-			c.window.Complete(winID, result)
-			issued = true
-		}
+		// Loop example
+		EncodeIFormat(OpADDI, 10, 0, 0), // r10 = 0 (counter)
+		// Loop start:
+		EncodeIFormat(OpADDI, 10, 10, 1), // r10++
+		EncodeBFormat(OpBLT, 10, 1, -4),  // if r10 < r1, loop
 
-		if issued {
-			c.window.MarkIssued(winID)
-		}
+		// End
+		EncodeIFormat(OpADDI, 11, 0, 42), // r11 = 42 (done)
 	}
+}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 5: DISPATCH (INNOVATION #44: 4-wide dispatch)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Move decoded instructions from fetch buffer to window
-	// Allocate physical registers (INNOVATION #36-39)
-	// Track dependencies (INNOVATION #52-53)
-
-	dispatched := 0
-	for dispatched < DispatchWidth && len(c.fetchBuffer) > 0 && c.window.CanDispatch() {
-		inst := c.fetchBuffer[0]
-		c.fetchBuffer = c.fetchBuffer[1:]
+// ═══════════════════════════════════════════════════════════════════════════════
+// BENCHMARK PROGRAMS AND TESTING
+// ═══════════════════════════════════════════════════════════════════════════════
 
-		// INNOVATION #36-39: Register renaming
-		winID, ok := c.window.Dispatch(inst)
-		if !ok {
-			// Failed to dispatch (no resources)
-			// Put back in buffer
-			c.fetchBuffer = append([]Instruction{inst}, c.fetchBuffer...)
-			break
-		}
+// CreateArraySumProgram creates a program that tests stride predictor
+//
+// TESTS: INNOVATION #60 (Stride predictor)
+//
+// CODE:
+//
+//	sum = 0
+//	for (i=0; i<100; i++)
+//	  sum += array[i]
+//
+// EXPECTED BEHAVIOR:
+//   - Stride predictor detects +4 offset pattern
+//   - Prefetches array elements ahead of time
+//   - Should achieve ~95%+ L1D hit rate
+func CreateArraySumProgram() []uint32 {
+	program := []uint32{
+		// Initialize
+		EncodeIFormat(OpADDI, 1, 0, 0),      // r1 = 0 (sum)
+		EncodeIFormat(OpADDI, 2, 0, 0),      // r2 = 0 (i)
+		EncodeIFormat(OpADDI, 3, 0, 100),    // r3 = 100 (limit)
+		EncodeIFormat(OpADDI, 4, 0, 0x3000), // r4 = array base
 
-		entry := c.window.GetEntry(winID)
-		if entry != nil {
-			// Store branch predictions
-			if inst.IsBranch || inst.IsJump {
-				// INNOVATION #29-32: Branch prediction
-				predicted, _ := c.branchPred.Predict(inst.PC)
-				predTarget := c.branchPred.PredictTarget(inst.PC, inst)
-				entry.Predicted = predicted
-				entry.PredictedAddr = predTarget
-			}
+		// Loop: (PC = 0x1010)
+		EncodeRFormat(OpADD, 5, 4, 2),   // r5 = array + i
+		EncodeIFormat(OpLW, 6, 5, 0),    // r6 = array[i]
+		EncodeRFormat(OpADD, 1, 1, 6),   // sum += array[i]
+		EncodeIFormat(OpADDI, 2, 2, 4),  // i += 4
+		EncodeBFormat(OpBLT, 2, 3, -16), // if i < 100, loop
 
-			// For jumps, always predict taken
-			if inst.IsJump {
-				entry.Predicted = true
-				entry.PredictedAddr = c.branchPred.PredictTarget(inst.PC, inst)
-			}
+		// End
+		EncodeIFormat(OpADDI, 7, 0, 42), // r7 = 42 (done marker)
+	}
+	return program
+}
 
-			// Query L1D predictor for loads (INNOVATION #59)
-			if inst.IsLoad {
-				predAddr, predictor, valid := c.dcache.predictor.Predict(inst.PC)
-				if valid {
-					entry.PredictedMemAddr = predAddr
-					entry.MemPredictor = predictor
-					entry.HasMemPrediction = true
+// CreateLinkedListProgram creates a program that tests Markov predictor
+//
+// TESTS: INNOVATION #61 (Markov predictor)
+//
+// CODE:
+//
+//	node = head
+//	while (node != NULL)
+//	  node = node->next
+//
+// EXPECTED BEHAVIOR:
+//   - Markov predictor learns the list traversal sequence
+//   - After first traversal, should predict subsequent traversals
+//   - Should achieve ~90%+ hit rate on second+ traversals
+func CreateLinkedListProgram() []uint32 {
+	program := []uint32{
+		// Setup linked list in memory at 0x4000
+		// Each node: [data:4 bytes][next:4 bytes]
+		EncodeIFormat(OpADDI, 1, 0, 0x4000), // r1 = head
 
-					// INNOVATION #67-68: Queue prefetch with deduplication
-					c.dcache.prefetchQueue.Enqueue(predAddr, predictor)
-				}
-			}
-		}
+		// Traverse loop:
+		EncodeIFormat(OpLW, 2, 1, 0),   // r2 = node->data
+		EncodeIFormat(OpLW, 1, 1, 4),   // r1 = node->next
+		EncodeBFormat(OpBNE, 1, 0, -8), // if node != NULL, loop
 
-		dispatched++
+		// End
+		EncodeIFormat(OpADDI, 3, 0, 42), // r3 = 42 (done)
 	}
+	return program
+}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 6: FETCH (INNOVATION #21-28, #5-6)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Get instructions from L1I cache
-	// Decode instructions (INNOVATION #5)
-	// Predict branches (INNOVATION #29-33)
-	// Fill fetch buffer
-
-	if len(c.fetchBuffer) < c.fetchBufferMax {
-		for i := 0; i < DispatchWidth && len(c.fetchBuffer) < c.fetchBufferMax; i++ {
-			// INNOVATION #21-28: Quad-buffered L1I with smart prefetch
-			word, hit := c.icache.Read(c.pc)
-
-			if !hit {
-				// Cache miss - fetch from memory
-				lineAddr := c.pc &^ (CacheLineSize - 1)
-				lineData := make([]byte, CacheLineSize)
-
-				// In real hardware, this triggers DRAM access
-				// In simulation, we fetch immediately
-				for j := 0; j < CacheLineSize; j++ {
-					if int(lineAddr)+j < len(c.memory) {
-						lineData[j] = c.memory[lineAddr+uint32(j)]
-					}
-				}
-
-				c.icache.Fill(lineAddr, lineData)
+// CreateMultiplyBenchmark tests the 1-cycle multiplier
+//
+// TESTS: INNOVATION #12 (1-cycle multiply)
+//
+// Performs 1000 multiplies to measure throughput
+func CreateMultiplyBenchmark() []uint32 {
+	program := []uint32{
+		// Initialize
+		EncodeIFormat(OpADDI, 1, 0, 123),  // r1 = 123
+		EncodeIFormat(OpADDI, 2, 0, 456),  // r2 = 456
+		EncodeIFormat(OpADDI, 3, 0, 0),    // r3 = 0 (counter)
+		EncodeIFormat(OpADDI, 4, 0, 1000), // r4 = 1000 (limit)
 
-				// Try again
-				word, hit = c.icache.Read(c.pc)
-				if !hit {
-					break // Still missing, wait
-				}
-			}
+		// Loop:
+		EncodeRFormat(OpMUL, 5, 1, 2),   // r5 = r1 * r2 (1 cycle!)
+		EncodeRFormat(OpMUL, 6, 2, 1),   // r6 = r2 * r1
+		EncodeRFormat(OpMUL, 7, 5, 6),   // r7 = r5 * r6
+		EncodeIFormat(OpADDI, 3, 3, 1),  // counter++
+		EncodeBFormat(OpBLT, 3, 4, -16), // if counter < 1000, loop
 
-			// INNOVATION #5: Single-cycle decode
-			inst := DecodeInstruction(word, c.pc)
-			c.fetchBuffer = append(c.fetchBuffer, inst)
-
-			// Update PC based on prediction
-			if inst.IsBranch || inst.IsJump {
-				// INNOVATION #29-33: Predict branch/jump target
-				predTarget := c.branchPred.PredictTarget(c.pc, inst)
-				c.pc = predTarget
-
-				// INNOVATION #22, #32: Confidence-based prefetch
-				_, conf := c.branchPred.Predict(inst.PC)
-				// Convert 4-bit confidence (0-15) to float32 (0.0-1.0)
-				confFloat := float32(conf) / 15.0
-				c.icache.TriggerBranchTargetPrefetch(predTarget, confFloat)
-			} else {
-				// Sequential execution
-				c.pc += 4
-			}
-		}
+		// End
+		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
 	}
+	return program
+}
 
-	// ═══════════════════════════════════════════════════════════════════════
-	// STAGE 7: PREFETCH (INNOVATION #17, #22, #27, #59, #67)
-	// ═══════════════════════════════════════════════════════════════════════
-	//
-	// Handle background prefetch requests
-	// This is KEY to our no-L2/L3 strategy!
-	//
-	// INNOVATION #17: No L2/L3 caches
-	//   We rely on intelligent prefetching instead
-	//   Saves 530M transistors! 🎯
-
-	// L1I prefetch (INNOVATION #22, #27)
-	if prefetchAddr, valid := c.icache.GetPrefetchAddr(); valid {
-		lineAddr := prefetchAddr &^ (CacheLineSize - 1)
-		lineData := make([]byte, CacheLineSize)
+// CreateDivideBenchmark tests the 4-cycle divider
+//
+// TESTS: INNOVATION #16 (4-cycle divide)
+//
+// Performs 100 divisions to measure throughput
+func CreateDivideBenchmark() []uint32 {
+	program := []uint32{
+		// Initialize
+		EncodeIFormat(OpADDI, 1, 0, 12345), // r1 = 12345
+		EncodeIFormat(OpADDI, 2, 0, 67),    // r2 = 67
+		EncodeIFormat(OpADDI, 3, 0, 0),     // r3 = 0 (counter)
+		EncodeIFormat(OpADDI, 4, 0, 100),   // r4 = 100 (limit)
 
-		for j := 0; j < CacheLineSize; j++ {
-			if int(lineAddr)+j < len(c.memory) {
-				lineData[j] = c.memory[lineAddr+uint32(j)]
-			}
-		}
+		// Loop:
+		EncodeRFormat(OpDIV, 5, 1, 2),   // r5 = r1 / r2 (4 cycles)
+		EncodeRFormat(OpREM, 6, 1, 2),   // r6 = r1 % r2 (4 cycles)
+		EncodeIFormat(OpADDI, 3, 3, 1),  // counter++
+		EncodeBFormat(OpBLT, 3, 4, -12), // if counter < 100, loop
 
-		c.icache.Fill(lineAddr, lineData)
+		// End
+		EncodeIFormat(OpADDI, 7, 0, 42), // r7 = 42 (done)
 	}
+	return program
+}
 
-	// L1D prefetch (INNOVATION #59, #67)
-	if prefetchAddr, valid := c.dcache.GetNextPrefetch(); valid {
-		// Check if already in cache
-		setIdx := c.dcache.getSetIndex(prefetchAddr)
-		tag := c.dcache.getTag(prefetchAddr)
-		inCache := false
+// CreateDivideMixBenchmark tests the divider pool's latency classes
+//
+// TESTS: INNOVATION #58 (divider pool), DIVU/REMU, RISC-V edge cases
+//
+// Each iteration issues five independent divides:
+//
+//	Signed, small quotient (early-out), unsigned, large quotient
+//	(full Newton-Raphson), and a division by zero (fast path)
+func CreateDivideMixBenchmark() []uint32 {
+	program := []uint32{
+		// Initialize
+		EncodeIFormat(OpADDI, 1, 0, -12345), // r1 = -12345
+		EncodeIFormat(OpADDI, 2, 0, 67),     // r2 = 67
+		EncodeIFormat(OpLUI, 8, 0, 0xABCD),  // r8 = 0x55E68000
+		EncodeIFormat(OpADDI, 3, 0, 0),      // r3 = 0 (counter)
+		EncodeIFormat(OpADDI, 4, 0, 100),    // r4 = 100 (limit)
 
-		for way := 0; way < L1Associativity; way++ {
-			if c.dcache.sets[setIdx][way].Valid && c.dcache.sets[setIdx][way].Tag == tag {
-				inCache = true
-				break
-			}
-		}
+		// Loop:
+		EncodeRFormat(OpDIV, 5, 1, 2),                      // r5 = -184 (early-out)
+		EncodeRFormatFunct(OpDIV, 6, 8, 2, FunctUnsigned),  // r6 = r8 / 67 (full)
+		EncodeRFormat(OpREM, 9, 1, 2),                      // r9 = -17 (early-out)
+		EncodeRFormatFunct(OpREM, 10, 8, 2, FunctUnsigned), // r10 = r8 % 67 (full)
+		EncodeRFormat(OpDIV, 11, 1, 0),                     // r11 = -1 (divide by zero)
+		EncodeIFormat(OpADDI, 3, 3, 1),                     // counter++
+		EncodeBFormat(OpBLT, 3, 4, -24),                    // if counter < 100, loop
 
-		// Fetch if not in cache
-		if !inCache {
-			lineAddr := prefetchAddr &^ (CacheLineSize - 1)
-			lineData := make([]byte, CacheLineSize)
+		// End
+		EncodeIFormat(OpADDI, 7, 0, 42), // r7 = 42 (done)
+	}
+	return program
+}
 
-			for j := 0; j < CacheLineSize; j++ {
-				if int(lineAddr)+j < len(c.memory) {
-					lineData[j] = c.memory[lineAddr+uint32(j)]
-				}
-			}
+// CreateMoveBenchmark tests rename-stage move elimination
+//
+// TESTS: INNOVATION #37 (move and zero-idiom elimination)
+//
+// Fibonacci by register shuffling: every iteration has one real add,
+// three moves and one zeroing idiom
+func CreateMoveBenchmark() []uint32 {
+	program := []uint32{
+		// Initialize
+		EncodeIFormat(OpADDI, 1, 0, 0),   // r1 = 0 (zero idiom)
+		EncodeIFormat(OpADDI, 2, 0, 1),   // r2 = 1
+		EncodeIFormat(OpADDI, 3, 0, 0),   // r3 = 0 (counter, zero idiom)
+		EncodeIFormat(OpADDI, 4, 0, 100), // r4 = 100 (limit)
 
-			c.dcache.Fill(lineAddr, lineData)
-		}
+		// Loop:
+		EncodeRFormat(OpADD, 5, 1, 2),   // r5 = r1 + r2
+		EncodeIFormat(OpADDI, 1, 2, 0),  // r1 = r2 (move)
+		EncodeIFormat(OpADDI, 2, 5, 0),  // r2 = r5 (move)
+		EncodeRFormat(OpXOR, 6, 6, 6),   // r6 = 0 (zero idiom)
+		EncodeRFormat(OpOR, 7, 5, 0),    // r7 = r5 (move)
+		EncodeIFormat(OpADDI, 3, 3, 1),  // counter++
+		EncodeBFormat(OpBLT, 3, 4, -24), // if counter < 100, loop
+
+		// End
+		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
 	}
+	return program
 }
 
-// Run executes for the specified number of cycles
-//
-// ALGORITHM:
+// CreateBranchPredictionTest tests the branch predictor
 //
-//	FOR each cycle until limit:
-//	  Execute one cycle
+// TESTS: INNOVATION #29-33 (4-bit counters + RSB)
 //
-// USED BY: Benchmark and test programs
-func (c *Core) Run(maxCycles uint64) {
-	for c.cycles < maxCycles {
-		c.Cycle()
+// Creates various branch patterns to test predictor accuracy
+func CreateBranchPredictionTest() []uint32 {
+	program := []uint32{
+		// Test 1: Loop branch (should predict taken well)
+		EncodeIFormat(OpADDI, 1, 0, 0),   // r1 = 0
+		EncodeIFormat(OpADDI, 2, 0, 100), // r2 = 100
+		// Loop:
+		EncodeIFormat(OpADDI, 1, 1, 1), // r1++
+		EncodeBFormat(OpBLT, 1, 2, -4), // if r1 < 100, loop (taken 99/100)
+
+		// Test 2: Function call and return (tests RSB)
+		EncodeIFormat(OpJAL, 31, 0, 16), // call function (save r31)
+		EncodeIFormat(OpADDI, 3, 0, 1),  // r3 = 1 (after return)
+		EncodeBFormat(OpBEQ, 0, 0, 12),  // skip to end
+
+		// Function:
+		EncodeIFormat(OpADDI, 4, 0, 10), // r4 = 10
+		EncodeIFormat(OpJALR, 0, 31, 0), // return (via RSB)
+
+		// End
+		EncodeIFormat(OpADDI, 5, 0, 42), // r5 = 42 (done)
 	}
+	return program
 }
 
-// GetIPC returns instructions per cycle (key performance metric)
+// CreateIndirectJumpTest calls a function through a register in a loop
 //
-// IPC (Instructions Per Cycle):
+// TESTS: INNOVATION #24 (indirect jump predictor as JALR target source)
 //
-//	Higher is better
-//	1.0 = one instruction per cycle (baseline)
-//	4.0 = four instructions per cycle (superscalar)
-//	Our target: ~4.15 IPC (competitive with Intel)
-func (c *Core) GetIPC() float64 {
-	if c.cycles == 0 {
-		return 0
+// Both the call (JALR through r10) and the return (JALR through r31)
+// are non-RSB indirect jumps with one stable target each
+func CreateIndirectJumpTest() []uint32 {
+	program := []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0),       // r1 = 0 (iteration counter)
+		EncodeIFormat(OpADDI, 2, 0, 50),      // r2 = 50 (limit)
+		EncodeIFormat(OpADDI, 10, 0, 0x101C), // r10 = &function
+
+		// Loop:
+		EncodeIFormat(OpJALR, 31, 10, 0), // call *r10 (indirect)
+		EncodeIFormat(OpADDI, 1, 1, 1),   // r1++
+		EncodeBFormat(OpBLT, 1, 2, -8),   // if r1 < 50, loop
+		EncodeBFormat(OpBEQ, 0, 0, 12),   // skip function
+
+		// Function (0x101C):
+		EncodeIFormat(OpADDI, 4, 4, 1),  // r4++
+		EncodeIFormat(OpJALR, 0, 31, 0), // return
+
+		// End
+		EncodeIFormat(OpADDI, 5, 0, 42), // r5 = 42 (done)
 	}
-	return float64(c.instructions) / float64(c.cycles)
+	return program
 }
 
-// GetStats returns comprehensive performance statistics
+// CreateAtomicTest tests atomic operations
 //
-// Shows all key metrics:
-//   - IPC (instructions per cycle)
-//   - Branch prediction accuracy
-//   - Cache hit rates
-//   - Predictor accuracy
-//   - Resource utilization
-func (c *Core) GetStats() string {
-	ipc := c.GetIPC()
+// TESTS: INNOVATION #71-72 (LR/SC atomic operations)
+//
+// Simulates atomic increment using LR/SC
+func CreateAtomicTest() []uint32 {
+	program := []uint32{
+		// Setup: counter at 0x5000
+		EncodeIFormat(OpADDI, 1, 0, 0x5000), // r1 = counter address
+		EncodeIFormat(OpADDI, 2, 0, 0),      // r2 = 0 (initial value)
+		EncodeIFormat(OpSW, 0, 2, 0x5000),   // store 0 to counter
 
-	branchAccuracy := float64(0)
-	if c.branches > 0 {
-		branchAccuracy = float64(c.branches-c.branchMispredicts) / float64(c.branches) * 100
-	}
+		// Atomic increment loop (10 iterations)
+		EncodeIFormat(OpADDI, 3, 0, 0),  // r3 = 0 (iteration counter)
+		EncodeIFormat(OpADDI, 4, 0, 10), // r4 = 10 (limit)
 
-	return fmt.Sprintf(`
-╔═══════════════════════════════════════════════════════════════════════════╗
-║                    SUPRAX-32 PERFORMANCE STATISTICS                       ║
-╚═══════════════════════════════════════════════════════════════════════════╝
+		// Loop:
+		// Retry:
+		EncodeIFormat(OpLR, 5, 1, 0),    // r5 = load reserved [counter]
+		EncodeIFormat(OpADDI, 5, 5, 1),  // r5++ (increment)
+		EncodeIFormat(OpSC, 6, 1, 5),    // store conditional, r6 = success
+		EncodeBFormat(OpBNE, 6, 0, -12), // if failed (r6!=0), retry
 
-EXECUTION METRICS:
-  Cycles:              %d
-  Instructions:        %d
-  IPC:                 %.3f (Target: 4.15)
+		// Success:
+		EncodeIFormat(OpADDI, 3, 3, 1),  // iteration++
+		EncodeBFormat(OpBLT, 3, 4, -20), // if iteration < 10, loop
 
-BRANCH PREDICTION:
-  Total Branches:      %d
-  Mispredictions:      %d
-  Accuracy:            %.2f%% (INNOVATION #29-33)
+		// Verify: counter should be 10
+		EncodeIFormat(OpLW, 7, 1, 0), // r7 = load [counter]
 
-MEMORY OPERATIONS:
-  Loads:               %d (30%% of instructions)
-  Stores:              %d
+		// End
+		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
+	}
+	return program
+}
 
-CACHE PERFORMANCE:
-  L1I Hit Rate:        %.2f%% (INNOVATION #21-28: Quad-buffer)
-  L1D Hit Rate:        %.2f%% (INNOVATION #18-20)
-  L1D Predictor Acc:   %.2f%% (INNOVATION #59: 5-way predictor)
+// CreateOutOfOrderTest tests out-of-order execution
+//
+// TESTS: INNOVATIONS #34-58 (Complete OOO engine)
+//
+// Creates code with independent operations that can execute in parallel
+func CreateOutOfOrderTest() []uint32 {
+	program := []uint32{
+		// Create long-latency operation (load)
+		EncodeIFormat(OpADDI, 1, 0, 0x6000), // r1 = address
+		EncodeIFormat(OpLW, 2, 1, 0),        // r2 = load (may miss, 100 cycles)
 
-RESOURCE UTILIZATION:
-  Window Fill:         %.1f%% (%d/%d entries) (INNOVATION #34)
-  Out-of-Order Depth:  %d instructions
+		// These should execute while waiting for load:
+		EncodeIFormat(OpADDI, 3, 0, 10), // r3 = 10 (independent!)
+		EncodeIFormat(OpADDI, 4, 0, 20), // r4 = 20 (independent!)
+		EncodeRFormat(OpMUL, 5, 3, 4),   // r5 = r3 * r4 (independent!)
+		EncodeRFormat(OpADD, 6, 5, 3),   // r6 = r5 + r3 (independent!)
 
-INNOVATION SUMMARY:
-  Total Innovations:   73 (across 7 tiers)
-  Transistors:         22.1M (Intel: 26,000M)
-  Simplicity Factor:   1,175× simpler than Intel
-  Efficiency:          %.3f IPC per million transistors
-  Intel Efficiency:    %.6f IPC per million transistors
-  Our Advantage:       %.0f× more efficient! 🎯
+		// This depends on load:
+		EncodeRFormat(OpADD, 7, 2, 6), // r7 = r2 + r6 (waits for r2)
 
-╔═══════════════════════════════════════════════════════════════════════════╗
-║  "Maximum Courage, Minimum Bloat" - Simple, Fast, Efficient              ║
-╚═══════════════════════════════════════════════════════════════════════════╝
-`,
-		c.cycles,
-		c.instructions,
-		ipc,
-		c.branches,
-		c.branchMispredicts,
-		branchAccuracy,
-		c.loads,
-		c.stores,
-		c.icache.GetHitRate()*100,
-		c.dcache.GetHitRate()*100,
-		c.dcache.GetPredictorAccuracy()*100,
-		float64(c.window.GetCount())/float64(WindowSize)*100,
-		c.window.GetCount(),
-		WindowSize,
-		c.window.GetCount(),
-		ipc/22.1,                 // Our efficiency
-		4.3/26000.0,              // Intel efficiency
-		(ipc/22.1)/(4.3/26000.0), // Advantage
-	)
+		// End
+		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
+	}
+	return program
 }
 
-// ═══════════════════════════════════════════════════════════════════════════════
-// HELPER FUNCTIONS FOR TESTING AND BENCHMARKING
-// ═══════════════════════════════════════════════════════════════════════════════
-
-// EncodeRFormat creates an R-format instruction
+// CreateComprehensiveBenchmark creates a program that exercises everything
 //
-// R-FORMAT: [opcode:5][rd:5][rs1:5][rs2:5][unused:12]
-func EncodeRFormat(opcode, rd, rs1, rs2 uint8) uint32 {
-	return (uint32(opcode) << 27) |
-		(uint32(rd) << 22) |
-		(uint32(rs1) << 17) |
-		(uint32(rs2) << 12)
-}
-
-// EncodeIFormat creates an I-format instruction
+// TESTS: ALL 73 INNOVATIONS!
 //
-// I-FORMAT: [opcode:5][rd:5][rs1:5][immediate:17]
-func EncodeIFormat(opcode, rd, rs1 uint8, imm int32) uint32 {
-	return (uint32(opcode) << 27) |
-		(uint32(rd) << 22) |
-		(uint32(rs1) << 17) |
-		(uint32(imm) & 0x1FFFF)
-}
+// This program is designed to stress-test every component:
+//   - All instruction types
+//   - All execution units
+//   - Branch prediction
+//   - Memory prediction
+//   - Register renaming
+//   - Out-of-order execution
+func CreateComprehensiveBenchmark() []uint32 {
+	program := []uint32{
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 1: ALU Operations (INNOVATION #56)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeIFormat(OpADDI, 1, 0, 100), // r1 = 100
+		EncodeIFormat(OpADDI, 2, 0, 200), // r2 = 200
+		EncodeRFormat(OpADD, 3, 1, 2),    // r3 = r1 + r2
+		EncodeRFormat(OpSUB, 4, 2, 1),    // r4 = r2 - r1
+		EncodeRFormat(OpAND, 5, 1, 2),    // r5 = r1 & r2
+		EncodeRFormat(OpOR, 6, 1, 2),     // r6 = r1 | r2
+		EncodeRFormat(OpXOR, 7, 1, 2),    // r7 = r1 ^ r2
+		EncodeRFormat(OpSLL, 8, 1, 2),    // r8 = r1 << r2
+		EncodeRFormat(OpSRL, 9, 2, 1),    // r9 = r2 >> r1
 
-// EncodeBFormat creates a B-format instruction
-//
-// B-FORMAT: [opcode:5][rs2:5][rs1:5][immediate:17]
-// Note: rd field is reused for rs2 (INNOVATION #4)
-func EncodeBFormat(opcode, rs1, rs2 uint8, imm int32) uint32 {
-	return (uint32(opcode) << 27) |
-		(uint32(rs2) << 22) |
-		(uint32(rs1) << 17) |
-		(uint32(imm) & 0x1FFFF)
-}
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 2: Multiply (INNOVATION #57: 1-cycle)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeRFormat(OpMUL, 10, 1, 2),  // r10 = r1 * r2 (low)
+		EncodeRFormat(OpMULH, 11, 1, 2), // r11 = r1 * r2 (high)
 
-// CreateSimpleProgram creates a test program
-//
-// Simple program that exercises all components:
-//   - ALU operations (INNOVATION #56)
-//   - Multiply (INNOVATION #57)
-//   - Divide (INNOVATION #58)
-//   - Loads/Stores (INNOVATION #69)
-//   - Branches (INNOVATION #29-33)
-func CreateSimpleProgram() []uint32 {
-	return []uint32{
-		// Initialize registers
-		EncodeIFormat(OpADDI, 1, 0, 10), // r1 = 10
-		EncodeIFormat(OpADDI, 2, 0, 20), // r2 = 20
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 3: Divide (INNOVATION #58: 4-cycle)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeRFormat(OpDIV, 12, 2, 1), // r12 = r2 / r1
+		EncodeRFormat(OpREM, 13, 2, 1), // r13 = r2 % r1
+
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 4: Array Sum (INNOVATION #60: Stride predictor)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeIFormat(OpADDI, 14, 0, 0),      // r14 = 0 (sum)
+		EncodeIFormat(OpADDI, 15, 0, 0),      // r15 = 0 (i)
+		EncodeIFormat(OpADDI, 16, 0, 100),    // r16 = 100 (limit)
+		EncodeIFormat(OpADDI, 17, 0, 0x3000), // r17 = array base
+
+		// Array loop:
+		EncodeRFormat(OpADD, 18, 17, 15),  // r18 = array + i
+		EncodeIFormat(OpLW, 19, 18, 0),    // r19 = array[i]
+		EncodeRFormat(OpADD, 14, 14, 19),  // sum += array[i]
+		EncodeIFormat(OpADDI, 15, 15, 4),  // i += 4
+		EncodeBFormat(OpBLT, 15, 16, -16), // if i < 100, loop
+
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 5: Nested Loops (Tests branch prediction)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeIFormat(OpADDI, 20, 0, 0),  // r20 = 0 (outer)
+		EncodeIFormat(OpADDI, 21, 0, 10), // r21 = 10 (outer limit)
 
-		// ALU operations (INNOVATION #56)
-		EncodeRFormat(OpADD, 3, 1, 2), // r3 = r1 + r2 (30)
-		EncodeRFormat(OpSUB, 4, 2, 1), // r4 = r2 - r1 (10)
+		// Outer loop:
+		EncodeIFormat(OpADDI, 22, 0, 0),  // r22 = 0 (inner)
+		EncodeIFormat(OpADDI, 23, 0, 10), // r23 = 10 (inner limit)
 
-		// Multiply (INNOVATION #57: 1-cycle)
-		EncodeRFormat(OpMUL, 5, 1, 2), // r5 = r1 * r2 (200)
+		// Inner loop:
+		EncodeRFormat(OpMUL, 24, 20, 22), // r24 = outer * inner
+		EncodeIFormat(OpADDI, 22, 22, 1), // inner++
+		EncodeBFormat(OpBLT, 22, 23, -8), // if inner < 10, inner loop
 
-		// Divide (INNOVATION #58: 4-cycle)
-		EncodeRFormat(OpDIV, 6, 2, 1), // r6 = r2 / r1 (2)
+		EncodeIFormat(OpADDI, 20, 20, 1),  // outer++
+		EncodeBFormat(OpBLT, 20, 21, -20), // if outer < 10, outer loop
 
-		// Load/Store (INNOVATION #69)
-		EncodeIFormat(OpSW, 0, 1, 0x2000), // Store r1 to [0x2000]
-		EncodeIFormat(OpLW, 7, 0, 0x2000), // Load r7 from [0x2000]
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 6: Function Calls (Tests RSB - INNOVATION #31)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeIFormat(OpJAL, 31, 0, 16), // call function
+		EncodeIFormat(OpADDI, 25, 0, 1), // r25 = 1 (after return)
+		EncodeBFormat(OpBEQ, 0, 0, 12),  // skip function
 
-		// Branch (INNOVATION #29-33)
-		EncodeBFormat(OpBEQ, 1, 7, 8),   // if r1 == r7, skip ahead
-		EncodeIFormat(OpADDI, 8, 0, 99), // r8 = 99 (skipped)
-		EncodeIFormat(OpADDI, 9, 0, 1),  // r9 = 1 (executed)
+		// Function body:
+		EncodeIFormat(OpADDI, 26, 0, 99), // r26 = 99
+		EncodeIFormat(OpJALR, 0, 31, 0),  // return
 
-		// Loop example
-		EncodeIFormat(OpADDI, 10, 0, 0), // r10 = 0 (counter)
-		// Loop start:
-		EncodeIFormat(OpADDI, 10, 10, 1), // r10++
-		EncodeBFormat(OpBLT, 10, 1, -4),  // if r10 < r1, loop
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 7: Atomic Operations (INNOVATION #71-72)
+		// ═══════════════════════════════════════════════════════════════
+		EncodeIFormat(OpADDI, 27, 0, 0x5000), // r27 = counter address
+		EncodeIFormat(OpLR, 28, 27, 0),       // r28 = load reserved
+		EncodeIFormat(OpADDI, 28, 28, 1),     // r28++
+		EncodeIFormat(OpSC, 29, 27, 28),      // store conditional
 
-		// End
-		EncodeIFormat(OpADDI, 11, 0, 42), // r11 = 42 (done)
+		// ═══════════════════════════════════════════════════════════════
+		// SECTION 8: End marker
+		// ═══════════════════════════════════════════════════════════════
+		EncodeIFormat(OpADDI, 30, 0, 42), // r30 = 42 (DONE!)
 	}
+	return program
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
-// BENCHMARK PROGRAMS AND TESTING
+// PERFORMANCE ANALYSIS TOOLS
 // ═══════════════════════════════════════════════════════════════════════════════
 
-// CreateArraySumProgram creates a program that tests stride predictor
-//
-// TESTS: INNOVATION #60 (Stride predictor)
-//
-// CODE:
-//
-//	sum = 0
-//	for (i=0; i<100; i++)
-//	  sum += array[i]
-//
-// EXPECTED BEHAVIOR:
-//   - Stride predictor detects +4 offset pattern
-//   - Prefetches array elements ahead of time
-//   - Should achieve ~95%+ L1D hit rate
-func CreateArraySumProgram() []uint32 {
-	program := []uint32{
-		// Initialize
-		EncodeIFormat(OpADDI, 1, 0, 0),      // r1 = 0 (sum)
-		EncodeIFormat(OpADDI, 2, 0, 0),      // r2 = 0 (i)
-		EncodeIFormat(OpADDI, 3, 0, 100),    // r3 = 100 (limit)
-		EncodeIFormat(OpADDI, 4, 0, 0x3000), // r4 = array base
+// RunBenchmark executes a program and returns detailed statistics
+func RunBenchmark(name string, program []uint32, cycles uint64) string {
+	core := NewCore(1024 * 1024) // 1MB memory
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
 
-		// Loop: (PC = 0x1010)
-		EncodeRFormat(OpADD, 5, 4, 2),   // r5 = array + i
-		EncodeIFormat(OpLW, 6, 5, 0),    // r6 = array[i]
-		EncodeRFormat(OpADD, 1, 1, 6),   // sum += array[i]
-		EncodeIFormat(OpADDI, 2, 2, 4),  // i += 4
-		EncodeBFormat(OpBLT, 2, 3, -16), // if i < 100, loop
+	return fmt.Sprintf(`
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  BENCHMARK: %-60s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// End
-		EncodeIFormat(OpADDI, 7, 0, 42), // r7 = 42 (done marker)
-	}
-	return program
+%s
+`, name, core.GetStats())
 }
 
-// CreateLinkedListProgram creates a program that tests Markov predictor
+// RunL1DCoverageReport executes a program and returns the L1D predictor's
+// per-component coverage next to the claimed mix (INNOVATION #59-65)
 //
-// TESTS: INNOVATION #61 (Markov predictor)
+// WHY PER BENCHMARK: Each program exercises one pattern (array sum →
 //
-// CODE:
+//	stride, linked list → Markov, ...), so the claimed 70/15/5/3/5 split
+//	only shows up on a blend; single-pattern runs show which component
+//	actually carries the load
+func RunL1DCoverageReport(name string, program []uint32, cycles uint64) string {
+	core := NewCore(1024 * 1024) // 1MB memory
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+
+	return fmt.Sprintf(`
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  L1D COVERAGE: %-57s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+
+%s
+%s`, name, core.dcache.GetPredictorCoverageReport(), core.dcache.PrefetchReport())
+}
+
+// RunValuePredictionReport runs a program with and without load value
+// prediction and reports coverage, accuracy and the IPC difference
 //
-//	node = head
-//	while (node != NULL)
-//	  node = node->next
+// WHY TWO RUNS: IPC gain is only meaningful against the same program
 //
-// EXPECTED BEHAVIOR:
-//   - Markov predictor learns the list traversal sequence
-//   - After first traversal, should predict subsequent traversals
-//   - Should achieve ~90%+ hit rate on second+ traversals
-func CreateLinkedListProgram() []uint32 {
-	program := []uint32{
-		// Setup linked list in memory at 0x4000
-		// Each node: [data:4 bytes][next:4 bytes]
-		EncodeIFormat(OpADDI, 1, 0, 0x4000), // r1 = head
+//	on the same core with the predictor off
+func RunValuePredictionReport(name string, program []uint32, cycles uint64) string {
+	run := func(valuePred bool) *Core {
+		core := NewCore(1024 * 1024) // 1MB memory
+		core.EnableValuePrediction(valuePred)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+		return core
+	}
 
-		// Traverse loop:
-		EncodeIFormat(OpLW, 2, 1, 0),   // r2 = node->data
-		EncodeIFormat(OpLW, 1, 1, 4),   // r1 = node->next
-		EncodeBFormat(OpBNE, 1, 0, -8), // if node != NULL, loop
+	base := run(false)
+	vp := run(true)
 
-		// End
-		EncodeIFormat(OpADDI, 3, 0, 42), // r3 = 42 (done)
+	gain := 0.0
+	if base.GetIPC() > 0 {
+		gain = (vp.GetIPC()/base.GetIPC() - 1) * 100
 	}
-	return program
+
+	return fmt.Sprintf(`
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  VALUE PREDICTION: %-53s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+
+  IPC without:         %.3f
+  IPC with:            %.3f
+  IPC gain:            %+.2f%%
+
+%s`, name, base.GetIPC(), vp.GetIPC(), gain, vp.GetValuePredictionStats())
 }
 
-// CreateMultiplyBenchmark tests the 1-cycle multiplier
+// RunPrefetcherComparison runs a program under several prefetch setups
+// and tabulates IPC, L1D hit rate and prefetch effectiveness
 //
-// TESTS: INNOVATION #12 (1-cycle multiply)
+// SETUPS:
 //
-// Performs 1000 multiplies to measure throughput
-func CreateMultiplyBenchmark() []uint32 {
-	program := []uint32{
-		// Initialize
-		EncodeIFormat(OpADDI, 1, 0, 123),  // r1 = 123
-		EncodeIFormat(OpADDI, 2, 0, 456),  // r2 = 456
-		EncodeIFormat(OpADDI, 3, 0, 0),    // r3 = 0 (counter)
-		EncodeIFormat(OpADDI, 4, 0, 1000), // r4 = 1000 (limit)
+//	5-way predictor alone (the design as shipped)
+//	Each hardware prefetcher alone (predictor prefetch off)
+//	Predictor plus all four together
+func RunPrefetcherComparison(name string, program []uint32, cycles uint64) string {
+	type setup struct {
+		label     string
+		predictor bool
+		attach    []func() Prefetcher
+	}
+	nextLine := func() Prefetcher { return NewNextLinePrefetcher() }
+	stream := func() Prefetcher { return NewStreamPrefetcher() }
+	bestOffset := func() Prefetcher { return NewBestOffsetPrefetcher() }
+	sms := func() Prefetcher { return NewSMSPrefetcher() }
 
-		// Loop:
-		EncodeRFormat(OpMUL, 5, 1, 2),   // r5 = r1 * r2 (1 cycle!)
-		EncodeRFormat(OpMUL, 6, 2, 1),   // r6 = r2 * r1
-		EncodeRFormat(OpMUL, 7, 5, 6),   // r7 = r5 * r6
-		EncodeIFormat(OpADDI, 3, 3, 1),  // counter++
-		EncodeBFormat(OpBLT, 3, 4, -16), // if counter < 1000, loop
+	setups := []setup{
+		{"5-way predictor", true, nil},
+		{"next-line", false, []func() Prefetcher{nextLine}},
+		{"stream", false, []func() Prefetcher{stream}},
+		{"best-offset", false, []func() Prefetcher{bestOffset}},
+		{"sms", false, []func() Prefetcher{sms}},
+		{"predictor + all", true, []func() Prefetcher{nextLine, stream, bestOffset, sms}},
+	}
 
-		// End
-		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  PREFETCHERS: %-58s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+
+  %-16s %7s %8s %8s %8s %8s
+`, name, "Setup", "IPC", "L1D Hit", "Issued", "Useful", "Acc")
+
+	for _, su := range setups {
+		core := NewCore(1024 * 1024) // 1MB memory
+		core.dcache.SetPredictorPrefetch(su.predictor)
+		for _, mk := range su.attach {
+			core.dcache.AddPrefetcher(mk())
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		var all PrefetchStats
+		for id := PredictorStride; id < NumPredictorIDs; id++ {
+			st := core.dcache.prefetchStats[id]
+			all.Issued += st.Issued
+			all.Useful += st.Useful
+			all.Useless += st.Useless
+		}
+		fmt.Fprintf(&b, "  %-16s %7.3f %7.2f%% %8d %8d %7.2f%%\n",
+			su.label, core.GetIPC(), core.dcache.GetHitRate()*100, all.Issued, all.Useful, all.Accuracy()*100)
 	}
-	return program
+
+	return b.String()
 }
 
-// CreateDivideBenchmark tests the 4-cycle divider
-//
-// TESTS: INNOVATION #16 (4-cycle divide)
+// RunFrontendPrefetchComparison runs a program with coverage-scored
+// L1I prefetch and with the fetch target queue, and tabulates IPC,
+// L1I demand misses, fetch stalls and prefetch accuracy
 //
-// Performs 100 divisions to measure throughput
-func CreateDivideBenchmark() []uint32 {
-	program := []uint32{
-		// Initialize
-		EncodeIFormat(OpADDI, 1, 0, 12345), // r1 = 12345
-		EncodeIFormat(OpADDI, 2, 0, 67),    // r2 = 67
-		EncodeIFormat(OpADDI, 3, 0, 0),     // r3 = 0 (counter)
-		EncodeIFormat(OpADDI, 4, 0, 100),   // r4 = 100 (limit)
+// USEFUL: A prefetched line that fetch later read
+// LATE:   A prefetch still in flight when fetch reached it
+func RunFrontendPrefetchComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  FRONTEND PREFETCH: %-52s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// Loop:
-		EncodeRFormat(OpDIV, 5, 1, 2),   // r5 = r1 / r2 (4 cycles)
-		EncodeRFormat(OpREM, 6, 1, 2),   // r6 = r1 % r2 (4 cycles)
-		EncodeIFormat(OpADDI, 3, 3, 1),  // counter++
-		EncodeBFormat(OpBLT, 3, 4, -12), // if counter < 100, loop
+  %-10s %7s %8s %8s %8s %8s %8s %8s %8s
+`, name, "Frontend", "IPC", "L1I Hit", "Stall", "Misses", "Issued", "Useful", "Late", "Acc")
 
-		// End
-		EncodeIFormat(OpADDI, 7, 0, 42), // r7 = 42 (done)
+	var ftq *FetchTargetQueue
+	for _, fdip := range []bool{false, true} {
+		core := NewCore(1024 * 1024) // 1MB memory
+		core.EnableFDIP(fdip)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		label := "coverage"
+		if fdip {
+			label = "fdip"
+			ftq = core.ftq
+		}
+
+		misses, issued, useful := core.icache.GetPrefetchCounts()
+		accuracy := 0.0
+		if issued > 0 {
+			accuracy = float64(useful) / float64(issued) * 100
+		}
+		fmt.Fprintf(&b, "  %-10s %7.3f %7.2f%% %7.2f%% %8d %8d %8d %8d %7.2f%%\n",
+			label, core.GetIPC(), core.icache.GetHitRate()*100, core.GetFetchStallRate()*100,
+			misses, issued, useful, core.ifill.LatePrefetches, accuracy)
 	}
-	return program
+
+	fmt.Fprintf(&b, "\n  FTQ: %s\n", ftq.GetStats())
+	return b.String()
 }
 
-// CreateBranchPredictionTest tests the branch predictor
-//
-// TESTS: INNOVATION #29-33 (4-bit counters + RSB)
+// RunL1ITelemetryReport executes a program and returns which L1I
+// prefetch sources paid off and how the four buffers were used
 //
-// Creates various branch patterns to test predictor accuracy
-func CreateBranchPredictionTest() []uint32 {
-	program := []uint32{
-		// Test 1: Loop branch (should predict taken well)
-		EncodeIFormat(OpADDI, 1, 0, 0),   // r1 = 0
-		EncodeIFormat(OpADDI, 2, 0, 100), // r2 = 100
-		// Loop:
-		EncodeIFormat(OpADDI, 1, 1, 1), // r1++
-		EncodeBFormat(OpBLT, 1, 2, -4), // if r1 < 100, loop (taken 99/100)
+// fdip picks the frontend: coverage sources, or the FTQ alone
+func RunL1ITelemetryReport(name string, program []uint32, cycles uint64, fdip bool) string {
+	core := NewCore(1024 * 1024) // 1MB memory
+	core.EnableFDIP(fdip)
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
 
-		// Test 2: Function call and return (tests RSB)
-		EncodeIFormat(OpJAL, 31, 0, 16), // call function (save r31)
-		EncodeIFormat(OpADDI, 3, 0, 1),  // r3 = 1 (after return)
-		EncodeBFormat(OpBEQ, 0, 0, 12),  // skip to end
+	return fmt.Sprintf(`
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  L1I TELEMETRY: %-56s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// Function:
-		EncodeIFormat(OpADDI, 4, 0, 10), // r4 = 10
-		EncodeIFormat(OpJALR, 0, 31, 0), // return (via RSB)
+  L1I: %s, Fetch stalls: %.2f%%
 
-		// End
-		EncodeIFormat(OpADDI, 5, 0, 42), // r5 = 42 (done)
+%s`, name, core.icache.GetStats(), core.GetFetchStallRate()*100, core.icache.TelemetryReport())
+}
+
+// RunIndirectPredictionComparison runs a program with JALR targets
+// predicted as PC+4 and from the L1I indirect table, and reports the
+// indirect-jump misprediction rate for each
+func RunIndirectPredictionComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  INDIRECT JUMPS: %-55s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+
+  %-16s %7s %9s %9s %9s
+`, name, "JALR Target", "IPC", "JALRs", "Mispred", "Rate")
+
+	for _, on := range []bool{false, true} {
+		core := NewCore(1024 * 1024) // 1MB memory
+		core.EnableIndirectPrediction(on)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		label := "pc+4"
+		if on {
+			label = "indirect table"
+		}
+		fmt.Fprintf(&b, "  %-16s %7.3f %9d %9d %8.2f%%\n",
+			label, core.GetIPC(), core.indirectJumps, core.indirectMispreds,
+			core.GetIndirectMispredictRate()*100)
 	}
-	return program
+
+	return b.String()
 }
 
-// CreateAtomicTest tests atomic operations
+// RunBranchRecoveryComparison runs a program with mispredicts repaired
+// at commit (full flush) and at execute (selective squash), and reports
+// the average penalty split into recovery wait and refill
 //
-// TESTS: INNOVATION #71-72 (LR/SC atomic operations)
+// WHAT TO LOOK FOR: Wait is how long a known-wrong path kept running;
 //
-// Simulates atomic increment using LR/SC
-func CreateAtomicTest() []uint32 {
-	program := []uint32{
-		// Setup: counter at 0x5000
-		EncodeIFormat(OpADDI, 1, 0, 0x5000), // r1 = counter address
-		EncodeIFormat(OpADDI, 2, 0, 0),      // r2 = 0 (initial value)
-		EncodeIFormat(OpSW, 0, 2, 0x5000),   // store 0 to counter
+//	Squashed is the right-path work a full flush threw away as well
+func RunBranchRecoveryComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  MISPREDICT RECOVERY: %-50s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// Atomic increment loop (10 iterations)
-		EncodeIFormat(OpADDI, 3, 0, 0),  // r3 = 0 (iteration counter)
-		EncodeIFormat(OpADDI, 4, 0, 10), // r4 = 10 (limit)
+  %-10s %7s %8s %8s %9s %8s %8s %8s
+`, name, "Recovery", "IPC", "Mispred", "Redirect", "Squashed", "Wait", "Refill", "Penalty")
 
-		// Loop:
-		// Retry:
-		EncodeIFormat(OpLR, 5, 1, 0),    // r5 = load reserved [counter]
-		EncodeIFormat(OpADDI, 5, 5, 1),  // r5++ (increment)
-		EncodeIFormat(OpSC, 6, 1, 5),    // store conditional, r6 = success
-		EncodeBFormat(OpBNE, 6, 0, -12), // if failed (r6!=0), retry
+	for _, early := range []bool{false, true} {
+		core := NewCore(1024 * 1024) // 1MB memory
+		core.EnableEarlyRecovery(early)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		label := "commit"
+		if early {
+			label = "execute"
+		}
+		wait, refill := core.GetMispredictPenalty()
+		fmt.Fprintf(&b, "  %-10s %7.3f %8d %8d %9d %8.1f %8.1f %8.1f\n",
+			label, core.GetIPC(), core.branchMispredicts, core.recoveries,
+			core.window.GetSquashed(), wait, refill, wait+refill)
+	}
+
+	return b.String()
+}
 
-		// Success:
-		EncodeIFormat(OpADDI, 3, 3, 1),  // iteration++
-		EncodeBFormat(OpBLT, 3, 4, -20), // if iteration < 10, loop
+// RunFrontendDepthComparison runs a program at several frontend depths
+// (fetch+decode+rename+dispatch cycles) and reports the mispredict
+// penalty each one pays
+//
+// WHAT TO LOOK FOR: Refill grows by one cycle per frontend stage; the
+//
+//	1+0+0+1 row is the old model, the only one where the whole penalty
+//	can stay near one cycle
+func RunFrontendDepthComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  FRONTEND DEPTH: %-55s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// Verify: counter should be 10
-		EncodeIFormat(OpLW, 7, 1, 0), // r7 = load [counter]
+  %-9s %5s %7s %8s %7s %7s %8s %8s %9s
+`, name, "Stages", "Depth", "IPC", "Redirect", "Wait", "Refill", "Penalty", "Starved", "Discarded")
+
+	for _, stages := range [][NumFrontendStages]int{
+		{1, 0, 0, 1}, // Old single-step frontend
+		{1, 1, 1, 1}, // Default
+		{2, 2, 1, 1},
+		{3, 3, 2, 2},
+	} {
+		cfg := DefaultCoreConfig()
+		cfg.FetchStages = stages[StageFetch]
+		cfg.DecodeStages = stages[StageDecode]
+		cfg.RenameStages = stages[StageRename]
+		cfg.DispatchStages = stages[StageDispatch]
+
+		core, err := NewCoreWithConfig(cfg, 1024*1024) // 1MB memory
+		if err != nil {
+			fmt.Fprintf(&b, "  %v\n", err)
+			continue
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
 
-		// End
-		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
+		label := fmt.Sprintf("%d+%d+%d+%d", stages[0], stages[1], stages[2], stages[3])
+		wait, refill := core.GetMispredictPenalty()
+		fmt.Fprintf(&b, "  %-9s %5d %7.3f %8d %7.1f %7.1f %8.1f %7.1f%% %9d\n",
+			label, core.frontend.Depth(), core.GetIPC(), core.recoveries,
+			wait, refill, wait+refill, core.frontend.GetStarvedRate()*100,
+			core.frontend.Discarded)
 	}
-	return program
+
+	return b.String()
 }
 
-// CreateOutOfOrderTest tests out-of-order execution
+// RunFetchBandwidthComparison runs a program under several fetch block
+// sizes and taken-branch limits, and reports fetch bandwidth and why
+// fetch stopped (percent of cycles)
 //
-// TESTS: INNOVATIONS #34-58 (Complete OOO engine)
+// WHAT TO LOOK FOR: Fetch/c is what the frontend can actually deliver;
 //
-// Creates code with independent operations that can execute in parallel
-func CreateOutOfOrderTest() []uint32 {
-	program := []uint32{
-		// Create long-latency operation (load)
-		EncodeIFormat(OpADDI, 1, 0, 0x6000), // r1 = address
-		EncodeIFormat(OpLW, 2, 1, 0),        // r2 = load (may miss, 100 cycles)
+//	a large Taken or BlkEnd column means loops and misaligned targets,
+//	not the fetch width (Full), set the limit
+func RunFetchBandwidthComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  FETCH BANDWIDTH: %-54s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// These should execute while waiting for load:
-		EncodeIFormat(OpADDI, 3, 0, 10), // r3 = 10 (independent!)
-		EncodeIFormat(OpADDI, 4, 0, 20), // r4 = 20 (independent!)
-		EncodeRFormat(OpMUL, 5, 3, 4),   // r5 = r3 * r4 (independent!)
-		EncodeRFormat(OpADD, 6, 5, 3),   // r6 = r5 + r3 (independent!)
+  %-5s %5s %5s %5s %7s %7s  %6s %6s %6s %6s %6s %6s
+`, name, "Width", "Block", "Taken", "Lines", "IPC", "Fetch/c",
+		"Full", "BlkEnd", "Taken", "Line", "Queue", "Miss")
+
+	for _, p := range []struct{ width, block, taken, lines int }{
+		{4, 16, 1, 1},
+		{4, 32, 1, 1}, // Default
+		{4, 64, 2, 1},
+		{4, 64, 2, 2},
+		{8, 64, 2, 2},
+	} {
+		cfg := DefaultCoreConfig()
+		cfg.FetchWidth = p.width
+		cfg.FetchBlockBytes = p.block
+		cfg.TakenBranchesPerCycle = p.taken
+		cfg.FetchLinesPerCycle = p.lines
+		cfg.FetchQueueSize = max(cfg.FetchQueueSize, 2*p.width)
+
+		core, err := NewCoreWithConfig(cfg, 1024*1024) // 1MB memory
+		if err != nil {
+			fmt.Fprintf(&b, "  %v\n", err)
+			continue
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
 
-		// This depends on load:
-		EncodeRFormat(OpADD, 7, 2, 6), // r7 = r2 + r6 (waits for r2)
+		fe := core.frontend
+		fmt.Fprintf(&b, "  %-5d %5d %5d %5d %7.3f %7.2f  %5.1f%% %5.1f%% %5.1f%% %5.1f%% %5.1f%% %5.1f%%\n",
+			p.width, p.block, p.taken, p.lines, core.GetIPC(), fe.GetFetchRate(),
+			fe.GetStopRate(FetchStopWidth)*100, fe.GetStopRate(FetchStopBlockEnd)*100,
+			fe.GetStopRate(FetchStopTaken)*100, fe.GetStopRate(FetchStopLine)*100,
+			fe.GetStopRate(FetchStopQueueFull)*100, fe.GetStopRate(FetchStopMiss)*100)
+	}
 
-		// End
-		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
+	return b.String()
+}
+
+// SchedulerPolicies returns a fresh instance of every built-in issue
+// policy for a core configured by cfg (oldest-first first)
+func SchedulerPolicies(cfg CoreConfig) []Scheduler {
+	return []Scheduler{
+		NewOldestFirstScheduler(),
+		NewCriticalPathScheduler(cfg.WindowSize, false),
+		NewCriticalPathScheduler(cfg.WindowSize, true),
+		NewRandomScheduler(1),
 	}
-	return program
 }
 
-// CreateComprehensiveBenchmark creates a program that exercises everything
+// RunSchedulerComparison runs every benchmark under each issue policy
+// and reports IPC, the mean, and the mean relative to oldest-first
 //
-// TESTS: ALL 73 INNOVATIONS!
+// WHAT TO LOOK FOR: critical-path vs oldest-first is the benefit
 //
-// This program is designed to stress-test every component:
-//   - All instruction types
-//   - All execution units
-//   - Branch prediction
-//   - Memory prediction
-//   - Register renaming
-//   - Out-of-order execution
-func CreateComprehensiveBenchmark() []uint32 {
-	program := []uint32{
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 1: ALU Operations (INNOVATION #56)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeIFormat(OpADDI, 1, 0, 100), // r1 = 100
-		EncodeIFormat(OpADDI, 2, 0, 200), // r2 = 200
-		EncodeRFormat(OpADD, 3, 1, 2),    // r3 = r1 + r2
-		EncodeRFormat(OpSUB, 4, 2, 1),    // r4 = r2 - r1
-		EncodeRFormat(OpAND, 5, 1, 2),    // r5 = r1 & r2
-		EncodeRFormat(OpOR, 6, 1, 2),     // r6 = r1 | r2
-		EncodeRFormat(OpXOR, 7, 1, 2),    // r7 = r1 ^ r2
-		EncodeRFormat(OpSLL, 8, 1, 2),    // r8 = r1 << r2
-		EncodeRFormat(OpSRL, 9, 2, 1),    // r9 = r2 >> r1
+//	proto/ooo claims; random shows how much order matters at all
+func RunSchedulerComparison(name string, benchmarks []SweepBenchmark) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  ISSUE SCHEDULING: %-53s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 2: Multiply (INNOVATION #57: 1-cycle)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeRFormat(OpMUL, 10, 1, 2),  // r10 = r1 * r2 (low)
-		EncodeRFormat(OpMULH, 11, 1, 2), // r11 = r1 * r2 (high)
+  %-24s`, name, "Policy")
+	for _, bench := range benchmarks {
+		label := bench.Name
+		if len(label) > 8 {
+			label = label[:8]
+		}
+		fmt.Fprintf(&b, " %9s", label)
+	}
+	fmt.Fprintf(&b, " %7s %9s\n", "Mean", "vs Oldest")
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 3: Divide (INNOVATION #58: 4-cycle)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeRFormat(OpDIV, 12, 2, 1), // r12 = r2 / r1
-		EncodeRFormat(OpREM, 13, 2, 1), // r13 = r2 % r1
+	cfg := DefaultCoreConfig()
+	var baseline float64
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 4: Array Sum (INNOVATION #60: Stride predictor)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeIFormat(OpADDI, 14, 0, 0),      // r14 = 0 (sum)
-		EncodeIFormat(OpADDI, 15, 0, 0),      // r15 = 0 (i)
-		EncodeIFormat(OpADDI, 16, 0, 100),    // r16 = 100 (limit)
-		EncodeIFormat(OpADDI, 17, 0, 0x3000), // r17 = array base
+	for p := range SchedulerPolicies(cfg) {
+		var mean float64
+		var row strings.Builder
+		policyName := ""
 
-		// Array loop:
-		EncodeRFormat(OpADD, 18, 17, 15),  // r18 = array + i
-		EncodeIFormat(OpLW, 19, 18, 0),    // r19 = array[i]
-		EncodeRFormat(OpADD, 14, 14, 19),  // sum += array[i]
-		EncodeIFormat(OpADDI, 15, 15, 4),  // i += 4
-		EncodeBFormat(OpBLT, 15, 16, -16), // if i < 100, loop
+		for _, bench := range benchmarks {
+			// Fresh policy per run: it carries state across cycles
+			sched := SchedulerPolicies(cfg)[p]
+			policyName = sched.Name()
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 5: Nested Loops (Tests branch prediction)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeIFormat(OpADDI, 20, 0, 0),  // r20 = 0 (outer)
-		EncodeIFormat(OpADDI, 21, 0, 10), // r21 = 10 (outer limit)
+			core := NewCore(1024 * 1024) // 1MB memory
+			if err := core.SetScheduler(sched); err != nil {
+				fmt.Fprintf(&row, " %9s", "error")
+				continue
+			}
+			core.LoadProgram(bench.Program, 0x1000)
+			core.Run(bench.Cycles)
 
-		// Outer loop:
-		EncodeIFormat(OpADDI, 22, 0, 0),  // r22 = 0 (inner)
-		EncodeIFormat(OpADDI, 23, 0, 10), // r23 = 10 (inner limit)
+			ipc := core.GetIPC()
+			mean += ipc / float64(len(benchmarks))
+			fmt.Fprintf(&row, " %9.3f", ipc)
+		}
 
-		// Inner loop:
-		EncodeRFormat(OpMUL, 24, 20, 22), // r24 = outer * inner
-		EncodeIFormat(OpADDI, 22, 22, 1), // inner++
-		EncodeBFormat(OpBLT, 22, 23, -8), // if inner < 10, inner loop
+		if p == 0 {
+			baseline = mean
+		}
+		rel := 0.0
+		if baseline > 0 {
+			rel = (mean/baseline - 1) * 100
+		}
+		fmt.Fprintf(&b, "  %-24s%s %7.3f %+8.1f%%\n", policyName, row.String(), mean, rel)
+	}
 
-		EncodeIFormat(OpADDI, 20, 20, 1),  // outer++
-		EncodeBFormat(OpBLT, 20, 21, -20), // if outer < 10, outer loop
+	return b.String()
+}
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 6: Function Calls (Tests RSB - INNOVATION #31)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeIFormat(OpJAL, 31, 0, 16), // call function
-		EncodeIFormat(OpADDI, 25, 0, 1), // r25 = 1 (after return)
-		EncodeBFormat(OpBEQ, 0, 0, 12),  // skip function
+// RunMultiplierComparison runs a program under several multiplier pool
+// shapes (units, latency, issue interval, result ports)
+//
+// WHAT TO LOOK FOR: II = latency is an unpipelined unit; BusWait counts
+//
+//	results that finished but found every result port taken
+func RunMultiplierComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  MULTIPLIER POOL: %-54s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-		// Function body:
-		EncodeIFormat(OpADDI, 26, 0, 99), // r26 = 99
-		EncodeIFormat(OpJALR, 0, 31, 0),  // return
+  %-5s %3s %3s %5s %7s %7s %8s  %s
+`, name, "Units", "Lat", "II", "Ports", "IPC", "Muls", "BusWait", "Utilization")
+
+	for _, p := range []struct{ units, latency, interval, ports int }{
+		{1, 1, 1, 1}, // Default
+		{1, 3, 3, 1}, // Not pipelined
+		{1, 3, 1, 1},
+		{2, 3, 1, 1},
+		{2, 3, 1, 2},
+	} {
+		cfg := DefaultCoreConfig()
+		cfg.NumMULs = p.units
+		cfg.MulLatency = p.latency
+		cfg.MulIssueInterval = p.interval
+		cfg.MulResultPorts = p.ports
+
+		core, err := NewCoreWithConfig(cfg, 1024*1024) // 1MB memory
+		if err != nil {
+			fmt.Fprintf(&b, "  %v\n", err)
+			continue
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		fmt.Fprintf(&b, "  %-5d %3d %3d %5d %7.3f %7d %8d ",
+			p.units, p.latency, p.interval, p.ports, core.GetIPC(),
+			core.multiplier.Issued, core.multiplier.BusWaits)
+		for _, util := range core.multiplier.GetUtilization(core.cycles) {
+			fmt.Fprintf(&b, " %5.1f%%", util*100)
+		}
+		b.WriteString("\n")
+	}
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 7: Atomic Operations (INNOVATION #71-72)
-		// ═══════════════════════════════════════════════════════════════
-		EncodeIFormat(OpADDI, 27, 0, 0x5000), // r27 = counter address
-		EncodeIFormat(OpLR, 28, 27, 0),       // r28 = load reserved
-		EncodeIFormat(OpADDI, 28, 28, 1),     // r28++
-		EncodeIFormat(OpSC, 29, 27, 28),      // store conditional
+	return b.String()
+}
 
-		// ═══════════════════════════════════════════════════════════════
-		// SECTION 8: End marker
-		// ═══════════════════════════════════════════════════════════════
-		EncodeIFormat(OpADDI, 30, 0, 42), // r30 = 42 (DONE!)
+// RunDividerComparison runs a program under several divider pool
+// shapes (units, latency, issue interval, early-out)
+//
+// WHAT TO LOOK FOR: Short% is the share of divides that skipped the
+//
+//	full latency (fast path or early-out); II = latency is unpipelined
+func RunDividerComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  DIVIDER POOL: %-57s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+
+  %-5s %3s %3s %5s %7s %7s %7s  %s
+`, name, "Units", "Lat", "II", "Early", "IPC", "Divs", "Short", "Utilization")
+
+	for _, p := range []struct{ units, latency, interval, early int }{
+		{1, 4, 4, 0}, // Default
+		{1, 4, 4, 1}, // Early-out
+		{1, 4, 1, 1}, // Pipelined
+		{2, 4, 4, 1},
+		{2, 4, 1, 1},
+		{1, 16, 16, 0}, // Radix-4 SRT for contrast
+	} {
+		cfg := DefaultCoreConfig()
+		cfg.NumDIVs = p.units
+		cfg.DivLatency = p.latency
+		cfg.DivIssueInterval = p.interval
+		cfg.DivEarlyOut = p.early
+
+		core, err := NewCoreWithConfig(cfg, 1024*1024) // 1MB memory
+		if err != nil {
+			fmt.Fprintf(&b, "  %v\n", err)
+			continue
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		fmt.Fprintf(&b, "  %-5d %3d %3d %5d %7.3f %7d %6.1f%% ",
+			p.units, p.latency, p.interval, p.early, core.GetIPC(),
+			core.divider.Issued, core.divider.GetEarlyOutRate()*100)
+		for _, util := range core.divider.GetUtilization(core.cycles) {
+			fmt.Fprintf(&b, " %5.1f%%", util*100)
+		}
+		b.WriteString("\n")
 	}
-	return program
+
+	return b.String()
 }
 
-// ═══════════════════════════════════════════════════════════════════════════════
-// PERFORMANCE ANALYSIS TOOLS
-// ═══════════════════════════════════════════════════════════════════════════════
+// RunMoveEliminationComparison runs a program with rename-stage
+// elimination off and on
+//
+// WHAT TO LOOK FOR: Every eliminated instruction is one less ALU issue
+//
+//	and one less physical register; Regs is the peak number of
+//	physical registers in use, committed state included (shared and
+//	zero mappings let the 32 architectural registers use fewer)
+func RunMoveEliminationComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  MOVE ELIMINATION: %-53s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
 
-// RunBenchmark executes a program and returns detailed statistics
-func RunBenchmark(name string, program []uint32, cycles uint64) string {
-	core := NewCore(1024 * 1024) // 1MB memory
-	core.LoadProgram(program, 0x1000)
-	core.Run(cycles)
+  %-5s %7s %8s %7s %7s %9s %6s
+`, name, "Elim", "IPC", "Instrs", "Moves", "Zeros", "FL Stall", "Regs")
 
-	return fmt.Sprintf(`
+	for _, on := range []int{0, 1} {
+		cfg := DefaultCoreConfig()
+		cfg.MoveElimination = on
+
+		core, err := NewCoreWithConfig(cfg, 1024*1024) // 1MB memory
+		if err != nil {
+			fmt.Fprintf(&b, "  %v\n", err)
+			continue
+		}
+		core.LoadProgram(program, 0x1000)
+
+		// Peak register use, sampled every cycle
+		peak := 0
+		for core.cycles < cycles {
+			core.Cycle()
+			inUse := cfg.NumPhysRegs - core.window.freeList.GetFreeCount()
+			peak = max(peak, inUse)
+		}
+
+		moves, zeros := core.window.GetEliminated()
+		_, freeListStalls := core.window.GetDispatchStalls()
+		fmt.Fprintf(&b, "  %-5s %7.3f %8d %7d %7d %9d %6d\n",
+			map[int]string{0: "off", 1: "on"}[on], core.GetIPC(), core.instructions,
+			moves, zeros, freeListStalls, peak)
+	}
+
+	return b.String()
+}
+
+// RunMemoryArbiterComparison runs a program with every prefetcher on
+// (FTQ frontend, 5-way predictor, all four L1D prefetchers) under
+// several port counts and arbitration policies
+//
+// WHAT TO LOOK FOR: Demand wait climbing as ports shrink means the
+//
+//	prefetchers are competing with the misses they are meant to hide
+func RunMemoryArbiterComparison(name string, program []uint32, cycles uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
 ╔═══════════════════════════════════════════════════════════════════════════╗
-║  BENCHMARK: %-60s  ║
+║  MEMORY PORTS: %-57s  ║
 ╚═══════════════════════════════════════════════════════════════════════════╝
 
-%s
-`, name, core.GetStats())
+  %-5s %-12s %7s %7s %7s   %s
+`, name, "Ports", "Policy", "IPC", "IStall", "Util", "Avg wait (i-dem d-dem i-pf d-pf)")
+
+	var last *Core
+	for _, ports := range []int{1, 2, 4} {
+		for _, policy := range []ArbiterPolicy{ArbiterDemandFirst, ArbiterRoundRobin} {
+			core := NewCore(1024 * 1024) // 1MB memory
+			core.SetMemoryPorts(ports, policy)
+			core.EnableFDIP(true)
+			core.dcache.AddPrefetcher(NewNextLinePrefetcher())
+			core.dcache.AddPrefetcher(NewStreamPrefetcher())
+			core.dcache.AddPrefetcher(NewBestOffsetPrefetcher())
+			core.dcache.AddPrefetcher(NewSMSPrefetcher())
+			core.LoadProgram(program, 0x1000)
+			core.Run(cycles)
+
+			fmt.Fprintf(&b, "  %-5d %-12s %7.3f %6.2f%% %6.2f%%  ",
+				ports, policy, core.GetIPC(), core.GetFetchStallRate()*100,
+				core.arbiter.GetPortUtilization()*100)
+			for k := MemClass(0); k < NumMemClasses; k++ {
+				fmt.Fprintf(&b, " %6.1f", core.arbiter.GetStats(k).AvgWait())
+			}
+			b.WriteString("\n")
+			last = core
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s", last.arbiter.Report())
+	return b.String()
 }
 
 // CompareWithIntel provides a detailed comparison with Intel
@@ -6538,6 +11869,347 @@ THE VERDICT: Nearly the same performance with 1,175× fewer transistors!
 		((intelTransistors-ourTransistors)/intelTransistors)*100*0.8)
 }
 
+// ═══════════════════════════════════════════════════════════════════════════════
+// DESIGN-SPACE SWEEP
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: The sizing comments quote sweep tables (window 32/40/48/64,
+//              issue 5/6/7/8-wide) but nothing regenerates them
+//
+// THE SOLUTION: A grid of CoreConfig parameters × a set of benchmarks
+//   Points:     Cartesian product of the swept values over a base config
+//   Execution:  Every (point, benchmark) pair on its own Core, a pool of
+//               goroutines running them concurrently
+//   Output:     One row per pair - IPC, MPKI, hit rates and estimated
+//               transistor cost - as CSV or JSON
+//   Invalid:    Points that fail Validate are kept with their error (so
+//               the table shows the hole) but not run
+//
+// TRANSISTOR ESTIMATE: First-order, calibrated to the 22.1M design
+//   Per-structure costs below times the configured counts; everything
+//   not configurable (predictors, decode, ...) is the fixed remainder,
+//   so the default config estimates exactly 22.1M
+//
+// MINECRAFT ANALOGY: Building the same farm in a dozen test worlds at
+//   once, each with one thing changed, and writing down the yields
+
+const (
+	BaselineTransistors = 22.1e6 // The shipped design (DefaultCoreConfig)
+
+	TransistorsPerWindowEntry  = 1750   // INNOVATION #34: see WindowSize
+	TransistorsPerPhysReg      = 32 * 8 // 32 bits of multi-ported register cell
+	TransistorsPerRefCounter   = 150    // Move elimination: holder count per register
+	TransistorsPerIssuePort    = 60000  // Select + bypass network per port
+	TransistorsPerDispatchLane = 40000  // Decode + rename per lane
+	TransistorsPerCommitLane   = 15000  // Retire + free per lane
+	TransistorsPerALU          = 25000  // Adder + barrel shifter
+	TransistorsPerMUL          = 80000  // INNOVATION #12: Wallace tree
+	TransistorsPerMulStage     = 1500   // Pipeline register between Wallace levels
+	TransistorsPerResultPort   = 10000  // Multiply result bus port + bypass
+	TransistorsPerDIV          = 40000  // INNOVATION #16: Table + iteration
+	TransistorsPerDivOverlap   = 20000  // Iteration multiplier per extra op in flight
+	TransistorsPerLSU          = 30000  // Address adder + queue slot
+	TransistorsPerCacheBit     = 6      // 6T SRAM (128KB L1I ≈ 6.1M)
+	TransistorsPerRSBEntry     = 256    // INNOVATION #31: 32-bit entry
+	TransistorsPerMemPort      = 50000  // DRAM request port
+	TransistorsPerLatchSlot    = 1000   // Frontend latch / fetch queue slot in flops
+)
+
+// configurableTransistors estimates the transistors that scale with cfg
+func configurableTransistors(cfg CoreConfig) float64 {
+	// Divides in flight per unit: latency / issue interval, rounded up
+	divOverlap := (cfg.DivLatency + max(cfg.DivIssueInterval, 1) - 1) / max(cfg.DivIssueInterval, 1)
+
+	t := cfg.WindowSize*TransistorsPerWindowEntry +
+		cfg.NumPhysRegs*TransistorsPerPhysReg +
+		cfg.MoveElimination*cfg.NumPhysRegs*TransistorsPerRefCounter +
+		cfg.IssueWidth*TransistorsPerIssuePort +
+		cfg.DispatchWidth*TransistorsPerDispatchLane +
+		cfg.CommitWidth*TransistorsPerCommitLane +
+		cfg.NumALUs*TransistorsPerALU +
+		cfg.NumMULs*TransistorsPerMUL +
+		cfg.NumMULs*(cfg.MulLatency-1)*TransistorsPerMulStage +
+		cfg.MulResultPorts*TransistorsPerResultPort +
+		cfg.NumDIVs*TransistorsPerDIV +
+		cfg.NumDIVs*(divOverlap-1)*TransistorsPerDivOverlap +
+		cfg.NumLSUs*TransistorsPerLSU +
+		(cfg.L1IBufferSize*cfg.L1IBufferCount+cfg.L1DCacheSize)*8*TransistorsPerCacheBit +
+		cfg.RSBSize*TransistorsPerRSBEntry +
+		cfg.MemPorts*TransistorsPerMemPort +
+		((cfg.frontendDepth()-1)*cfg.DispatchWidth+cfg.FetchQueueSize)*TransistorsPerLatchSlot
+	return float64(t)
+}
+
+// EstimateTransistors returns the estimated transistor count of cfg
+func EstimateTransistors(cfg CoreConfig) float64 {
+	fixed := BaselineTransistors - configurableTransistors(DefaultCoreConfig())
+	return fixed + configurableTransistors(cfg)
+}
+
+// SweepParam is one swept axis: a CoreConfig field and its values
+type SweepParam struct {
+	Name   string // JSON field name (see CoreConfigFields)
+	Values []int
+}
+
+// SweepBenchmark is one program run at every sweep point
+type SweepBenchmark struct {
+	Name    string
+	Program []uint32
+	Cycles  uint64
+}
+
+// Sweep describes a design-space exploration run
+type Sweep struct {
+	Base       CoreConfig // Values for every field not swept
+	Params     []SweepParam
+	Benchmarks []SweepBenchmark
+	Workers    int // Concurrent cores (0 = one per CPU)
+	MemorySize int // Bytes of memory per core (0 = 1MB)
+}
+
+// SweepResult is one (point, benchmark) row of a sweep
+type SweepResult struct {
+	Point     int        `json:"point"` // Index into Points()
+	Config    CoreConfig `json:"config"`
+	Benchmark string     `json:"benchmark"`
+	Error     string     `json:"error,omitempty"` // Config failed Validate: not run
+
+	Cycles       uint64  `json:"cycles"`
+	Instructions uint64  `json:"instructions"`
+	IPC          float64 `json:"ipc"`
+	BranchMPKI   float64 `json:"branch_mpki"` // Mispredicts per 1000 instructions
+	L1IMPKI      float64 `json:"l1i_mpki"`
+	L1DMPKI      float64 `json:"l1d_mpki"`
+	L1IHitRate   float64 `json:"l1i_hit_rate"`
+	L1DHitRate   float64 `json:"l1d_hit_rate"`
+
+	WindowStallRate   float64 `json:"window_stall_rate"`    // Dispatch: window full
+	FreeListStallRate float64 `json:"free_list_stall_rate"` // Dispatch: no free register
+	Transistors       float64 `json:"transistors"`
+	IPCPerMT          float64 `json:"ipc_per_mtransistor"`
+}
+
+// Points returns every config in the grid, first parameter varying slowest
+//
+// A sweep without benchmarks is an error: it would produce no rows
+func (s *Sweep) Points() ([]CoreConfig, error) {
+	if len(s.Benchmarks) == 0 {
+		return nil, fmt.Errorf("sweep: no benchmarks")
+	}
+	points := []CoreConfig{s.Base}
+
+	for _, p := range s.Params {
+		if len(p.Values) == 0 {
+			return nil, fmt.Errorf("sweep: parameter %q has no values", p.Name)
+		}
+
+		next := make([]CoreConfig, 0, len(points)*len(p.Values))
+		for _, base := range points {
+			for _, v := range p.Values {
+				cfg := base
+				if err := cfg.Set(p.Name, v); err != nil {
+					return nil, err
+				}
+				next = append(next, cfg)
+			}
+		}
+		points = next
+	}
+	return points, nil
+}
+
+// Run executes every benchmark at every point, concurrently
+//
+// ALGORITHM:
+//
+//	STEP 1: Expand the grid (Points)
+//	STEP 2: Queue one job per (point, benchmark)
+//	STEP 3: Workers each build a fresh Core, run, and fill in their row
+//	STEP 4: Return rows in point-major order (independent of scheduling)
+//
+// Cores share nothing, so results are identical to a serial run
+func (s *Sweep) Run() ([]SweepResult, error) {
+	points, err := s.Points()
+	if err != nil {
+		return nil, err
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	memorySize := s.MemorySize
+	if memorySize <= 0 {
+		memorySize = 1024 * 1024
+	}
+
+	results := make([]SweepResult, len(points)*len(s.Benchmarks))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				point := i / len(s.Benchmarks)
+				results[i] = runSweepPoint(point, points[point], s.Benchmarks[i%len(s.Benchmarks)], memorySize)
+			}
+		}()
+	}
+
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// runSweepPoint runs one benchmark on a core built from cfg
+func runSweepPoint(point int, cfg CoreConfig, bench SweepBenchmark, memorySize int) SweepResult {
+	r := SweepResult{
+		Point:       point,
+		Config:      cfg,
+		Benchmark:   bench.Name,
+		Transistors: EstimateTransistors(cfg),
+	}
+
+	core, err := NewCoreWithConfig(cfg, memorySize)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	core.LoadProgram(bench.Program, 0x1000)
+	core.Run(bench.Cycles)
+
+	r.Cycles = core.cycles
+	r.Instructions = core.instructions
+	r.IPC = core.GetIPC()
+	r.L1IHitRate = core.icache.GetHitRate()
+	r.L1DHitRate = core.dcache.GetHitRate()
+	r.WindowStallRate, r.FreeListStallRate = core.GetDispatchStallRates()
+	r.IPCPerMT = r.IPC / (r.Transistors / 1e6)
+
+	if core.instructions > 0 {
+		kilo := float64(core.instructions) / 1000
+		r.BranchMPKI = float64(core.branchMispredicts) / kilo
+		r.L1IMPKI = float64(core.icache.misses) / kilo
+		r.L1DMPKI = float64(core.dcache.accesses-core.dcache.hits) / kilo
+	}
+	return r
+}
+
+// WriteSweepCSV writes results as CSV, one column per config field
+func WriteSweepCSV(w io.Writer, results []SweepResult) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"point", "benchmark"}
+	header = append(header, CoreConfigFields[:]...)
+	header = append(header, "cycles", "instructions", "ipc", "branch_mpki", "l1i_mpki", "l1d_mpki",
+		"l1i_hit_rate", "l1d_hit_rate", "window_stall_rate", "free_list_stall_rate",
+		"transistors", "ipc_per_mtransistor", "error")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		row := []string{strconv.Itoa(r.Point), r.Benchmark}
+		for _, name := range CoreConfigFields {
+			row = append(row, strconv.Itoa(*r.Config.field(name)))
+		}
+		row = append(row,
+			strconv.FormatUint(r.Cycles, 10),
+			strconv.FormatUint(r.Instructions, 10),
+			strconv.FormatFloat(r.IPC, 'f', 4, 64),
+			strconv.FormatFloat(r.BranchMPKI, 'f', 3, 64),
+			strconv.FormatFloat(r.L1IMPKI, 'f', 3, 64),
+			strconv.FormatFloat(r.L1DMPKI, 'f', 3, 64),
+			strconv.FormatFloat(r.L1IHitRate, 'f', 4, 64),
+			strconv.FormatFloat(r.L1DHitRate, 'f', 4, 64),
+			strconv.FormatFloat(r.WindowStallRate, 'f', 4, 64),
+			strconv.FormatFloat(r.FreeListStallRate, 'f', 4, 64),
+			strconv.FormatFloat(r.Transistors, 'f', 0, 64),
+			strconv.FormatFloat(r.IPCPerMT, 'f', 4, 64),
+			r.Error,
+		)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSweepJSON writes results as an indented JSON array
+func WriteSweepJSON(w io.Writer, results []SweepResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// SweepBenchmarks returns the standard benchmark set for sweeps
+func SweepBenchmarks(cycles uint64) []SweepBenchmark {
+	return []SweepBenchmark{
+		{"Array Sum", CreateArraySumProgram(), cycles},
+		{"Linked List", CreateLinkedListProgram(), cycles},
+		{"Multiply", CreateMultiplyBenchmark(), cycles},
+		{"Divide", CreateDivideBenchmark(), cycles},
+		{"Branch Prediction", CreateBranchPredictionTest(), cycles},
+		{"Out-of-Order", CreateOutOfOrderTest(), cycles},
+		{"Comprehensive", CreateComprehensiveBenchmark(), cycles},
+	}
+}
+
+// RunSweepReport runs a sweep and returns a per-point summary table
+//
+// Rows average IPC and MPKI over the benchmarks; errors show in place
+func RunSweepReport(name string, s *Sweep) string {
+	results, err := s.Run()
+	if err != nil {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+╔═══════════════════════════════════════════════════════════════════════════╗
+║  DESIGN SWEEP: %-57s  ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+
+`, name)
+
+	for _, p := range s.Params {
+		fmt.Fprintf(&b, "  %-16s", p.Name)
+	}
+	fmt.Fprintf(&b, " %7s %8s %8s %8s %8s\n", "IPC", "BrMPKI", "L1DMPKI", "Trans(M)", "IPC/MT")
+
+	n := len(s.Benchmarks)
+	for i := 0; i+n <= len(results); i += n {
+		row := results[i : i+n]
+		for _, p := range s.Params {
+			fmt.Fprintf(&b, "  %-16d", *row[0].Config.field(p.Name))
+		}
+		if row[0].Error != "" {
+			fmt.Fprintf(&b, " %s\n", row[0].Error)
+			continue
+		}
+
+		var ipc, brMPKI, dMPKI float64
+		for _, r := range row {
+			ipc += r.IPC
+			brMPKI += r.BranchMPKI
+			dMPKI += r.L1DMPKI
+		}
+		k := float64(n)
+		fmt.Fprintf(&b, " %7.3f %8.2f %8.2f %8.2f %8.4f\n",
+			ipc/k, brMPKI/k, dMPKI/k, row[0].Transistors/1e6, ipc/k/(row[0].Transistors/1e6))
+	}
+	return b.String()
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // COMPLETE INNOVATION CATALOG (ALL 73 INNOVATIONS)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -6779,6 +12451,11 @@ USAGE:
   4. Get statistics:
      fmt.Println(core.GetStats())
 
+  Other sizes: build from a CoreConfig (JSON via LoadCoreConfig/Save)
+     cfg := DefaultCoreConfig()
+     cfg.WindowSize = 64
+     core, err := NewCoreWithConfig(cfg, 1024*1024)
+
 INSTRUCTION SET:
 
   R-FORMAT: [opcode:5][rd:5][rs1:5][rs2:5][unused:12]
@@ -6808,6 +12485,8 @@ BENCHMARKS INCLUDED:
   - CreateLinkedListProgram():      Markov predictor test
   - CreateMultiplyBenchmark():      1-cycle multiply test
   - CreateDivideBenchmark():        4-cycle divide test
+  - CreateDivideMixBenchmark():     Signed/unsigned divide pool test
+  - CreateMoveBenchmark():          Move elimination test
   - CreateBranchPredictionTest():   Branch predictor test
   - CreateAtomicTest():             LR/SC atomic test
   - CreateOutOfOrderTest():         OOO execution test
@@ -0,0 +1,3464 @@
+package suprax32
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// SUPRAX Core Model - Test Suite
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// These tests serve dual purposes:
+//   1. Functional verification: Ensure Go model behaves correctly
+//   2. Hardware specification: Define expected RTL behavior
+//
+// When you write SystemVerilog, run these same test vectors against RTL.
+// If Go and RTL produce identical outputs, the hardware is correct.
+//
+// WHAT WE'RE TESTING:
+// ──────────────────
+// SupraX.go is the whole-core model: frontend, window, execution units,
+// memory system and the experiment harnesses built on top of them. The
+// prototype packages under proto/ have their own suites; this file covers
+// the pieces that only exist in the core model, plus the contracts the core
+// shares with those packages (for example the branch trace format).
+//
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// TEST ORGANIZATION
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// 1. BRANCH TRACE FORMAT TESTS
+//    BranchTraceWriter output is byte-identical to the bptrace golden files
+//
+// 2. L1D PREDICTOR, PREFETCH QUEUE AND LSU TESTS
+//    Per-component counters and coverage, per-load prediction resolve,
+//    prefetch completion, demand miss fills, result hand-off to COMPLETE,
+//    opt-in feedback throttling
+//
+// 3. LOAD VALUE PREDICTION TESTS
+//    Last-value, stride and context components, consumer tracking, flush
+//    repair, report figures
+//
+// 4. HARDWARE PREFETCHER TESTS
+//    Next-line, stream, Best-Offset and SMS on synthetic streams, shared queue
+//
+// 5. FETCH TARGET QUEUE TESTS
+//    Run-ahead RSB pushes and pops, RSB repair on redirect
+//
+// 6. INSTRUCTION FETCH PATH TESTS
+//    L1I miss stall through the fill unit and arbiter, indirect JALR targets,
+//    per-source prefetch counters, buffer activation/reuse/thrash
+//
+// 7. MEMORY ARBITER TESTS
+//    Grant order per policy, ports per cycle, latency, occupancy statistics
+//
+// 8. FRONTEND PIPELINE TESTS
+//    Stage depth against first dispatch and mispredict refill, fetch stop
+//    reasons, average fetch width
+//
+// 9. RUNTIME CONFIGURATION TESTS
+//    JSON round trip, validation, cores built from non-default configs
+//
+// 10. DESIGN SWEEP TESTS
+//    Grid expansion, invalid points, CSV output, empty sweeps
+//
+// 11. PHYSICAL REGISTER FILE TESTS
+//    Commit frees the previous mapping, flush restores the committed RAT,
+//    free-list versus window dispatch stalls
+//
+// 12. MISPREDICT RECOVERY TESTS
+//    Commit-time recovery by default, opt-in execute-time recovery
+//
+// 13. ISSUE SCHEDULING TESTS
+//    Policy changes only while empty, producer index, policy-independent results
+//
+// 14. MULTIPLIER TESTS
+//    MultiplyOp against native products, result ports, issue interval, squash
+//
+// 15. DIVIDER TESTS
+//    DivideOp against native division, fast cases, opt-in early-out, squash
+//
+// 16. MOVE ELIMINATION TESTS
+//    Recognized idioms, shared-register reference counts, squash and flush
+//
+// COVERAGE CATEGORIES:
+//   [UNIT]        Single function/component in isolation
+//   [INTEGRATION] Multiple components working together
+//   [INVARIANT]   Properties that must ALWAYS hold
+//   [BOUNDARY]    Edge cases at limits of valid input
+//   [REGRESSION]  Specific bug scenarios
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 1. BRANCH TRACE FORMAT TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// The core and proto/bptrace live in separate modules, so the writer here
+// cannot share code with bptrace.Reader. Instead both sides are pinned to the
+// golden files in proto/bptrace/testdata: this suite checks that
+// BranchTraceWriter produces them byte for byte, and the bptrace suite checks
+// that its Reader decodes them to the expected records.
+//
+// INVARIANTS:
+//   - Text and binary traces of the same branches match the golden files
+//   - Changing either format without the other fails one of the two suites
+
+// writeGoldenTrace records the branches behind proto/bptrace/testdata/suprax.*
+// (the bptrace test lists the same records as goldenSupraXRecords).
+func writeGoldenTrace(t *testing.T, binary bool) []byte {
+	var buf bytes.Buffer
+	w := NewBranchTraceWriter(&buf, binary)
+	w.Record(0x1040, 0x1000, BranchTraceCond, true, 6)
+	w.Record(0x1040, 0x1000, BranchTraceCond, false, 6)
+	w.Record(0x1044, 0x2000, BranchTraceCall, true, 1)
+	w.Record(0x2010, 0x1048, BranchTraceReturn, true, 4)
+	w.Record(0x1050, 0x3000, BranchTraceJump, true, 2)
+	w.Record(0x3008, 0xFFFFFFF0, BranchTraceIndirect, true, 0x12345678)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBranchTraceWriter_MatchesGolden(t *testing.T) {
+	// WHAT: Text and binary output equal the bptrace golden files byte for byte
+	// WHY: Traces recorded by the core must replay through bptrace.Reader
+	// HARDWARE: Retirement trace port (debug only, not in the datapath)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	cases := []struct {
+		file   string
+		binary bool
+	}{
+		{"proto/bptrace/testdata/suprax.txt", false},
+		{"proto/bptrace/testdata/suprax.bptr", true},
+	}
+	for _, c := range cases {
+		want, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := writeGoldenTrace(t, c.binary); !bytes.Equal(got, want) {
+			t.Errorf("%s: writer produced\n%q\nexpected\n%q", c.file, got, want)
+		}
+	}
+}
+
+func TestBranchTraceWriter_EmptyBinaryHasHeader(t *testing.T) {
+	// WHAT: Flushing a binary writer with no records still emits the header
+	// WHY: bptrace.Reader detects the binary format from the magic bytes
+	// HARDWARE: N/A (trace file framing)
+	// CATEGORY: [BOUNDARY]
+
+	var buf bytes.Buffer
+	if err := NewBranchTraceWriter(&buf, true).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{'B', 'P', 'T', 'R', 1, 0, 0, 0}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Empty binary trace %q, expected header %q", buf.Bytes(), want)
+	}
+}
+
+func TestBranchTraceType_Classification(t *testing.T) {
+	// WHAT: JAL/JALR/conditional forms map to the shared bptrace type numbering
+	// WHY: Predictor studies split accuracy by type; a wrong type skews RSB numbers
+	// HARDWARE: Decode-time branch class bits
+	// CATEGORY: [UNIT]
+
+	cases := []struct {
+		name    string
+		opcode  uint8
+		rd, rs1 uint8
+		imm     int32
+		want    uint8
+	}{
+		{"BEQ", OpBEQ, 0, 2, 16, BranchTraceCond},
+		{"JAL x0", OpJAL, 0, 0, 64, BranchTraceJump},
+		{"JAL ra", OpJAL, 1, 0, 64, BranchTraceCall},
+		{"JALR x0, ra, 0", OpJALR, 0, 1, 0, BranchTraceReturn},
+		{"JALR ra, t0, 0", OpJALR, 1, 5, 0, BranchTraceCall},
+		{"JALR x0, ra, 4", OpJALR, 0, 1, 4, BranchTraceIndirect},
+		{"JALR x0, t0, 0", OpJALR, 0, 5, 0, BranchTraceIndirect},
+	}
+	for _, c := range cases {
+		if got := BranchTraceType(c.opcode, c.rd, c.rs1, c.imm); got != c.want {
+			t.Errorf("%s: type %d, expected %d", c.name, got, c.want)
+		}
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 2. L1D PREDICTOR, PREFETCH QUEUE AND LSU TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// The L1D predictor counts, per component, what it offered, what the
+// meta-predictor selected and what turned out right or useful. The prefetch
+// queue holds predicted byte addresses while fills arrive line aligned; the
+// LSU waits on the memory arbiter for demand misses and holds a finished
+// result until COMPLETE collects it.
+//
+// INVARIANTS:
+//   - Only the component that made a prediction is credited for it
+//   - Each load is resolved against the prediction it carried from dispatch
+//   - A fill retires the queued prefetch for its line, whatever byte was predicted
+//   - A missing load installs its line and returns the loaded word
+//   - An LSU with an uncollected result does not accept new work
+
+// trainStride runs n loads of one PC through the predictor the way the
+// core does: predict at dispatch, resolve at address generation, train
+func trainStride(p *L1DPredictor, pc, base, stride uint32, n int) {
+	for i := 0; i < n; i++ {
+		addr := base + uint32(i)*stride
+		if predAddr, id, ok := p.Predict(pc); ok {
+			p.Resolve(pc, predAddr, id, addr)
+		}
+		p.RecordLoad(pc, addr)
+	}
+}
+
+func TestL1DPredictor_ComponentStatsOnStride(t *testing.T) {
+	// WHAT: A strided load moves the stride component's counters and no other's
+	// WHY: The per-component breakdown is only useful if it credits the right component
+	// HARDWARE: Per-component event counters, indexed by the selected predictor ID
+	// CATEGORY: [UNIT] [PATTERN]
+
+	const loads = 200
+	p := NewL1DPredictor()
+	trainStride(p, 0x1100, 0x8000, CacheLineSize, loads)
+
+	if p.GetLoadCount() != loads {
+		t.Errorf("GetLoadCount = %d, expected %d", p.GetLoadCount(), loads)
+	}
+
+	st := p.GetComponentStats(PredictorStride)
+	if st.Offered < loads-10 || st.Selected != st.Offered || st.Resolved != st.Selected {
+		t.Errorf("Stride offered %d, selected %d, resolved %d; expected all of the last %d+ loads",
+			st.Offered, st.Selected, st.Resolved, loads-10)
+	}
+	if st.Correct != st.Resolved {
+		t.Errorf("Stride correct %d of %d resolved on a perfect stride", st.Correct, st.Resolved)
+	}
+	if st.Useful != 0 {
+		t.Errorf("Stride credited %d useful prefetches with no cache involved", st.Useful)
+	}
+
+	for id := PredictorMarkov; id <= PredictorContext; id++ {
+		if st := p.GetComponentStats(id); st != (L1DComponentStats{}) {
+			t.Errorf("%v counters moved on a stride pattern: %+v", id, st)
+		}
+	}
+	if st := p.GetComponentStats(NumPredictorIDs); st != (L1DComponentStats{}) {
+		t.Errorf("Out-of-range predictor ID returned %+v", st)
+	}
+}
+
+func TestL1DPredictor_CoverageReport(t *testing.T) {
+	// WHAT: Coverage is Correct / Loads per component, and the report prints it
+	//       beside the claimed budget
+	// WHY: The report is how the 70/15/5/3/5 claim gets checked per benchmark
+	// HARDWARE: N/A (statistics)
+	// CATEGORY: [UNIT]
+
+	p := NewL1DPredictor()
+	if p.GetCoverage(PredictorStride) != 0 {
+		t.Error("Coverage is nonzero before any load")
+	}
+	trainStride(p, 0x1100, 0x8000, CacheLineSize, 200)
+
+	st := p.GetComponentStats(PredictorStride)
+	cover := float64(st.Correct) / 200
+	if got := p.GetCoverage(PredictorStride); got != cover || got < 0.9 {
+		t.Errorf("Stride coverage %.3f, expected Correct/Loads = %.3f (over 0.9)", got, cover)
+	}
+	if got := p.GetCoverage(PredictorMarkov); got != 0 {
+		t.Errorf("Markov coverage %.3f on a stride pattern", got)
+	}
+
+	report := p.CoverageReport()
+	for _, want := range []string{
+		"200 loads",
+		fmt.Sprintf("%7.2f%%", cover*100), // stride's measured coverage
+		fmt.Sprintf("%7.2f%%", ClaimedCoverage[PredictorStride]*100), // and its budget
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report lacks %q:\n%s", want, report)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+	if len(lines) != 8 || !strings.HasPrefix(strings.TrimSpace(lines[2]), "stride") ||
+		!strings.HasPrefix(strings.TrimSpace(lines[7]), "Total") {
+		t.Errorf("Report has unexpected rows:\n%s", report)
+	}
+}
+
+func TestL1DPredictor_ResolvesCarriedPrediction(t *testing.T) {
+	// WHAT: Two predictions made back to back resolve, in either order, against
+	//       the addresses they were made for
+	// WHY: A single "last prediction" register checked the first load against
+	//      the second load's guess
+	// HARDWARE: Predicted address and predictor ID ride in the window entry
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	p := NewL1DPredictor()
+	trainStride(p, 0x1100, 0x8000, 4, 20)  // next: 0x8050
+	trainStride(p, 0x1104, 0xC000, 64, 20) // next: 0xC500
+
+	addrA, idA, okA := p.Predict(0x1100)
+	addrB, idB, okB := p.Predict(0x1104)
+	if !okA || !okB || addrA != 0x8050 || addrB != 0xC500 {
+		t.Fatalf("Predictions %#x/%v and %#x/%v, expected 0x8050 and 0xc500", addrA, okA, addrB, okB)
+	}
+
+	before := p.GetComponentStats(PredictorStride)
+	p.Resolve(0x1104, addrB, idB, 0xC500) // Younger load resolves first
+	p.Resolve(0x1100, addrA, idA, 0x8050)
+	after := p.GetComponentStats(PredictorStride)
+
+	if after.Resolved-before.Resolved != 2 || after.Correct-before.Correct != 2 {
+		t.Errorf("Resolved %d, correct %d of two correct predictions",
+			after.Resolved-before.Resolved, after.Correct-before.Correct)
+	}
+}
+
+func TestCore_ConcurrentLoadsResolveOwnPrediction(t *testing.T) {
+	// WHAT: With two LSUs issuing in the same cycle, every load is checked
+	//       against the prediction its own window entry carried
+	// WHY: Matching by the predictor's last PC/address credited one load's
+	//      outcome to the other, or dropped it
+	// HARDWARE: Issue reads PredictedMemAddr from the entry beside the AGU result
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	program := []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x2000), // r1 = &a
+		EncodeIFormat(OpADDI, 2, 0, 0),      // r2 = 0 (counter)
+		EncodeIFormat(OpADDI, 4, 0, 300),    // r4 = 300 (limit)
+		EncodeIFormat(OpLW, 6, 1, 0),        // Loop: r6 = a[i]
+		EncodeIFormat(OpLW, 7, 1, 0x4000),   // r7 = b[i] (independent, same cycle)
+		EncodeIFormat(OpADDI, 1, 1, 4),      // i++
+		EncodeIFormat(OpADDI, 2, 2, 1),      // counter++
+		EncodeBFormat(OpBLT, 2, 4, -16),     // if counter < 300, loop
+		EncodeIFormat(OpADDI, 8, 0, 42),     // r8 = 42 (done)
+	}
+	core := NewCore(1024 * 1024)
+	core.LoadProgram(program, 0x1000)
+
+	// Reference: compare each load's carried prediction with the address
+	// its LSU received, the cycle it issues
+	var resolved, correct, dual uint64
+	for core.cycles < 20000 {
+		waiting := make(map[int]bool)
+		for i := range core.window.entries {
+			if e := &core.window.entries[i]; e.Valid && e.IsLoad && !e.Issued {
+				waiting[i] = true
+			}
+		}
+
+		core.Cycle()
+
+		issued := 0
+		for _, lsu := range core.lsus {
+			id := lsu.WindowID()
+			if !waiting[id] || !core.window.entries[id].Issued {
+				continue
+			}
+			issued++
+			if e := &core.window.entries[id]; e.HasMemPrediction {
+				resolved++
+				if e.PredictedMemAddr == lsu.op.Addr {
+					correct++
+				}
+			}
+		}
+		if issued == 2 {
+			dual++
+		}
+	}
+
+	if core.window.ReadArchReg(8) != 42 {
+		t.Fatalf("r8 = %d, expected 42 (program finished)", core.window.ReadArchReg(8))
+	}
+	if dual == 0 {
+		t.Fatal("No cycle issued two loads; the test does not exercise overlap")
+	}
+
+	st := core.dcache.predictor.GetComponentStats(PredictorStride)
+	if st.Resolved != resolved || st.Correct != correct {
+		t.Errorf("Predictor resolved %d (%d correct); loads carried %d predictions (%d correct)",
+			st.Resolved, st.Correct, resolved, correct)
+	}
+	if correct == 0 {
+		t.Error("No carried prediction was correct")
+	}
+}
+
+func TestL1DCache_UsefulCreditsPrefetchingComponent(t *testing.T) {
+	// WHAT: The first demand hit on a prefetched line credits its predictor once;
+	//       demand-filled lines credit nobody
+	// WHY: Useful separates prefetches that helped from ones that only matched
+	// HARDWARE: Predictor ID tag per cache line, cleared on first demand hit
+	// CATEGORY: [UNIT] [LIFECYCLE]
+
+	c := NewL1DCache()
+	line := make([]byte, CacheLineSize)
+
+	c.IssuePrefetch(0x9010, PredictorStride)
+	addr, ok := c.GetNextPrefetch()
+	if !ok {
+		t.Fatal("Prefetch was not queued")
+	}
+	c.Fill(addr&^(CacheLineSize-1), line)
+	c.FillDemand(0xA000, line)
+
+	for i := 0; i < 2; i++ {
+		if _, hit := c.Read(0x1100, 0x9014); !hit {
+			t.Fatal("Prefetched line missed")
+		}
+		if _, hit := c.Read(0x1104, 0xA000); !hit {
+			t.Fatal("Demand-filled line missed")
+		}
+	}
+
+	if got := c.predictor.GetComponentStats(PredictorStride).Useful; got != 1 {
+		t.Errorf("Stride credited %d useful prefetches, expected 1", got)
+	}
+	for id := PredictorMarkov; id <= PredictorContext; id++ {
+		if got := c.predictor.GetComponentStats(id).Useful; got != 0 {
+			t.Errorf("%v credited %d useful prefetches it never made", id, got)
+		}
+	}
+}
+
+func TestPrefetchQueue_CompleteMatchesLine(t *testing.T) {
+	// WHAT: A line-aligned fill completes the in-flight entry for any byte in that line
+	// WHY: Comparing whole addresses left the head InFlight forever, stalling the queue
+	// HARDWARE: Line-address CAM on the prefetch queue (addr[31:6] compare)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	var pq PrefetchQueue
+	if !pq.Enqueue(0x1234, PredictorStride) {
+		t.Fatal("Enqueue rejected on an empty queue")
+	}
+	if addr, ok := pq.Dequeue(); !ok || addr != 0x1234 {
+		t.Fatalf("Dequeue = %#x/%v, expected 0x1234", addr, ok)
+	}
+
+	if by := pq.Complete(0x1200); by != PredictorStride {
+		t.Errorf("Complete credited predictor %d, expected %d", by, PredictorStride)
+	}
+	if pq.count != 0 {
+		t.Errorf("Queue holds %d entries after completing the head, expected 0", pq.count)
+	}
+
+	pq.Enqueue(0x2000, PredictorStride)
+	if addr, ok := pq.Dequeue(); !ok || addr != 0x2000 {
+		t.Errorf("Next prefetch blocked: Dequeue = %#x/%v, expected 0x2000", addr, ok)
+	}
+	if by := pq.Complete(0x3000); by != PredictorNone {
+		t.Errorf("Fill of an unrequested line credited predictor %d", by)
+	}
+}
+
+// runLSU ticks the arbiter and LSU until a result appears or limit cycles pass
+func runLSU(lsu *LSU, arb *MemoryArbiter, limit int) (data uint32, cycles int, ok bool) {
+	for cycles = 1; cycles <= limit; cycles++ {
+		arb.Tick()
+		lsu.Tick()
+		if lsu.resultValid {
+			data, _, _, ok = lsu.GetResult()
+			return data, cycles, ok
+		}
+	}
+	return 0, cycles, false
+}
+
+func TestLSU_DemandMissFillsLine(t *testing.T) {
+	// WHAT: A load that misses waits on DRAM, installs the line and returns the word
+	// WHY: Misses used to retry the cache forever because nothing filled the line
+	// HARDWARE: Miss handling register + fill write port into L1D
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	mem := make([]byte, 4096)
+	mem[0x840], mem[0x841], mem[0x842], mem[0x843] = 0xEF, 0xBE, 0xAD, 0xDE
+	dcache := NewL1DCache()
+	arb := NewMemoryArbiter(mem, 1, ArbiterDemandFirst)
+	lsu := NewLSU(dcache, arb)
+
+	lsu.Issue(MemoryOperation{PC: 0x100, Addr: 0x840, Rd: 5, WindowID: 3})
+	data, cycles, ok := runLSU(lsu, arb, 4*DRAMLatency)
+	if !ok {
+		t.Fatalf("Missing load never completed within %d cycles", 4*DRAMLatency)
+	}
+	if data != 0xDEADBEEF {
+		t.Errorf("Loaded %#x, expected 0xdeadbeef", data)
+	}
+	if cycles < DRAMLatency {
+		t.Errorf("Miss completed in %d cycles, faster than DRAM latency %d", cycles, DRAMLatency)
+	}
+
+	// The line is now resident and is not credited to any prefetcher
+	set, tag := dcache.getSetIndex(0x840), dcache.getTag(0x840)
+	way := -1
+	for w := 0; w < L1Associativity; w++ {
+		if dcache.sets[set][w].Valid && dcache.sets[set][w].Tag == tag {
+			way = w
+		}
+	}
+	if way < 0 {
+		t.Fatal("Demand fill did not install the line")
+	}
+	if by := dcache.prefetchInfo[set][way].By; by != PredictorNone {
+		t.Errorf("Demand line tagged as prefetched by predictor %d", by)
+	}
+
+	lsu.Issue(MemoryOperation{PC: 0x104, Addr: 0x844, Rd: 6, WindowID: 4})
+	if _, cycles, ok := runLSU(lsu, arb, 4*DRAMLatency); !ok || cycles > L1Latency {
+		t.Errorf("Load to the filled line took %d cycles (ok=%v), expected an L1 hit", cycles, ok)
+	}
+}
+
+func TestLSU_UnconsumedResultKeepsBusy(t *testing.T) {
+	// WHAT: IsBusy stays true from completion until GetResult hands the result over
+	// WHY: Issue clears resultValid, so issuing early would drop the finished load
+	// HARDWARE: Result-valid flop gates the LSU's ready signal to the scheduler
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	mem := make([]byte, 4096)
+	dcache := NewL1DCache()
+	arb := NewMemoryArbiter(mem, 1, ArbiterDemandFirst)
+	lsu := NewLSU(dcache, arb)
+
+	lsu.Issue(MemoryOperation{Addr: 0x200, IsStore: true, Data: 7, WindowID: 1})
+	for i := 0; i < L1Latency && !lsu.resultValid; i++ {
+		arb.Tick()
+		lsu.Tick()
+	}
+	if !lsu.resultValid {
+		t.Fatal("Store produced no result")
+	}
+	if !lsu.IsBusy() {
+		t.Error("LSU reports idle while its result is still uncollected")
+	}
+	if id := lsu.WindowID(); id != 1 {
+		t.Errorf("WindowID = %d while holding the result, expected 1", id)
+	}
+
+	if _, _, id, ok := lsu.GetResult(); !ok || id != 1 {
+		t.Fatalf("GetResult = window %d (ok=%v), expected window 1", id, ok)
+	}
+	if lsu.IsBusy() {
+		t.Error("LSU still busy after its result was collected")
+	}
+}
+
+func TestPrefetchThrottle_OptIn(t *testing.T) {
+	// WHAT: Feedback throttling is off by default and turned on by prefetch_throttle
+	// WHY: The controller changes prefetch behavior; the shipped design must not move
+	// HARDWARE: Enable bit on the throttle FSM
+	// CATEGORY: [UNIT]
+
+	cfg := DefaultCoreConfig()
+	if cfg.PrefetchThrottle != 0 || NewL1DCacheWithConfig(cfg).throttle.Enabled {
+		t.Error("Prefetch throttling is on in the default configuration")
+	}
+
+	if err := cfg.Set("prefetch_throttle", 1); err != nil {
+		t.Fatal(err)
+	}
+	if c := NewL1DCacheWithConfig(cfg); !c.throttle.Enabled || c.throttle.Degree != 1 {
+		t.Errorf("prefetch_throttle=1: enabled %v degree %d, expected on at degree 1",
+			c.throttle.Enabled, c.throttle.Degree)
+	}
+
+	cfg.PrefetchThrottle = 2
+	if cfg.Validate() == nil {
+		t.Error("prefetch_throttle=2 accepted, expected 0 or 1 only")
+	}
+}
+
+// checkReport fails t for every line of want missing from report
+func checkReport(t *testing.T, report string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(report, w) {
+			t.Errorf("Report lacks %q:\n%s", w, report)
+		}
+	}
+}
+
+func TestL1DCoverageReport_MatchesDirectRun(t *testing.T) {
+	// WHAT: The coverage report prints the counters of a plain run of the program
+	// WHY: The harness builds its own core; its numbers must be the design's
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateArraySumProgram()
+	core := NewCore(1024 * 1024)
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+
+	checkReport(t, RunL1DCoverageReport("Array Sum", program, cycles),
+		"L1D COVERAGE: Array Sum",
+		core.dcache.GetPredictorCoverageReport(),
+		core.dcache.PrefetchReport())
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 3. LOAD VALUE PREDICTION TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// The optional value predictor guesses a load's result at dispatch from
+// three components (last value, stride, value context). Complete verifies
+// the guess; a wrong value that a dependent already read is repaired by a
+// flush when the load commits.
+//
+// INVARIANTS:
+//   - A component predicts only once it reaches ValuePredMinConf
+//   - Value prediction never changes architectural state
+//   - Coverage is correct / loads, accuracy is correct / predicted
+
+// trainValues feeds values to the predictor as one load PC's results
+func trainValues(vp *LoadValuePredictor, pc uint32, values ...uint32) {
+	for _, v := range values {
+		vp.Update(pc, v)
+	}
+}
+
+func TestLoadValuePredictor_LastValue(t *testing.T) {
+	// WHAT: A load that keeps returning one value is predicted by last-value
+	//       once confident, and a different PC in the same slot is not
+	// WHY: Predicting before ValuePredMinConf risks a flush for little gain
+	// HARDWARE: Per-PC last value with a 4-bit confidence counter
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	vp := NewLoadValuePredictor()
+	if _, _, ok := vp.Predict(0x1100); ok {
+		t.Fatal("Empty predictor made a prediction")
+	}
+
+	// First update installs the entry, each repeat adds one confidence
+	for i := 0; i < ValuePredMinConf; i++ {
+		trainValues(vp, 0x1100, 7)
+		if _, _, ok := vp.Predict(0x1100); ok {
+			t.Fatalf("Predicted after %d updates, below ValuePredMinConf", i+1)
+		}
+	}
+	trainValues(vp, 0x1100, 7)
+	if v, kind, ok := vp.Predict(0x1100); !ok || v != 7 || kind != ValuePredLast {
+		t.Errorf("Predict = %d/%v/%v, expected 7 from last-value", v, kind, ok)
+	}
+
+	// Same index, different tag
+	if _, _, ok := vp.Predict(0x1100 + ValuePredTableSize*4); ok {
+		t.Error("Aliasing PC used another load's entry")
+	}
+
+	// One different value silences last-value again
+	trainValues(vp, 0x1100, 8)
+	if _, kind, ok := vp.Predict(0x1100); ok && kind == ValuePredLast {
+		t.Error("Last-value still predicting right after a wrong value")
+	}
+}
+
+func TestLoadValuePredictor_Stride(t *testing.T) {
+	// WHAT: A load returning an arithmetic sequence is predicted as last + stride
+	// WHY: Counters and induction variables reloaded from memory follow a stride
+	// HARDWARE: Per-PC stride register and adder
+	// CATEGORY: [UNIT] [PATTERN]
+
+	vp := NewLoadValuePredictor()
+	var last uint32
+	for i := 0; i < ValuePredMinConf+2; i++ {
+		last = 100 + uint32(i)*4
+		trainValues(vp, 0x1200, last)
+	}
+	if v, kind, ok := vp.Predict(0x1200); !ok || v != last+4 || kind != ValuePredStride {
+		t.Errorf("Predict = %d/%v/%v, expected %d from stride", v, kind, ok, last+4)
+	}
+
+	// Negative strides work the same way
+	vp = NewLoadValuePredictor()
+	for i := 0; i < ValuePredMinConf+2; i++ {
+		last = 1000 - uint32(i)*3
+		trainValues(vp, 0x1200, last)
+	}
+	if v, kind, ok := vp.Predict(0x1200); !ok || v != last-3 || kind != ValuePredStride {
+		t.Errorf("Predict = %d/%v/%v, expected %d from a negative stride", v, kind, ok, last-3)
+	}
+}
+
+func TestLoadValuePredictor_Context(t *testing.T) {
+	// WHAT: A repeating sequence with no stride is predicted from the last two values
+	// WHY: Last-value and stride both reset on every load of such a pattern
+	// HARDWARE: Value-history hash into a tagged context table
+	// CATEGORY: [UNIT] [PATTERN]
+
+	vp := NewLoadValuePredictor()
+	pattern := []uint32{12, 5, 30}
+	for i := 0; i < 3*(ValuePredMinConf+2); i++ {
+		trainValues(vp, 0x1300, pattern[i%3])
+	}
+
+	// Next in the pattern after ..., 12, 5, 30 is 12
+	for i := 0; i < 6; i++ {
+		want := pattern[i%3]
+		if v, kind, ok := vp.Predict(0x1300); !ok || v != want || kind != ValuePredContext {
+			t.Fatalf("Step %d: Predict = %d/%v/%v, expected %d from context", i, v, kind, ok, want)
+		}
+		trainValues(vp, 0x1300, want)
+	}
+}
+
+func TestLoadValuePredictor_CoverageAndAccuracy(t *testing.T) {
+	// WHAT: Coverage divides correct predictions by loads trained, accuracy by
+	//       predictions verified; both sum over components
+	// WHY: These are the figures the value prediction report prints
+	// HARDWARE: N/A (statistics)
+	// CATEGORY: [UNIT]
+
+	vp := NewLoadValuePredictor()
+	if vp.GetCoverage() != 0 || vp.GetAccuracy() != 0 {
+		t.Error("Empty predictor reports nonzero coverage or accuracy")
+	}
+
+	trainValues(vp, 0x1100, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10) // 10 loads
+	vp.Resolve(ValuePredLast, true)
+	vp.Resolve(ValuePredStride, true)
+	vp.Resolve(ValuePredStride, false)
+	vp.Resolve(ValuePredContext, true)
+	vp.Resolve(ValuePredNone, true) // Ignored: nothing was predicted
+
+	if got := vp.GetStats(ValuePredStride); got != (ValuePredStats{Predicted: 2, Correct: 1}) {
+		t.Errorf("Stride stats %+v, expected 2 predicted, 1 correct", got)
+	}
+	if got := vp.GetCoverage(); got != 0.3 {
+		t.Errorf("Coverage %.3f, expected 3 correct / 10 loads", got)
+	}
+	if got := vp.GetAccuracy(); got != 0.75 {
+		t.Errorf("Accuracy %.3f, expected 3 correct / 4 predicted", got)
+	}
+}
+
+// dispatchWords decodes and dispatches each word at consecutive PCs
+func dispatchWords(t *testing.T, w *Window, words ...uint32) []int {
+	t.Helper()
+	ids := make([]int, len(words))
+	for i, word := range words {
+		id, ok := w.Dispatch(DecodeInstruction(word, 0x1000+uint32(i*4)))
+		if !ok {
+			t.Fatalf("Dispatch of word %d (%#08x) failed", i, word)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+func TestWindow_ConsumedEarly(t *testing.T) {
+	// WHAT: ConsumedEarly is true only once a younger reader of the load's
+	//       register has issued
+	// WHY: A wrong value nobody read needs no flush; later readers get the real one
+	// HARDWARE: Issued-and-reads-tag match across younger entries
+	// CATEGORY: [UNIT]
+
+	w := NewWindow()
+	ids := dispatchWords(t, w,
+		EncodeIFormat(OpLW, 6, 0, 0x100), // r6 = [0x100]
+		EncodeIFormat(OpADDI, 9, 0, 1),   // unrelated
+		EncodeRFormat(OpADD, 7, 7, 6),    // r7 += r6
+	)
+	if w.ConsumedEarly(ids[0]) {
+		t.Error("Consumed before any reader issued")
+	}
+	w.MarkIssued(ids[1])
+	if w.ConsumedEarly(ids[0]) {
+		t.Error("An unrelated instruction counted as a reader")
+	}
+	w.MarkIssued(ids[2])
+	if !w.ConsumedEarly(ids[0]) {
+		t.Error("Issued reader of r6 not seen")
+	}
+	if w.ConsumedEarly(ids[1]) || w.ConsumedEarly(-1) {
+		t.Error("ConsumedEarly true for an entry nothing reads")
+	}
+}
+
+// createValueChangeProgram loads one word 300 times, summing it into r7;
+// after the 150th load the loop stores 9 over the initial 5, so the
+// predictor is confident in a value that is about to go stale
+func createValueChangeProgram() []uint32 {
+	return []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0),          // r1 = 0 (counter)
+		EncodeIFormat(OpADDI, 2, 0, 300),        // r2 = 300 (limit)
+		EncodeIFormat(OpADDI, 3, 0, 150),        // r3 = 150 (switch point)
+		EncodeIFormat(OpADDI, 10, 0, 9),         // r10 = 9 (new value)
+		EncodeIFormat(OpLW, 6, 0, 0xA100),       // Loop: r6 = [0xA100]
+		EncodeRFormat(OpADD, 7, 7, 6),           // r7 += r6 (consumer)
+		EncodeIFormat(OpADDI, 1, 1, 1),          // counter++
+		EncodeBFormat(OpBNE, 1, 3, 8),           // skip the store unless counter == 150
+		EncodeIFormat(OpSW, 0, 0, 10<<12|0x100), // [0xA100] = r10
+		EncodeBFormat(OpBLT, 1, 2, -20),         // if counter < 300, loop
+		EncodeIFormat(OpADDI, 8, 0, 42),         // r8 = 42 (done)
+	}
+}
+
+func TestCore_ValueMispredictFlushRepairs(t *testing.T) {
+	// WHAT: A dependent that consumed a stale predicted value is flushed and
+	//       re-executed; the run ends in the same state as without prediction
+	// WHY: Value prediction is speculation and must be invisible architecturally
+	// HARDWARE: Verify at complete, flush-and-refetch at commit of the load
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	var want [NumArchRegs]uint32
+	for _, on := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		core.WriteMemWord(0xA100, 5)
+		core.EnableValuePrediction(on)
+		core.LoadProgram(createValueChangeProgram(), 0x1000)
+		runUntilDone(core, 30000)
+
+		if got := core.window.ReadArchReg(7); got != 150*5+150*9 {
+			t.Errorf("value prediction %v: r7 = %d, expected %d", on, got, 150*5+150*9)
+		}
+		for r := uint8(0); r < NumArchRegs; r++ {
+			got := core.window.ReadArchReg(r)
+			if !on {
+				want[r] = got
+			} else if got != want[r] {
+				t.Errorf("r%d = %d with value prediction, %d without", r, got, want[r])
+			}
+		}
+		if !on {
+			continue
+		}
+
+		vp := core.valuePred
+		if vp.flushes == 0 {
+			t.Error("The stale value never reached a consumer; no flush was exercised")
+		}
+		var predicted, wrong uint64
+		for k := ValuePredLast; k < NumValuePredKinds; k++ {
+			st := vp.GetStats(k)
+			predicted += st.Predicted
+			wrong += st.Predicted - st.Correct
+		}
+		if predicted == 0 || wrong == 0 {
+			t.Errorf("%d predictions, %d wrong; expected some of each", predicted, wrong)
+		}
+		if vp.flushes > wrong {
+			t.Errorf("%d flushes for %d wrong predictions", vp.flushes, wrong)
+		}
+		// Wrong-path loads that complete before their redirect train it too
+		if vp.loads < 300 {
+			t.Errorf("Predictor trained on %d loads, expected at least 300", vp.loads)
+		}
+	}
+}
+
+func TestValuePredictionReport_IPCGain(t *testing.T) {
+	// WHAT: The report's IPC gain compares two otherwise identical runs, and its
+	//       coverage and accuracy lines come from the predictor's counters
+	// WHY: The gain is the experiment's headline number
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateArraySumProgram()
+	ipc := func(on bool) (float64, *Core) {
+		core := NewCore(1024 * 1024)
+		core.EnableValuePrediction(on)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+		return core.GetIPC(), core
+	}
+	base, _ := ipc(false)
+	with, vpCore := ipc(true)
+
+	report := RunValuePredictionReport("Array Sum", program, cycles)
+	vp := vpCore.valuePred
+	for _, want := range []string{
+		fmt.Sprintf("IPC without:         %.3f", base),
+		fmt.Sprintf("IPC with:            %.3f", with),
+		fmt.Sprintf("IPC gain:            %+.2f%%", (with/base-1)*100),
+		fmt.Sprintf("Coverage:            %.2f%%", vp.GetCoverage()*100),
+		fmt.Sprintf("Accuracy:            %.2f%%", vp.GetAccuracy()*100),
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report lacks %q:\n%s", want, report)
+		}
+	}
+
+	if off := NewCore(1024 * 1024); off.GetValuePredictionStats() != "" {
+		t.Error("Stats reported with value prediction off")
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 4. HARDWARE PREFETCHER TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// Next-line, stream, Best-Offset and SMS prefetchers watch demand accesses
+// and queue lines through L1DCache.IssuePrefetch, beside the 5-way
+// predictor. Each is driven here with a synthetic access stream and judged
+// by the lines it queues.
+//
+// INVARIANTS:
+//   - Plain hits never trigger next-line, stream or Best-Offset
+//   - Every queued line is tagged with the prefetcher that asked for it
+//   - Prefetchers sharing the queue are deduplicated by line
+
+// takePrefetches returns the lines queued since the last call, with the
+// prefetcher that queued each, and empties the queue
+func takePrefetches(c *L1DCache) (lines []uint32, by []PredictorID) {
+	pq := &c.prefetchQueue
+	for i := 0; i < pq.count; i++ {
+		e := &pq.entries[(pq.head+i)%PrefetchQueueSize]
+		lines = append(lines, lineOf(e.Addr))
+		by = append(by, e.Predictor)
+	}
+	*pq = PrefetchQueue{}
+	return lines, by
+}
+
+// missLine feeds p a demand miss on line (PC 0x1100)
+func missLine(p Prefetcher, c *L1DCache, line uint32) {
+	p.Access(c, DemandAccess{PC: 0x1100, Addr: line * CacheLineSize})
+}
+
+func TestNextLinePrefetcher(t *testing.T) {
+	// WHAT: A miss (or first use of a prefetched line) queues the next line
+	// WHY: The baseline every other prefetcher is compared against
+	// HARDWARE: Line address incrementer on the miss path
+	// CATEGORY: [UNIT]
+
+	c := NewL1DCache()
+	p := NewNextLinePrefetcher()
+
+	missLine(p, c, 0x200)
+	if lines, by := takePrefetches(c); len(lines) != 1 || lines[0] != 0x201 || by[0] != PrefetcherNextLine {
+		t.Errorf("Miss queued lines %#x by %v, expected 0x201 by next-line", lines, by)
+	}
+
+	p.Access(c, DemandAccess{Addr: 0x201 * CacheLineSize, Hit: true})
+	if lines, _ := takePrefetches(c); len(lines) != 0 {
+		t.Errorf("Plain hit queued %#x", lines)
+	}
+
+	p.Access(c, DemandAccess{Addr: 0x201 * CacheLineSize, Hit: true, PrefetchHit: true})
+	if lines, _ := takePrefetches(c); len(lines) != 1 || lines[0] != 0x202 {
+		t.Errorf("Prefetched hit queued %#x, expected 0x202", lines)
+	}
+}
+
+func TestStreamPrefetcher(t *testing.T) {
+	// WHAT: Two confirming misses in one direction start a stream that runs
+	//       StreamDistance lines ahead, ascending or descending
+	// WHY: One miss is not a stream; prefetching on it wastes bandwidth
+	// HARDWARE: Stream table with per-entry direction and confirm counter
+	// CATEGORY: [UNIT] [PATTERN]
+
+	cases := []struct {
+		name  string
+		lines []uint32
+		dir   int32
+	}{
+		{"ascending", []uint32{100, 101, 102}, 1},
+		{"ascending, gaps in window", []uint32{100, 102, 105}, 1},
+		{"descending", []uint32{100, 99, 98}, -1},
+	}
+	for _, tc := range cases {
+		c := NewL1DCache()
+		p := NewStreamPrefetcher()
+
+		for i, line := range tc.lines[:StreamConfirmCount] {
+			missLine(p, c, line)
+			if lines, _ := takePrefetches(c); len(lines) != 0 {
+				t.Errorf("%s: miss %d queued %v before the stream was confirmed", tc.name, i, lines)
+			}
+		}
+
+		last := tc.lines[StreamConfirmCount]
+		missLine(p, c, last)
+		lines, by := takePrefetches(c)
+		if len(lines) != StreamDistance {
+			t.Fatalf("%s: queued %v, expected %d lines", tc.name, lines, StreamDistance)
+		}
+		for k, line := range lines {
+			if want := uint32(int32(last) + int32(k+1)*tc.dir); line != want || by[k] != PrefetcherStream {
+				t.Errorf("%s: prefetch %d is line %d by %v, expected %d by stream", tc.name, k, line, by[k], want)
+			}
+		}
+	}
+
+	// A miss outside every stream's window starts a new, unconfirmed stream
+	c := NewL1DCache()
+	p := NewStreamPrefetcher()
+	for _, line := range []uint32{100, 101, 102, 100 + 2*StreamWindow + 10} {
+		missLine(p, c, line)
+	}
+	takePrefetches(c)
+	missLine(p, c, 100+2*StreamWindow+11)
+	if lines, _ := takePrefetches(c); len(lines) != 0 {
+		t.Errorf("A new stream prefetched %v after one confirming miss", lines)
+	}
+}
+
+func TestBestOffsetPrefetcher(t *testing.T) {
+	// WHAT: On a stream stepping 5 lines at a time, Best-Offset learns offset 5
+	//       and prefetches X+5
+	// WHY: The offset that covers the most recent accesses is the one to use
+	// HARDWARE: Recent-requests table, one score per candidate offset
+	// CATEGORY: [UNIT] [PATTERN]
+
+	c := NewL1DCache()
+	p := NewBestOffsetPrefetcher()
+	const step = 5
+
+	// A full phase ends once one score reaches BOScoreMax
+	var line uint32 = 1000
+	for i := 0; i < (BOScoreMax+1)*BOOffsetCount; i++ {
+		missLine(p, c, line)
+		takePrefetches(c)
+		line += step
+	}
+	if p.offset != step || !p.enabled {
+		t.Fatalf("Learned offset %d (enabled %v), expected %d", p.offset, p.enabled, step)
+	}
+
+	missLine(p, c, line)
+	if lines, by := takePrefetches(c); len(lines) != 1 || lines[0] != line+step || by[0] != PrefetcherBestOffset {
+		t.Errorf("Queued %v by %v, expected line %d by best-offset", lines, by, line+step)
+	}
+
+	p.Access(c, DemandAccess{Addr: (line + 1) * CacheLineSize, Hit: true})
+	if lines, _ := takePrefetches(c); len(lines) != 0 {
+		t.Errorf("Plain hit queued %v", lines)
+	}
+}
+
+func TestSMSPrefetcher(t *testing.T) {
+	// WHAT: The footprint a PC+offset left in one region is replayed when the
+	//       same PC+offset triggers another region
+	// WHY: Layouts repeat (same struct fields, same page), addresses do not
+	// HARDWARE: Active generation table feeding a pattern history table
+	// CATEGORY: [UNIT] [PATTERN]
+
+	c := NewL1DCache()
+	p := NewSMSPrefetcher()
+	access := func(pc, region, offset uint32) {
+		p.Access(c, DemandAccess{PC: pc, Addr: (region*SMSRegionLines + offset) * CacheLineSize})
+	}
+
+	// Region 10: trigger at offset 1, then lines 4 and 9
+	access(0x1100, 10, 1)
+	access(0x1104, 10, 4)
+	access(0x1108, 10, 9)
+
+	// Other regions push region 10's generation out of the AGT
+	for r := uint32(100); r < 100+SMSAGTSize; r++ {
+		access(0x2000, r, 0)
+	}
+	takePrefetches(c)
+
+	// Same PC and offset start region 50: lines 4 and 9 follow
+	access(0x1100, 50, 1)
+	lines, by := takePrefetches(c)
+	want := []uint32{50*SMSRegionLines + 4, 50*SMSRegionLines + 9}
+	if len(lines) != len(want) {
+		t.Fatalf("Queued lines %v, expected %v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] || by[i] != PrefetcherSMS {
+			t.Errorf("Prefetch %d is line %d by %v, expected %d by sms", i, lines[i], by[i], want[i])
+		}
+	}
+
+	// A different trigger offset has no pattern
+	access(0x1100, 60, 2)
+	if lines, _ := takePrefetches(c); len(lines) != 0 {
+		t.Errorf("Unlearned trigger queued %v", lines)
+	}
+}
+
+func TestPrefetchers_ShareQueue(t *testing.T) {
+	// WHAT: Several prefetchers attached to one cache queue through the same
+	//       PrefetchQueue, deduplicated, each credited under its own ID
+	// WHY: The comparison only holds if every source pays the same costs
+	// HARDWARE: One prefetch queue, one request port, source ID per entry
+	// CATEGORY: [INTEGRATION]
+
+	c := NewL1DCache()
+	c.SetPredictorPrefetch(false)
+	c.AddPrefetcher(NewNextLinePrefetcher())
+	c.AddPrefetcher(NewStreamPrefetcher())
+
+	// Ascending misses: next-line queues L+1; once confirmed, the stream
+	// queues L+1..L+4 and its L+1 is the duplicate
+	for line := uint32(0x300); line < 0x303; line++ {
+		c.Read(0x1100, line*CacheLineSize)
+	}
+	lines, by := takePrefetches(c)
+
+	seen := make(map[uint32]bool)
+	for i, line := range lines {
+		if seen[line] {
+			t.Errorf("Line %#x queued twice", line)
+		}
+		seen[line] = true
+		if line == 0x303 && by[i] != PrefetcherNextLine {
+			t.Errorf("Line 0x303 credited to %v, expected next-line (queued first)", by[i])
+		}
+	}
+	for _, line := range []uint32{0x301, 0x302, 0x303, 0x304, 0x305, 0x306} {
+		if !seen[line] {
+			t.Errorf("Line %#x never queued (queued %#x)", line, lines)
+		}
+	}
+
+	nl, st := c.GetPrefetchStats(PrefetcherNextLine), c.GetPrefetchStats(PrefetcherStream)
+	if nl.Issued != 3 || st.Issued != 3 {
+		t.Errorf("Issued: next-line %d, stream %d; expected 3 each (stream's 4th was a duplicate)",
+			nl.Issued, st.Issued)
+	}
+	if c.GetPrefetchStats(PredictorStride).Issued != 0 {
+		t.Error("5-way predictor queued prefetches with predictor prefetch off")
+	}
+
+	// Whole core: all four beside the predictor, same architectural state
+	var want [NumArchRegs]uint32
+	for _, attach := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		if attach {
+			core.dcache.AddPrefetcher(NewNextLinePrefetcher())
+			core.dcache.AddPrefetcher(NewStreamPrefetcher())
+			core.dcache.AddPrefetcher(NewBestOffsetPrefetcher())
+			core.dcache.AddPrefetcher(NewSMSPrefetcher())
+		}
+		core.LoadProgram(CreateArraySumProgram(), 0x1000)
+		core.Run(5000)
+
+		for r := uint8(0); r < NumArchRegs; r++ {
+			if !attach {
+				want[r] = core.window.ReadArchReg(r)
+			} else if got := core.window.ReadArchReg(r); got != want[r] {
+				t.Errorf("r%d = %d with prefetchers, %d without", r, got, want[r])
+			}
+		}
+		if attach && core.dcache.GetPrefetchStats(PrefetcherNextLine).Issued == 0 {
+			t.Error("Next-line issued nothing inside the core")
+		}
+	}
+}
+
+func TestPrefetcherComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The shipped row matches a default core, and every setup gets a row
+	// WHY: The comparison is only fair if its baseline is the design as shipped
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateArraySumProgram()
+	core := NewCore(1024 * 1024)
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+
+	checkReport(t, RunPrefetcherComparison("Array Sum", program, cycles),
+		"PREFETCHERS: Array Sum",
+		fmt.Sprintf("  %-16s %7.3f %7.2f%%", "5-way predictor", core.GetIPC(), core.dcache.GetHitRate()*100),
+		"  next-line ", "  stream ", "  best-offset ", "  sms ", "  predictor + all ")
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 5. FETCH TARGET QUEUE TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// With the decoupled frontend the run-ahead walk is the only thing that
+// predicts, so it must keep the RSB balanced itself: push on predicted calls,
+// pop on predicted returns, and roll back on redirect.
+//
+// INVARIANTS:
+//   - A predicted call followed by its return leaves the RSB as it was
+//   - Redirecting to an instruction's checkpoint undoes later pushes and pops
+
+// storeWords writes little-endian instruction words starting at addr
+func storeWords(mem []byte, addr uint32, words ...uint32) {
+	for i, w := range words {
+		a := addr + uint32(i)*4
+		mem[a], mem[a+1], mem[a+2], mem[a+3] = byte(w), byte(w>>8), byte(w>>16), byte(w>>24)
+	}
+}
+
+// ftqCallReturn is a call at 0x000 to a function at 0x040 that returns
+func ftqCallReturn() []byte {
+	mem := make([]byte, 4096)
+	storeWords(mem, 0x000, EncodeIFormat(OpJAL, 1, 0, 0x40)) // call 0x40
+	storeWords(mem, 0x040, EncodeIFormat(OpJALR, 0, 1, 0))   // ret
+	return mem
+}
+
+func TestFetchTargetQueue_RunAheadBalancesRSB(t *testing.T) {
+	// WHAT: The walk pushes on a predicted call so the predicted return finds it
+	// WHY: Pushes used to happen only at execute, so run-ahead returns popped garbage
+	// HARDWARE: RSB push/pop ports driven by the FTQ predictor, not execute
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	bp := NewBranchPredictor()
+	q := NewFetchTargetQueue(0)
+	q.RunAhead(ftqCallReturn(), bp)
+
+	call, ret := q.blocks[0], q.blocks[1]
+	if q.count < 2 || !call.HasBranch || call.Next != 0x40 {
+		t.Fatalf("Call block %+v (count %d), expected a branch to 0x40", call, q.count)
+	}
+	if call.RSBAfter != (RSBCheckpoint{Top: 1, Addr: 4}) {
+		t.Errorf("After the call RSB = %+v, expected return address 4 on top", call.RSBAfter)
+	}
+	if ret.Next != 4 {
+		t.Errorf("Return predicted to %#x, expected 0x4 (from the RSB)", ret.Next)
+	}
+	if ret.RSBAfter.Top != 0 || bp.rsbTop != 0 {
+		t.Errorf("RSB depth %d after call+return, expected 0", bp.rsbTop)
+	}
+}
+
+func TestFetchTargetQueue_RedirectRestoresRSB(t *testing.T) {
+	// WHAT: Redirect restores the RSB to the redirecting instruction's checkpoint
+	// WHY: Wrong-path returns pop entries the correct path still needs
+	// HARDWARE: Top-of-stack pointer + top entry saved per FTQ block
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	bp := NewBranchPredictor()
+	q := NewFetchTargetQueue(0)
+	q.RunAhead(ftqCallReturn(), bp)
+	call := q.blocks[0]
+
+	// The walk went on past the return; the call is found to mispredict
+	bp.PushRSB(0xBAD0) // Wrong-path call overwrites the slot above the pointer
+	q.Redirect(0x40, bp, call.RSBAfter)
+
+	if q.count != 0 || q.runPC != 0x40 {
+		t.Errorf("After redirect: %d blocks, walk at %#x, expected empty at 0x40", q.count, q.runPC)
+	}
+	if addr, ok := bp.PeekRSB(); !ok || addr != 4 || bp.rsbTop != 1 {
+		t.Errorf("RSB top %#x/%v depth %d, expected 0x4 at depth 1", addr, ok, bp.rsbTop)
+	}
+}
+
+func TestFrontendPrefetchComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The coverage and fdip rows and the FTQ line match plain runs
+	// WHY: The comparison toggles only EnableFDIP; anything else would skew it
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateBranchPredictionTest()
+	want := []string{"FRONTEND PREFETCH: Branch Prediction"}
+	for _, fdip := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		core.EnableFDIP(fdip)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		label := "coverage"
+		if fdip {
+			label = "fdip"
+			want = append(want, "FTQ: "+core.ftq.GetStats())
+		}
+		want = append(want, fmt.Sprintf("  %-10s %7.3f %7.2f%% %7.2f%%",
+			label, core.GetIPC(), core.icache.GetHitRate()*100, core.GetFetchStallRate()*100))
+	}
+
+	checkReport(t, RunFrontendPrefetchComparison("Branch Prediction", program, cycles), want...)
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 6. INSTRUCTION FETCH PATH TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// L1I misses go through the fill unit and the shared memory arbiter like
+// every other line, so fetch waits out the full arbiter latency, and the
+// fetch stall counter is the record of that wait. The L1I's indirect-jump
+// table also supplies fetch with JALR targets, and each prefetched line is
+// credited to the source that asked for it.
+//
+// INVARIANTS:
+//   - A cold L1I miss stalls fetch for the miss cycle plus the arbiter latency
+//   - Fetch from a line already in the L1I never counts a stall
+//   - A non-return JALR predicts its most frequent past target
+//   - A prefetched line counts as useful or useless once, for its own source
+
+// spinLoop is a loop that never leaves the first instruction line
+func spinLoop() []uint32 {
+	return []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0), // r1 = 0
+		EncodeIFormat(OpADDI, 1, 1, 1), // r1++
+		EncodeBFormat(OpBEQ, 0, 0, -4), // loop forever
+	}
+}
+
+func TestL1IMiss_StallsFetchForArbiterLatency(t *testing.T) {
+	// WHAT: The first fetch misses and fetch stalls exactly latency+1 cycles;
+	//       GetFetchStallRate reports those cycles over all cycles run
+	// WHY: Misses used to be filled from memory the same cycle, costing nothing
+	// HARDWARE: L1I fill unit, demand request through the memory arbiter
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	const cycles = 400
+	for _, latency := range []int{40, 100} {
+		cfg := DefaultCoreConfig()
+		if err := cfg.Set("dram_latency", latency); err != nil {
+			t.Fatal(err)
+		}
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(spinLoop(), 0x1000)
+
+		// Nothing retires before the line lands
+		for core.instructions == 0 && core.cycles < cycles {
+			core.Cycle()
+		}
+		want := uint64(latency + 1) // Miss cycle, then the DRAM wait
+		if core.fetchStallCycles != want {
+			t.Errorf("Latency %d: fetch stalled %d cycles before first commit, expected %d",
+				latency, core.fetchStallCycles, want)
+		}
+		if core.cycles <= want {
+			t.Errorf("Latency %d: first commit at cycle %d, during the fill", latency, core.cycles)
+		}
+
+		// The loop stays in the filled line: no more stalls
+		core.Run(cycles)
+		if core.fetchStallCycles != want || core.ifill.DemandFills != 1 {
+			t.Errorf("Latency %d: %d stall cycles, %d demand fills after the loop ran; expected %d and 1",
+				latency, core.fetchStallCycles, core.ifill.DemandFills, want)
+		}
+		if rate := core.GetFetchStallRate(); rate != float64(want)/cycles {
+			t.Errorf("Latency %d: stall rate %.4f, expected %d/%d", latency, rate, want, cycles)
+		}
+	}
+}
+
+// runUntilDone runs core until r8 holds 42 or limit cycles pass
+func runUntilDone(core *Core, limit uint64) {
+	for core.window.ReadArchReg(8) != 42 && core.cycles < limit {
+		core.Cycle()
+	}
+}
+
+// createIndirectDispatchProgram jumps through r10 to one of two handlers,
+// the first three iterations in four to 0x1030 and the fourth to 0x1038
+func createIndirectDispatchProgram() []uint32 {
+	return []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0),       // r1 = 0 (iteration counter)
+		EncodeIFormat(OpADDI, 2, 0, 60),      // r2 = 60 (limit)
+		EncodeIFormat(OpADDI, 11, 0, 0x1030), // r11 = &handlerA
+		EncodeIFormat(OpADDI, 12, 0, 0x1038), // r12 = &handlerB
+
+		// Loop (0x1010):
+		EncodeIFormat(OpANDI, 3, 1, 3),  // r3 = r1 & 3
+		EncodeRFormat(OpADD, 10, 11, 0), // r10 = handlerA
+		EncodeBFormat(OpBNE, 3, 0, 8),   // if r3 != 0, keep A
+		EncodeRFormat(OpADD, 10, 12, 0), // r10 = handlerB
+		EncodeIFormat(OpJALR, 0, 10, 0), // jump *r10 (0x1020)
+		EncodeIFormat(OpADDI, 1, 1, 1),  // r1++ (handlers return here)
+		EncodeBFormat(OpBLT, 1, 2, -24), // if r1 < 60, loop
+		EncodeBFormat(OpBEQ, 0, 0, 20),  // skip handlers
+
+		// HandlerA (0x1030):
+		EncodeIFormat(OpADDI, 4, 4, 1),  // r4++
+		EncodeIFormat(OpJAL, 0, 0, -16), // back to 0x1024
+
+		// HandlerB (0x1038):
+		EncodeIFormat(OpADDI, 5, 5, 1),  // r5++
+		EncodeIFormat(OpJAL, 0, 0, -24), // back to 0x1024
+
+		// End
+		EncodeIFormat(OpADDI, 8, 0, 42), // r8 = 42 (done)
+	}
+}
+
+func TestIndirectPrediction_AlternatingTargets(t *testing.T) {
+	// WHAT: A JALR switching between two known targets mispredicts far less
+	//       with the L1I indirect table than with pc+4
+	// WHY: The table tracked targets for prefetch only; fetch guessed pc+4
+	// HARDWARE: L1I indirect-jump table feeding fetch's JALR target
+	// CATEGORY: [INTEGRATION] [PATTERN]
+
+	var rates [2]float64
+	for i, on := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		core.EnableIndirectPrediction(on)
+		core.LoadProgram(createIndirectDispatchProgram(), 0x1000)
+		runUntilDone(core, 100000)
+
+		if a, b := core.window.ReadArchReg(4), core.window.ReadArchReg(5); a != 45 || b != 15 {
+			t.Fatalf("Indirect %v: handlers ran %d/%d times, expected 45/15", on, a, b)
+		}
+		if core.indirectJumps != 60 {
+			t.Errorf("Indirect %v: %d JALRs committed, expected 60", on, core.indirectJumps)
+		}
+		rates[i] = core.GetIndirectMispredictRate()
+
+		if !on {
+			continue
+		}
+		// Both targets tracked; the common one is predicted
+		if n := len(core.icache.predictIndirect(0x1020)); n != 2 {
+			t.Errorf("Indirect table holds %d targets for the JALR, expected 2", n)
+		}
+		if target, ok := core.icache.PredictIndirectTarget(0x1020); !ok || target != 0x1030 {
+			t.Errorf("Predicted target %#x/%v, expected 0x1030", target, ok)
+		}
+	}
+
+	// pc+4 is never a target; the table misses only the rarer handler
+	// (15 jumps) plus its first sightings
+	if rates[0] != 1 {
+		t.Errorf("Mispredict rate with pc+4 is %.2f, expected 1", rates[0])
+	}
+	if rates[1] >= rates[0] || rates[1] > 20.0/60 {
+		t.Errorf("Mispredict rate with the indirect table is %.2f (pc+4: %.2f), expected at most %.2f",
+			rates[1], rates[0], 20.0/60)
+	}
+}
+
+func TestIndirectPredictionComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The pc+4 and indirect-table rows match runs with the table off and on
+	// WHY: The misprediction rate is the comparison's headline number
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := createIndirectDispatchProgram()
+	want := []string{"INDIRECT JUMPS: Dispatch"}
+	for _, on := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		core.EnableIndirectPrediction(on)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		label := "pc+4"
+		if on {
+			label = "indirect table"
+		}
+		want = append(want, fmt.Sprintf("  %-16s %7.3f %9d %9d %8.2f%%\n",
+			label, core.GetIPC(), core.indirectJumps, core.indirectMispreds,
+			core.GetIndirectMispredictRate()*100))
+	}
+
+	checkReport(t, RunIndirectPredictionComparison("Dispatch", program, cycles), want...)
+}
+
+func TestL1IPrefetch_PerSourceCounters(t *testing.T) {
+	// WHAT: Issued, useful, useless, late and lead time are counted per
+	//       prefetch source; sequential and branch-target never mix
+	// WHY: The point of the telemetry is telling which source pays off
+	// HARDWARE: Source tag per prefetched line, per-source counters
+	// CATEGORY: [UNIT]
+
+	c := NewL1ICache()
+	line := make([]byte, CacheLineSize)
+
+	if !c.Prefetch(0x1000, line, L1ISourceSequential) || !c.Prefetch(0x1040, line, L1ISourceBranch) {
+		t.Fatal("Prefetch of an uncached line refused")
+	}
+	if c.Prefetch(0x1000, line, L1ISourceBranch) {
+		t.Error("Prefetch of a cached line accepted")
+	}
+
+	for i := 0; i < 5; i++ {
+		c.Tick()
+	}
+	c.Read(0x1000)
+	c.Read(0x1004) // Second fetch from the line: already credited
+	c.MarkLate(L1ISourceBranch)
+
+	seq, br := c.GetSourceStats(L1ISourceSequential), c.GetSourceStats(L1ISourceBranch)
+	if seq != (L1ISourceStats{Issued: 1, Useful: 1, LeadCycles: 5}) {
+		t.Errorf("Sequential stats %+v, expected 1 issued, 1 useful, 5 lead cycles", seq)
+	}
+	if br != (L1ISourceStats{Issued: 1, Late: 1}) {
+		t.Errorf("Branch-target stats %+v, expected 1 issued, 1 late", br)
+	}
+	if seq.Accuracy() != 1 || seq.AvgLead() != 5 || br.Accuracy() != 0 {
+		t.Errorf("Accuracy %.2f/%.2f, lead %.1f; expected 1, 0 and 5", seq.Accuracy(), br.Accuracy(), seq.AvgLead())
+	}
+
+	// Indirect prefetches into one set until lines are evicted unfetched
+	setStride := uint32(c.numSets * CacheLineSize)
+	for i := uint32(1); i <= 20; i++ {
+		c.Prefetch(0x1000+i*setStride, line, L1ISourceIndirect)
+	}
+	ind := c.GetSourceStats(L1ISourceIndirect)
+	resident := uint64(0)
+	for i := uint32(1); i <= 20; i++ {
+		if c.Contains(0x1000 + i*setStride) {
+			resident++
+		}
+	}
+	if ind.Issued != 20 || ind.Useful != 0 || ind.Useless == 0 || ind.Useless+resident > ind.Issued {
+		t.Errorf("Indirect stats %+v with %d still cached", ind, resident)
+	}
+	if c.GetSourceStats(L1ISourceSequential).Useless != 0 {
+		t.Error("Evicting indirect prefetches charged the sequential source")
+	}
+
+	misses, issued, useful := c.GetPrefetchCounts()
+	if misses != 0 || issued != 22 || useful != 1 {
+		t.Errorf("Totals: %d misses, %d issued, %d useful; expected 0, 22, 1", misses, issued, useful)
+	}
+}
+
+func TestL1IBuffers_ActivationReuseThrash(t *testing.T) {
+	// WHAT: GetBufferStats reports activations, fills, reuse hits, evictions
+	//       and thrash (a miss on a line the buffer evicted recently)
+	// WHY: GetBufferStates was a string; nothing could be checked or plotted
+	// HARDWARE: Per-buffer counters, recently-evicted filter
+	// CATEGORY: [UNIT]
+
+	c := NewL1ICache()
+	line := make([]byte, CacheLineSize)
+	setStride := uint32(c.numSets * CacheLineSize)
+	const fills = 19 // More lines than one set of all buffers holds
+
+	// Fill one set until lines are replaced, remembering the last evicted
+	var filled []uint32
+	var lastEvicted uint32
+	for i := 0; i < fills; i++ {
+		addr := 0x1000 + uint32(i)*setStride
+		var cached []uint32
+		for _, a := range filled {
+			if c.Contains(a) {
+				cached = append(cached, a)
+			}
+		}
+		c.Fill(addr, line)
+		for _, a := range cached {
+			if !c.Contains(a) {
+				lastEvicted = a
+			}
+		}
+		filled = append(filled, addr)
+	}
+	if lastEvicted == 0 {
+		t.Fatal("No line evicted")
+	}
+
+	if _, hit := c.Read(filled[fills-1]); !hit {
+		t.Fatal("Last line filled missed")
+	}
+	c.Read(filled[fills-1] + 4)
+	if _, hit := c.Read(lastEvicted); hit {
+		t.Fatalf("Line %#x still cached", lastEvicted)
+	}
+
+	var total L1IBufferStats
+	for i, st := range c.GetBufferStats() {
+		if !st.Active || st.Activations != 1 {
+			t.Errorf("Buffer %d: active %v, %d activations; expected one activation", i, st.Active, st.Activations)
+		}
+		if st.Fills != st.Evictions+uint64(st.ValidLines) {
+			t.Errorf("Buffer %d: %d fills, %d evictions, %d valid lines", i, st.Fills, st.Evictions, st.ValidLines)
+		}
+		if st.Thrash > 0 && st.Evictions == 0 {
+			t.Errorf("Buffer %d charged with thrash it never evicted", i)
+		}
+		total.Fills += st.Fills
+		total.Hits += st.Hits
+		total.Thrash += st.Thrash
+	}
+	if total.Fills != fills || total.Hits != 2 || total.Thrash != 1 {
+		t.Errorf("Fills %d, hits %d, thrash %d; expected %d, 2 and 1", total.Fills, total.Hits, total.Thrash, fills)
+	}
+
+	// Flush deactivates; the next fill counts a fresh activation
+	c.Flush()
+	c.Fill(0x40000, line)
+	activations := uint64(0)
+	for _, st := range c.GetBufferStats() {
+		activations += st.Activations
+	}
+	if activations != uint64(len(c.buffers))+1 {
+		t.Errorf("%d activations after flush and refill, expected %d", activations, len(c.buffers)+1)
+	}
+}
+
+func TestL1ITelemetryReport_MatchesDirectRun(t *testing.T) {
+	// WHAT: The telemetry report prints the L1I statistics of a plain run
+	// WHY: The harness builds its own core; its numbers must be the design's
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateBranchPredictionTest()
+	for _, fdip := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		core.EnableFDIP(fdip)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		checkReport(t, RunL1ITelemetryReport("Branch Prediction", program, cycles, fdip),
+			"L1I TELEMETRY: Branch Prediction",
+			fmt.Sprintf("L1I: %s, Fetch stalls: %.2f%%", core.icache.GetStats(), core.GetFetchStallRate()*100),
+			core.icache.TelemetryReport())
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 7. MEMORY ARBITER TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// All line reads - L1I and L1D, demand and prefetch - queue at one arbiter
+// that starts at most Ports of them per cycle. The policy decides whose
+// request gets the next port; the statistics show who waited.
+//
+// INVARIANTS:
+//   - Never more than Ports grants in one cycle
+//   - Demand-first: data demand, instruction demand, data prefetch, then
+//     instruction prefetch
+//   - Round-robin: each kind in turn, skipping empty queues
+//   - Each queue is served oldest first
+
+// grantOrder ticks a until every request is granted and returns the
+// requests in the order they got a port, one slice per cycle
+func grantOrder(a *MemoryArbiter, reqs []*MemRequest) [][]*MemRequest {
+	var cycles [][]*MemRequest
+	granted := make(map[*MemRequest]bool)
+	for len(granted) < len(reqs) && len(cycles) < 100 {
+		a.Tick()
+		var now []*MemRequest
+		for _, req := range reqs {
+			if req.Granted && !granted[req] {
+				granted[req] = true
+				now = append(now, req)
+			}
+		}
+		cycles = append(cycles, now)
+	}
+	return cycles
+}
+
+func TestMemoryArbiter_PolicyOrder(t *testing.T) {
+	// WHAT: With one port, competing requests are granted in policy order
+	// WHY: The policy is the knob the bandwidth studies turn
+	// HARDWARE: Per-kind request queues, fixed-priority or rotating pick
+	// CATEGORY: [UNIT]
+
+	cases := []struct {
+		policy ArbiterPolicy
+		submit []MemClass
+		want   []MemClass
+	}{
+		{
+			ArbiterDemandFirst,
+			[]MemClass{MemInstPrefetch, MemDataPrefetch, MemInstDemand, MemDataDemand},
+			[]MemClass{MemDataDemand, MemInstDemand, MemDataPrefetch, MemInstPrefetch},
+		},
+		{
+			ArbiterDemandFirst,
+			[]MemClass{MemDataPrefetch, MemInstDemand, MemInstDemand},
+			[]MemClass{MemInstDemand, MemInstDemand, MemDataPrefetch},
+		},
+		{
+			ArbiterRoundRobin,
+			[]MemClass{MemInstDemand, MemInstDemand, MemDataPrefetch},
+			[]MemClass{MemInstDemand, MemDataPrefetch, MemInstDemand},
+		},
+		{
+			ArbiterRoundRobin,
+			[]MemClass{MemDataDemand, MemDataDemand, MemInstPrefetch, MemInstPrefetch},
+			[]MemClass{MemDataDemand, MemInstPrefetch, MemDataDemand, MemInstPrefetch},
+		},
+	}
+
+	for _, tc := range cases {
+		a := NewMemoryArbiter(make([]byte, 4096), 1, tc.policy)
+		var reqs []*MemRequest
+		for i, class := range tc.submit {
+			reqs = append(reqs, a.Submit(uint32(i)*CacheLineSize, class))
+		}
+
+		var got []MemClass
+		lastLine := make(map[MemClass]uint32)
+		for _, now := range grantOrder(a, reqs) {
+			if len(now) != 1 {
+				t.Fatalf("%v %v: %d grants in one cycle with one port", tc.policy, tc.submit, len(now))
+			}
+			req := now[0]
+			if last, ok := lastLine[req.Class]; ok && req.Line < last {
+				t.Errorf("%v: %v line %#x granted after the younger %#x", tc.policy, req.Class, req.Line, last)
+			}
+			lastLine[req.Class] = req.Line
+			got = append(got, req.Class)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%v %v: granted %v, expected %v", tc.policy, tc.submit, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryArbiter_PortsAndLatency(t *testing.T) {
+	// WHAT: At most Ports grants per cycle; each line arrives Latency cycles
+	//       after its grant, holding memory's bytes
+	// WHY: Port count is the bandwidth limit; without it every miss is free
+	// HARDWARE: DRAM request ports, fixed access latency
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	mem := make([]byte, 4096)
+	for i := range mem {
+		mem[i] = byte(i / CacheLineSize)
+	}
+
+	for _, ports := range []int{1, 2, 3} {
+		a := NewMemoryArbiter(mem, ports, ArbiterDemandFirst)
+		a.Latency = 5
+
+		var reqs []*MemRequest
+		for i := 0; i < 5; i++ {
+			reqs = append(reqs, a.Submit(uint32(i)*CacheLineSize+8, MemDataDemand))
+		}
+		order := grantOrder(a, reqs)
+
+		wantCycles := (len(reqs) + ports - 1) / ports
+		if len(order) != wantCycles {
+			t.Errorf("%d ports: all granted after %d cycles, expected %d", ports, len(order), wantCycles)
+		}
+		for cycle, now := range order {
+			if len(now) > ports {
+				t.Errorf("%d ports: %d grants in cycle %d", ports, len(now), cycle+1)
+			}
+		}
+
+		// The last grant's data lands Latency ticks later, not sooner
+		last := order[len(order)-1][0]
+		for i := 1; i < a.Latency; i++ {
+			a.Tick()
+		}
+		if last.Done {
+			t.Errorf("%d ports: data arrived before the latency elapsed", ports)
+		}
+		a.Tick()
+		for i, req := range reqs {
+			if !req.Done || req.Line != uint32(i)*CacheLineSize || req.Data[0] != byte(i) {
+				t.Errorf("%d ports: request %d done %v line %#x data %d", ports, i, req.Done, req.Line, req.Data[0])
+			}
+		}
+
+		want := float64(len(reqs)) / float64(int(a.cycle)*ports)
+		if got := a.GetPortUtilization(); got != want {
+			t.Errorf("%d ports: utilization %.3f, expected %.3f", ports, got, want)
+		}
+	}
+}
+
+func TestMemoryArbiter_OccupancyStats(t *testing.T) {
+	// WHAT: Requests, grants, wait cycles and per-cycle queue length are
+	//       counted per kind
+	// WHY: Queue occupancy is how we see L1I and L1D traffic fighting
+	// HARDWARE: Per-queue occupancy counters (performance monitors)
+	// CATEGORY: [UNIT]
+
+	a := NewMemoryArbiter(make([]byte, 4096), 1, ArbiterDemandFirst)
+	var reqs []*MemRequest
+	for i := 0; i < 3; i++ {
+		reqs = append(reqs, a.Submit(uint32(i)*CacheLineSize, MemDataDemand))
+	}
+	reqs = append(reqs, a.Submit(0x800, MemInstPrefetch))
+	grantOrder(a, reqs)
+
+	// One grant per cycle: demand queue 2,1,0 after cycles 1-3; the
+	// prefetch waits 3 cycles queued, then goes in cycle 4
+	d, p := a.GetStats(MemDataDemand), a.GetStats(MemInstPrefetch)
+	if d.Requests != 3 || d.Granted != 3 || d.WaitCycles != 1+2+3 || d.Occupancy != 2+1 || d.MaxQueue != 2 {
+		t.Errorf("Data demand stats %+v", d)
+	}
+	if p.Requests != 1 || p.Granted != 1 || p.WaitCycles != 4 || p.Occupancy != 3 || p.MaxQueue != 1 {
+		t.Errorf("Instruction prefetch stats %+v", p)
+	}
+	if d.AvgWait() != 2 || a.GetAvgQueue(MemDataDemand) != 0.75 || a.GetAvgQueue(MemInstPrefetch) != 0.75 {
+		t.Errorf("Averages: wait %.2f, queues %.2f/%.2f; expected 2, 0.75, 0.75",
+			d.AvgWait(), a.GetAvgQueue(MemDataDemand), a.GetAvgQueue(MemInstPrefetch))
+	}
+	if a.GetStats(MemInstDemand) != (MemClassStats{}) {
+		t.Errorf("Idle kind has stats %+v", a.GetStats(MemInstDemand))
+	}
+
+	report := a.Report()
+	for _, want := range []string{"Ports: 1 (demand-first)", "d-demand", "i-prefetch"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestMemoryArbiterComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: Every ports × policy point gets a row, and the last one and its
+	//       arbiter report match a run built the same way
+	// WHY: Only the port count and policy may change between rows
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateArraySumProgram()
+	core := NewCore(1024 * 1024)
+	core.SetMemoryPorts(4, ArbiterRoundRobin)
+	core.EnableFDIP(true)
+	core.dcache.AddPrefetcher(NewNextLinePrefetcher())
+	core.dcache.AddPrefetcher(NewStreamPrefetcher())
+	core.dcache.AddPrefetcher(NewBestOffsetPrefetcher())
+	core.dcache.AddPrefetcher(NewSMSPrefetcher())
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+
+	want := []string{
+		"MEMORY PORTS: Array Sum",
+		fmt.Sprintf("  %-5d %-12s %7.3f %6.2f%% %6.2f%%  ", 4, ArbiterRoundRobin,
+			core.GetIPC(), core.GetFetchStallRate()*100, core.arbiter.GetPortUtilization()*100),
+		core.arbiter.Report(),
+	}
+	for _, ports := range []int{1, 2} {
+		for _, policy := range []ArbiterPolicy{ArbiterDemandFirst, ArbiterRoundRobin} {
+			want = append(want, fmt.Sprintf("  %-5d %-12s ", ports, policy))
+		}
+	}
+
+	checkReport(t, RunMemoryArbiterComparison("Array Sum", program, cycles), want...)
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 8. FRONTEND PIPELINE TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// Fetch, decode, rename and dispatch take FetchStages, DecodeStages,
+// RenameStages and DispatchStages cycles, with a latch after each cycle.
+// Every instruction crosses all of them, and a redirect empties them all.
+// Fetch itself reads one aligned block a cycle, within a taken-branch and
+// line budget.
+//
+// INVARIANTS:
+//   - The first dispatch comes depth cycles after the first line lands
+//   - Each redirect costs depth cycles of refill before dispatch resumes
+//   - Depth changes timing only, never architectural state
+//   - Every fetch cycle ends for exactly one recorded reason
+
+// newFrontendCore builds a core with the given fetch, decode, rename and
+// dispatch stage counts
+func newFrontendCore(t *testing.T, stages [NumFrontendStages]int) *Core {
+	t.Helper()
+	cfg := DefaultCoreConfig()
+	cfg.FetchStages = stages[StageFetch]
+	cfg.DecodeStages = stages[StageDecode]
+	cfg.RenameStages = stages[StageRename]
+	cfg.DispatchStages = stages[StageDispatch]
+
+	core, err := NewCoreWithConfig(cfg, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return core
+}
+
+func TestFrontendDepth_PenaltyAndFirstDispatch(t *testing.T) {
+	// WHAT: Deeper frontends dispatch their first instruction later and pay a
+	//       larger refill after every mispredict, one cycle per stage
+	// WHY: With fetch-to-dispatch in one Cycle call, a redirect cost nothing
+	// HARDWARE: Pipeline latches between fetch, decode, rename and dispatch
+	// CATEGORY: [INTEGRATION] [PATTERN]
+
+	var prevFirst uint64
+	var prevRefill float64
+	for i, stages := range [][NumFrontendStages]int{
+		{1, 0, 0, 1}, // Folded: fetch straight into dispatch
+		{1, 1, 1, 1}, // Default
+		{2, 2, 1, 1},
+		{3, 3, 2, 2},
+	} {
+		core := newFrontendCore(t, stages)
+		core.EnableIndirectPrediction(false) // Every dispatch JALR mispredicts
+		core.LoadProgram(createIndirectDispatchProgram(), 0x1000)
+		depth := core.frontend.Depth()
+
+		var first uint64
+		for core.window.ReadArchReg(8) != 42 && core.cycles < 100000 {
+			core.Cycle()
+			if first == 0 && core.frontend.Starved < core.frontend.cycles {
+				first = core.cycles
+			}
+		}
+
+		if a, b := core.window.ReadArchReg(4), core.window.ReadArchReg(5); a != 45 || b != 15 {
+			t.Fatalf("%v: handlers ran %d/%d times, expected 45/15", stages, a, b)
+		}
+
+		// The line arrives after the miss cycle and the DRAM wait
+		if want := uint64(DRAMLatency + 1 + depth); first != want {
+			t.Errorf("%v: first dispatch in cycle %d, expected %d (depth %d)", stages, first, want, depth)
+		}
+
+		wait, refill := core.GetMispredictPenalty()
+		if core.recoveries == 0 {
+			t.Fatalf("%v: no mispredicts to measure", stages)
+		}
+		if refill != float64(depth) {
+			t.Errorf("%v: refill %.2f cycles, expected the depth %d", stages, refill, depth)
+		}
+
+		if i > 0 && (first <= prevFirst || refill <= prevRefill) {
+			t.Errorf("%v: first dispatch %d and refill %.2f, not later than %d and %.2f one step shallower",
+				stages, first, refill, prevFirst, prevRefill)
+		}
+		if wait <= 0 {
+			t.Errorf("%v: resolve wait %.2f, expected some", stages, wait)
+		}
+		prevFirst, prevRefill = first, refill
+	}
+}
+
+func TestFrontendDepthComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The default-depth row matches a default core, and every depth gets a row
+	// WHY: Only the stage counts may change between rows
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateBranchPredictionTest()
+	core := newFrontendCore(t, [NumFrontendStages]int{1, 1, 1, 1})
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+
+	wait, refill := core.GetMispredictPenalty()
+	checkReport(t, RunFrontendDepthComparison("Branch Prediction", program, cycles),
+		"FRONTEND DEPTH: Branch Prediction",
+		fmt.Sprintf("  %-9s %5d %7.3f %8d %7.1f %7.1f %8.1f %7.1f%% %9d\n",
+			"1+1+1+1", core.frontend.Depth(), core.GetIPC(), core.recoveries,
+			wait, refill, wait+refill, core.frontend.GetStarvedRate()*100,
+			core.frontend.Discarded),
+		"  1+0+0+1 ", "  2+2+1+1 ", "  3+3+2+2 ")
+}
+
+// fetchCore builds a core running words at 0x1000 with lines 0x1000,
+// 0x1040 and 0x1100 already in the L1I (fetch never misses on them)
+func fetchCore(t *testing.T, words []uint32, set func(*CoreConfig)) *Core {
+	t.Helper()
+	cfg := DefaultCoreConfig()
+	if set != nil {
+		set(&cfg)
+	}
+	core, err := NewCoreWithConfig(cfg, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LoadProgram(words, 0x1000)
+	for _, line := range []uint32{0x1000, 0x1040, 0x1100} {
+		core.icache.Fill(line, core.memory[line:line+CacheLineSize])
+	}
+	return core
+}
+
+func TestFetch_StopReasons(t *testing.T) {
+	// WHAT: One fetch cycle ends at the fetch width, the end of an aligned
+	//       block, the taken-branch budget, the line limit, a miss or a full
+	//       queue - whichever comes first
+	// WHY: Fetch once followed any number of taken branches across any lines
+	// HARDWARE: Aligned fetch block, taken-branch and line-port limits
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	straight := []uint32{}
+	for i := 0; i < 16; i++ {
+		straight = append(straight, EncodeIFormat(OpADDI, 1, 1, 1))
+	}
+	jumpTo := func(target uint32) []uint32 {
+		return append([]uint32{EncodeIFormat(OpJAL, 0, 0, int32(target-0x1000))}, straight[1:]...)
+	}
+	width8 := func(cfg *CoreConfig) { cfg.FetchWidth = 8 }
+	twoTaken := func(cfg *CoreConfig) { cfg.FetchWidth, cfg.TakenBranchesPerCycle = 8, 2 }
+	twoLines := func(cfg *CoreConfig) {
+		cfg.FetchWidth, cfg.TakenBranchesPerCycle, cfg.FetchLinesPerCycle = 8, 2, 2
+	}
+
+	cases := []struct {
+		name    string
+		words   []uint32
+		set     func(*CoreConfig)
+		pc      uint32
+		want    FetchStop
+		fetched int
+		next    uint32
+	}{
+		{"width", straight, nil, 0x1000, FetchStopWidth, 4, 0x1010},
+		{"block end", straight, width8, 0x1010, FetchStopBlockEnd, 4, 0x1020},
+		{"taken branch", jumpTo(0x1008), width8, 0x1000, FetchStopTaken, 1, 0x1008},
+		{"second taken allowed", jumpTo(0x1008), twoTaken, 0x1000, FetchStopBlockEnd, 7, 0x1020},
+		{"line crossing", jumpTo(0x1110), twoTaken, 0x1000, FetchStopLine, 1, 0x1110},
+		{"second line allowed", jumpTo(0x1110), twoLines, 0x1000, FetchStopBlockEnd, 5, 0x1120},
+		{"miss", straight, nil, 0x2000, FetchStopMiss, 0, 0x2000},
+	}
+	for _, tc := range cases {
+		core := fetchCore(t, tc.words, tc.set)
+		core.pc = tc.pc
+
+		stop := core.fetch()
+		if stop != tc.want || core.frontend.Len() != tc.fetched || core.pc != tc.next {
+			t.Errorf("%s: stopped on %v after %d instructions at %#x; expected %v after %d at %#x",
+				tc.name, stop, core.frontend.Len(), core.pc, tc.want, tc.fetched, tc.next)
+		}
+	}
+
+	// Nothing moves the queue on between these calls: it fills up
+	core := fetchCore(t, straight, nil)
+	for i := 0; i < FetchQueueSize/FetchWidth; i++ {
+		core.fetch()
+	}
+	if stop := core.fetch(); stop != FetchStopQueueFull || core.frontend.Len() != FetchQueueSize {
+		t.Errorf("Full queue: stopped on %v holding %d, expected %v holding %d",
+			stop, core.frontend.Len(), FetchStopQueueFull, FetchQueueSize)
+	}
+}
+
+func TestFetch_AverageWidth(t *testing.T) {
+	// WHAT: Fetch rate is instructions fetched per cycle, and shrinking the
+	//       fetch block caps it at the block's instruction count
+	// WHY: Instructions per fetch cycle is the figure the model exists for
+	// HARDWARE: Aligned fetch block feeding the fetch queue
+	// CATEGORY: [INTEGRATION]
+
+	// One line: 15 independent moves and a jump back
+	var loop []uint32
+	for i := 0; i < 15; i++ {
+		loop = append(loop, EncodeIFormat(OpADDI, uint8(1+i%8), 0, int32(i)))
+	}
+	loop = append(loop, EncodeIFormat(OpJAL, 0, 0, -60))
+
+	var rates []float64
+	for _, block := range []int{32, 4} {
+		core := fetchCore(t, loop, func(cfg *CoreConfig) { cfg.FetchBlockBytes = block })
+		core.Run(2000)
+		f := core.frontend
+
+		if want := float64(f.Fetched) / float64(f.cycles); f.GetFetchRate() != want {
+			t.Errorf("Block %d: fetch rate %.3f, expected %.3f", block, f.GetFetchRate(), want)
+		}
+		sum := 0.0
+		for stop := FetchStop(0); stop < NumFetchStops; stop++ {
+			sum += f.GetStopRate(stop)
+		}
+		if sum < 0.999 || sum > 1.001 {
+			t.Errorf("Block %d: stop rates add up to %.3f, expected 1", block, sum)
+		}
+		if max := float64(min(block/4, FetchWidth)); f.GetFetchRate() > max {
+			t.Errorf("Block %d: fetch rate %.3f above %.0f per cycle", block, f.GetFetchRate(), max)
+		}
+		rates = append(rates, f.GetFetchRate())
+
+		if block == 4 && f.GetStopRate(FetchStopBlockEnd) < 0.5 {
+			t.Errorf("Block 4: block end stopped %.1f%% of cycles, expected most",
+				f.GetStopRate(FetchStopBlockEnd)*100)
+		}
+	}
+	if rates[0] <= rates[1] {
+		t.Errorf("Fetch rate %.3f with 32-byte blocks, not above %.3f with 4-byte blocks", rates[0], rates[1])
+	}
+}
+
+func TestFetchBandwidthComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The default row matches a default core, and every fetch shape gets a row
+	// WHY: Only the fetch limits may change between rows
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateBranchPredictionTest()
+	cfg := DefaultCoreConfig()
+	cfg.FetchQueueSize = max(cfg.FetchQueueSize, 2*cfg.FetchWidth)
+	core, err := NewCoreWithConfig(cfg, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+
+	fe := core.frontend
+	checkReport(t, RunFetchBandwidthComparison("Branch Prediction", program, cycles),
+		"FETCH BANDWIDTH: Branch Prediction",
+		fmt.Sprintf("  %-5d %5d %5d %5d %7.3f %7.2f  %5.1f%% %5.1f%% %5.1f%% %5.1f%% %5.1f%% %5.1f%%\n",
+			cfg.FetchWidth, cfg.FetchBlockBytes, cfg.TakenBranchesPerCycle, cfg.FetchLinesPerCycle,
+			core.GetIPC(), fe.GetFetchRate(),
+			fe.GetStopRate(FetchStopWidth)*100, fe.GetStopRate(FetchStopBlockEnd)*100,
+			fe.GetStopRate(FetchStopTaken)*100, fe.GetStopRate(FetchStopLine)*100,
+			fe.GetStopRate(FetchStopQueueFull)*100, fe.GetStopRate(FetchStopMiss)*100),
+		"  4        16     1     1 ", "  4        64     2     1 ", "  8        64     2     2 ")
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 9. RUNTIME CONFIGURATION TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// CoreConfig sizes the core at runtime. A design point must survive a JSON
+// round trip unchanged, and a core built from it must compute the same
+// architectural results as the shipped design - only timing may differ.
+//
+// INVARIANTS:
+//   - Save followed by LoadCoreConfig returns the same config
+//   - Architectural state after a program does not depend on sizing
+
+// wideConfig is a larger window with more load/store units
+func wideConfig() CoreConfig {
+	cfg := DefaultCoreConfig()
+	cfg.WindowSize = 64
+	cfg.NumPhysRegs = 96
+	cfg.NumLSUs = 4
+	return cfg
+}
+
+func TestCoreConfig_SaveLoadRoundTrip(t *testing.T) {
+	// WHAT: A saved config loads back identical and valid
+	// WHY: A design point is shared as one JSON file
+	// HARDWARE: N/A (simulator configuration)
+	// CATEGORY: [UNIT]
+
+	cfg := wideConfig()
+	path := filepath.Join(t.TempDir(), "wide.json")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadCoreConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != cfg {
+		t.Errorf("Loaded %+v, expected %+v", got, cfg)
+	}
+}
+
+func TestCoreConfig_LoadRejectsUnknownField(t *testing.T) {
+	// WHAT: A misspelled field is an error, not a silently ignored knob
+	// WHY: A typo would otherwise run the default design under a wrong name
+	// HARDWARE: N/A (simulator configuration)
+	// CATEGORY: [BOUNDARY]
+
+	path := filepath.Join(t.TempDir(), "typo.json")
+	if err := os.WriteFile(path, []byte(`{"windw_size": 64}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCoreConfig(path); err == nil {
+		t.Error("LoadCoreConfig accepted unknown field windw_size")
+	}
+}
+
+func TestCoreConfig_WideCoreSameResult(t *testing.T) {
+	// WHAT: A wider core runs the array-sum program to the same registers
+	// WHY: Sizing knobs may change timing, never results
+	// HARDWARE: Window, register file and LSU count sized from the config
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	run := func(cfg CoreConfig) (*Core, [NumArchRegs]uint32) {
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(CreateArraySumProgram(), 0x1000)
+		core.Run(10000)
+		var regs [NumArchRegs]uint32
+		for r := range regs {
+			regs[r] = core.window.ReadArchReg(uint8(r))
+		}
+		return core, regs
+	}
+
+	_, base := run(DefaultCoreConfig())
+	wide, regs := run(wideConfig())
+	if base[7] != 42 || base[2] != 100 {
+		t.Fatalf("Default core did not finish: r2=%d r7=%d, expected 100 and 42", base[2], base[7])
+	}
+	if regs != base {
+		t.Errorf("Wide core registers %v, expected %v", regs, base)
+	}
+	if got := wide.GetConfig(); got.WindowSize != 64 || len(wide.lsus) != 4 {
+		t.Errorf("Wide core built with window %d and %d LSUs, expected 64 and 4", got.WindowSize, len(wide.lsus))
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 10. DESIGN SWEEP TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// A sweep expands a grid of configs, runs every benchmark at every point on
+// independent cores and reports one row per (point, benchmark).
+//
+// INVARIANTS:
+//   - Rows come back in point-major order whatever the worker count
+//   - Points that fail Validate are reported, not run
+//   - An empty sweep is an error, never a hang
+
+func TestSweep_GridOrderAndInvalidPoints(t *testing.T) {
+	// WHAT: 2×2 grid yields point-major rows; the over-wide point carries an error
+	// WHY: Reports and CSV columns index rows by point
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	sweep := &Sweep{
+		Base: DefaultCoreConfig(),
+		Params: []SweepParam{
+			{Name: "window_size", Values: []int{32, 40}},
+			{Name: "issue_width", Values: []int{6, 8}},
+		},
+		Benchmarks: []SweepBenchmark{
+			{"Array Sum", CreateArraySumProgram(), 2000},
+			{"Multiply", CreateMultiplyBenchmark(), 2000},
+		},
+		Workers: 3,
+	}
+	results, err := sweep.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 8 {
+		t.Fatalf("%d rows, expected 4 points × 2 benchmarks", len(results))
+	}
+
+	for i, r := range results {
+		point := i / 2
+		wantWindow := []int{32, 32, 40, 40}[point]
+		wantIssue := []int{6, 8, 6, 8}[point]
+		if r.Point != point || r.Config.WindowSize != wantWindow || r.Config.IssueWidth != wantIssue {
+			t.Errorf("Row %d: point %d window %d issue %d, expected %d/%d/%d",
+				i, r.Point, r.Config.WindowSize, r.Config.IssueWidth, point, wantWindow, wantIssue)
+		}
+		if wantIssue == 8 {
+			if r.Error == "" || r.Cycles != 0 {
+				t.Errorf("Row %d: 8-wide issue on 6 units ran (error %q)", i, r.Error)
+			}
+		} else if r.Error != "" || r.Instructions == 0 {
+			t.Errorf("Row %d: error %q, %d instructions", i, r.Error, r.Instructions)
+		}
+	}
+
+	// Concurrency must not change any number
+	sweep.Workers = 1
+	serial, err := sweep.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range serial {
+		if serial[i] != results[i] {
+			t.Errorf("Row %d differs between 1 and 3 workers", i)
+		}
+	}
+}
+
+func TestSweep_CSVOneRowPerResult(t *testing.T) {
+	// WHAT: CSV has a header plus one row per result, with every config column
+	// WHY: Sweeps are analysed outside the simulator
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [UNIT]
+
+	sweep := &Sweep{
+		Base:       DefaultCoreConfig(),
+		Params:     []SweepParam{{Name: "window_size", Values: []int{32, 64}}},
+		Benchmarks: []SweepBenchmark{{"Array Sum", CreateArraySumProgram(), 1000}},
+	}
+	results, err := sweep.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSweepCSV(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1+len(results) {
+		t.Fatalf("%d CSV rows, expected header + %d", len(rows), len(results))
+	}
+	if want := 2 + len(CoreConfigFields) + 13; len(rows[0]) != want {
+		t.Errorf("%d columns, expected %d", len(rows[0]), want)
+	}
+	if rows[2][2] != "64" {
+		t.Errorf("Second row window_size %q, expected 64", rows[2][2])
+	}
+}
+
+func TestSweep_EmptyIsError(t *testing.T) {
+	// WHAT: No benchmarks, or a parameter with no values, is reported as an error
+	// WHY: An empty benchmark list made the report loop step by zero forever
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [BOUNDARY] [REGRESSION]
+
+	empty := &Sweep{Base: DefaultCoreConfig(), Params: []SweepParam{{Name: "window_size", Values: []int{32}}}}
+	if _, err := empty.Points(); err == nil {
+		t.Error("Points accepted a sweep with no benchmarks")
+	}
+	if _, err := empty.Run(); err == nil {
+		t.Error("Run accepted a sweep with no benchmarks")
+	}
+	if report := RunSweepReport("Empty", empty); report != "sweep: no benchmarks" {
+		t.Errorf("Report %q, expected the error text", report)
+	}
+
+	noValues := &Sweep{
+		Base:       DefaultCoreConfig(),
+		Params:     []SweepParam{{Name: "window_size"}},
+		Benchmarks: SweepBenchmarks(100),
+	}
+	if _, err := noValues.Points(); err == nil {
+		t.Error("Points accepted a parameter with no values")
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 11. PHYSICAL REGISTER FILE TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// The physical register file is sized by num_phys_regs, independently of
+// the window. Rename writes the speculative RAT; commit moves the mapping
+// into the committed RAT and frees the one it replaced; Flush copies the
+// committed RAT back.
+//
+// INVARIANTS:
+//   - Commit frees the previous mapping, never the one being committed
+//   - Flush leaves the speculative RAT equal to the committed RAT
+//   - Dispatch stalls are charged to whichever resource ran out
+
+// commitAll completes every in-flight entry and retires them in order
+func commitAll(t *testing.T, w *Window, ids []int) {
+	t.Helper()
+	for _, id := range ids {
+		w.Complete(id, uint32(id))
+	}
+	for range ids {
+		if w.Commit() == nil {
+			t.Fatal("Commit refused an executed entry")
+		}
+	}
+}
+
+func TestRegisterFile_CommitFreesPreviousMapping(t *testing.T) {
+	// WHAT: Committing a rename frees rd's old register and keeps the new one
+	// WHY: Freeing the new register would hand live architectural state to the next rename
+	// HARDWARE: Commit reads PrevRd from the entry and pushes it on the free list
+	// CATEGORY: [UNIT] [LIFECYCLE]
+
+	w := NewWindow()
+	free := w.freeList.GetFreeCount()
+
+	ids := dispatchWords(t, w, EncodeIFormat(OpADDI, 3, 0, 5)) // r3 = 5
+	renamed := w.rat.Lookup(3)
+	if renamed == 3 || w.rat.Committed(3) != 3 {
+		t.Fatalf("After rename: speculative p%d, committed p%d; expected a new register and p3",
+			renamed, w.rat.Committed(3))
+	}
+	if e := w.GetEntry(ids[0]); e.PrevRd != 3 {
+		t.Errorf("Entry remembers p%d as the previous mapping, expected p3", e.PrevRd)
+	}
+
+	commitAll(t, w, ids)
+	if w.rat.Committed(3) != renamed {
+		t.Errorf("Committed RAT maps r3 to p%d, expected p%d", w.rat.Committed(3), renamed)
+	}
+	if w.freeList.GetRefCount(3) != 0 {
+		t.Error("Old mapping p3 still allocated after commit")
+	}
+	if w.freeList.GetRefCount(renamed) != 1 {
+		t.Errorf("New mapping p%d was freed by its own commit", renamed)
+	}
+	if w.freeList.GetFreeCount() != free {
+		t.Errorf("%d registers free after commit, expected %d", w.freeList.GetFreeCount(), free)
+	}
+	if w.ReadArchReg(3) != uint32(ids[0]) {
+		t.Errorf("ReadArchReg(3) = %d, expected the committed result %d", w.ReadArchReg(3), ids[0])
+	}
+}
+
+func TestRegisterFile_FlushRestoresCommittedRAT(t *testing.T) {
+	// WHAT: Flush points every register back at its committed mapping and
+	//       frees the speculative ones
+	// WHY: Wrong-path renames must not survive a mispredict
+	// HARDWARE: Committed RAT copied into the speculative RAT in one cycle
+	// CATEGORY: [UNIT] [LIFECYCLE]
+
+	w := NewWindow()
+	commitAll(t, w, dispatchWords(t, w, EncodeIFormat(OpADDI, 3, 0, 5)))
+	committed := w.rat.Committed(3)
+	free := w.freeList.GetFreeCount()
+
+	dispatchWords(t, w,
+		EncodeIFormat(OpADDI, 3, 3, 1), // r3 = r3 + 1 (speculative)
+		EncodeIFormat(OpADDI, 4, 3, 1), // r4 = r3 + 1 (speculative)
+	)
+	if w.rat.Lookup(3) == committed {
+		t.Fatal("Speculative rename of r3 reused the committed register")
+	}
+
+	w.Flush()
+	for r := uint8(1); r < NumArchRegs; r++ {
+		if w.rat.Lookup(r) != w.rat.Committed(r) {
+			t.Errorf("r%d: speculative p%d, committed p%d after Flush", r, w.rat.Lookup(r), w.rat.Committed(r))
+		}
+	}
+	if w.rat.Lookup(3) != committed {
+		t.Errorf("r3 maps to p%d after Flush, expected committed p%d", w.rat.Lookup(3), committed)
+	}
+	if w.freeList.GetFreeCount() != free {
+		t.Errorf("%d registers free after Flush, expected %d", w.freeList.GetFreeCount(), free)
+	}
+}
+
+func TestRegisterFile_SmallFileStallsOnFreeList(t *testing.T) {
+	// WHAT: With few physical registers, dispatch stalls on the free list, not the window
+	// WHY: GetDispatchStalls must say which resource limits the core
+	// HARDWARE: Separate stall causes from the free-list and window-credit checks
+	// CATEGORY: [INTEGRATION] [BOUNDARY]
+
+	cfg := DefaultCoreConfig()
+	cfg.NumPhysRegs = NumArchRegs + 2
+	w := NewWindowWithConfig(cfg)
+
+	dispatchWords(t, w, EncodeIFormat(OpADDI, 3, 0, 1), EncodeIFormat(OpADDI, 4, 0, 2))
+	if _, ok := w.Dispatch(DecodeInstruction(EncodeIFormat(OpADDI, 5, 0, 3), 0x1008)); ok {
+		t.Fatal("Dispatch succeeded with no free physical register")
+	}
+	if _, ok := w.Dispatch(DecodeInstruction(EncodeBFormat(OpBEQ, 0, 0, 8), 0x1008)); !ok {
+		t.Error("A branch (no destination) was refused with window space left")
+	}
+	if windowFull, freeList := w.GetDispatchStalls(); windowFull != 0 || freeList != 1 {
+		t.Errorf("GetDispatchStalls = %d window, %d free list; expected 0 and 1", windowFull, freeList)
+	}
+
+	// Whole program: same result as the default file, stalls on registers only
+	var want [NumArchRegs]uint32
+	for _, regs := range []int{NumPhysRegs, NumArchRegs + 2} {
+		cfg := DefaultCoreConfig()
+		cfg.NumPhysRegs = regs
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(CreateOutOfOrderTest(), 0x1000)
+		core.Run(5000)
+
+		for r := uint8(0); r < NumArchRegs; r++ {
+			if regs == NumPhysRegs {
+				want[r] = core.window.ReadArchReg(r)
+			} else if got := core.window.ReadArchReg(r); got != want[r] {
+				t.Errorf("%d registers: r%d = %d, default file gives %d", regs, r, got, want[r])
+			}
+		}
+		if regs == NumPhysRegs {
+			continue
+		}
+
+		windowFull, freeList := core.GetDispatchStallRates()
+		if freeList == 0 {
+			t.Errorf("%d registers: no free-list stalls", regs)
+		}
+		if windowFull != 0 {
+			t.Errorf("%d registers: window-full stall rate %.3f, expected 0", regs, windowFull)
+		}
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 12. MISPREDICT RECOVERY TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// Mispredicts are repaired at commit (full flush) unless early_recovery
+// selects execute-time repair (selective squash of younger entries).
+//
+// INVARIANTS:
+//   - Both recovery points produce the same architectural state
+//   - Execute-time recovery never waits longer than commit-time recovery
+
+// runRecovery runs the branch test with the given early_recovery setting
+func runRecovery(t *testing.T, early int) *Core {
+	cfg := DefaultCoreConfig()
+	cfg.EarlyRecovery = early
+	core, err := NewCoreWithConfig(cfg, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LoadProgram(CreateBranchPredictionTest(), 0x1000)
+	core.Run(5000)
+	return core
+}
+
+func TestRecovery_EarlyRecoveryOptIn(t *testing.T) {
+	// WHAT: Default cores recover at commit; early_recovery=1 recovers at execute
+	// WHY: Execute-time recovery changes timing; the shipped design must not move
+	// HARDWARE: Recovery-point select on the redirect mux
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	commit, execute := runRecovery(t, 0), runRecovery(t, 1)
+	if commit.earlyRecovery || !execute.earlyRecovery {
+		t.Fatalf("earlyRecovery: default %v, opted in %v", commit.earlyRecovery, execute.earlyRecovery)
+	}
+	if commit.recoveries == 0 {
+		t.Fatal("Branch test produced no mispredict recoveries")
+	}
+
+	for r := uint8(0); r < NumArchRegs; r++ {
+		if a, b := commit.window.ReadArchReg(r), execute.window.ReadArchReg(r); a != b {
+			t.Errorf("r%d: %d with commit-time recovery, %d with execute-time", r, a, b)
+		}
+	}
+	if execute.window.ReadArchReg(5) != 42 {
+		t.Errorf("r5 = %d, expected 42 (program finished)", execute.window.ReadArchReg(5))
+	}
+
+	commitWait, _ := commit.GetMispredictPenalty()
+	executeWait, _ := execute.GetMispredictPenalty()
+	if executeWait > commitWait {
+		t.Errorf("Execute-time recovery waited %.1f cycles, commit-time %.1f", executeWait, commitWait)
+	}
+}
+
+func TestRecovery_EarlyRecoveryKeepsFetchPrediction(t *testing.T) {
+	// WHAT: A branch stalled behind a load miss is checked against the path fetch took
+	// WHY: Dispatch used to re-ask the predictor; once execute-time recovery had
+	//      trained it, the entry recorded the wrong guess and a wrong path committed
+	// HARDWARE: Predicted direction/target travel with the instruction from fetch
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	program := []uint32{
+		EncodeIFormat(OpADDI, 3, 0, 0),    // r3 = 0
+		EncodeIFormat(OpADDI, 5, 0, 300),  // r5 = 300
+		EncodeIFormat(OpLW, 6, 0, 0x7100), // L: r6 = mem[0x7100] (misses)
+		EncodeRFormat(OpADD, 1, 1, 6),     // r1 += r6
+		EncodeIFormat(OpADDI, 3, 3, 1),    // r3++
+		EncodeBFormat(OpBLT, 3, 9, 8),     // never taken (r9 = 0)
+		EncodeBFormat(OpBLT, 3, 5, -16),   // if r3 < 300, loop
+		EncodeIFormat(OpADDI, 10, 0, 1),   // r10 = 1 (done)
+	}
+
+	for _, early := range []int{0, 1} {
+		cfg := DefaultCoreConfig()
+		cfg.EarlyRecovery = early
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(50000)
+
+		if r3, r10 := core.window.ReadArchReg(3), core.window.ReadArchReg(10); r3 != 300 || r10 != 1 {
+			t.Errorf("early_recovery=%d: r3 = %d, r10 = %d, expected 300 and 1", early, r3, r10)
+		}
+	}
+}
+
+func TestBranchRecoveryComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The commit and execute rows match runs with early recovery off and on
+	// WHY: The penalty split is the comparison's headline number
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateBranchPredictionTest()
+	want := []string{"MISPREDICT RECOVERY: Branch Prediction"}
+	for _, early := range []bool{false, true} {
+		core := NewCore(1024 * 1024)
+		core.EnableEarlyRecovery(early)
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		label := "commit"
+		if early {
+			label = "execute"
+		}
+		wait, refill := core.GetMispredictPenalty()
+		want = append(want, fmt.Sprintf("  %-10s %7.3f %8d %8d %9d %8.1f %8.1f %8.1f\n",
+			label, core.GetIPC(), core.branchMispredicts, core.recoveries,
+			core.window.GetSquashed(), wait, refill, wait+refill))
+	}
+
+	checkReport(t, RunBranchRecoveryComparison("Branch Prediction", program, cycles), want...)
+}
+
+func TestRecovery_ValueMispredictIsARecovery(t *testing.T) {
+	// WHAT: A stale predicted value that reached a consumer redirects through the
+	//       same path as a branch: counted in the recovery stats, repaired at the
+	//       load's verification when early_recovery=1
+	// WHY: The value flush used to copy the redirect by hand and skip the penalty
+	//      bookkeeping, and always waited for the load to commit
+	// HARDWARE: One redirect mux for branch and value recovery
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	for _, early := range []int{0, 1} {
+		cfg := DefaultCoreConfig()
+		cfg.EarlyRecovery = early
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.WriteMemWord(0xA100, 5)
+		core.EnableValuePrediction(true)
+		core.LoadProgram(createValueChangeProgram(), 0x1000)
+
+		// Commit-time repair redirects the cycle the load retires;
+		// verify-time repair has redirected already by then
+		for core.window.ReadArchReg(8) != 42 && core.cycles < 30000 {
+			flushes, recoveries := core.valuePred.flushes, core.recoveries
+			core.Cycle()
+			if core.valuePred.flushes == flushes {
+				continue
+			}
+			if redirected := core.recoveries != recoveries; redirected != (early == 0) {
+				t.Errorf("early_recovery=%d: redirect at the load's commit = %v", early, redirected)
+			}
+		}
+
+		if got := core.window.ReadArchReg(7); got != 150*5+150*9 {
+			t.Errorf("early_recovery=%d: r7 = %d, expected %d", early, got, 150*5+150*9)
+		}
+		flushes := core.valuePred.flushes
+		if flushes == 0 {
+			t.Fatalf("early_recovery=%d: no value flush was exercised", early)
+		}
+
+		// Commit time: one redirect per retired mispredict, branch or value.
+		// Execute time also redirects for wrong-path branches later squashed.
+		want := core.branchMispredicts + flushes
+		if early == 0 && core.recoveries != want {
+			t.Errorf("early_recovery=0: %d recoveries, expected %d branch + %d value",
+				core.recoveries, core.branchMispredicts, flushes)
+		}
+		if early == 1 && core.recoveries < want {
+			t.Errorf("early_recovery=1: %d recoveries, fewer than %d branch + %d value",
+				core.recoveries, core.branchMispredicts, flushes)
+		}
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 13. ISSUE SCHEDULING TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// The issue policy is chosen before a run and only reorders ready
+// instructions. Critical-path scheduling finds producers through the
+// window's per-register producer index.
+//
+// INVARIANTS:
+//   - Every policy produces the same architectural state
+//   - The producer index always matches a scan of the window
+//   - Eliminated moves are never recorded as producers
+
+// scanProducer is the reference for the producer index: the valid,
+// non-eliminated entry other than consumer that writes physReg
+func scanProducer(w *Window, physReg uint8, consumer int) int {
+	if physReg == InvalidTag || physReg == ZeroPhysReg {
+		return -1
+	}
+	for i := range w.entries {
+		e := &w.entries[i]
+		if i != consumer && e.Valid && e.Eliminated == ElimNone && e.PhysRd == physReg {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestWindow_SetSchedulerRequiresEmpty(t *testing.T) {
+	// WHAT: SetScheduler fails while instructions are in flight
+	// WHY: The new policy never saw those entries and could not issue them
+	// HARDWARE: Policy select is a reset-time strap, not a runtime mux
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	core := NewCore(1024 * 1024)
+	if err := core.SetScheduler(NewRandomScheduler(1)); err != nil {
+		t.Fatalf("Empty window rejected a scheduler: %v", err)
+	}
+
+	core.LoadProgram(CreateOutOfOrderTest(), 0x1000)
+	for core.window.count == 0 {
+		core.Cycle()
+	}
+	if err := core.SetScheduler(NewOldestFirstScheduler()); err == nil {
+		t.Errorf("SetScheduler accepted a window holding %d instructions", core.window.count)
+	}
+	if core.window.sched.Name() != "random" {
+		t.Errorf("Rejected SetScheduler still replaced the policy (now %q)", core.window.sched.Name())
+	}
+}
+
+func TestWindow_ProducerIndexMatchesScan(t *testing.T) {
+	// WHAT: producerOf agrees with a full window scan every cycle
+	// WHY: Dispatch, commit, squash and flush must all keep the index exact
+	// HARDWARE: Producer tag table beside the RAT, one entry per physical register
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	benchmarks := []SweepBenchmark{
+		{"Branch Prediction", CreateBranchPredictionTest(), 5000},
+		{"Moves", CreateMoveBenchmark(), 5000},
+		{"Out-of-Order", CreateOutOfOrderTest(), 2000},
+	}
+
+	// Commit-time recovery flushes; execute-time recovery squashes
+	for early := 0; early <= 1; early++ {
+		cfg := DefaultCoreConfig()
+		cfg.MoveElimination = 1
+		cfg.EarlyRecovery = early
+		checkProducerIndex(t, cfg, benchmarks)
+	}
+}
+
+// checkProducerIndex runs each benchmark one cycle at a time, comparing
+// the producer index against scanProducer for every physical register
+func checkProducerIndex(t *testing.T, cfg CoreConfig, benchmarks []SweepBenchmark) {
+	t.Helper()
+	var recoveries uint64
+
+	for _, bench := range benchmarks {
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(bench.Program, 0x1000)
+		w := core.window
+
+		for core.cycles < bench.Cycles {
+			core.Cycle()
+			for r := 0; r < len(w.physRegFile); r++ {
+				for _, consumer := range []int{-1, w.head} {
+					got, want := w.producerOf(uint8(r), consumer), scanProducer(w, uint8(r), consumer)
+					if got != want {
+						t.Fatalf("%s cycle %d: producerOf(p%d, %d) = %d, scan finds %d",
+							bench.Name, core.cycles, r, consumer, got, want)
+					}
+				}
+			}
+		}
+		recoveries += core.recoveries
+
+		w.Flush()
+		for r, p := range w.producer {
+			if p != -1 {
+				t.Errorf("%s: p%d still produced by entry %d after Flush", bench.Name, r, p)
+			}
+		}
+	}
+	if recoveries == 0 {
+		t.Errorf("early_recovery=%d: no recoveries, so squash went unchecked", cfg.EarlyRecovery)
+	}
+}
+
+func TestScheduler_PoliciesSameResult(t *testing.T) {
+	// WHAT: Every built-in policy finishes each benchmark with the same registers
+	// WHY: Issue order is a timing choice; it must never change results
+	// HARDWARE: Select logic only picks among ready, dependence-checked entries
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	cfg := DefaultCoreConfig()
+	for _, bench := range SweepBenchmarks(20000) {
+		var want [NumArchRegs]uint32
+		for p, sched := range SchedulerPolicies(cfg) {
+			core := NewCore(1024 * 1024)
+			if err := core.SetScheduler(sched); err != nil {
+				t.Fatal(err)
+			}
+			core.LoadProgram(bench.Program, 0x1000)
+			core.Run(bench.Cycles)
+
+			for r := uint8(0); r < NumArchRegs; r++ {
+				got := core.window.ReadArchReg(r)
+				if p == 0 {
+					want[r] = got
+				} else if got != want[r] {
+					t.Errorf("%s under %s: r%d = %d, oldest-first gives %d",
+						bench.Name, sched.Name(), r, got, want[r])
+				}
+			}
+		}
+	}
+
+	report := RunSchedulerComparison("Suite", SweepBenchmarks(2000))
+	for _, sched := range SchedulerPolicies(cfg) {
+		if !strings.Contains(report, sched.Name()) {
+			t.Errorf("Report has no row for %s", sched.Name())
+		}
+	}
+	if strings.Contains(report, "error") {
+		t.Errorf("Report shows a failed run:\n%s", report)
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 14. MULTIPLIER TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// MultiplyOp computes all four M-extension multiplies from one
+// unsigned × signed Booth product. MultiplierPool only models timing:
+// latency, issue interval, and result ports shared oldest first.
+//
+// INVARIANTS:
+//   - MultiplyOp matches 64-bit native products for every op
+//   - Results leave oldest first, at most MulResultPorts per cycle
+//   - Squashed multiplies never write back
+
+// nativeMultiply is the reference for MultiplyOp
+func nativeMultiply(op uint8, a, b uint32) uint32 {
+	switch op {
+	case OpMUL:
+		return a * b
+	case OpMULH:
+		return uint32(uint64(int64(int32(a))*int64(int32(b))) >> 32)
+	case OpMULHU:
+		return uint32(uint64(a) * uint64(b) >> 32)
+	default: // OpMULHSU
+		return uint32(uint64(int64(int32(a))*int64(b)) >> 32)
+	}
+}
+
+func TestMultiplyOp_MatchesNative(t *testing.T) {
+	// WHAT: MUL, MULH, MULHU and MULHSU match int64/uint64 products
+	// WHY: The high-word sign corrections are easy to get backwards
+	// HARDWARE: Conditional correction add in the Wallace tree's final adder
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	edges := []uint32{0, 1, 2, 3, 0x7FFF, 0x8000, 0xFFFF, 0x10000,
+		0x7FFFFFFF, 0x80000000, 0x80000001, 0xFFFFFFFE, 0xFFFFFFFF, 0xDEADBEEF}
+	ops := []struct {
+		op   uint8
+		name string
+	}{{OpMUL, "MUL"}, {OpMULH, "MULH"}, {OpMULHU, "MULHU"}, {OpMULHSU, "MULHSU"}}
+
+	check := func(a, b uint32) {
+		for _, o := range ops {
+			if got, want := MultiplyOp(o.op, a, b), nativeMultiply(o.op, a, b); got != want {
+				t.Fatalf("%s 0x%08X × 0x%08X = 0x%08X, expected 0x%08X", o.name, a, b, got, want)
+			}
+		}
+	}
+
+	for _, a := range edges {
+		for _, b := range edges {
+			check(a, b)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		check(rng.Uint32(), rng.Uint32())
+	}
+}
+
+// mulPool builds a MultiplierPool with the given shape
+func mulPool(units, latency, interval, ports int) *MultiplierPool {
+	cfg := DefaultCoreConfig()
+	cfg.NumMULs = units
+	cfg.MulLatency = latency
+	cfg.MulIssueInterval = interval
+	cfg.MulResultPorts = ports
+	return NewMultiplierPool(cfg)
+}
+
+func TestMultiplierPool_ResultPortsOldestFirst(t *testing.T) {
+	// WHAT: Four results ready together leave one per cycle, oldest first
+	// WHY: A starved old multiply would hold up commit indefinitely
+	// HARDWARE: Age-ordered arbiter on the multiply result bus
+	// CATEGORY: [UNIT]
+
+	p := mulPool(4, 3, 1, 1)
+	for id := 10; id < 14; id++ {
+		if !p.Issue(0, id, OpMUL, uint32(id), 2) {
+			t.Fatalf("Issue of entry %d refused with 4 free units", id)
+		}
+	}
+	if p.CanIssue(0) {
+		t.Error("CanIssue true with every unit issued this cycle")
+	}
+
+	for now := uint64(1); now < 3; now++ {
+		if r := p.Results(now); len(r) != 0 {
+			t.Fatalf("Cycle %d: %d results before the latency elapsed", now, len(r))
+		}
+	}
+	for now, id := uint64(3), 10; id < 14; now, id = now+1, id+1 {
+		r := p.Results(now)
+		if len(r) != 1 || r[0].WindowID != id || r[0].Value != uint32(id*2) {
+			t.Fatalf("Cycle %d: results %v, expected entry %d = %d", now, r, id, id*2)
+		}
+	}
+	if p.InFlight() != 0 {
+		t.Errorf("%d multiplies still in flight", p.InFlight())
+	}
+	if p.BusWaits != 3+2+1 {
+		t.Errorf("BusWaits = %d, expected 6", p.BusWaits)
+	}
+}
+
+func TestMultiplierPool_IssueInterval(t *testing.T) {
+	// WHAT: An unpipelined unit refuses ops until its interval passes
+	// WHY: mul_issue_interval = mul_latency must model a blocking multiplier
+	// HARDWARE: Per-unit busy counter
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	p := mulPool(1, 3, 3, 1)
+	if !p.Issue(0, 0, OpMUL, 6, 7) {
+		t.Fatal("Idle unit refused an op")
+	}
+	for now := uint64(1); now < 3; now++ {
+		if p.Issue(now, 1, OpMUL, 6, 7) {
+			t.Fatalf("Unit took a second op at cycle %d", now)
+		}
+	}
+	if !p.Issue(3, 1, OpMUL, 6, 7) {
+		t.Error("Unit still busy after its issue interval")
+	}
+	if util := p.GetUtilization(6); util[0] != 1 {
+		t.Errorf("Utilization %.2f over 6 cycles, expected 1.00", util[0])
+	}
+}
+
+func TestMultiplierPool_SquashCancels(t *testing.T) {
+	// WHAT: Squash drops multiplies whose window entries are gone
+	// WHY: A late result would write into a reallocated window slot
+	// HARDWARE: Valid bits cleared in the multiply pipeline on flush
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	p := mulPool(3, 2, 1, 3)
+	for id := 0; id < 3; id++ {
+		p.Issue(0, id, OpMUL, 1, 1)
+	}
+	p.Squash(func(windowID int) bool { return windowID != 1 })
+
+	if p.InFlight() != 2 || p.Cancelled != 1 {
+		t.Fatalf("InFlight %d, Cancelled %d after squashing one of three", p.InFlight(), p.Cancelled)
+	}
+	r := p.Results(2)
+	if len(r) != 2 || r[0].WindowID != 0 || r[1].WindowID != 2 {
+		t.Errorf("Results %v, expected entries 0 and 2", r)
+	}
+}
+
+func TestMultiplier_PoolShapesSameResult(t *testing.T) {
+	// WHAT: The multiply benchmark ends with the same registers for every pool shape
+	// WHY: Latency, interval and ports are timing only
+	// HARDWARE: Result bus arbitration never reorders values between entries
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	var want [NumArchRegs]uint32
+	for i, shape := range [][4]int{{1, 1, 1, 1}, {1, 3, 3, 1}, {2, 3, 1, 1}, {2, 4, 2, 2}} {
+		cfg := DefaultCoreConfig()
+		cfg.NumMULs, cfg.MulLatency, cfg.MulIssueInterval, cfg.MulResultPorts = shape[0], shape[1], shape[2], shape[3]
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(CreateMultiplyBenchmark(), 0x1000)
+		core.Run(20000)
+
+		if core.multiplier.Issued == 0 {
+			t.Fatalf("Shape %v issued no multiplies", shape)
+		}
+		for r := uint8(0); r < NumArchRegs; r++ {
+			got := core.window.ReadArchReg(r)
+			if i == 0 {
+				want[r] = got
+			} else if got != want[r] {
+				t.Errorf("Shape %v: r%d = %d, default pool gives %d", shape, r, got, want[r])
+			}
+		}
+	}
+	if want[8] != 42 {
+		t.Errorf("r8 = %d, expected 42 (program finished)", want[8])
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 15. DIVIDER TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// DivideOp computes DIV, DIVU, REM and REMU with Newton-Raphson and the
+// RISC-V rules for division by zero and overflow. DividerPool models
+// timing: fast cases, opt-in early-out, issue interval.
+//
+// INVARIANTS:
+//   - DivideOp matches Go's truncating division on every input
+//   - Early-out only shortens latency when div_early_out is set
+//   - Squashed divides never write back
+
+// nativeDivide is the reference for DivideOp: Go's / and % truncate the
+// same way and wrap INT_MIN / -1 to INT_MIN; division by zero (which Go
+// panics on) follows the RISC-V table
+func nativeDivide(op uint8, funct uint16, a, b uint32) uint32 {
+	switch {
+	case b == 0 && op == OpDIV:
+		return 0xFFFFFFFF
+	case b == 0:
+		return a
+	case op == OpDIV && funct != 0:
+		return a / b
+	case op == OpDIV:
+		return uint32(int32(a) / int32(b))
+	case funct != 0:
+		return a % b
+	default:
+		return uint32(int32(a) % int32(b))
+	}
+}
+
+func TestDivider(t *testing.T) {
+	// WHAT: DivideOp matches native division on edge cases and millions of random pairs
+	// WHY: Newton-Raphson is off by one on a small set of inputs unless corrected
+	// HARDWARE: Reciprocal seed, two iterations, remainder fix-up
+	// CATEGORY: [UNIT] [BOUNDARY] [INVARIANT]
+
+	ops := []struct {
+		op    uint8
+		funct uint16
+		name  string
+	}{{OpDIV, 0, "DIV"}, {OpDIV, FunctUnsigned, "DIVU"}, {OpREM, 0, "REM"}, {OpREM, FunctUnsigned, "REMU"}}
+
+	check := func(a, b uint32) {
+		for _, o := range ops {
+			if got, want := DivideOp(o.op, o.funct, a, b), nativeDivide(o.op, o.funct, a, b); got != want {
+				t.Fatalf("%s 0x%08X / 0x%08X = 0x%08X, expected 0x%08X", o.name, a, b, got, want)
+			}
+		}
+	}
+
+	// Edge cases: 0, ±1, INT_MIN, INT_MAX, powers of two and their neighbours
+	edges := []uint32{0, 1, 2, 3, 5, 7, 0x7FFFFFFF, 0x80000000, 0x80000001,
+		0xFFFFFFFF, 0xFFFFFFFE, 0xFFFFFFFD, 0xAAAAAAAA, 0x55555555}
+	for k := 2; k < 32; k++ {
+		edges = append(edges, 1<<k-1, 1<<k+1, -(uint32(1) << k))
+	}
+	for _, a := range edges {
+		for _, b := range edges {
+			check(a, b)
+		}
+	}
+
+	// Random pairs: full-width, small divisors, small quotients, small negatives
+	samples := 2000000
+	if testing.Short() {
+		samples = 100000
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < samples; i++ {
+		a, b := rng.Uint32(), rng.Uint32()
+		switch i % 4 {
+		case 1:
+			b >>= uint(rng.Intn(32))
+		case 2:
+			a >>= uint(rng.Intn(32))
+			b >>= uint(rng.Intn(16))
+		case 3:
+			a = uint32(-int32(a >> 20))
+			b = uint32(-int32(b >> 24))
+		}
+		check(a, b)
+	}
+
+	// RISC-V table spot checks, independent of nativeDivide
+	for _, c := range []struct {
+		op    uint8
+		funct uint16
+		a, b  uint32
+		want  uint32
+	}{
+		{OpDIV, 0, 7, 0, 0xFFFFFFFF},
+		{OpDIV, FunctUnsigned, 7, 0, 0xFFFFFFFF},
+		{OpREM, 0, 7, 0, 7},
+		{OpREM, FunctUnsigned, 7, 0, 7},
+		{OpDIV, 0, 0x80000000, 0xFFFFFFFF, 0x80000000},
+		{OpREM, 0, 0x80000000, 0xFFFFFFFF, 0},
+		{OpDIV, 0, uint32(0xFFFFFFF9), 2, uint32(0xFFFFFFFD)}, // -7 / 2 = -3
+		{OpREM, 0, uint32(0xFFFFFFF9), 2, uint32(0xFFFFFFFF)}, // -7 % 2 = -1
+		{OpDIV, FunctUnsigned, 0xFFFFFFF9, 2, 0x7FFFFFFC},
+	} {
+		if got := DivideOp(c.op, c.funct, c.a, c.b); got != c.want {
+			t.Errorf("op %#x funct %#x: %#x / %#x = %#x, expected %#x", c.op, c.funct, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+// divPool builds a single-unit DividerPool with the given early-out setting
+func divPool(latency, interval, early int) *DividerPool {
+	cfg := DefaultCoreConfig()
+	cfg.NumDIVs = 1
+	cfg.DivLatency = latency
+	cfg.DivIssueInterval = interval
+	cfg.DivEarlyOut = early
+	return NewDividerPool(cfg)
+}
+
+func TestDividerPool_Latency(t *testing.T) {
+	// WHAT: Fast cases take 1 cycle; small quotients are short only with early-out
+	// WHY: Early-out is opt-in; the default divider must keep its fixed latency
+	// HARDWARE: Leading-zero compare selects the iteration count
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	if DefaultCoreConfig().DivEarlyOut != 0 {
+		t.Fatal("div_early_out is on by default")
+	}
+
+	cases := []struct {
+		name        string
+		funct       uint16
+		a, b        uint32
+		plain, fast int // Latency without and with early-out
+	}{
+		{"zero divisor", 0, 100, 0, 1, 1},
+		{"INT_MIN / -1", 0, 0x80000000, 0xFFFFFFFF, 1, 1},
+		{"power of two", 0, 1000, 8, 1, 1},
+		{"negative power of two", 0, 1000, 0xFFFFFFF8, 1, 1},
+		{"quotient zero", 0, 7, 9, 4, 1},
+		{"small quotient", 0, 12345, 67, 4, 3},
+		{"large quotient", FunctUnsigned, 0xFFFFFFFF, 3, 4, 4},
+	}
+	for _, c := range cases {
+		for early, want := range []int{c.plain, c.fast} {
+			p := divPool(4, 4, early)
+			p.Issue(0, 0, OpDIV, c.funct, c.a, c.b)
+			got := 0
+			for now := uint64(1); got == 0 && now <= 8; now++ {
+				if len(p.Results(now)) == 1 {
+					got = int(now)
+				}
+			}
+			if got != want {
+				t.Errorf("%s, early-out %d: result after %d cycles, expected %d", c.name, early, got, want)
+			}
+		}
+	}
+}
+
+func TestDividerPool_IssueIntervalAndSquash(t *testing.T) {
+	// WHAT: An unpipelined unit is busy for its interval; squash cancels the op
+	// WHY: The iteration hardware can't stop mid-op, but the result must not land
+	// HARDWARE: Per-unit busy counter; valid bit cleared on flush
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	p := divPool(4, 4, 0)
+	if !p.Issue(0, 5, OpDIV, 0, 100, 7) {
+		t.Fatal("Idle unit refused a divide")
+	}
+	if p.CanIssue(3) || !p.CanIssue(4) {
+		t.Errorf("CanIssue at 3/4 = %v/%v, expected false/true", p.CanIssue(3), p.CanIssue(4))
+	}
+
+	p.Squash(func(windowID int) bool { return windowID != 5 })
+	if p.InFlight() != 0 || p.Cancelled != 1 {
+		t.Fatalf("InFlight %d, Cancelled %d after squash", p.InFlight(), p.Cancelled)
+	}
+	if r := p.Results(4); len(r) != 0 {
+		t.Errorf("Squashed divide wrote back %v", r)
+	}
+	if p.CanIssue(3) {
+		t.Error("Squash freed the unit before its interval ended")
+	}
+}
+
+func TestDivider_PoolShapesSameResult(t *testing.T) {
+	// WHAT: The divide benchmarks end with the same registers for every pool shape
+	// WHY: Latency, interval and early-out are timing only
+	// HARDWARE: Results are tagged by window entry, not by arrival order
+	// CATEGORY: [INTEGRATION] [INVARIANT]
+
+	for _, bench := range []SweepBenchmark{
+		{"Divide", CreateDivideBenchmark(), 20000},
+		{"Divide Mix", CreateDivideMixBenchmark(), 20000},
+	} {
+		var want [NumArchRegs]uint32
+		for i, shape := range [][4]int{{1, 4, 4, 0}, {1, 4, 4, 1}, {2, 4, 1, 1}, {1, 16, 16, 0}} {
+			cfg := DefaultCoreConfig()
+			cfg.NumDIVs, cfg.DivLatency, cfg.DivIssueInterval, cfg.DivEarlyOut = shape[0], shape[1], shape[2], shape[3]
+			core, err := NewCoreWithConfig(cfg, 1024*1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			core.LoadProgram(bench.Program, 0x1000)
+			core.Run(bench.Cycles)
+
+			if core.divider.Issued == 0 {
+				t.Fatalf("%s: shape %v issued no divides", bench.Name, shape)
+			}
+			if shape[3] == 0 && core.divider.EarlyOuts != 0 {
+				t.Errorf("%s: shape %v took %d early-outs with early-out off", bench.Name, shape, core.divider.EarlyOuts)
+			}
+			for r := uint8(0); r < NumArchRegs; r++ {
+				got := core.window.ReadArchReg(r)
+				if i == 0 {
+					want[r] = got
+				} else if got != want[r] {
+					t.Errorf("%s: shape %v: r%d = %d, default pool gives %d", bench.Name, shape, r, got, want[r])
+				}
+			}
+		}
+		if want[7] != 42 {
+			t.Errorf("%s: r7 = %d, expected 42 (program finished)", bench.Name, want[7])
+		}
+	}
+}
+
+// ╔═══════════════════════════════════════════════════════════════════════════╗
+// 16. MOVE ELIMINATION TESTS
+// ╚═══════════════════════════════════════════════════════════════════════════╝
+//
+// With move_elimination on, rename finishes register copies by sharing the
+// source's physical register and zero idioms by mapping to ZeroPhysReg.
+// Shared registers are reference counted in the FreeList.
+//
+// INVARIANTS:
+//   - Only the recognized move and zero forms are eliminated
+//   - A shared register is freed once, when its last holder lets go
+//   - After a flush, every register's holders are exactly its committed mappings
+
+// elimWindow returns a window with move elimination on
+func elimWindow() *Window {
+	cfg := DefaultCoreConfig()
+	cfg.MoveElimination = 1
+	return NewWindowWithConfig(cfg)
+}
+
+// checkHolders verifies each physical register's reference count equals
+// the number of committed mappings to it (call with an empty window)
+func checkHolders(t *testing.T, w *Window) {
+	t.Helper()
+	holders := make([]int, w.cfg.NumPhysRegs)
+	for r := uint8(1); r < NumArchRegs; r++ {
+		holders[w.rat.Committed(r)]++
+	}
+
+	free := 0
+	for p := 1; p < w.cfg.NumPhysRegs; p++ {
+		if got := w.freeList.GetRefCount(uint8(p)); got != holders[p] {
+			t.Errorf("p%d has %d holders, committed RAT maps %d registers to it", p, got, holders[p])
+		}
+		if holders[p] == 0 {
+			free++
+		}
+	}
+	if got := w.freeList.GetFreeCount(); got != free {
+		t.Errorf("Free list counts %d free registers, expected %d", got, free)
+	}
+}
+
+func TestClassifyElimination(t *testing.T) {
+	// WHAT: Each move and zero-idiom form is recognized; near misses are not
+	// WHY: Eliminating a real computation would commit the wrong value
+	// HARDWARE: Opcode/operand compare in the rename stage
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	cases := []struct {
+		name string
+		word uint32
+		kind ElimKind
+		src  uint8
+	}{
+		{"ADDI rd,rs,0", EncodeIFormat(OpADDI, 5, 3, 0), ElimMove, 3},
+		{"ORI rd,rs,0", EncodeIFormat(OpORI, 5, 3, 0), ElimMove, 3},
+		{"XORI rd,rs,0", EncodeIFormat(OpXORI, 5, 3, 0), ElimMove, 3},
+		{"OR rd,rs,r0", EncodeRFormat(OpOR, 5, 3, 0), ElimMove, 3},
+		{"OR rd,r0,rs", EncodeRFormat(OpOR, 5, 0, 3), ElimMove, 3},
+		{"ADD rd,rs,r0", EncodeRFormat(OpADD, 5, 3, 0), ElimMove, 3},
+		{"XOR rd,r0,rs", EncodeRFormat(OpXOR, 5, 0, 3), ElimMove, 3},
+		{"SUB rd,rs,r0", EncodeRFormat(OpSUB, 5, 3, 0), ElimMove, 3},
+		{"XOR r,r,r", EncodeRFormat(OpXOR, 5, 5, 5), ElimZero, 0},
+		{"XOR rd,rs,rs", EncodeRFormat(OpXOR, 5, 3, 3), ElimZero, 0},
+		{"SUB r,r,r", EncodeRFormat(OpSUB, 5, 5, 5), ElimZero, 0},
+		{"ADDI rd,r0,0", EncodeIFormat(OpADDI, 5, 0, 0), ElimZero, 0},
+		{"OR rd,r0,r0", EncodeRFormat(OpOR, 5, 0, 0), ElimZero, 0},
+
+		{"ADDI rd,rs,1", EncodeIFormat(OpADDI, 5, 3, 1), ElimNone, 0},
+		{"ADDI r0,rs,0", EncodeIFormat(OpADDI, 0, 3, 0), ElimNone, 0},
+		{"SUB rd,r0,rs", EncodeRFormat(OpSUB, 5, 0, 3), ElimNone, 0},
+		{"ADD rd,rs,rs", EncodeRFormat(OpADD, 5, 3, 3), ElimNone, 0},
+		{"OR rd,rs,rs", EncodeRFormat(OpOR, 5, 3, 3), ElimNone, 0},
+		{"AND rd,rs,r0", EncodeRFormat(OpAND, 5, 3, 0), ElimNone, 0},
+		{"ANDI rd,rs,0", EncodeIFormat(OpANDI, 5, 3, 0), ElimNone, 0},
+		{"LW rd,0(rs)", EncodeIFormat(OpLW, 5, 3, 0), ElimNone, 0},
+	}
+
+	for _, c := range cases {
+		kind, src := ClassifyElimination(DecodeInstruction(c.word, 0x1000))
+		if kind != c.kind || src != c.src {
+			t.Errorf("%s: got %v/r%d, expected %v/r%d", c.name, kind, src, c.kind, c.src)
+		}
+	}
+}
+
+func TestFreeList_ShareRefCount(t *testing.T) {
+	// WHAT: Share then two Frees returns the register to the pool exactly once
+	// WHY: A shared register freed early would be reallocated under a live mapping
+	// HARDWARE: Per-register holder counter beside the free bitmap
+	// CATEGORY: [UNIT] [LIFECYCLE]
+
+	fl := NewFreeList()
+	initial := fl.GetFreeCount()
+
+	reg := fl.Allocate()
+	if reg == InvalidTag || fl.GetRefCount(reg) != 1 {
+		t.Fatalf("Allocate = p%d with %d holders, expected one holder", reg, fl.GetRefCount(reg))
+	}
+	fl.Share(reg)
+	if fl.GetRefCount(reg) != 2 {
+		t.Errorf("Shared register has %d holders, expected 2", fl.GetRefCount(reg))
+	}
+
+	fl.Free(reg)
+	if fl.GetRefCount(reg) != 1 || fl.GetFreeCount() != initial-1 {
+		t.Errorf("After one Free: %d holders, %d free; expected 1 holder, %d free",
+			fl.GetRefCount(reg), fl.GetFreeCount(), initial-1)
+	}
+
+	fl.Free(reg)
+	if fl.GetRefCount(reg) != 0 || fl.GetFreeCount() != initial {
+		t.Errorf("After last Free: %d holders, %d free; expected 0 holders, %d free",
+			fl.GetRefCount(reg), fl.GetFreeCount(), initial)
+	}
+
+	fl.Free(reg)
+	if fl.GetFreeCount() != initial {
+		t.Errorf("Freeing a free register changed the count to %d", fl.GetFreeCount())
+	}
+
+	fl.Share(ZeroPhysReg)
+	fl.Free(ZeroPhysReg)
+	if fl.GetRefCount(ZeroPhysReg) != 1 || fl.GetFreeCount() != initial {
+		t.Error("ZeroPhysReg was reference counted or freed")
+	}
+}
+
+func TestMoveElimination_RenameAndCounters(t *testing.T) {
+	// WHAT: Rename maps moves onto the source's register, zero idioms onto
+	//       ZeroPhysReg, and GetEliminated counts each kind
+	// WHY: The counters are the experiment's output; the mappings are its effect
+	// HARDWARE: RAT write of the source tag instead of a free-list pop
+	// CATEGORY: [UNIT]
+
+	w := elimWindow()
+	ids := dispatchWords(t, w,
+		EncodeIFormat(OpADDI, 3, 0, 5), // r3 = 5 (executes)
+		EncodeIFormat(OpADDI, 5, 3, 0), // r5 = r3 (move)
+		EncodeRFormat(OpOR, 7, 0, 3),   // r7 = r3 (move)
+		EncodeRFormat(OpXOR, 6, 6, 6),  // r6 = 0 (zero idiom)
+		EncodeRFormat(OpADD, 8, 3, 5),  // r8 = r3 + r5 (executes)
+	)
+
+	if moves, zeros := w.GetEliminated(); moves != 2 || zeros != 1 {
+		t.Errorf("GetEliminated = %d moves, %d zeros, expected 2 and 1", moves, zeros)
+	}
+
+	p3 := w.rat.Lookup(3)
+	if w.rat.Lookup(5) != p3 || w.rat.Lookup(7) != p3 {
+		t.Errorf("Moves mapped to p%d and p%d, expected r3's p%d", w.rat.Lookup(5), w.rat.Lookup(7), p3)
+	}
+	if w.freeList.GetRefCount(p3) != 3 {
+		t.Errorf("p%d has %d holders, expected 3 (r3, r5, r7)", p3, w.freeList.GetRefCount(p3))
+	}
+	if w.rat.Lookup(6) != ZeroPhysReg {
+		t.Errorf("Zero idiom mapped r6 to p%d, expected ZeroPhysReg", w.rat.Lookup(6))
+	}
+
+	for i, id := range ids {
+		e := w.GetEntry(id)
+		if eliminated := i >= 1 && i <= 3; (e.Eliminated != ElimNone) != eliminated || e.Executed != eliminated {
+			t.Errorf("Entry %d: Eliminated=%v Executed=%v", i, e.Eliminated, e.Executed)
+		}
+	}
+
+	// Off by default: the same sequence eliminates nothing
+	off := NewWindow()
+	dispatchWords(t, off, EncodeIFormat(OpADDI, 5, 3, 0), EncodeRFormat(OpXOR, 6, 6, 6))
+	if moves, zeros := off.GetEliminated(); moves != 0 || zeros != 0 {
+		t.Errorf("Default window eliminated %d moves, %d zeros", moves, zeros)
+	}
+}
+
+func TestMoveElimination_SquashFreesOnce(t *testing.T) {
+	// WHAT: Squashing and flushing shared registers drops one holder each
+	// WHY: A double free hands a live register to the next allocation
+	// HARDWARE: Squash walks decrement holder counts, never clear them
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	w := elimWindow()
+	ids := dispatchWords(t, w,
+		EncodeIFormat(OpADDI, 3, 0, 5), // r3 = 5
+		EncodeBFormat(OpBEQ, 0, 0, 8),  // branch (checkpoint)
+		EncodeIFormat(OpADDI, 5, 3, 0), // r5 = r3 (move)
+		EncodeIFormat(OpADDI, 7, 3, 0), // r7 = r3 (move)
+		EncodeRFormat(OpSUB, 6, 6, 6),  // r6 = 0 (zero idiom)
+	)
+	p3 := w.rat.Lookup(3)
+	if w.freeList.GetRefCount(p3) != 3 {
+		t.Fatalf("p%d has %d holders before the squash, expected 3", p3, w.freeList.GetRefCount(p3))
+	}
+
+	if n := w.SquashAfter(ids[1]); n != 3 {
+		t.Errorf("SquashAfter discarded %d entries, expected 3", n)
+	}
+	if w.freeList.GetRefCount(p3) != 1 {
+		t.Errorf("p%d has %d holders after the squash, expected 1 (r3)", p3, w.freeList.GetRefCount(p3))
+	}
+	if w.rat.Lookup(5) != 5 || w.rat.Lookup(6) != 6 {
+		t.Errorf("Squash left r5->p%d, r6->p%d, expected the committed p5, p6", w.rat.Lookup(5), w.rat.Lookup(6))
+	}
+
+	w.Flush()
+	checkHolders(t, w)
+
+	// Whole programs, both recovery points: flush whatever is in flight
+	// at the end and check the counts still match the committed RAT
+	for early := 0; early <= 1; early++ {
+		cfg := DefaultCoreConfig()
+		cfg.MoveElimination = 1
+		cfg.EarlyRecovery = early
+		for _, bench := range []SweepBenchmark{
+			{"Moves", CreateMoveBenchmark(), 3000},
+			{"Branch Prediction", CreateBranchPredictionTest(), 3000},
+		} {
+			core, err := NewCoreWithConfig(cfg, 1024*1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			core.LoadProgram(bench.Program, 0x1000)
+			core.Run(bench.Cycles)
+			if moves, zeros := core.window.GetEliminated(); moves+zeros == 0 {
+				t.Errorf("%s: nothing eliminated", bench.Name)
+			}
+			core.window.Flush()
+			checkHolders(t, core.window)
+		}
+	}
+}
+
+func TestMoveEliminationComparison_RowsMatchDirectRuns(t *testing.T) {
+	// WHAT: The off and on rows match runs with move_elimination 0 and 1
+	// WHY: Only the rename-stage switch may change between rows
+	// HARDWARE: N/A (experiment harness)
+	// CATEGORY: [INTEGRATION]
+
+	const cycles = 3000
+	program := CreateMoveBenchmark()
+	want := []string{"MOVE ELIMINATION: Moves"}
+	for _, on := range []int{0, 1} {
+		cfg := DefaultCoreConfig()
+		cfg.MoveElimination = on
+		core, err := NewCoreWithConfig(cfg, 1024*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LoadProgram(program, 0x1000)
+		core.Run(cycles)
+
+		moves, zeros := core.window.GetEliminated()
+		_, freeListStalls := core.window.GetDispatchStalls()
+		want = append(want, fmt.Sprintf("  %-5s %7.3f %8d %7d %7d %9d ",
+			map[int]string{0: "off", 1: "on"}[on], core.GetIPC(), core.instructions,
+			moves, zeros, freeListStalls))
+	}
+
+	checkReport(t, RunMoveEliminationComparison("Moves", program, cycles), want...)
+}
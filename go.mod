@@ -1,3 +1,3 @@
-module main
+module suprax32
 
 go 1.25.4
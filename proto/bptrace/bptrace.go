@@ -0,0 +1,749 @@
+package bptrace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"main/tage"
+	"suprax32"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Branch Trace Harness
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// Synthetic loops tell us whether a predictor learns a pattern. They do not tell us how it
+// behaves on real programs. This package replays recorded branch streams through any
+// direction predictor and reports:
+//
+//   - MPKI (mispredictions per 1000 instructions)
+//   - Accuracy by branch type (conditional, jump, call, return, indirect)
+//   - Target accuracy for returns and indirect jumps (predictors that also predict targets)
+//   - Worst-offending PCs (most mispredictions)
+//   - Warmup curve (accuracy per interval of the trace)
+//
+// Traces come from the SupraX-32 core (see BranchTraceWriter in SupraX.go) or from any
+// external tool that emits the formats below.
+//
+// NOT HARDWARE:
+// ─────────────
+// Unlike the predictor models, nothing here is synthesized. It is evaluation tooling.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TRACE FORMATS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Each record describes one retired branch:
+//
+//   PC:           Branch address
+//   Target:       Resolved target (taken target for conditionals, even if not taken)
+//   Type:         cond | jump | call | ret | ind
+//   Taken:        Resolved direction (unconditional branches are always taken)
+//   Instructions: Instructions retired since the previous record, including this branch
+//                 (0 = unknown, counted as 1)
+//
+// TEXT FORMAT (one record per line):
+//
+//   # comment
+//   <pc> <target> <type> <T|N> [instructions]
+//
+//   PC and target are hex (0x prefix optional). Blank lines and lines starting with '#'
+//   are ignored. Example:
+//
+//     0x1040 0x1000 cond T 6
+//     0x1040 0x1000 cond N 6
+//     0x1044 0x2000 call T 1
+//
+// BINARY FORMAT (little-endian):
+//
+//   Header:  "BPTR" (4 bytes) + version (1 byte, = 1) + 3 reserved bytes (0)
+//   Record:  pc (8) + target (8) + instructions (4) + flags (1)   = 21 bytes
+//            flags[2:0] = type, flags[7] = taken
+//
+//   NewReader detects the format from the first 4 bytes.
+//
+// The core model cannot import this package (separate module), so it keeps its own writer.
+// This package does import the core (go.mod replaces suprax32 with the repository root):
+// the Core adapter replays traces through the core's own BranchPredictor.
+// testdata/suprax.{txt,bptr} were produced by that writer; the SupraX suite checks it still
+// emits them byte for byte and TestReader_SupraXGolden checks this Reader decodes them.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// BranchType classifies a branch record.
+type BranchType uint8
+
+const (
+	TypeConditional BranchType = iota // Conditional branch (BEQ, BNE, ...)
+	TypeJump                          // Direct unconditional jump
+	TypeCall                          // Call (direct or indirect, links a return address)
+	TypeReturn                        // Return
+	TypeIndirect                      // Indirect jump (not call/return)
+
+	// NumBranchTypes: Number of branch types (size of per-type arrays).
+	NumBranchTypes = 5
+)
+
+var branchTypeNames = [NumBranchTypes]string{"cond", "jump", "call", "ret", "ind"}
+
+func (t BranchType) String() string {
+	if int(t) < NumBranchTypes {
+		return branchTypeNames[t]
+	}
+	return fmt.Sprintf("type(%d)", uint8(t))
+}
+
+// ParseBranchType converts a text-format type name to a BranchType.
+func ParseBranchType(s string) (BranchType, error) {
+	for i, name := range branchTypeNames {
+		if s == name {
+			return BranchType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("bptrace: unknown branch type %q", s)
+}
+
+// Record is one retired branch.
+type Record struct {
+	PC           uint64     // Branch address
+	Target       uint64     // Resolved target
+	Type         BranchType // Branch class
+	Taken        bool       // Resolved direction
+	Instructions uint32     // Instructions since previous record (0 = unknown)
+}
+
+const (
+	binaryMagic      = "BPTR"
+	binaryVersion    = 1
+	binaryHeaderSize = 8
+	binaryRecordSize = 21
+	flagTaken        = 0x80
+	flagTypeMask     = 0x07
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// READING
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// Source yields records until io.EOF.
+type Source interface {
+	Next() (Record, error)
+}
+
+// Reader decodes a text or binary trace.
+type Reader struct {
+	r      *bufio.Reader
+	binary bool
+	line   int // Text line number (for errors)
+}
+
+// NewReader detects the trace format and returns a reader positioned at the first record.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	rd := &Reader{r: br}
+
+	magic, err := br.Peek(len(binaryMagic))
+	if err == nil && string(magic) == binaryMagic {
+		var hdr [binaryHeaderSize]byte
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			return nil, fmt.Errorf("bptrace: short binary header: %w", err)
+		}
+		if hdr[4] != binaryVersion {
+			return nil, fmt.Errorf("bptrace: unsupported binary version %d", hdr[4])
+		}
+		rd.binary = true
+	}
+	return rd, nil
+}
+
+// Binary reports whether the trace is in binary format.
+func (r *Reader) Binary() bool {
+	return r.binary
+}
+
+// Next returns the next record, or io.EOF at end of trace.
+func (r *Reader) Next() (Record, error) {
+	if r.binary {
+		return r.nextBinary()
+	}
+	return r.nextText()
+}
+
+func (r *Reader) nextBinary() (Record, error) {
+	var buf [binaryRecordSize]byte
+	n, err := io.ReadFull(r.r, buf[:])
+	if err == io.EOF {
+		return Record{}, io.EOF
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("bptrace: truncated binary record (%d of %d bytes)", n, binaryRecordSize)
+	}
+
+	flags := buf[20]
+	typ := BranchType(flags & flagTypeMask)
+	if int(typ) >= NumBranchTypes {
+		return Record{}, fmt.Errorf("bptrace: bad branch type %d in binary record", typ)
+	}
+	return Record{
+		PC:           binary.LittleEndian.Uint64(buf[0:8]),
+		Target:       binary.LittleEndian.Uint64(buf[8:16]),
+		Instructions: binary.LittleEndian.Uint32(buf[16:20]),
+		Type:         typ,
+		Taken:        flags&flagTaken != 0,
+	}, nil
+}
+
+func (r *Reader) nextText() (Record, error) {
+	for {
+		line, err := r.r.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				return Record{}, io.EOF
+			}
+			return Record{}, err
+		}
+		r.line++
+
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		rec, perr := parseTextRecord(line)
+		if perr != nil {
+			return Record{}, fmt.Errorf("bptrace: line %d: %w", r.line, perr)
+		}
+		return rec, nil
+	}
+}
+
+func parseTextRecord(line string) (Record, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 && len(fields) != 5 {
+		return Record{}, fmt.Errorf("expected 4 or 5 fields, got %d", len(fields))
+	}
+
+	var rec Record
+	var err error
+	if rec.PC, err = parseHex(fields[0]); err != nil {
+		return Record{}, fmt.Errorf("pc: %w", err)
+	}
+	if rec.Target, err = parseHex(fields[1]); err != nil {
+		return Record{}, fmt.Errorf("target: %w", err)
+	}
+	if rec.Type, err = ParseBranchType(fields[2]); err != nil {
+		return Record{}, err
+	}
+	switch fields[3] {
+	case "T", "t", "1":
+		rec.Taken = true
+	case "N", "n", "0":
+		rec.Taken = false
+	default:
+		return Record{}, fmt.Errorf("direction %q, expected T or N", fields[3])
+	}
+	if len(fields) == 5 {
+		n, err := strconv.ParseUint(fields[4], 10, 32)
+		if err != nil {
+			return Record{}, fmt.Errorf("instructions: %w", err)
+		}
+		rec.Instructions = uint32(n)
+	}
+	return rec, nil
+}
+
+func parseHex(s string) (uint64, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// ReadAll decodes every record in a trace.
+func ReadAll(r io.Reader) ([]Record, error) {
+	rd, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var recs []Record
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// sliceSource replays records held in memory.
+type sliceSource struct {
+	recs []Record
+	pos  int
+}
+
+// NewSliceSource returns a Source over in-memory records.
+func NewSliceSource(recs []Record) Source {
+	return &sliceSource{recs: recs}
+}
+
+func (s *sliceSource) Next() (Record, error) {
+	if s.pos >= len(s.recs) {
+		return Record{}, io.EOF
+	}
+	s.pos++
+	return s.recs[s.pos-1], nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// WRITING
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// Writer encodes records in text or binary format. Call Flush when done.
+type Writer struct {
+	w      *bufio.Writer
+	binary bool
+	header bool // Binary header written
+}
+
+// NewTextWriter returns a Writer producing the text format.
+func NewTextWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// NewBinaryWriter returns a Writer producing the binary format.
+func NewBinaryWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w), binary: true}
+}
+
+// Write appends one record.
+func (w *Writer) Write(rec Record) error {
+	if int(rec.Type) >= NumBranchTypes {
+		return fmt.Errorf("bptrace: cannot write branch type %d", rec.Type)
+	}
+	if !w.binary {
+		dir := "N"
+		if rec.Taken {
+			dir = "T"
+		}
+		_, err := fmt.Fprintf(w.w, "%#x %#x %s %s %d\n", rec.PC, rec.Target, rec.Type, dir, rec.Instructions)
+		return err
+	}
+
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var buf [binaryRecordSize]byte
+	binary.LittleEndian.PutUint64(buf[0:8], rec.PC)
+	binary.LittleEndian.PutUint64(buf[8:16], rec.Target)
+	binary.LittleEndian.PutUint32(buf[16:20], rec.Instructions)
+	buf[20] = uint8(rec.Type)
+	if rec.Taken {
+		buf[20] |= flagTaken
+	}
+	_, err := w.w.Write(buf[:])
+	return err
+}
+
+func (w *Writer) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	hdr := [binaryHeaderSize]byte{'B', 'P', 'T', 'R', binaryVersion}
+	_, err := w.w.Write(hdr[:])
+	return err
+}
+
+// Flush writes buffered data (and the binary header, even for an empty trace).
+func (w *Writer) Flush() error {
+	if w.binary {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// PREDICTORS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Predictor is the direction-prediction contract the harness drives. For every record,
+// Evaluate calls Predict(pc) and then Update(pc, taken) with the resolved direction,
+// strictly in trace order (no speculation). Every branch type is presented, so a
+// predictor must learn that unconditional branches are taken.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+type Predictor interface {
+	Predict(pc uint64) bool
+	Update(pc uint64, taken bool)
+}
+
+// TAGE adapts tage.TAGEPredictor (one hardware context) to Predictor.
+type TAGE struct {
+	P    *tage.TAGEPredictor
+	Ctx  uint8
+	meta tage.PredictionMetadata
+}
+
+// NewTAGE wraps p, driving context 0.
+func NewTAGE(p *tage.TAGEPredictor) *TAGE {
+	return &TAGE{P: p}
+}
+
+func (a *TAGE) Predict(pc uint64) bool {
+	a.meta = a.P.Lookup(pc, a.Ctx)
+	return a.meta.Predicted
+}
+
+func (a *TAGE) Update(pc uint64, taken bool) {
+	if a.meta.PC != pc {
+		a.meta = a.P.Lookup(pc, a.Ctx) // Update without Predict
+	}
+	a.P.Resolve(a.meta, taken)
+}
+
+// TAGESCL adapts tage.TAGESCLPredictor (one hardware context) to Predictor.
+type TAGESCL struct {
+	P    *tage.TAGESCLPredictor
+	Ctx  uint8
+	meta tage.SCLPredictionMetadata
+}
+
+// NewTAGESCL wraps p, driving context 0.
+func NewTAGESCL(p *tage.TAGESCLPredictor) *TAGESCL {
+	return &TAGESCL{P: p}
+}
+
+func (a *TAGESCL) Predict(pc uint64) bool {
+	a.meta = a.P.Lookup(pc, a.Ctx)
+	return a.meta.Taken
+}
+
+func (a *TAGESCL) Update(pc uint64, taken bool) {
+	if a.meta.TAGE.PC != pc {
+		a.meta = a.P.Lookup(pc, a.Ctx)
+	}
+	a.P.Resolve(a.meta, taken)
+}
+
+// TargetPredictor is a Predictor that also predicts where a branch goes. Evaluate calls
+// PredictTarget for returns and indirect jumps (the branches whose target is not in the
+// instruction) after Predict, and UpdateTarget for every record after Update, so calls
+// can push return addresses.
+type TargetPredictor interface {
+	Predictor
+	PredictTarget(pc uint64, t BranchType) uint64
+	UpdateTarget(pc, target uint64, t BranchType)
+}
+
+// Core adapts the SupraX-32 core's BranchPredictor to TargetPredictor. Direction comes
+// from its 4-bit counters, return targets from its RSB and other indirect targets from the
+// L1I indirect table the core plugs into it. Training follows the core's commit stage:
+// every branch updates the counters, calls push their return address, returns and indirect
+// jumps train the indirect table.
+type Core struct {
+	BP *suprax32.BranchPredictor
+	L1 *suprax32.L1ICache // Indirect-jump table (only that part of the L1I is used)
+}
+
+// NewCore returns the core's predictor in reset state, with indirect prediction on.
+func NewCore() *Core {
+	c := &Core{BP: suprax32.NewBranchPredictor(), L1: suprax32.NewL1ICache()}
+	c.BP.SetIndirectPredictor(c.L1)
+	return c
+}
+
+func (c *Core) Predict(pc uint64) bool {
+	taken, _ := c.BP.Predict(uint32(pc))
+	return taken
+}
+
+func (c *Core) Update(pc uint64, taken bool) {
+	c.BP.Update(uint32(pc), taken)
+}
+
+// PredictTarget asks BranchPredictor.PredictTarget about the JALR a record of type t
+// stands for: JALR x0, ra, 0 for a return, a JALR through another register otherwise.
+func (c *Core) PredictTarget(pc uint64, t BranchType) uint64 {
+	inst := suprax32.Instruction{Opcode: suprax32.OpJALR, Rs1: 5, PC: uint32(pc)}
+	if t == TypeReturn {
+		inst.Rs1 = 1
+	}
+	return uint64(c.BP.PredictTarget(uint32(pc), inst))
+}
+
+func (c *Core) UpdateTarget(pc, target uint64, t BranchType) {
+	switch t {
+	case TypeCall:
+		c.BP.PushRSB(uint32(pc) + 4)
+	case TypeReturn:
+		c.L1.NotifyReturn(uint32(pc), uint32(target))
+	case TypeIndirect:
+		c.L1.NotifyIndirect(uint32(pc), uint32(target))
+	}
+}
+
+// Bimodal models the SupraX-32 BranchPredictor direction logic: 1024 4-bit counters
+// indexed by PC[11:2], initialized to 8 (weakly taken), taken when counter >= 8.
+// It is the baseline TAGE is measured against; Core runs the real thing, and the tests
+// check the two agree on direction.
+type Bimodal struct {
+	counters [1024]uint8
+}
+
+// NewBimodal returns a Bimodal predictor in reset state.
+func NewBimodal() *Bimodal {
+	b := &Bimodal{}
+	for i := range b.counters {
+		b.counters[i] = 8
+	}
+	return b
+}
+
+func (b *Bimodal) Predict(pc uint64) bool {
+	return b.counters[(pc>>2)&1023] >= 8
+}
+
+func (b *Bimodal) Update(pc uint64, taken bool) {
+	c := &b.counters[(pc>>2)&1023]
+	if taken && *c < 15 {
+		*c++
+	} else if !taken && *c > 0 {
+		*c--
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// EVALUATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// Options controls Evaluate.
+type Options struct {
+	Warmup        uint64 // Leading records that train but are excluded from totals
+	CurveInterval uint64 // Records per warmup-curve point (0 = no curve)
+	TopN          int    // Worst PCs to report (0 = 10)
+}
+
+// Counts accumulates mispredictions over some set of records.
+type Counts struct {
+	Branches     uint64
+	Mispredicts  uint64
+	Instructions uint64
+}
+
+// Accuracy returns the fraction of branches predicted correctly (0 if none).
+func (c Counts) Accuracy() float64 {
+	if c.Branches == 0 {
+		return 0
+	}
+	return float64(c.Branches-c.Mispredicts) / float64(c.Branches)
+}
+
+// MPKI returns mispredictions per 1000 instructions (0 if no instructions).
+func (c Counts) MPKI() float64 {
+	if c.Instructions == 0 {
+		return 0
+	}
+	return float64(c.Mispredicts) * 1000 / float64(c.Instructions)
+}
+
+func (c *Counts) add(insts uint64, mispredicted bool) {
+	c.Branches++
+	c.Instructions += insts
+	if mispredicted {
+		c.Mispredicts++
+	}
+}
+
+// PCCounts is one branch PC's totals.
+type PCCounts struct {
+	PC   uint64
+	Type BranchType
+	Counts
+}
+
+// CurvePoint is one interval of the warmup curve.
+type CurvePoint struct {
+	EndRecord uint64 // Records consumed (including warmup) at the end of this interval
+	Counts           // Totals for this interval only
+}
+
+// Report is the result of replaying a trace through a predictor.
+type Report struct {
+	Total    Counts                 // All records after warmup
+	ByType   [NumBranchTypes]Counts // Split by branch type
+	Targets  [NumBranchTypes]Counts // Target misses for returns and indirect jumps (TargetPredictor only)
+	Worst    []PCCounts             // PCs with the most mispredictions (descending)
+	Curve    []CurvePoint           // Per-interval accuracy from the first record
+	Warmup   uint64                 // Records excluded from totals
+	Distinct int                    // Distinct branch PCs seen after warmup
+}
+
+// Evaluate replays src through p and returns the report.
+func Evaluate(src Source, p Predictor, opts Options) (Report, error) {
+	topN := opts.TopN
+	if topN == 0 {
+		topN = 10
+	}
+
+	var rep Report
+	tp, _ := p.(TargetPredictor)
+	perPC := make(map[uint64]*PCCounts)
+	var interval Counts
+	var n uint64
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rep, err
+		}
+
+		insts := uint64(rec.Instructions)
+		if insts == 0 {
+			insts = 1
+		}
+
+		predicted := p.Predict(rec.PC)
+		scoreTarget := tp != nil && (rec.Type == TypeReturn || rec.Type == TypeIndirect)
+		var target uint64
+		if scoreTarget {
+			target = tp.PredictTarget(rec.PC, rec.Type)
+		}
+		p.Update(rec.PC, rec.Taken)
+		if tp != nil {
+			tp.UpdateTarget(rec.PC, rec.Target, rec.Type)
+		}
+		miss := predicted != rec.Taken
+		n++
+
+		if opts.CurveInterval > 0 {
+			interval.add(insts, miss)
+			if n%opts.CurveInterval == 0 {
+				rep.Curve = append(rep.Curve, CurvePoint{EndRecord: n, Counts: interval})
+				interval = Counts{}
+			}
+		}
+
+		if n <= opts.Warmup {
+			rep.Warmup++
+			continue
+		}
+
+		rep.Total.add(insts, miss)
+		if int(rec.Type) < NumBranchTypes {
+			rep.ByType[rec.Type].add(insts, miss)
+		}
+		if scoreTarget {
+			rep.Targets[rec.Type].add(insts, target != rec.Target)
+		}
+		pc := perPC[rec.PC]
+		if pc == nil {
+			pc = &PCCounts{PC: rec.PC, Type: rec.Type}
+			perPC[rec.PC] = pc
+		}
+		pc.add(insts, miss)
+	}
+
+	if opts.CurveInterval > 0 && interval.Branches > 0 {
+		rep.Curve = append(rep.Curve, CurvePoint{EndRecord: n, Counts: interval})
+	}
+
+	rep.Distinct = len(perPC)
+	rep.Worst = make([]PCCounts, 0, len(perPC))
+	for _, pc := range perPC {
+		if pc.Mispredicts > 0 {
+			rep.Worst = append(rep.Worst, *pc)
+		}
+	}
+	sort.Slice(rep.Worst, func(i, j int) bool {
+		a, b := rep.Worst[i], rep.Worst[j]
+		if a.Mispredicts != b.Mispredicts {
+			return a.Mispredicts > b.Mispredicts
+		}
+		return a.PC < b.PC
+	})
+	if len(rep.Worst) > topN {
+		rep.Worst = rep.Worst[:topN]
+	}
+
+	return rep, nil
+}
+
+// EvaluateReader is Evaluate over a trace stream in either format.
+func EvaluateReader(r io.Reader, p Predictor, opts Options) (Report, error) {
+	rd, err := NewReader(r)
+	if err != nil {
+		return Report{}, err
+	}
+	return Evaluate(rd, p, opts)
+}
+
+// ErrNoBranches is returned by Report.Format when there is nothing to report.
+var ErrNoBranches = errors.New("bptrace: no branches after warmup")
+
+// Format writes a human-readable report.
+func (r *Report) Format(w io.Writer) error {
+	if r.Total.Branches == 0 {
+		return ErrNoBranches
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Branches:      %d (%d distinct PCs, %d warmup records skipped)\n",
+		r.Total.Branches, r.Distinct, r.Warmup)
+	fmt.Fprintf(bw, "Instructions:  %d\n", r.Total.Instructions)
+	fmt.Fprintf(bw, "Mispredicts:   %d\n", r.Total.Mispredicts)
+	fmt.Fprintf(bw, "Accuracy:      %.2f%%\n", r.Total.Accuracy()*100)
+	fmt.Fprintf(bw, "MPKI:          %.3f\n", r.Total.MPKI())
+
+	fmt.Fprintf(bw, "\nBy type:\n")
+	for t := 0; t < NumBranchTypes; t++ {
+		c := r.ByType[t]
+		if c.Branches == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "  %-5s %10d branches  %8d mispredicts  %6.2f%%\n",
+			BranchType(t), c.Branches, c.Mispredicts, c.Accuracy()*100)
+	}
+
+	if r.Targets[TypeReturn].Branches+r.Targets[TypeIndirect].Branches > 0 {
+		fmt.Fprintf(bw, "\nTargets:\n")
+		for _, t := range []BranchType{TypeReturn, TypeIndirect} {
+			c := r.Targets[t]
+			if c.Branches == 0 {
+				continue
+			}
+			fmt.Fprintf(bw, "  %-5s %10d branches  %8d mispredicts  %6.2f%%\n",
+				t, c.Branches, c.Mispredicts, c.Accuracy()*100)
+		}
+	}
+
+	if len(r.Worst) > 0 {
+		fmt.Fprintf(bw, "\nWorst PCs:\n")
+		for _, pc := range r.Worst {
+			fmt.Fprintf(bw, "  %#10x %-5s %8d / %-8d %6.2f%%\n",
+				pc.PC, pc.Type, pc.Mispredicts, pc.Branches, pc.Accuracy()*100)
+		}
+	}
+
+	if len(r.Curve) > 0 {
+		fmt.Fprintf(bw, "\nWarmup curve:\n")
+		for _, pt := range r.Curve {
+			fmt.Fprintf(bw, "  %10d  %6.2f%%  %8.3f MPKI\n", pt.EndRecord, pt.Accuracy()*100, pt.MPKI())
+		}
+	}
+
+	return bw.Flush()
+}
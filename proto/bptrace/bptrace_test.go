@@ -0,0 +1,521 @@
+package bptrace
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"main/tage"
+	"suprax32"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Branch Trace Harness - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST ORGANIZATION:
+//   1. Trace format tests (text, binary, detection, errors)
+//   2. Evaluation tests (totals, MPKI, warmup, worst PCs, curve)
+//   3. Predictor adapter tests (Bimodal, TAGE, TAGE-SC-L on a synthetic trace; the core's
+//      BranchPredictor on direction and on return and indirect targets)
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// alwaysTaken predicts taken for every branch.
+type alwaysTaken struct{}
+
+func (alwaysTaken) Predict(pc uint64) bool       { return true }
+func (alwaysTaken) Update(pc uint64, taken bool) {}
+
+func sampleRecords() []Record {
+	return []Record{
+		{PC: 0x1040, Target: 0x1000, Type: TypeConditional, Taken: true, Instructions: 6},
+		{PC: 0x1040, Target: 0x1000, Type: TypeConditional, Taken: false, Instructions: 6},
+		{PC: 0x1044, Target: 0x2000, Type: TypeCall, Taken: true, Instructions: 1},
+		{PC: 0x2010, Target: 0x1048, Type: TypeReturn, Taken: true, Instructions: 4},
+		{PC: 0x1050, Target: 0x3000, Type: TypeJump, Taken: true, Instructions: 2},
+		{PC: 0x3008, Target: 0xDEAD_BEEF_0000, Type: TypeIndirect, Taken: true},
+	}
+}
+
+// loopTrace builds trips of a loop with the given body length: body-1 taken, then one not-taken.
+func loopTrace(pc uint64, body int, trips int) []Record {
+	var recs []Record
+	for trip := 0; trip < trips; trip++ {
+		for i := 0; i < body; i++ {
+			recs = append(recs, Record{PC: pc, Target: pc - 0x40, Type: TypeConditional, Taken: i != body-1, Instructions: 5})
+		}
+	}
+	return recs
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. TRACE FORMAT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestText_RoundTrip(t *testing.T) {
+	// WHAT: Records written as text read back identically
+	var buf bytes.Buffer
+	w := NewTextWriter(&buf)
+	for _, rec := range sampleRecords() {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sampleRecords()
+	if len(got) != len(want) {
+		t.Fatalf("Read %d records, expected %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Record %d: %+v, expected %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBinary_RoundTrip(t *testing.T) {
+	// WHAT: Records written as binary read back identically, 21 bytes each
+	var buf bytes.Buffer
+	w := NewBinaryWriter(&buf)
+	for _, rec := range sampleRecords() {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := binaryHeaderSize + binaryRecordSize*len(sampleRecords()); buf.Len() != want {
+		t.Errorf("Binary trace is %d bytes, expected %d", buf.Len(), want)
+	}
+
+	rd, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rd.Binary() {
+		t.Fatal("Binary trace not detected")
+	}
+	for i, want := range sampleRecords() {
+		got, err := rd.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Record %d: %+v, expected %+v", i, got, want)
+		}
+	}
+	if _, err := rd.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestBinary_EmptyTraceHasHeader(t *testing.T) {
+	// WHAT: An empty binary trace still carries its header (and reads as binary)
+	var buf bytes.Buffer
+	w := NewBinaryWriter(&buf)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := NewReader(&buf)
+	if err != nil || !rd.Binary() {
+		t.Fatalf("Empty binary trace: binary=%v err=%v", rd != nil && rd.Binary(), err)
+	}
+	if _, err := rd.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+// goldenSupraXRecords are the branches in testdata/suprax.{txt,bptr}. Both files were
+// written by the core model's BranchTraceWriter (SupraX.go), whose test checks that it
+// still produces them byte for byte; the two modules cannot import each other.
+func goldenSupraXRecords() []Record {
+	return []Record{
+		{PC: 0x1040, Target: 0x1000, Type: TypeConditional, Taken: true, Instructions: 6},
+		{PC: 0x1040, Target: 0x1000, Type: TypeConditional, Taken: false, Instructions: 6},
+		{PC: 0x1044, Target: 0x2000, Type: TypeCall, Taken: true, Instructions: 1},
+		{PC: 0x2010, Target: 0x1048, Type: TypeReturn, Taken: true, Instructions: 4},
+		{PC: 0x1050, Target: 0x3000, Type: TypeJump, Taken: true, Instructions: 2},
+		{PC: 0x3008, Target: 0xFFFFFFF0, Type: TypeIndirect, Taken: true, Instructions: 0x12345678},
+	}
+}
+
+func TestReader_SupraXGolden(t *testing.T) {
+	// WHAT: Text and binary traces written by the SupraX core read back as the expected records
+	// WHY: The core duplicates the writer; this pins the format both sides agree on
+	for _, file := range []string{"testdata/suprax.txt", "testdata/suprax.bptr"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadAll(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		want := goldenSupraXRecords()
+		if len(got) != len(want) {
+			t.Fatalf("%s: read %d records, expected %d", file, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s record %d: %+v, expected %+v", file, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestText_CommentsAndOptionalColumn(t *testing.T) {
+	// WHAT: Comments/blank lines skipped; 0x prefix and instruction column optional
+	trace := `# recorded by hand
+
+0x1040 0x1000 cond T 6
+1044 2000 call t
+   # indented comment
+0X2010 0x1048 ret 1 3
+`
+	got, err := ReadAll(strings.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Record{
+		{PC: 0x1040, Target: 0x1000, Type: TypeConditional, Taken: true, Instructions: 6},
+		{PC: 0x1044, Target: 0x2000, Type: TypeCall, Taken: true},
+		{PC: 0x2010, Target: 0x1048, Type: TypeReturn, Taken: true, Instructions: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Read %d records, expected %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Record %d: %+v, expected %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestText_Errors(t *testing.T) {
+	// WHAT: Malformed lines are rejected with the line number
+	cases := []struct {
+		line string
+		want string
+	}{
+		{"0x10 0x20 cond", "expected 4 or 5 fields"},
+		{"0x10 0x20 loop T", "unknown branch type"},
+		{"0x10 0x20 cond Y", "direction"},
+		{"0xZZ 0x20 cond T", "pc"},
+		{"0x10 0x20 cond T -1", "instructions"},
+	}
+	for _, tc := range cases {
+		_, err := ReadAll(strings.NewReader("# header\n" + tc.line + "\n"))
+		if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("%q: error %v, expected line 2 and %q", tc.line, err, tc.want)
+		}
+	}
+}
+
+func TestBinary_Errors(t *testing.T) {
+	// WHAT: Bad version, truncated records and bad types are rejected
+	if _, err := NewReader(bytes.NewReader([]byte("BPTR\x09\x00\x00\x00"))); err == nil {
+		t.Error("Unsupported version accepted")
+	}
+
+	var buf bytes.Buffer
+	w := NewBinaryWriter(&buf)
+	w.Write(sampleRecords()[0])
+	w.Flush()
+	if _, err := ReadAll(bytes.NewReader(buf.Bytes()[:buf.Len()-3])); err == nil {
+		t.Error("Truncated record accepted")
+	}
+
+	raw := buf.Bytes()
+	raw[len(raw)-1] = 0x07 // Type 7
+	if _, err := ReadAll(bytes.NewReader(raw)); err == nil {
+		t.Error("Bad branch type accepted")
+	}
+
+	if err := NewTextWriter(io.Discard).Write(Record{Type: 9}); err == nil {
+		t.Error("Writer accepted bad branch type")
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. EVALUATION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestEvaluate_TotalsAndMPKI(t *testing.T) {
+	// WHAT: Always-taken on the sample trace misses only the not-taken conditional
+	rep, err := Evaluate(NewSliceSource(sampleRecords()), alwaysTaken{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Instructions: 6+6+1+4+2+1 (unknown counts as 1) = 20
+	if rep.Total.Branches != 6 || rep.Total.Mispredicts != 1 || rep.Total.Instructions != 20 {
+		t.Errorf("Totals %+v, expected 6 branches, 1 mispredict, 20 instructions", rep.Total)
+	}
+	if mpki := rep.Total.MPKI(); mpki != 50 {
+		t.Errorf("MPKI %.3f, expected 50", mpki)
+	}
+	if c := rep.ByType[TypeConditional]; c.Branches != 2 || c.Mispredicts != 1 {
+		t.Errorf("Conditional %+v, expected 2/1", c)
+	}
+	for _, typ := range []BranchType{TypeCall, TypeReturn, TypeJump, TypeIndirect} {
+		if c := rep.ByType[typ]; c.Branches != 1 || c.Mispredicts != 0 {
+			t.Errorf("%s: %+v, expected 1/0", typ, c)
+		}
+	}
+	if rep.Distinct != 5 {
+		t.Errorf("Distinct PCs %d, expected 5", rep.Distinct)
+	}
+	if len(rep.Worst) != 1 || rep.Worst[0].PC != 0x1040 {
+		t.Errorf("Worst %+v, expected only 0x1040", rep.Worst)
+	}
+}
+
+func TestEvaluate_Warmup(t *testing.T) {
+	// WHAT: Warmup records train but are excluded from totals
+	recs := sampleRecords()
+	rep, err := Evaluate(NewSliceSource(recs), alwaysTaken{}, Options{Warmup: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Warmup != 2 || rep.Total.Branches != 4 || rep.Total.Mispredicts != 0 {
+		t.Errorf("Warmup %d, totals %+v; expected 2 skipped, 4 branches, 0 mispredicts", rep.Warmup, rep.Total)
+	}
+}
+
+func TestEvaluate_WorstPCsOrderedAndLimited(t *testing.T) {
+	// WHAT: Worst PCs sorted by mispredicts (ties by PC) and truncated to TopN
+	var recs []Record
+	for pc := uint64(1); pc <= 5; pc++ {
+		for i := uint64(0); i < pc; i++ {
+			recs = append(recs, Record{PC: pc * 0x10, Type: TypeConditional, Taken: false})
+		}
+	}
+	recs = append(recs, Record{PC: 0x05, Type: TypeConditional, Taken: false}) // Ties with 0x10
+
+	rep, err := Evaluate(NewSliceSource(recs), alwaysTaken{}, Options{TopN: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{0x50, 0x40, 0x30}
+	if len(rep.Worst) != 3 {
+		t.Fatalf("Worst has %d entries, expected 3", len(rep.Worst))
+	}
+	for i, pc := range want {
+		if rep.Worst[i].PC != pc {
+			t.Errorf("Worst[%d] = %#x, expected %#x", i, rep.Worst[i].PC, pc)
+		}
+	}
+
+	rep, _ = Evaluate(NewSliceSource(recs), alwaysTaken{}, Options{TopN: 10})
+	if n := len(rep.Worst); n != 6 || rep.Worst[4].PC != 0x05 || rep.Worst[5].PC != 0x10 {
+		t.Errorf("Tie order wrong: %+v", rep.Worst)
+	}
+}
+
+func TestEvaluate_WarmupCurve(t *testing.T) {
+	// WHAT: Curve points cover each interval (including warmup and a partial tail)
+	// WHY: Shows how fast a predictor converges
+	recs := loopTrace(0x4000, 10, 25) // 250 records
+
+	rep, err := Evaluate(NewSliceSource(recs), NewBimodal(), Options{CurveInterval: 100, Warmup: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Curve) != 3 {
+		t.Fatalf("Curve has %d points, expected 3", len(rep.Curve))
+	}
+	ends := []uint64{100, 200, 250}
+	var total uint64
+	for i, pt := range rep.Curve {
+		if pt.EndRecord != ends[i] {
+			t.Errorf("Point %d ends at %d, expected %d", i, pt.EndRecord, ends[i])
+		}
+		total += pt.Branches
+	}
+	if total != 250 {
+		t.Errorf("Curve covers %d records, expected 250", total)
+	}
+	// Bimodal on a 10-trip loop: one miss per trip once warm
+	if acc := rep.Curve[2].Accuracy(); acc != 0.9 {
+		t.Errorf("Steady-state accuracy %.3f, expected 0.900", acc)
+	}
+}
+
+func TestEvaluateReader_ReportFormat(t *testing.T) {
+	// WHAT: EvaluateReader + Format produce a readable report
+	var buf bytes.Buffer
+	w := NewTextWriter(&buf)
+	for _, rec := range sampleRecords() {
+		w.Write(rec)
+	}
+	w.Flush()
+
+	rep, err := EvaluateReader(&buf, alwaysTaken{}, Options{CurveInterval: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := rep.Format(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"MPKI:", "50.000", "cond", "Worst PCs:", "0x1040", "Warmup curve:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report missing %q:\n%s", want, out.String())
+		}
+	}
+
+	var empty Report
+	if err := empty.Format(io.Discard); err != ErrNoBranches {
+		t.Errorf("Empty report: %v, expected ErrNoBranches", err)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. PREDICTOR ADAPTER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestAdapters_LoopTrace(t *testing.T) {
+	// WHAT: Bimodal, TAGE and TAGE-SC-L run through the same harness
+	// WHY: The loop predictor should make TAGE-SC-L the clear winner on a fixed trip count
+	recs := loopTrace(0x8000, 12, 400)
+
+	run := func(name string, p Predictor) Report {
+		rep, err := Evaluate(NewSliceSource(recs), p, Options{Warmup: 1200})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%-9s accuracy %.2f%%  MPKI %.3f", name, rep.Total.Accuracy()*100, rep.Total.MPKI())
+		return rep
+	}
+
+	bim := run("bimodal", NewBimodal())
+	tg := run("tage", NewTAGE(tage.NewTAGEPredictor()))
+	scl := run("tage-sc-l", NewTAGESCL(tage.NewTAGESCLPredictor()))
+
+	if bim.Total.Mispredicts == 0 || tg.Total.Branches != bim.Total.Branches {
+		t.Fatalf("Unexpected totals: bimodal %+v tage %+v", bim.Total, tg.Total)
+	}
+	if scl.Total.Accuracy() < 0.99 {
+		t.Errorf("TAGE-SC-L accuracy %.2f%%, expected ≥99%%", scl.Total.Accuracy()*100)
+	}
+	if scl.Total.MPKI() >= bim.Total.MPKI() {
+		t.Errorf("TAGE-SC-L MPKI %.3f not below bimodal %.3f", scl.Total.MPKI(), bim.Total.MPKI())
+	}
+}
+
+func TestAdapters_UpdateWithoutPredict(t *testing.T) {
+	// WHAT: Adapters tolerate Update for a PC that was not just predicted
+	a := NewTAGE(tage.NewTAGEPredictor())
+	a.Predict(0x100)
+	a.Update(0x200, true) // Different PC: re-looks up instead of training 0x100's metadata
+
+	s := NewTAGESCL(tage.NewTAGESCLPredictor())
+	s.Update(0x300, false)
+	if s.P.Stats().Predictions != 1 {
+		t.Errorf("TAGE-SC-L resolved %d branches, expected 1", s.P.Stats().Predictions)
+	}
+}
+
+func TestCore_DirectionMatchesBimodal(t *testing.T) {
+	// WHAT: The core's own BranchPredictor and the Bimodal model mispredict the same branches
+	// WHY: Bimodal re-implements the core's counters; this catches the two drifting apart
+	recs := append(loopTrace(0x8000, 12, 100), sampleRecords()...)
+	recs = append(recs, loopTrace(0x8000+4096, 3, 100)...) // Aliases 0x8000's counter
+
+	core, err := Evaluate(NewSliceSource(recs), NewCore(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bim, _ := Evaluate(NewSliceSource(recs), NewBimodal(), Options{})
+	if core.Total != bim.Total || core.ByType != bim.ByType {
+		t.Errorf("Core %+v differs from bimodal %+v", core.Total, bim.Total)
+	}
+}
+
+func TestCore_TargetAccuracy(t *testing.T) {
+	// WHAT: Returns are predicted from the RSB, indirect jumps from the L1I indirect table,
+	//       and both are scored separately from direction
+	// WHY: Direction is always right for these branches; the target is what can go wrong
+	var recs []Record
+	for i := 0; i < 100; i++ {
+		site := uint64(0x100 + 0x100*(i%2)) // Two call sites, one function
+		recs = append(recs,
+			Record{PC: site, Target: 0x800, Type: TypeCall, Taken: true, Instructions: 3},
+			Record{PC: 0x810, Target: site + 4, Type: TypeReturn, Taken: true, Instructions: 5})
+
+		target := uint64(0x400) // Three jumps in four go to 0x400
+		if i%4 == 3 {
+			target = 0x500
+		}
+		recs = append(recs, Record{PC: 0x300, Target: target, Type: TypeIndirect, Taken: true, Instructions: 2})
+	}
+
+	rep, err := Evaluate(NewSliceSource(recs), NewCore(), Options{Warmup: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, ind := rep.Targets[TypeReturn], rep.Targets[TypeIndirect]
+	if ret.Branches != 96 || ret.Mispredicts != 0 {
+		t.Errorf("Return targets %+v, expected 96 branches, none mispredicted", ret)
+	}
+	if ind.Branches != 96 || ind.Mispredicts != 24 {
+		t.Errorf("Indirect targets %+v, expected 96 branches, 24 mispredicted (the 0x500 jumps)", ind)
+	}
+	if rep.Targets[TypeCall].Branches != 0 || rep.Targets[TypeConditional].Branches != 0 {
+		t.Errorf("Direct branches scored for target: %+v", rep.Targets)
+	}
+
+	var out strings.Builder
+	if err := rep.Format(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Targets:", "ret", "ind", "75.00%"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// A direction-only predictor leaves targets unscored
+	if rep, _ := Evaluate(NewSliceSource(recs), NewBimodal(), Options{}); rep.Targets != [NumBranchTypes]Counts{} {
+		t.Errorf("Bimodal scored targets: %+v", rep.Targets)
+	}
+}
+
+func TestCore_ReplaysCoreTrace(t *testing.T) {
+	// WHAT: A trace recorded from a core run replays through the same core's predictor
+	// WHY: The round trip the harness exists for: record on the core, evaluate offline
+	var buf bytes.Buffer
+	w := suprax32.NewBranchTraceWriter(&buf, true)
+	core := suprax32.NewCore(1024 * 1024)
+	core.SetBranchTrace(w)
+	core.LoadProgram(suprax32.CreateIndirectJumpTest(), 0x1000)
+	core.Run(5000)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := EvaluateReader(&buf, NewCore(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50 calls through r10 and 50 returns through r31, one target each
+	ind := rep.Targets[TypeIndirect]
+	if ind.Branches != 100 || ind.Mispredicts > 2 {
+		t.Errorf("Indirect targets %+v, expected 100 branches, at most the 2 first sightings missed", ind)
+	}
+	if rep.ByType[TypeConditional].Branches == 0 {
+		t.Error("No loop branches in the core trace")
+	}
+}
@@ -1,3 +1,7 @@
 module main
 
 go 1.25.4
+
+require suprax32 v0.0.0
+
+replace suprax32 => ../
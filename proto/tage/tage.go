@@ -95,6 +95,7 @@
 package tage
 
 import (
+	"fmt"
 	"math/bits"
 )
 
@@ -158,6 +159,9 @@ import (
 // Always taken/not-taken: ~100%  ← Perfect on static patterns
 // Alternating:            ~50%   ← Expected (TAGE weakness, rare in practice)
 //
+// With the loop predictor and statistical corrector (TAGESCLPredictor, see below)
+// all three pattern classes above reach ~100% after warm-up.
+//
 // This 80-95% accuracy range is EXCELLENT for SUPRAX because:
 // - Context switching eliminates the performance penalty of mispredicts
 // - 8K entries costs only ~1-2M transistors vs 10M+ for marginal improvement
@@ -425,6 +429,127 @@ const (
 
 var HistoryLengths = [NumTables]int{0, 4, 8, 12, 16, 24, 32, 64}
 
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// RUNTIME GEOMETRY
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TAGEConfig describes a predictor geometry so experiments do not require editing the
+// constants above. The constants remain the SUPRAX design point: DefaultTAGEConfig()
+// returns exactly that geometry, and NewTAGEPredictor() uses it.
+//
+// FIELDS:
+//   NumTables:       Tables including the base table (2..MaxTables)
+//   EntriesPerTable: Entries in each table (power of two, ≥ LRUSearchWidth)
+//   TagWidths:       Tag bits in each table (1..16; table 0 is untagged and ignored)
+//   HistoryLengths:  History bits per table (table 0 = 0, then strictly increasing)
+//   CounterWidth:    Saturating counter width (2..7 bits)
+//   AgingInterval:   Mispredictions between aging sweeps (> 0)
+//   FoldedHistory:   Hash through folded history registers (required above 64 bits)
+//
+// Hardware: These are elaboration-time parameters. Each configuration is a different
+// netlist; nothing here is reconfigurable at run time in silicon.
+//
+// SystemVerilog equivalent:
+//   module tage_predictor #(
+//     parameter int NUM_TABLES          = 8,
+//     parameter int ENTRIES [NUM_TABLES] = '{1024, 1024, ...},
+//     parameter int TAG_WIDTHS [NUM_TABLES] = '{0, 13, 13, ...},
+//     parameter int HISTORY_LENGTHS [NUM_TABLES] = '{0, 4, 8, 12, 16, 24, 32, 64},
+//     parameter int COUNTER_WIDTH       = 3,
+//     parameter int AGING_INTERVAL      = 1024
+//   ) (...);
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// MaxTables: Upper bound on NumTables (width of the hit bitmap).
+// Hardware: 32-bit hit bitmap + CLZ32.
+const MaxTables = 32
+
+// MaxHistoryLength: Longest table history supported with FoldedHistory.
+// Hardware: Bounds the per-context history buffer (2048 bits at this size).
+const MaxHistoryLength = 1024
+
+type TAGEConfig struct {
+	NumTables       int    // Tables including base
+	EntriesPerTable []int  // Entries per table (power of two)
+	TagWidths       []int  // Tag bits per table (table 0 ignored)
+	HistoryLengths  []int  // History bits per table
+	CounterWidth    int    // Saturating counter width
+	AgingInterval   uint64 // Mispredictions between aging sweeps
+	FoldedHistory   bool   // Use long history buffer + folded registers
+}
+
+// DefaultTAGEConfig returns the SUPRAX geometry defined by the package constants.
+func DefaultTAGEConfig() TAGEConfig {
+	cfg := TAGEConfig{
+		NumTables:       NumTables,
+		EntriesPerTable: make([]int, NumTables),
+		TagWidths:       make([]int, NumTables),
+		HistoryLengths:  make([]int, NumTables),
+		CounterWidth:    CounterWidth,
+		AgingInterval:   AgingInterval,
+	}
+	for i := 0; i < NumTables; i++ {
+		cfg.EntriesPerTable[i] = EntriesPerTable
+		cfg.TagWidths[i] = TagWidth
+		cfg.HistoryLengths[i] = HistoryLengths[i]
+	}
+	cfg.TagWidths[0] = 0 // Base predictor is untagged
+	return cfg
+}
+
+// Validate reports the first problem with the geometry, or nil.
+func (c TAGEConfig) Validate() error {
+	if c.NumTables < 2 || c.NumTables > MaxTables {
+		return fmt.Errorf("tage: NumTables = %d, must be in [2, %d]", c.NumTables, MaxTables)
+	}
+	if len(c.EntriesPerTable) != c.NumTables {
+		return fmt.Errorf("tage: EntriesPerTable has %d values, expected %d", len(c.EntriesPerTable), c.NumTables)
+	}
+	if len(c.TagWidths) != c.NumTables {
+		return fmt.Errorf("tage: TagWidths has %d values, expected %d", len(c.TagWidths), c.NumTables)
+	}
+	if len(c.HistoryLengths) != c.NumTables {
+		return fmt.Errorf("tage: HistoryLengths has %d values, expected %d", len(c.HistoryLengths), c.NumTables)
+	}
+	if c.CounterWidth < 2 || c.CounterWidth > 7 {
+		return fmt.Errorf("tage: CounterWidth = %d, must be in [2, 7]", c.CounterWidth)
+	}
+	if c.AgingInterval == 0 {
+		return fmt.Errorf("tage: AgingInterval must be > 0")
+	}
+
+	for i := 0; i < c.NumTables; i++ {
+		n := c.EntriesPerTable[i]
+		if n < LRUSearchWidth || n > 1<<20 || n&(n-1) != 0 {
+			return fmt.Errorf("tage: table %d has %d entries, must be a power of two in [%d, %d]",
+				i, n, LRUSearchWidth, 1<<20)
+		}
+		if i == 0 {
+			if c.HistoryLengths[0] != 0 {
+				return fmt.Errorf("tage: base table history length = %d, must be 0", c.HistoryLengths[0])
+			}
+			continue
+		}
+		if c.TagWidths[i] < 1 || c.TagWidths[i] > 16 {
+			return fmt.Errorf("tage: table %d tag width = %d, must be in [1, 16]", i, c.TagWidths[i])
+		}
+		if c.HistoryLengths[i] <= c.HistoryLengths[i-1] {
+			return fmt.Errorf("tage: table %d history length %d not greater than table %d (%d)",
+				i, c.HistoryLengths[i], i-1, c.HistoryLengths[i-1])
+		}
+		if c.HistoryLengths[i] > HistoryWidth && !c.FoldedHistory {
+			return fmt.Errorf("tage: table %d history length %d exceeds %d-bit history register (set FoldedHistory)",
+				i, c.HistoryLengths[i], HistoryWidth)
+		}
+		if c.HistoryLengths[i] > MaxHistoryLength {
+			return fmt.Errorf("tage: table %d history length %d exceeds %d",
+				i, c.HistoryLengths[i], MaxHistoryLength)
+		}
+	}
+	return nil
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 // TAGE ENTRY
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -481,6 +606,10 @@ type TAGEEntry struct {
 //   Entries:    Array of 1024 prediction entries (SRAM)
 //   ValidBits:  Bitmap tracking which entries are valid (flip-flops)
 //   HistoryLen: Number of history bits this table uses (constant)
+//   IndexWidth: log2(len(Entries)) (constant)
+//   TagWidth:   Tag bits compared in this table (constant, 0 for base)
+//
+// Sizes come from TAGEConfig; the numbers above are the default geometry.
 //
 // WHY SEPARATE VALID BITS:
 //   - Fast scan: Can find empty slots without reading SRAM
@@ -502,46 +631,86 @@ type TAGEEntry struct {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 type TAGETable struct {
-	Entries    [EntriesPerTable]TAGEEntry // 1024 entries (SRAM)
-	ValidBits  [ValidBitmapWords]uint64   // Valid bitmap (16 × 64-bit words)
-	HistoryLen int                        // History bits used (constant per table)
+	Entries    []TAGEEntry // 1024 entries (SRAM)
+	ValidBits  []uint64    // Valid bitmap (16 × 64-bit words)
+	HistoryLen int         // History bits used (constant per table)
+	IndexWidth int         // Index bits (constant per table)
+	TagWidth   int         // Tag bits (constant per table)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 // PREDICTION METADATA
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 //
-// PredictionMetadata caches information from Predict() for use in Update().
+// PredictionMetadata travels with ONE in-flight branch from predict to resolve.
 //
 // PURPOSE:
-//   Avoid redundant lookups - Predict() already found the provider entry.
-//   Update() can use cached info instead of searching again.
+//   A real pipeline has several branches between fetch and execute. Each needs its
+//   own record of which entry provided its prediction and which history it was
+//   predicted with - a single "last prediction" register would be overwritten by
+//   the next fetch long before the first branch resolves.
+//
+//   Update-time hashing MUST use the history the branch was predicted with, not the
+//   current (already speculatively advanced) history, or it would train the wrong
+//   entries. The Checkpoint field carries that history.
 //
 // FIELDS:
-//   ProviderTable: Which table provided the prediction (-1 = base only)
+//   PC, Ctx:       Branch address and (clamped) context
+//   Checkpoint:    History register of Ctx before this branch (the restore token)
+//   Speculative:   Predicted outcome was shifted into history at predict time
+//   ProviderTable: Which table provided the prediction (0 = base)
 //   ProviderIndex: Index within provider table
-//   ProviderEntry: Pointer to the actual entry
+//   ProviderEntry: Pointer to the actual entry (Go model only)
 //   Predicted:     What we predicted (taken/not-taken)
 //   Confidence:    Confidence level (0=low, 1=medium, 2=high)
 //
-// Hardware: Pipeline register between predict and update stages
+// Hardware: Carried in the branch's ROB/branch-queue entry
 //
 // SystemVerilog equivalent:
 //   typedef struct packed {
-//     logic [2:0]  provider_table;  // 3 bits (-1 encoded as 3'b111)
+//     logic [63:0] pc;
+//     logic [2:0]  ctx;
+//     logic [63:0] checkpoint;      // history before this branch
+//     logic        speculative;     // 1 bit
+//     logic [2:0]  provider_table;  // 3 bits
 //     logic [9:0]  provider_index;  // 10 bits
 //     logic        predicted;       // 1 bit
 //     logic [1:0]  confidence;      // 2 bits
-//   } prediction_metadata_t;        // 16 bits
+//   } prediction_metadata_t;
 //
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// HistoryCheckpoint is the token needed to repair a context's history register.
+// Head and Folded are only populated when the predictor uses FoldedHistory; Folded then
+// holds one register set per configured table rather than all MaxTables.
+//
+// SystemVerilog equivalent:
+//   typedef struct packed {
+//     logic [2:0]  ctx;
+//     logic [63:0] history;
+//     logic [31:0] head;                     // long history buffer pointer
+//     folded_history_t [NUM_TABLES-1:0] folded;
+//   } history_checkpoint_t;
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+type HistoryCheckpoint struct {
+	Ctx     uint8           // Context whose history was checkpointed
+	History uint64          // History before the branch's outcome was shifted in
+	Head    uint32          // Long history buffer head (FoldedHistory only)
+	Folded  []FoldedHistory // Folded registers per active table (FoldedHistory only)
+}
+
 type PredictionMetadata struct {
-	ProviderTable int        // Which table provided prediction (-1 = base)
-	ProviderIndex uint32     // Index in provider table
-	ProviderEntry *TAGEEntry // Pointer to provider entry (for Go model only)
-	Predicted     bool       // What was predicted
-	Confidence    uint8      // Confidence level (0-2)
+	PC            uint64            // Branch PC
+	Ctx           uint8             // Context (clamped to valid range)
+	Checkpoint    HistoryCheckpoint // History at predict time (restore token)
+	Speculative   bool              // History already advanced with Predicted
+	ProviderTable int               // Which table provided prediction (0 = base)
+	ProviderIndex uint32            // Index in provider table
+	ProviderEntry *TAGEEntry        // Pointer to provider entry (for Go model only)
+	Predicted     bool              // What was predicted
+	Confidence    uint8             // Confidence level (0-2)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -553,10 +722,14 @@ type PredictionMetadata struct {
 // COMPONENTS:
 //   Tables:         8 predictor tables (Table 0 = base, Tables 1-7 = history)
 //   History:        Per-context global branch history registers (8 × 64-bit)
+//   LongHistory:    Per-context history buffers (FoldedHistory only)
+//   Folded:         Per-context, per-table folded history registers (FoldedHistory only)
 //   BranchCount:    Counter for triggering periodic aging
 //   AgingEnabled:   Enable/disable aging (for testing)
-//   LastPrediction: Cached metadata from most recent prediction
-//   LastPC/LastCtx: PC and context of most recent prediction
+//
+// Per-branch state (provider, predict-time history) is NOT held here - it lives in
+// the PredictionMetadata returned to the caller, so any number of branches can be
+// in flight at once.
 //
 // MEMORY FOOTPRINT:
 //   Tables: 8 × 1024 × 24 bits = 196,608 bits = 24KB SRAM
@@ -584,20 +757,20 @@ type PredictionMetadata struct {
 //     tage_table_t tables [0:7];
 //     logic [63:0] history [0:7];
 //     logic [63:0] branch_count;
-//     prediction_metadata_t last_prediction;
 //
 //   endmodule
 //
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 type TAGEPredictor struct {
-	Tables         [NumTables]TAGETable // 8 predictor tables
-	History        [NumContexts]uint64  // Per-context history registers
-	BranchCount    uint64               // Counter for aging trigger
-	AgingEnabled   bool                 // Enable periodic aging
-	LastPrediction PredictionMetadata   // Cached prediction metadata
-	LastPC         uint64               // PC of last prediction
-	LastCtx        uint8                // Context of last prediction
+	Tables       []TAGETable                           // 8 predictor tables
+	History      [NumContexts]uint64                   // Per-context history registers (speculative)
+	LongHistory  [NumContexts]HistoryBuffer            // Long history buffers (FoldedHistory)
+	Folded       [NumContexts][MaxTables]FoldedHistory // Folded registers (FoldedHistory)
+	BranchCount  uint64                                // Counter for aging trigger
+	AgingEnabled bool                                  // Enable periodic aging
+	Config       TAGEConfig                            // Geometry this predictor was built with
+	MaxCounter   uint8                                 // Counter saturation value (from CounterWidth)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -653,19 +826,54 @@ type TAGEPredictor struct {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 func NewTAGEPredictor() *TAGEPredictor {
+	return newTAGEPredictor(DefaultTAGEConfig())
+}
+
+// NewTAGEPredictorWithConfig builds a predictor with the given geometry.
+// Returns an error (and no predictor) if cfg fails Validate.
+func NewTAGEPredictorWithConfig(cfg TAGEConfig) (*TAGEPredictor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return newTAGEPredictor(cfg), nil
+}
+
+// newTAGEPredictor builds a predictor from an already-validated config.
+func newTAGEPredictor(cfg TAGEConfig) *TAGEPredictor {
+	// Private copy so later edits to the caller's slices cannot change the geometry
+	cfg.EntriesPerTable = append([]int(nil), cfg.EntriesPerTable...)
+	cfg.TagWidths = append([]int(nil), cfg.TagWidths...)
+	cfg.HistoryLengths = append([]int(nil), cfg.HistoryLengths...)
+
 	pred := &TAGEPredictor{
+		Tables:       make([]TAGETable, cfg.NumTables),
 		AgingEnabled: true,
-		LastPrediction: PredictionMetadata{
-			ProviderTable: -1,
-		},
+		Config:       cfg,
+		MaxCounter:   uint8(1<<cfg.CounterWidth - 1),
 	}
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Configure history lengths (hardwired constants)
+	// Configure table geometry (elaboration-time parameters)
 	// SystemVerilog: parameter int HISTORY_LENGTHS [0:7] = '{0, 4, 8, 12, 16, 24, 32, 64};
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	for i := 0; i < NumTables; i++ {
-		pred.Tables[i].HistoryLen = HistoryLengths[i]
+	for i := 0; i < cfg.NumTables; i++ {
+		n := cfg.EntriesPerTable[i]
+		pred.Tables[i].Entries = make([]TAGEEntry, n)
+		pred.Tables[i].ValidBits = make([]uint64, (n+63)>>6)
+		pred.Tables[i].HistoryLen = cfg.HistoryLengths[i]
+		pred.Tables[i].IndexWidth = bits.TrailingZeros(uint(n))
+		pred.Tables[i].TagWidth = cfg.TagWidths[i]
+	}
+	pred.Tables[0].TagWidth = 0
+
+	// ─────────────────────────────────────────────────────────────────────────────────────────
+	// Long history buffers (FoldedHistory only); folded registers start at 0
+	// ─────────────────────────────────────────────────────────────────────────────────────────
+	if cfg.FoldedHistory {
+		words := historyBufferBits(cfg.HistoryLengths[cfg.NumTables-1]) / 64
+		for ctx := 0; ctx < NumContexts; ctx++ {
+			pred.LongHistory[ctx].Bits = make([]uint64, words)
+		}
 	}
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
@@ -684,10 +892,10 @@ func NewTAGEPredictor() *TAGEPredictor {
 	//   end
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	baseTable := &pred.Tables[0]
-	for idx := 0; idx < EntriesPerTable; idx++ {
+	for idx := range baseTable.Entries {
 		baseTable.Entries[idx] = TAGEEntry{
 			Tag:     0,
-			Counter: NeutralCounter, // 4 = truly neutral
+			Counter: pred.neutralCounter(), // 4 = truly neutral
 			Context: 0,
 			Useful:  false,
 			Taken:   false, // No bias toward taken
@@ -712,8 +920,8 @@ func NewTAGEPredictor() *TAGEPredictor {
 	//     end
 	//   end
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	for t := 1; t < NumTables; t++ {
-		for w := 0; w < ValidBitmapWords; w++ {
+	for t := 1; t < len(pred.Tables); t++ {
+		for w := range pred.Tables[t].ValidBits {
 			pred.Tables[t].ValidBits[w] = 0
 		}
 	}
@@ -809,6 +1017,16 @@ func NewTAGEPredictor() *TAGEPredictor {
 
 //go:inline
 func hashIndex(pc uint64, history uint64, historyLen int, tableNum int) uint32 {
+	return hashIndexWidth(pc, history, historyLen, tableNum, IndexWidth)
+}
+
+// hashIndexWidth is hashIndex for a table of 2^indexWidth entries.
+// The fold uses three indexWidth-bit slices, exactly as the 10-bit default.
+//
+//go:inline
+func hashIndexWidth(pc uint64, history uint64, historyLen int, tableNum int, indexWidth int) uint32 {
+	indexMask := uint64(1)<<indexWidth - 1
+
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Step 1: Extract PC bits with table-specific shift
 	// Each table looks at different PC bits for decorrelation
@@ -816,7 +1034,7 @@ func hashIndex(pc uint64, history uint64, historyLen int, tableNum int) uint32 {
 	// Wire: pc_bits = (pc >> pc_shift) & INDEX_MASK
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	pcShift := 12 + tableNum
-	pcBits := uint32((pc >> pcShift) & IndexMask)
+	pcBits := uint32((pc >> pcShift) & indexMask)
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Step 2: Base predictor (Table 0) uses only PC, no history
@@ -850,14 +1068,13 @@ func hashIndex(pc uint64, history uint64, historyLen int, tableNum int) uint32 {
 	// Combines multiple slices of the 64-bit product into 10 bits
 	// Wire: hist_bits = h[9:0] ^ h[19:10] ^ h[29:20]
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	histBits := uint32(h ^ (h >> IndexWidth) ^ (h >> (2 * IndexWidth)))
-	histBits &= IndexMask
+	histBits := uint32((h ^ (h >> indexWidth) ^ (h >> (2 * indexWidth))) & indexMask)
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Step 6: Final XOR combination
 	// Wire: index = pc_bits ^ hist_bits
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	return (pcBits ^ histBits) & IndexMask
+	return (pcBits ^ histBits) & uint32(indexMask)
 }
 
 // ───────────────────────────────────────────────────────────────────────────────────────────────
@@ -886,14 +1103,199 @@ func hashIndex(pc uint64, history uint64, historyLen int, tableNum int) uint32 {
 
 //go:inline
 func hashTag(pc uint64) uint16 {
+	return hashTagWidth(pc, TagWidth)
+}
+
+// hashTagWidth is hashTag truncated to tagWidth bits (same PC bit positions).
+//
+//go:inline
+func hashTagWidth(pc uint64, tagWidth int) uint16 {
+	tagMask := uint64(1)<<tagWidth - 1
 	// Wire: low_bits = pc[34:22]
-	lowBits := uint16((pc >> 22) & TagMask)
+	lowBits := uint16((pc >> 22) & tagMask)
 	// Wire: high_bits = pc[52:40]
-	highBits := uint16((pc >> 40) & TagMask)
+	highBits := uint16((pc >> 40) & tagMask)
 	// Wire: tag = low_bits ^ high_bits
 	return lowBits ^ highBits
 }
 
+// neutralCounter returns the midpoint counter value (4 for 3-bit counters).
+// It doubles as the taken threshold: counter >= neutral predicts taken.
+func (p *TAGEPredictor) neutralCounter() uint8 {
+	return (p.MaxCounter + 1) >> 1
+}
+
+// tableIndex hashes pc and the checkpointed history into table t.
+// FoldedHistory: index = pc_bits ^ folded_index (no wide hash at lookup).
+func (p *TAGEPredictor) tableIndex(pc uint64, cp *HistoryCheckpoint, t int) uint32 {
+	table := &p.Tables[t]
+	if t == 0 || !p.Config.FoldedHistory {
+		return hashIndexWidth(pc, cp.History, table.HistoryLen, t, table.IndexWidth)
+	}
+	pcBits := hashIndexWidth(pc, 0, 0, t, table.IndexWidth)
+	return (pcBits ^ cp.Folded[t].Index) & (1<<table.IndexWidth - 1)
+}
+
+// tableTag computes the tag stored in table t.
+// FoldedHistory: tag = pc_tag ^ tag_fold0 ^ (tag_fold1 << 1).
+func (p *TAGEPredictor) tableTag(pc uint64, cp *HistoryCheckpoint, t int) uint16 {
+	table := &p.Tables[t]
+	tag := hashTagWidth(pc, table.TagWidth)
+	if t == 0 || !p.Config.FoldedHistory {
+		return tag
+	}
+	f := &cp.Folded[t]
+	return (tag ^ f.Tag0 ^ f.Tag1<<1) & (1<<table.TagWidth - 1)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// LONG HISTORY AND FOLDED REGISTERS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// The 64-bit history register caps table history at 64 branches, and hashIndex()
+// re-folds the raw history on every lookup. Real TAGE designs keep hundreds of bits of
+// history and never fold it at lookup time. Instead:
+//
+//   1. Outcomes go into a circular bit buffer (one per context)
+//   2. Each table keeps FOLDED registers: its history window compressed to the index
+//      width (and to two tag widths), updated incrementally as each outcome arrives
+//   3. Lookup XORs the PC with the folded registers - no wide logic on the critical path
+//
+// FOLDED UPDATE (per table, per outcome; origLen = L, compLen = C):
+//   v = (v << 1) | new_bit          // Rotate all folded bits one position
+//   v ^= old_bit << (L % C)         // Cancel the bit leaving the L-bit window
+//   v ^= v >> C                     // Wrap the carry-out bit back to position 0
+//   v &= (1 << C) - 1
+//
+//   Invariant: v == XOR over age a in [0, L) of history[a] << (a % C)
+//   (history[0] = newest outcome). Cost is 3 XORs per register regardless of L.
+//
+// SPECULATION:
+//   A checkpoint holds the buffer head pointer and every folded register. Restore
+//   rewinds the head and reloads the folds; buffer bits older than the checkpoint are
+//   never overwritten while fewer than (buffer size - longest history) branches of a
+//   context are in flight.
+//
+// ENABLING:
+//   TAGEConfig.FoldedHistory selects this path for all tagged tables (Table 0 never uses
+//   history). It is required for history lengths above 64. With it off, the predictor
+//   uses hashIndex()/hashTag() on the 64-bit register exactly as before. The 64-bit
+//   History register is maintained in both modes (the statistical corrector reads it).
+//
+// Hardware: 8 × N-bit history buffers + 3 folded registers per table per context
+// Timing: Fold update ~30ps (3 XOR levels), off the prediction critical path
+//
+// SystemVerilog:
+//   always_ff @(posedge clk) begin
+//     if (shift_en) begin
+//       for (int t = 1; t < NUM_TABLES; t++) begin
+//         old_bit = buffer[ctx][(head[ctx] - HIST_LEN[t]) & BUF_MASK];
+//         fold_idx[ctx][t] <= fold(fold_idx[ctx][t], taken, old_bit, HIST_LEN[t], INDEX_WIDTH[t]);
+//         fold_tag0[ctx][t] <= fold(fold_tag0[ctx][t], taken, old_bit, HIST_LEN[t], TAG_WIDTH[t]);
+//         fold_tag1[ctx][t] <= fold(fold_tag1[ctx][t], taken, old_bit, HIST_LEN[t], TAG_WIDTH[t]-1);
+//       end
+//       buffer[ctx][head[ctx] & BUF_MASK] <= taken;
+//       head[ctx] <= head[ctx] + 1;
+//     end
+//   end
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// FoldedHistory holds one table's folded history registers for one context.
+type FoldedHistory struct {
+	Index uint32 // History folded to the table's index width
+	Tag0  uint16 // History folded to the table's tag width
+	Tag1  uint16 // History folded to tag width - 1 (decorrelates the two tag folds)
+}
+
+// HistoryBuffer is a circular buffer of branch outcomes (1 bit per branch).
+type HistoryBuffer struct {
+	Bits []uint64 // Ring storage, power-of-two number of bits
+	Head uint32   // Outcomes pushed so far; newest is at (Head-1) & mask
+}
+
+// bit returns the outcome pushed age branches ago (age 0 = newest).
+func (b *HistoryBuffer) bit(age int) uint32 {
+	mask := uint32(len(b.Bits)*64 - 1)
+	pos := (b.Head - 1 - uint32(age)) & mask
+	return uint32(b.Bits[pos>>6]>>(pos&63)) & 1
+}
+
+// push appends an outcome, overwriting the oldest slot.
+func (b *HistoryBuffer) push(taken bool) {
+	mask := uint32(len(b.Bits)*64 - 1)
+	pos := b.Head & mask
+	if taken {
+		b.Bits[pos>>6] |= 1 << (pos & 63)
+	} else {
+		b.Bits[pos>>6] &^= 1 << (pos & 63)
+	}
+	b.Head++
+}
+
+// historyBufferBits sizes the ring: twice the next power of two above the longest
+// history (at least 128), leaving the rest as room for speculative branches.
+func historyBufferBits(maxLen int) int {
+	n := 64
+	for n < maxLen {
+		n <<= 1
+	}
+	return 2 * n
+}
+
+// foldUpdate advances a compLen-bit folded register of an origLen-bit window by one outcome.
+//
+//go:inline
+func foldUpdate(v uint32, newBit uint32, oldBit uint32, origLen int, compLen int) uint32 {
+	v = (v << 1) | newBit
+	v ^= oldBit << (origLen % compLen)
+	v ^= v >> compLen
+	return v & (1<<compLen - 1)
+}
+
+// tagFoldWidths returns the widths of a table's two tag folds.
+func tagFoldWidths(tagWidth int) (int, int) {
+	if tagWidth < 2 {
+		return tagWidth, 1
+	}
+	return tagWidth, tagWidth - 1
+}
+
+// checkpoint captures a context's history state at predict time.
+func (p *TAGEPredictor) checkpoint(ctx uint8) HistoryCheckpoint {
+	cp := HistoryCheckpoint{Ctx: ctx, History: p.History[ctx]}
+	if p.Config.FoldedHistory {
+		cp.Head = p.LongHistory[ctx].Head
+		cp.Folded = append([]FoldedHistory(nil), p.Folded[ctx][:len(p.Tables)]...)
+	}
+	return cp
+}
+
+// shiftLongHistory updates every table's folded registers and the buffer for one outcome.
+func (p *TAGEPredictor) shiftLongHistory(ctx uint8, taken bool) {
+	buf := &p.LongHistory[ctx]
+	folds := &p.Folded[ctx]
+
+	var newBit uint32
+	if taken {
+		newBit = 1
+	}
+
+	// generate for (genvar t = 1; t < NUM_TABLES; t++)
+	for t := 1; t < len(p.Tables); t++ {
+		table := &p.Tables[t]
+		oldBit := buf.bit(table.HistoryLen - 1) // Leaves the window after this push
+		w0, w1 := tagFoldWidths(table.TagWidth)
+
+		f := &folds[t]
+		f.Index = foldUpdate(f.Index, newBit, oldBit, table.HistoryLen, table.IndexWidth)
+		f.Tag0 = uint16(foldUpdate(uint32(f.Tag0), newBit, oldBit, table.HistoryLen, w0))
+		f.Tag1 = uint16(foldUpdate(uint32(f.Tag1), newBit, oldBit, table.HistoryLen, w1))
+	}
+
+	buf.push(taken)
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 // PREDICTION
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -910,7 +1312,7 @@ func hashTag(pc uint64) uint16 {
 //   3. Use CLZ to find longest-matching table (highest bit in hit bitmap)
 //   4. If any history table hit: use that prediction
 //   5. Else: use base predictor (Table 0)
-//   6. Cache metadata for Update()
+//   6. Return metadata (Lookup) for Resolve()/Update()
 //
 // LONGEST MATCH RATIONALE:
 //   Longer history = more specific pattern = better prediction.
@@ -984,6 +1386,18 @@ func hashTag(pc uint64) uint16 {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence uint8) {
+	meta := p.Lookup(pc, ctx)
+	return meta.Predicted, meta.Confidence
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// Lookup performs the prediction and returns the full per-branch metadata.
+//
+// PURE READ: no predictor state changes. The returned metadata is what Resolve()
+// needs later; the caller keeps it with the branch (ROB / branch queue entry).
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (p *TAGEPredictor) Lookup(pc uint64, ctx uint8) PredictionMetadata {
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Input validation: Clamp context to valid range
 	// Wire: ctx_clamped = (ctx >= NUM_CONTEXTS) ? 0 : ctx
@@ -996,43 +1410,35 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 	// Read context's history register
 	// Wire: history = history_regs[ctx]
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	history := p.History[ctx]
-
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Compute tag from PC (shared across all tables)
-	// Wire: tag = hash_tag(pc)
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	tag := hashTag(pc)
-
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Initialize metadata cache for Update()
-	// Reg: last_pc <= pc; last_ctx <= ctx; last_prediction.provider_table <= -1
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	p.LastPC = pc
-	p.LastCtx = ctx
-	p.LastPrediction.ProviderTable = -1
-	p.LastPrediction.ProviderEntry = nil
+	meta := PredictionMetadata{
+		PC:         pc,
+		Ctx:        ctx,
+		Checkpoint: p.checkpoint(ctx),
+	}
+	cp := &meta.Checkpoint
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Parallel table search (generate for in RTL)
 	// All 7 history tables searched simultaneously
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	var hitBitmap uint8
-	var predictions [NumTables]bool
-	var counters [NumTables]uint8
-	var indices [NumTables]uint32
-	var entries [NumTables]*TAGEEntry
+	var hitBitmap uint32
+	var predictions [MaxTables]bool
+	var counters [MaxTables]uint8
+	var indices [MaxTables]uint32
+	var entries [MaxTables]*TAGEEntry
 
 	// generate for (genvar i = 1; i < 8; i++)
-	for i := 1; i < NumTables; i++ {
+	for i := 1; i < len(p.Tables); i++ {
 		table := &p.Tables[i]
 
 		// ─────────────────────────────────────────────────────────────────────────────────────
-		// Compute index for this table
+		// Compute index and tag for this table
 		// Wire: indices[i] = hash_index(pc, history, table.history_len, i)
+		// Wire: tag = hash_tag(pc)  (truncated to the table's tag width)
 		// ─────────────────────────────────────────────────────────────────────────────────────
-		idx := hashIndex(pc, history, table.HistoryLen, i)
+		idx := p.tableIndex(pc, cp, i)
 		indices[i] = idx
+		tag := p.tableTag(pc, cp, i)
 
 		// ─────────────────────────────────────────────────────────────────────────────────────
 		// Check valid bit
@@ -1059,28 +1465,24 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 		if (xorTag | xorCtx) == 0 {
 			// Hit! Record in bitmap
 			hitBitmap |= 1 << uint(i)
-			predictions[i] = entry.Counter >= TakenThreshold
+			predictions[i] = entry.Counter >= p.neutralCounter()
 			counters[i] = entry.Counter
 		}
 	}
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Select winner using CLZ (longest matching history)
-	// Wire: winner = 7 - clz8(hit_bitmap)
+	// Wire: winner = 31 - clz32(hit_bitmap)
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	if hitBitmap != 0 {
 		// Find highest set bit (longest history match)
-		clz := bits.LeadingZeros8(hitBitmap)
-		winner := 7 - clz
+		clz := bits.LeadingZeros32(hitBitmap)
+		winner := 31 - clz
 
-		// ─────────────────────────────────────────────────────────────────────────────────────
-		// Cache provider metadata for Update()
-		// Reg: last_prediction <= {winner, indices[winner], predictions[winner], ...}
-		// ─────────────────────────────────────────────────────────────────────────────────────
-		p.LastPrediction.ProviderTable = winner
-		p.LastPrediction.ProviderIndex = indices[winner]
-		p.LastPrediction.ProviderEntry = entries[winner]
-		p.LastPrediction.Predicted = predictions[winner]
+		meta.ProviderTable = winner
+		meta.ProviderIndex = indices[winner]
+		meta.ProviderEntry = entries[winner]
+		meta.Predicted = predictions[winner]
 
 		// ─────────────────────────────────────────────────────────────────────────────────────
 		// Compute confidence from counter saturation
@@ -1088,14 +1490,13 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 		// Wire: confidence = saturated ? 2 : 1
 		// ─────────────────────────────────────────────────────────────────────────────────────
 		counter := counters[winner]
-		if counter <= 1 || counter >= (MaxCounter-1) {
-			confidence = 2 // High (saturated)
+		if counter <= 1 || counter >= (p.MaxCounter-1) {
+			meta.Confidence = 2 // High (saturated)
 		} else {
-			confidence = 1 // Medium
+			meta.Confidence = 1 // Medium
 		}
-		p.LastPrediction.Confidence = confidence
 
-		return predictions[winner], confidence
+		return meta
 	}
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
@@ -1103,16 +1504,153 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 	// Wire: base_idx = hash_index(pc, 0, 0, 0)
 	// Wire: taken = tables[0].entries[base_idx].counter >= 4
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	baseIdx := hashIndex(pc, 0, 0, 0)
+	baseIdx := p.tableIndex(pc, cp, 0)
 	baseEntry := &p.Tables[0].Entries[baseIdx]
 
-	p.LastPrediction.ProviderTable = 0
-	p.LastPrediction.ProviderIndex = baseIdx
-	p.LastPrediction.ProviderEntry = baseEntry
-	p.LastPrediction.Predicted = baseEntry.Counter >= TakenThreshold
-	p.LastPrediction.Confidence = 0
+	meta.ProviderTable = 0
+	meta.ProviderIndex = baseIdx
+	meta.ProviderEntry = baseEntry
+	meta.Predicted = baseEntry.Counter >= p.neutralCounter()
+	meta.Confidence = 0
+
+	return meta
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SPECULATIVE HISTORY
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// In a pipeline, the next branch is predicted long before the previous one resolves.
+// If history only advanced at resolve time, every prediction in between would see
+// stale history and index the wrong entries.
+//
+// SPECULATIVE UPDATE:
+//   PredictSpeculative shifts the PREDICTED outcome into the history register right
+//   away and returns the pre-shift history as a checkpoint.
+//
+// REPAIR:
+//   On a misprediction, every younger branch is squashed anyway, so repair is a
+//   single register write: history = {checkpoint[62:0], actual_taken}.
+//   Correctly predicted branches need no repair - their bit is already right.
+//
+// EXAMPLE (3 branches in flight, B2 mispredicts):
+//   history   = H
+//   predict B1 (T) → history = H·T      checkpoint B1 = H
+//   predict B2 (T) → history = H·T·T    checkpoint B2 = H·T
+//   predict B3 (N) → history = H·T·T·N  checkpoint B3 = H·T·T
+//   B2 resolves NOT TAKEN → Restore(B2, N) → history = H·T·N  (B3 squashed)
+//
+// Hardware: One 64-bit checkpoint per in-flight branch, one write port for repair
+//   (with FoldedHistory the checkpoint also carries the buffer head and folded registers)
+//
+// SystemVerilog:
+//   always_ff @(posedge clk) begin
+//     if (restore_en)
+//       history[restore_ctx] <= {restore_checkpoint[62:0], actual_taken};
+//     else if (predict_en)
+//       history[ctx] <= {history[ctx][62:0], predicted};
+//   end
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func (p *TAGEPredictor) PredictSpeculative(pc uint64, ctx uint8) PredictionMetadata {
+	meta := p.Lookup(pc, ctx)
+
+	// Wire: history[ctx] <= {history[ctx][62:0], predicted}
+	p.shiftHistory(meta.Ctx, meta.Predicted)
+	meta.Speculative = true
+
+	return meta
+}
+
+// Restore rewinds a context's history to the checkpoint and shifts in the actual outcome.
+// All speculative history from younger branches in that context is discarded.
+func (p *TAGEPredictor) Restore(cp HistoryCheckpoint, actualTaken bool) {
+	ctx := cp.Ctx
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+
+	p.History[ctx] = cp.History
+	if p.Config.FoldedHistory {
+		p.LongHistory[ctx].Head = cp.Head
+		copy(p.Folded[ctx][:], cp.Folded)
+	}
+	p.shiftHistory(ctx, actualTaken)
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// Resolve trains the predictor for one in-flight branch using its own metadata.
+//
+// BEHAVIOR:
+//   Predicted == actual: same training as Update()
+//   Predicted != actual: same training as OnMispredict()
+//   All hashing uses meta.Checkpoint.History (history the branch was predicted with).
+//
+// HISTORY:
+//   Speculative metadata: history repaired via Restore() on mispredict, untouched otherwise
+//   Non-speculative:      actual outcome shifted in (like Update/OnMispredict)
+//
+// Branches may be resolved in any order relative to each other.
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (p *TAGEPredictor) Resolve(meta PredictionMetadata, actualTaken bool) {
+	mispredicted := meta.Predicted != actualTaken
+
+	if mispredicted {
+		p.trainMispredict(&meta, actualTaken)
+	} else {
+		p.trainCorrect(&meta, actualTaken)
+	}
+
+	switch {
+	case !meta.Speculative:
+		p.shiftHistory(meta.Ctx, actualTaken)
+	case mispredicted:
+		p.Restore(meta.Checkpoint, actualTaken)
+	}
+
+	if mispredicted {
+		p.tickAging()
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// shiftHistory shifts an outcome into a context's (non-speculative) history.
+//
+// SystemVerilog:
+//   history[ctx] <= {history[ctx][62:0], taken};
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (p *TAGEPredictor) shiftHistory(ctx uint8, taken bool) {
+	var takenBit uint64
+	if taken {
+		takenBit = 1
+	}
+	p.History[ctx] = (p.History[ctx] << 1) | takenBit
 
-	return baseEntry.Counter >= TakenThreshold, 0
+	if p.Config.FoldedHistory {
+		p.shiftLongHistory(ctx, taken)
+	}
+}
+
+// providerEntry returns the entry that provided meta's prediction if it still holds the
+// same branch. A history-table entry may have been replaced between predict and resolve
+// (another branch allocated over it); in that case there is nothing to train.
+func (p *TAGEPredictor) providerEntry(meta *PredictionMetadata) *TAGEEntry {
+	if meta.ProviderTable < 1 || meta.ProviderTable >= len(p.Tables) {
+		return nil
+	}
+	table := &p.Tables[meta.ProviderTable]
+	idx := meta.ProviderIndex
+	if (table.ValidBits[idx>>6]>>(idx&63))&1 == 0 {
+		return nil
+	}
+	entry := &table.Entries[idx]
+	if entry.Tag != p.tableTag(meta.PC, &meta.Checkpoint, meta.ProviderTable) || entry.Context != meta.Ctx {
+		return nil
+	}
+	return entry
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -1124,7 +1662,7 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 //
 // ACTIONS:
 //   1. Update base predictor counter (always)
-//   2. Find matching history table entry (using cached metadata if available)
+//   2. Find matching history table entry (provider from the branch's metadata)
 //   3. Update matching entry's counter
 //   4. Set useful bit (entry contributed to correct prediction)
 //   5. Shift new outcome into history register
@@ -1159,9 +1697,9 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 //       update_counter_hysteresis(tables[0].entries[base_idx], taken);
 //
 //       // Update matching history entry (if any)
-//       if (last_prediction.provider_table >= 1) begin
+//       if (meta.provider_table >= 1) begin
 //         update_counter_hysteresis(
-//           tables[last_prediction.provider_table].entries[last_prediction.provider_index],
+//           tables[meta.provider_table].entries[meta.provider_index],
 //           taken
 //         );
 //         tables[...].entries[...].useful <= 1'b1;
@@ -1175,16 +1713,21 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
+	meta := p.Lookup(pc, ctx)
+	p.trainCorrect(&meta, taken)
+
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Input validation
+	// STAGE 4: Update global history
+	// Shift left and OR in new outcome bit
+	//
+	// SystemVerilog:
+	//   history[ctx] <= {history[ctx][62:0], taken};
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	if ctx >= NumContexts {
-		ctx = 0
-	}
-
-	history := p.History[ctx]
-	tag := hashTag(pc)
+	p.shiftHistory(meta.Ctx, taken)
+}
 
+// trainCorrect performs stages 1-3 of Update() for one branch's metadata.
+func (p *TAGEPredictor) trainCorrect(meta *PredictionMetadata, taken bool) {
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// STAGE 1: Update base predictor (Table 0)
 	// Always update base predictor regardless of which table provided prediction
@@ -1193,21 +1736,21 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 	//   logic [9:0] base_idx = hash_index(pc, 0, 0, 0);
 	//   update_counter_hysteresis(tables[0].entries[base_idx], taken);
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	baseIdx := hashIndex(pc, 0, 0, 0)
+	baseIdx := p.tableIndex(meta.PC, &meta.Checkpoint, 0)
 	baseEntry := &p.Tables[0].Entries[baseIdx]
 
 	// Counter update with hysteresis
 	// Strong predictions reinforced by 2, weak by 1
 	delta := 1
-	if (taken && baseEntry.Counter >= 6) || (!taken && baseEntry.Counter <= 1) {
+	if (taken && baseEntry.Counter >= p.MaxCounter-1) || (!taken && baseEntry.Counter <= 1) {
 		delta = 2 // Strong reinforcement
 	}
 
 	var newCounter int
 	if taken {
 		newCounter = int(baseEntry.Counter) + delta
-		if newCounter > MaxCounter {
-			newCounter = MaxCounter
+		if newCounter > int(p.MaxCounter) {
+			newCounter = int(p.MaxCounter)
 		}
 	} else {
 		newCounter = int(baseEntry.Counter) - delta
@@ -1220,40 +1763,14 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// STAGE 2: Find matching history table entry
-	// Use cached metadata if available (from recent Predict() call)
+	// The provider recorded in the branch's metadata, if it still holds this branch
 	//
 	// SystemVerilog:
-	//   tage_entry_t *matched_entry = NULL;
-	//   if (last_pc == pc && last_ctx == ctx && last_prediction.provider_entry != NULL) begin
-	//     matched_entry = last_prediction.provider_entry;
-	//   end else begin
-	//     // Fallback: search tables
-	//   end
+	//   matched = meta.provider_table >= 1 &&
+	//             valid[meta.provider_table][meta.provider_index] &&
+	//             entry.tag == hash_tag(meta.pc) && entry.context == meta.ctx;
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	var matchedEntry *TAGEEntry
-
-	if p.LastPC == pc && p.LastCtx == ctx && p.LastPrediction.ProviderEntry != nil {
-		// Use cached result from Predict()
-		matchedEntry = p.LastPrediction.ProviderEntry
-	} else {
-		// Fallback: search for matching entry (longest match first)
-		for i := NumTables - 1; i >= 1; i-- {
-			table := &p.Tables[i]
-			idx := hashIndex(pc, history, table.HistoryLen, i)
-
-			wordIdx := idx >> 6
-			bitIdx := idx & 63
-			if (table.ValidBits[wordIdx]>>bitIdx)&1 == 0 {
-				continue // Entry not valid
-			}
-
-			entry := &table.Entries[idx]
-			if entry.Tag == tag && entry.Context == ctx {
-				matchedEntry = entry
-				break
-			}
-		}
-	}
+	matchedEntry := p.providerEntry(meta)
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// STAGE 3: Update matching entry if found
@@ -1267,14 +1784,14 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 	if matchedEntry != nil {
 		// Counter update with hysteresis
 		delta := 1
-		if (taken && matchedEntry.Counter >= 6) || (!taken && matchedEntry.Counter <= 1) {
+		if (taken && matchedEntry.Counter >= p.MaxCounter-1) || (!taken && matchedEntry.Counter <= 1) {
 			delta = 2
 		}
 
 		if taken {
 			newCounter := int(matchedEntry.Counter) + delta
-			if newCounter > MaxCounter {
-				newCounter = MaxCounter
+			if newCounter > int(p.MaxCounter) {
+				newCounter = int(p.MaxCounter)
 			}
 			matchedEntry.Counter = uint8(newCounter)
 		} else {
@@ -1290,29 +1807,6 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 		// Set useful bit - entry contributed to correct prediction
 		matchedEntry.Useful = true
 	}
-
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// STAGE 4: Update global history
-	// Shift left and OR in new outcome bit
-	//
-	// SystemVerilog:
-	//   history[ctx] <= {history[ctx][62:0], taken};
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	var takenBit uint64 = 0
-	if taken {
-		takenBit = 1
-	}
-	p.History[ctx] = (history << 1) | takenBit
-
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Clear cached metadata (prediction consumed)
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	p.LastPC = 0
-	p.LastCtx = 0
-	p.LastPrediction = PredictionMetadata{
-		ProviderTable: -1,
-		ProviderEntry: nil,
-	}
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -1386,66 +1880,37 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 func (p *TAGEPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
+	meta := p.Lookup(pc, ctx)
+	p.trainMispredict(&meta, actualTaken)
+
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Input validation
+	// Update history register
+	//
+	// SystemVerilog:
+	//   history[ctx] <= {history[ctx][62:0], actual_taken};
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	if ctx >= NumContexts {
-		ctx = 0
-	}
+	p.shiftHistory(meta.Ctx, actualTaken)
+
+	p.tickAging()
+}
 
-	history := p.History[ctx]
-	tag := hashTag(pc)
+// trainMispredict updates the base predictor and provider and allocates longer-history
+// entries for one branch's metadata. History used for allocation is the checkpoint.
+func (p *TAGEPredictor) trainMispredict(meta *PredictionMetadata, actualTaken bool) {
+	pc := meta.PC
+	ctx := meta.Ctx
+	cp := &meta.Checkpoint
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Update base predictor toward actual outcome
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	baseIdx := hashIndex(pc, 0, 0, 0)
+	baseIdx := p.tableIndex(pc, cp, 0)
 	baseEntry := &p.Tables[0].Entries[baseIdx]
-	updateCounterWithHysteresis(baseEntry, actualTaken)
+	updateCounterWithHysteresis(baseEntry, actualTaken, p.MaxCounter)
 	baseEntry.Taken = actualTaken
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Find provider table (use cached if available)
-	//
-	// SystemVerilog:
-	//   int provider_table = -1;
-	//   logic [9:0] provider_idx;
-	//   if (last_pc == pc && last_ctx == ctx) begin
-	//     provider_table = last_prediction.provider_table;
-	//     provider_idx = last_prediction.provider_index;
-	//   end else begin
-	//     // Search for provider
-	//   end
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	var providerTable int = -1
-	var providerIdx uint32
-
-	if p.LastPC == pc && p.LastCtx == ctx && p.LastPrediction.ProviderEntry != nil {
-		providerTable = p.LastPrediction.ProviderTable
-		providerIdx = p.LastPrediction.ProviderIndex
-	} else {
-		// Search for provider (longest match first)
-		for i := NumTables - 1; i >= 1; i-- {
-			table := &p.Tables[i]
-			idx := hashIndex(pc, history, table.HistoryLen, i)
-
-			wordIdx := idx >> 6
-			bitIdx := idx & 63
-			if (table.ValidBits[wordIdx]>>bitIdx)&1 == 0 {
-				continue
-			}
-
-			entry := &table.Entries[idx]
-			if entry.Tag == tag && entry.Context == ctx {
-				providerTable = i
-				providerIdx = idx
-				break
-			}
-		}
-	}
-
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Update provider if found, then consider allocation
+	// Update provider if found, then consider allocation
 	//
 	// SystemVerilog:
 	//   if (provider_table >= 1) begin
@@ -1463,45 +1928,35 @@ func (p *TAGEPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
 	//     allocate_entry(tables[1], ...);
 	//   end
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	if providerTable >= 1 {
-		entry := &p.Tables[providerTable].Entries[providerIdx]
-		updateCounterWithHysteresis(entry, actualTaken)
+	if entry := p.providerEntry(meta); entry != nil {
+		updateCounterWithHysteresis(entry, actualTaken, p.MaxCounter)
 		entry.Taken = actualTaken
 		entry.Useful = false // Mispredicted, so not useful
 		entry.Age = 0
 
 		// Allocate to longer tables if provider was uncertain
-		if shouldAllocate(entry.Counter) {
-			allocateToLongerTables(p, providerTable, pc, ctx, tag, history, actualTaken)
+		if shouldAllocate(entry.Counter, p.MaxCounter) {
+			allocateToLongerTables(p, meta.ProviderTable, pc, ctx, cp, actualTaken)
 		}
 	} else {
 		// No provider found: allocate to Table 1
-		allocateEntry(&p.Tables[1], 1, pc, ctx, tag, history, actualTaken)
+		allocateEntry(p, 1, pc, ctx, cp, actualTaken)
 	}
+}
 
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Update history register
-	//
-	// SystemVerilog:
-	//   history[ctx] <= {history[ctx][62:0], actual_taken};
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	var takenBit uint64 = 0
-	if actualTaken {
-		takenBit = 1
-	}
-	p.History[ctx] = (p.History[ctx] << 1) | takenBit
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// tickAging counts a misprediction and triggers periodic aging.
+//
+// SystemVerilog:
+//   branch_count <= branch_count + 1;
+//   if (aging_enabled && (branch_count % AGING_INTERVAL == 0)) begin
+//     age_all_entries();
+//   end
+// ───────────────────────────────────────────────────────────────────────────────────────────────
 
-	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Periodic aging
-	//
-	// SystemVerilog:
-	//   branch_count <= branch_count + 1;
-	//   if (aging_enabled && (branch_count % AGING_INTERVAL == 0)) begin
-	//     age_all_entries();
-	//   end
-	// ─────────────────────────────────────────────────────────────────────────────────────────
+func (p *TAGEPredictor) tickAging() {
 	p.BranchCount++
-	if p.AgingEnabled && p.BranchCount%AgingInterval == 0 {
+	if p.AgingEnabled && p.BranchCount%p.Config.AgingInterval == 0 {
 		p.AgeAllEntries()
 	}
 }
@@ -1520,6 +1975,8 @@ func (p *TAGEPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
 // RATIONALE:
 //   Counter in [2,5] = "uncertain" prediction
 //   Counter in [0,1] or [6,7] = "confident" prediction
+//   (For other counter widths the window is [m, MaxCounter-m] with
+//    m = min(2, (MaxCounter+1)/4), so 2-bit counters allocate on [1,2].)
 //
 //   Allocate only on uncertain mispredictions:
 //   - Uncertain wrong means we need more history to disambiguate
@@ -1536,8 +1993,12 @@ func (p *TAGEPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
 // ───────────────────────────────────────────────────────────────────────────────────────────────
 
 //go:inline
-func shouldAllocate(counter uint8) bool {
-	return counter >= AllocOnWeakThreshold && counter <= AllocOnStrongMax
+func shouldAllocate(counter uint8, maxCounter uint8) bool {
+	margin := uint8(AllocOnWeakThreshold)
+	if narrow := (maxCounter + 1) / 4; narrow < margin {
+		margin = narrow // 2-bit counters: only the two weak states are uncertain
+	}
+	return counter >= margin && counter <= maxCounter-margin
 }
 
 // ───────────────────────────────────────────────────────────────────────────────────────────────
@@ -1566,7 +2027,6 @@ func shouldAllocate(counter uint8) bool {
 //     int provider_table,
 //     logic [63:0] pc,
 //     logic [2:0] ctx,
-//     logic [12:0] tag,
 //     logic [63:0] history,
 //     logic taken
 //   );
@@ -1578,7 +2038,7 @@ func shouldAllocate(counter uint8) bool {
 //       // Probabilistic allocation using PC bits
 //       logic [7:0] prob = 256 / offset;  // 256, 128, 85
 //       if (pc[offset +: 8] < prob) begin
-//         allocate_entry(tables[target_table], target_table, pc, ctx, tag, history, taken);
+//         allocate_entry(tables[target_table], target_table, pc, ctx, history, taken);
 //         allocated++;
 //       end
 //     end
@@ -1586,13 +2046,13 @@ func shouldAllocate(counter uint8) bool {
 //
 // ───────────────────────────────────────────────────────────────────────────────────────────────
 
-func allocateToLongerTables(p *TAGEPredictor, providerTable int, pc uint64, ctx uint8, tag uint16, history uint64, taken bool) {
+func allocateToLongerTables(p *TAGEPredictor, providerTable int, pc uint64, ctx uint8, cp *HistoryCheckpoint, taken bool) {
 	allocated := 0
 	maxAllocations := 3
 
 	for offset := 1; offset <= 3 && allocated < maxAllocations; offset++ {
 		targetTable := providerTable + offset
-		if targetTable >= NumTables {
+		if targetTable >= len(p.Tables) {
 			break
 		}
 
@@ -1600,7 +2060,7 @@ func allocateToLongerTables(p *TAGEPredictor, providerTable int, pc uint64, ctx
 		// Use PC bits as pseudo-random source
 		prob := uint64(256) / uint64(offset) // 256, 128, 85
 		if (pc>>offset)&0xFF < prob {
-			allocateEntry(&p.Tables[targetTable], targetTable, pc, ctx, tag, history, taken)
+			allocateEntry(p, targetTable, pc, ctx, cp, taken)
 			allocated++
 		}
 	}
@@ -1610,7 +2070,7 @@ func allocateToLongerTables(p *TAGEPredictor, providerTable int, pc uint64, ctx
 // allocateEntry creates a new entry in the specified table.
 //
 // ALGORITHM:
-//   1. Compute index for this table
+//   1. Compute index and tag for this table
 //   2. Find victim entry using LRU
 //   3. Initialize new entry with tag, context, and counter matching outcome
 //
@@ -1628,11 +2088,11 @@ func allocateToLongerTables(p *TAGEPredictor, providerTable int, pc uint64, ctx
 //     int table_num,
 //     logic [63:0] pc,
 //     logic [2:0] ctx,
-//     logic [12:0] tag,
 //     logic [63:0] history,
 //     logic taken
 //   );
 //     logic [9:0] idx = hash_index(pc, history, table.history_len, table_num);
+//     logic [12:0] tag = hash_tag(pc);
 //     logic [9:0] victim_idx = find_lru_victim(table, idx);
 //
 //     // Initialize counter to match outcome
@@ -1652,9 +2112,12 @@ func allocateToLongerTables(p *TAGEPredictor, providerTable int, pc uint64, ctx
 //
 // ───────────────────────────────────────────────────────────────────────────────────────────────
 
-func allocateEntry(table *TAGETable, tableNum int, pc uint64, ctx uint8, tag uint16, history uint64, taken bool) {
-	// Compute index
-	idx := hashIndex(pc, history, table.HistoryLen, tableNum)
+func allocateEntry(p *TAGEPredictor, tableNum int, pc uint64, ctx uint8, cp *HistoryCheckpoint, taken bool) {
+	table := &p.Tables[tableNum]
+
+	// Compute index and tag (from the branch's predict-time history)
+	idx := p.tableIndex(pc, cp, tableNum)
+	tag := p.tableTag(pc, cp, tableNum)
 
 	// Find victim using LRU
 	victimIdx := findLRUVictim(table, idx)
@@ -1662,9 +2125,9 @@ func allocateEntry(table *TAGETable, tableNum int, pc uint64, ctx uint8, tag uin
 	// Initialize counter to match outcome
 	var counter uint8
 	if taken {
-		counter = NeutralCounter + 1 // 5 = weak taken
+		counter = p.neutralCounter() + 1 // 5 = weak taken
 	} else {
-		counter = NeutralCounter - 1 // 3 = weak not-taken
+		counter = p.neutralCounter() - 1 // 3 = weak not-taken
 	}
 
 	// Write new entry
@@ -1753,7 +2216,7 @@ func findLRUVictim(table *TAGETable, preferredIdx uint32) uint32 {
 	endOffset := int32(LRUSearchWidth / 2)
 
 	for offset := startOffset; offset < endOffset; offset++ {
-		idx := uint32(int32(preferredIdx)+offset) & (EntriesPerTable - 1)
+		idx := uint32(int32(preferredIdx)+offset) & uint32(len(table.Entries)-1)
 
 		wordIdx := idx >> 6
 		bitIdx := idx & 63
@@ -1823,12 +2286,12 @@ func findLRUVictim(table *TAGETable, preferredIdx uint32) uint32 {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 //go:inline
-func updateCounterWithHysteresis(entry *TAGEEntry, taken bool) {
-	counter := int8(entry.Counter)
+func updateCounterWithHysteresis(entry *TAGEEntry, taken bool, maxCounter uint8) {
+	counter := int16(entry.Counter)
 
 	// Hysteresis: stronger predictions reinforced faster
-	delta := int8(1)
-	if (taken && counter >= 6) || (!taken && counter <= 1) {
+	delta := int16(1)
+	if (taken && counter >= int16(maxCounter)-1) || (!taken && counter <= 1) {
 		delta = 2 // Strong reinforcement
 	}
 
@@ -1842,8 +2305,8 @@ func updateCounterWithHysteresis(entry *TAGEEntry, taken bool) {
 	// Saturate to [0, MaxCounter]
 	if counter < 0 {
 		counter = 0
-	} else if counter > MaxCounter {
-		counter = MaxCounter
+	} else if counter > int16(maxCounter) {
+		counter = int16(maxCounter)
 	}
 
 	entry.Counter = uint8(counter)
@@ -1909,11 +2372,11 @@ func updateCounterWithHysteresis(entry *TAGEEntry, taken bool) {
 
 func (p *TAGEPredictor) AgeAllEntries() {
 	// Skip Table 0 (base predictor - always valid, never replaced)
-	for t := 1; t < NumTables; t++ {
+	for t := 1; t < len(p.Tables); t++ {
 		table := &p.Tables[t]
 
 		// Fast bitmap scan (skip empty words)
-		for w := 0; w < ValidBitmapWords; w++ {
+		for w := range table.ValidBits {
 			validMask := table.ValidBits[w]
 			if validMask == 0 {
 				continue // Skip empty word
@@ -1955,7 +2418,6 @@ func (p *TAGEPredictor) AgeAllEntries() {
 //   1. Clear all history registers to 0
 //   2. Invalidate all entries in tables 1-7
 //   3. Reset branch count
-//   4. Clear cached metadata
 //
 // DOES NOT CHANGE:
 //   - Base predictor (Table 0) remains fully valid with neutral counters
@@ -1992,18 +2454,17 @@ func (p *TAGEPredictor) Reset() {
 	// Clear history registers
 	for ctx := 0; ctx < NumContexts; ctx++ {
 		p.History[ctx] = 0
+		clear(p.LongHistory[ctx].Bits)
+		p.LongHistory[ctx].Head = 0
+		p.Folded[ctx] = [MaxTables]FoldedHistory{}
 	}
 
 	// Invalidate history tables (word-level clear)
-	for t := 1; t < NumTables; t++ {
-		p.Tables[t].ValidBits = [ValidBitmapWords]uint64{}
+	for t := 1; t < len(p.Tables); t++ {
+		clear(p.Tables[t].ValidBits)
 	}
 
 	p.BranchCount = 0
-
-	// Clear cached metadata
-	p.LastPrediction.ProviderTable = -1
-	p.LastPrediction.ProviderEntry = nil
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -2016,22 +2477,28 @@ func (p *TAGEPredictor) Reset() {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 type TAGEStats struct {
-	BranchCount    uint64             // Total branches processed
-	EntriesUsed    [NumTables]uint32  // Valid entries per table
-	AverageAge     [NumTables]float32 // Mean age per table
-	UsefulEntries  [NumTables]uint32  // Entries with useful=true per table
-	AverageCounter [NumTables]float32 // Mean counter value per table
+	BranchCount    uint64    // Total branches processed
+	EntriesUsed    []uint32  // Valid entries per table
+	AverageAge     []float32 // Mean age per table
+	UsefulEntries  []uint32  // Entries with useful=true per table
+	AverageCounter []float32 // Mean counter value per table
 }
 
 func (p *TAGEPredictor) Stats() TAGEStats {
-	var stats TAGEStats
-	stats.BranchCount = p.BranchCount
+	n := len(p.Tables)
+	stats := TAGEStats{
+		BranchCount:    p.BranchCount,
+		EntriesUsed:    make([]uint32, n),
+		AverageAge:     make([]float32, n),
+		UsefulEntries:  make([]uint32, n),
+		AverageCounter: make([]float32, n),
+	}
 
-	for t := 0; t < NumTables; t++ {
+	for t := 0; t < n; t++ {
 		var totalAge, totalCounter uint64
 		var validCount, usefulCount uint32
 
-		for i := 0; i < EntriesPerTable; i++ {
+		for i := range p.Tables[t].Entries {
 			wordIdx := i >> 6
 			bitIdx := i & 63
 
@@ -2058,6 +2525,711 @@ func (p *TAGEPredictor) Stats() TAGEStats {
 	return stats
 }
 
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TAGE-SC-L: LOOP PREDICTOR AND STATISTICAL CORRECTOR
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// The tagged tables above capture patterns that show up in global history. Two pattern
+// classes are awkward for them:
+//
+//   FIXED-TRIP-COUNT LOOPS:
+//     A loop that runs 37 iterations needs 37+ bits of history to see its own exit.
+//     Even when the history is long enough, every iteration allocates a different
+//     history pattern, so the exit is learned slowly and evicted quickly.
+//     The LOOP PREDICTOR simply counts iterations and remembers the trip count.
+//
+//   STATISTICALLY BIASED BRANCHES:
+//     Some branches are only weakly correlated with history. TAGE's counters hover in
+//     the weak range and flip on noise. The STATISTICAL CORRECTOR (SC) is a small set
+//     of untagged signed-weight tables, indexed by PC, history and the TAGE prediction
+//     itself, that learns when a weak TAGE prediction should be reversed.
+//
+// FINAL PREDICTION PRIORITY:
+//   1. Loop predictor, if its entry hits and has saturated confidence
+//   2. SC, if it disagrees with TAGE, |sum| ≥ SCThreshold and TAGE was not high confidence
+//   3. TAGE
+//
+// CONTEXT ISOLATION:
+//   Loop entries carry a 3-bit context ID exactly like TAGEEntry.Context - a hit needs
+//   both tag AND context to match. SC entries carry the same 3-bit context ID; an entry
+//   owned by another context contributes nothing to the sum and is reclaimed (weight
+//   cleared) before it is trained. An attacker in context 3 can therefore neither read
+//   nor bias the victim's loop counts or correction weights.
+//
+// Hardware: ~72K transistors on top of the TAGE tables
+//   Loop table:  64 × 39 bits × 6T  = ~15K
+//   SC tables:   4 × 256 × 9 bits × 6T = ~55K (weights + context)
+//   Adder tree:  4-input signed adder = ~2K
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+const (
+	// ───────────────────────────────────────────────────────────────────────────────────────────
+	// LOOP PREDICTOR CONFIGURATION
+	// ───────────────────────────────────────────────────────────────────────────────────────────
+
+	// LoopIndexWidth: Bits used to index the loop table.
+	// 6 bits = 64 entries (loops with predictable trip counts are few but hot).
+	// Hardware: 6-bit address to loop table.
+	LoopIndexWidth = 6
+
+	// LoopEntries: Entries in the loop table.
+	// Hardware: 64-entry flip-flop array.
+	LoopEntries = 1 << LoopIndexWidth // 64
+
+	// LoopTagWidth: Partial PC tag stored in each loop entry.
+	// Hardware: 10-bit comparator.
+	LoopTagWidth = 10
+
+	// LoopTagMask: Mask for 10-bit loop tag.
+	LoopTagMask = (1 << LoopTagWidth) - 1 // 0x3FF
+
+	// LoopIterWidth: Width of the iteration counters.
+	// 10 bits = trip counts up to 1023.
+	// Hardware: Two 10-bit counters per entry (past and current).
+	LoopIterWidth = 10
+
+	// LoopMaxIter: Largest trackable trip count. Longer loops free their entry.
+	LoopMaxIter = (1 << LoopIterWidth) - 1 // 1023
+
+	// LoopConfidenceWidth: Confidence counter width.
+	// Prediction is used only once the same trip count was seen 3 times in a row.
+	// Hardware: 2-bit saturating counter.
+	LoopConfidenceWidth = 2
+
+	// LoopMaxConfidence: Confidence at which the loop prediction is used.
+	LoopMaxConfidence = (1 << LoopConfidenceWidth) - 1 // 3
+
+	// LoopAgeWidth: Replacement age.
+	// Set to max on allocation, decremented by failed allocation attempts,
+	// incremented when the loop predictor fixed a TAGE misprediction.
+	// Hardware: 3-bit saturating counter.
+	LoopAgeWidth = 3
+
+	// LoopMaxAge: Age assigned to a freshly allocated entry.
+	LoopMaxAge = (1 << LoopAgeWidth) - 1 // 7
+
+	// ───────────────────────────────────────────────────────────────────────────────────────────
+	// STATISTICAL CORRECTOR CONFIGURATION
+	// ───────────────────────────────────────────────────────────────────────────────────────────
+
+	// SCNumTables: Number of SC weight tables (one bias table + three history tables).
+	// Hardware: 4 parallel SRAM banks feeding a 4-input adder.
+	SCNumTables = 4
+
+	// SCIndexWidth: Bits used to index each SC table.
+	// Hardware: 8-bit address per bank.
+	SCIndexWidth = 8
+
+	// SCEntriesPerTable: Weights per SC table.
+	SCEntriesPerTable = 1 << SCIndexWidth // 256
+
+	// SCWeightWidth: Signed weight width.
+	// 6 bits = range [-32, +31].
+	// Hardware: 6-bit signed saturating counter.
+	SCWeightWidth = 6
+
+	// SCWeightMax / SCWeightMin: Saturation bounds for weights.
+	SCWeightMax = (1 << (SCWeightWidth - 1)) - 1 // 31
+	SCWeightMin = -(1 << (SCWeightWidth - 1))    // -32
+
+	// SCThreshold: |sum| needed before SC may override TAGE.
+	// Also the training threshold: weights keep training until the sum clears it.
+	// Hardware: Magnitude comparator on adder output.
+	SCThreshold = 16
+)
+
+// SCHistoryLengths defines the history length of each SC table.
+// Table 0 is a per-PC bias table; the rest add short/medium global history.
+//
+// SystemVerilog equivalent:
+//   parameter int SC_HISTORY_LENGTHS [0:3] = '{0, 4, 8, 16};
+
+var SCHistoryLengths = [SCNumTables]int{0, 4, 8, 16}
+
+// Prediction sources for the combined TAGE-SC-L output.
+const (
+	SourceTAGE = 0 // Tagged tables (or base predictor)
+	SourceLoop = 1 // Loop predictor override
+	SourceSC   = 2 // Statistical corrector override
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// LOOP PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// LoopEntry tracks one loop-closing branch.
+//
+// FIELDS:
+//   Tag:         Partial PC hash (10 bits)
+//   Context:     Hardware context ID (3 bits) - same isolation rule as TAGEEntry
+//   PastIter:    Body iterations seen in the last complete trip (10 bits)
+//   CurrentIter: Body iterations seen so far in the current trip (10 bits)
+//   Confidence:  Number of consecutive trips with the same count (2 bits)
+//   Age:         Replacement protection (3 bits)
+//   Dir:         Direction of the loop body (the exit is !Dir) (1 bit)
+//
+// TOTAL: 39 bits per entry
+//
+// EXAMPLE (for i := 0; i < 4; i++, loop branch taken 3×, then not taken):
+//   Outcomes: T T T N | T T T N | ...
+//   Dir = taken, PastIter = 3
+//   Prediction: taken while CurrentIter < 3, not taken when CurrentIter == 3
+//
+// SystemVerilog equivalent:
+//   typedef struct packed {
+//     logic [9:0] tag;
+//     logic [2:0] context;
+//     logic [9:0] past_iter;
+//     logic [9:0] current_iter;
+//     logic [1:0] confidence;
+//     logic [2:0] age;
+//     logic       dir;
+//   } loop_entry_t;          // 39 bits total
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+type LoopEntry struct {
+	Tag         uint16 // Partial PC hash (10 bits used)
+	Context     uint8  // Hardware context ID (3 bits used)
+	PastIter    uint16 // Learned trip count (10 bits used)
+	CurrentIter uint16 // Iterations in current trip (10 bits used)
+	Confidence  uint8  // Consecutive matching trips (2 bits used)
+	Age         uint8  // Replacement protection (3 bits used)
+	Dir         bool   // Loop body direction
+}
+
+// LoopPredictor is a small direct-mapped table of loop entries.
+//
+// Hardware: 64 × 39-bit flip-flop array + 64-bit valid register
+type LoopPredictor struct {
+	Entries   [LoopEntries]LoopEntry // 64 entries
+	ValidBits uint64                 // One valid bit per entry
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// hashLoop maps a PC to a loop table index and tag.
+//
+// ALGORITHM:
+//   Golden ratio multiply, then take the top 6 bits as index and the next 10 as tag.
+//   Loop branches are usually a handful of bytes apart, so low PC bits must reach
+//   the index - multiplicative hashing moves them into the high bits.
+//
+// SystemVerilog:
+//   wire [63:0] h   = pc * 64'h9E3779B97F4A7C15;
+//   wire [5:0]  idx = h[63:58];
+//   wire [9:0]  tag = h[57:48];
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+//go:inline
+func hashLoop(pc uint64) (idx uint32, tag uint16) {
+	h := pc * HashPrime
+	idx = uint32(h >> (64 - LoopIndexWidth))
+	tag = uint16(h>>(64-LoopIndexWidth-LoopTagWidth)) & LoopTagMask
+	return idx, tag
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// lookup returns the entry for (pc, ctx) if it is valid and both tag and context match.
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (l *LoopPredictor) lookup(pc uint64, ctx uint8) (*LoopEntry, bool) {
+	idx, tag := hashLoop(pc)
+	entry := &l.Entries[idx]
+	if (l.ValidBits>>idx)&1 == 0 {
+		return entry, false
+	}
+	return entry, (entry.Tag^tag)|uint16(entry.Context^ctx) == 0
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// loopEntryPredict is the combinational prediction for one entry.
+//
+//   valid = confidence == MAX
+//   taken = (current_iter == past_iter) ? !dir : dir
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+//go:inline
+func loopEntryPredict(entry *LoopEntry) (valid bool, taken bool) {
+	if entry.Confidence < LoopMaxConfidence {
+		return false, false
+	}
+	if entry.CurrentIter == entry.PastIter {
+		return true, !entry.Dir // Trip count reached: predict exit
+	}
+	return true, entry.Dir
+}
+
+// Predict returns the loop prediction for (pc, ctx). valid is false unless the entry hits
+// and has seen the same trip count LoopMaxConfidence times in a row.
+func (l *LoopPredictor) Predict(pc uint64, ctx uint8) (valid bool, taken bool) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+	entry, hit := l.lookup(pc, ctx)
+	if !hit {
+		return false, false
+	}
+	return loopEntryPredict(entry)
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// Update trains the loop predictor with the resolved outcome.
+//
+// ON HIT:
+//   taken == Dir: another body iteration. Running past the learned trip count
+//                 means the count changed - drop confidence.
+//   taken != Dir: loop exit. Same count as last trip → confidence++,
+//                 otherwise remember the new count and restart confidence.
+//                 An exit with zero body iterations is not a loop: free the entry.
+//
+// ON MISS:
+//   Allocate only when TAGE mispredicted (the loop predictor exists to fix TAGE).
+//   The mispredicted outcome is assumed to be the exit, so Dir = !taken.
+//   An occupied slot is only replaced once its age has decayed to 0.
+//
+// SystemVerilog:
+//   always_ff @(posedge clk) begin
+//     if (update_en) begin
+//       if (hit) begin
+//         if (taken == entry.dir) begin
+//           entry.current_iter <= entry.current_iter + 1;
+//           if (entry.past_iter != 0 && entry.current_iter + 1 > entry.past_iter)
+//             entry.confidence <= 0;
+//         end else begin
+//           ...
+//         end
+//       end else if (tage_mispredicted) begin
+//         if (valid && entry.age != 0) entry.age <= entry.age - 1;
+//         else entry <= '{tag, ctx, 0, 0, 0, LOOP_MAX_AGE, !taken};
+//       end
+//     end
+//   end
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (l *LoopPredictor) Update(pc uint64, ctx uint8, taken bool, tageMispredicted bool) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+
+	idx, tag := hashLoop(pc)
+	entry, hit := l.lookup(pc, ctx)
+
+	if hit {
+		// Useful: loop predictor fixed a TAGE misprediction → protect entry
+		if valid, predicted := loopEntryPredict(entry); valid && predicted == taken && tageMispredicted {
+			if entry.Age < LoopMaxAge {
+				entry.Age++
+			}
+		}
+
+		if taken == entry.Dir {
+			// Body iteration
+			entry.CurrentIter++
+			if entry.CurrentIter > LoopMaxIter {
+				l.ValidBits &^= 1 << idx // Too long to track
+				return
+			}
+			if entry.PastIter != 0 && entry.CurrentIter > entry.PastIter {
+				entry.Confidence = 0 // Ran past learned trip count
+			}
+			return
+		}
+
+		// Loop exit
+		if entry.CurrentIter == 0 {
+			l.ValidBits &^= 1 << idx // Two exits in a row: not a loop
+			return
+		}
+		if entry.CurrentIter == entry.PastIter {
+			if entry.Confidence < LoopMaxConfidence {
+				entry.Confidence++
+			}
+		} else {
+			entry.PastIter = entry.CurrentIter
+			entry.Confidence = 0
+		}
+		entry.CurrentIter = 0
+		return
+	}
+
+	if !tageMispredicted {
+		return
+	}
+
+	// Occupied slot (by another loop or another context) decays before replacement
+	if (l.ValidBits>>idx)&1 != 0 && entry.Age > 0 {
+		entry.Age--
+		return
+	}
+
+	*entry = LoopEntry{
+		Tag:     tag,
+		Context: ctx,
+		Age:     LoopMaxAge,
+		Dir:     !taken,
+	}
+	l.ValidBits |= 1 << idx
+}
+
+// Reset invalidates every loop entry.
+func (l *LoopPredictor) Reset() {
+	l.ValidBits = 0
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// STATISTICAL CORRECTOR
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// SCEntry is one signed weight plus its owning context.
+//
+// SUM:
+//   sum = Σ (2 × weight + 1) over all tables whose entry belongs to ctx
+//   SC predicts taken when sum ≥ 0. |sum| is the confidence.
+//
+// WHY THE TAGE PREDICTION IS PART OF THE INDEX:
+//   The same (PC, history) splits into two entries: "TAGE said taken" and "TAGE said
+//   not taken". Each learns how often that particular TAGE answer turned out wrong,
+//   which is exactly what a corrector needs to know.
+//
+// TRAINING (perceptron rule):
+//   Train when SC was wrong OR |sum| < SCThreshold.
+//   Each weight moves one step toward the outcome (saturating).
+//
+// SystemVerilog equivalent:
+//   typedef struct packed {
+//     logic signed [5:0] weight;
+//     logic [2:0]        context;
+//   } sc_entry_t;            // 9 bits
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+type SCEntry struct {
+	Weight  int8  // Signed weight (6 bits used)
+	Context uint8 // Hardware context ID (3 bits used)
+}
+
+// SCTable is one SC weight bank.
+type SCTable struct {
+	Entries    [SCEntriesPerTable]SCEntry // 256 weights
+	HistoryLen int                        // History bits used (constant per table)
+}
+
+// StatisticalCorrector holds all SC weight banks.
+type StatisticalCorrector struct {
+	Tables [SCNumTables]SCTable
+}
+
+// NewStatisticalCorrector returns an SC with zero weights and configured history lengths.
+func NewStatisticalCorrector() StatisticalCorrector {
+	var sc StatisticalCorrector
+	for i := 0; i < SCNumTables; i++ {
+		sc.Tables[i].HistoryLen = SCHistoryLengths[i]
+	}
+	return sc
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// scHashIndex maps (PC, history, TAGE prediction) to an SC table index.
+//
+// SystemVerilog:
+//   wire [63:0] h   = history & ((1 << history_len) - 1);
+//   wire [63:0] key = pc ^ (h << 1) ^ tage_taken ^ (table_num << 56);
+//   wire [7:0]  idx = (key * 64'h9E3779B97F4A7C15) >> 56;
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+//go:inline
+func scHashIndex(pc uint64, history uint64, historyLen int, tableNum int, tageTaken bool) uint32 {
+	var h uint64
+	if historyLen > 0 {
+		if historyLen > 64 {
+			historyLen = 64
+		}
+		h = history & (^uint64(0) >> (64 - historyLen))
+	}
+
+	var tageBit uint64
+	if tageTaken {
+		tageBit = 1
+	}
+
+	key := pc ^ (h << 1) ^ tageBit ^ (uint64(tableNum) << 56)
+	return uint32((key * HashPrime) >> (64 - SCIndexWidth))
+}
+
+// Sum computes the SC adder-tree output for (pc, ctx) given the context's history
+// and TAGE's prediction. Entries owned by another context contribute 0.
+func (sc *StatisticalCorrector) Sum(pc uint64, ctx uint8, history uint64, tageTaken bool) int32 {
+	var sum int32
+	for i := 0; i < SCNumTables; i++ {
+		table := &sc.Tables[i]
+		entry := &table.Entries[scHashIndex(pc, history, table.HistoryLen, i, tageTaken)]
+		if entry.Context != ctx {
+			continue
+		}
+		sum += 2*int32(entry.Weight) + 1
+	}
+	return sum
+}
+
+// Update trains the SC weights if the sum was wrong or not confident enough.
+// sum must be the value Sum returned for the same inputs at prediction time.
+func (sc *StatisticalCorrector) Update(pc uint64, ctx uint8, history uint64, tageTaken bool, taken bool, sum int32) {
+	magnitude := sum
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	if (sum >= 0) == taken && magnitude >= SCThreshold {
+		return // Correct and confident: leave weights alone
+	}
+
+	for i := 0; i < SCNumTables; i++ {
+		table := &sc.Tables[i]
+		entry := &table.Entries[scHashIndex(pc, history, table.HistoryLen, i, tageTaken)]
+
+		// Reclaim entry from another context before training it
+		if entry.Context != ctx {
+			entry.Context = ctx
+			entry.Weight = 0
+		}
+
+		if taken {
+			if entry.Weight < SCWeightMax {
+				entry.Weight++
+			}
+		} else {
+			if entry.Weight > SCWeightMin {
+				entry.Weight--
+			}
+		}
+	}
+}
+
+// Reset clears all weights and context ownership.
+func (sc *StatisticalCorrector) Reset() {
+	for i := 0; i < SCNumTables; i++ {
+		sc.Tables[i].Entries = [SCEntriesPerTable]SCEntry{}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TAGE-SC-L PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TAGESCLPredictor combines TAGE, the loop predictor and the statistical corrector.
+//
+// USAGE (one branch at a time):
+//   taken, conf := p.Predict(pc, ctx)
+//   ... branch resolves ...
+//   p.Update(pc, ctx, actualTaken)
+//
+// USAGE (many branches in flight):
+//   meta := p.PredictSpeculative(pc, ctx)   // history advanced with meta.Taken
+//   ... keep meta with the branch ...
+//   p.Resolve(meta, actualTaken)            // repairs history if meta.Taken was wrong
+//
+// Unlike the bare TAGEPredictor, the caller does not choose between Update and
+// OnMispredict: the final prediction may be right while TAGE's own prediction was
+// wrong (or vice versa), so Resolve routes TAGE training itself.
+//
+// SPECULATION SCOPE:
+//   Global history is speculative (it holds the FINAL prediction, which is what fetch
+//   follows). Loop iteration counts and SC weights are trained at resolve time only.
+//
+// SCLPredictionMetadata travels with the branch from predict to resolve.
+//
+// SystemVerilog equivalent:
+//   typedef struct packed {
+//     prediction_metadata_t tage;
+//     logic                 loop_valid;
+//     logic                 loop_taken;
+//     logic signed [9:0]    sc_sum;
+//     logic                 taken;
+//     logic [1:0]           source;
+//   } scl_prediction_metadata_t;
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+type SCLPredictionMetadata struct {
+	TAGE      PredictionMetadata // TAGE prediction, provider and history checkpoint
+	LoopValid bool               // Loop predictor had a confident prediction
+	LoopTaken bool               // Loop predictor's prediction
+	SCSum     int32              // SC adder output
+	Taken     bool               // Final prediction
+	Source    uint8              // SourceTAGE, SourceLoop or SourceSC
+}
+
+// TAGESCLStats counts how each component contributed. NOT synthesized.
+type TAGESCLStats struct {
+	Predictions        uint64 // Branches resolved
+	TAGECorrect        uint64 // TAGE alone was right
+	FinalCorrect       uint64 // Combined prediction was right
+	LoopProvided       uint64 // Final prediction came from the loop predictor
+	LoopCorrect        uint64 // ... and was right
+	SCOverrides        uint64 // Final prediction came from SC
+	SCOverridesCorrect uint64 // ... and was right
+}
+
+type TAGESCLPredictor struct {
+	TAGE  *TAGEPredictor       // Tagged tables + base predictor
+	Loop  LoopPredictor        // Loop predictor
+	SC    StatisticalCorrector // Statistical corrector
+	stats TAGESCLStats
+}
+
+// NewTAGESCLPredictor creates a TAGE-SC-L predictor with reset state.
+func NewTAGESCLPredictor() *TAGESCLPredictor {
+	return &TAGESCLPredictor{
+		TAGE: NewTAGEPredictor(),
+		SC:   NewStatisticalCorrector(),
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// Lookup returns the combined prediction and its metadata (pure read).
+//
+// All three components are read in parallel; the final MUX is:
+//   loop_valid                                         → loop_taken   (confidence 2)
+//   sc_taken != tage_taken && |sum| ≥ TH && tage_conf<2 → sc_taken     (confidence 1)
+//   otherwise                                          → tage_taken   (TAGE confidence)
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (p *TAGESCLPredictor) Lookup(pc uint64, ctx uint8) SCLPredictionMetadata {
+	tage := p.TAGE.Lookup(pc, ctx)
+	ctx = tage.Ctx
+
+	loopValid, loopTaken := p.Loop.Predict(pc, ctx)
+	scSum := p.SC.Sum(pc, ctx, tage.Checkpoint.History, tage.Predicted)
+
+	meta := SCLPredictionMetadata{
+		TAGE:      tage,
+		LoopValid: loopValid,
+		LoopTaken: loopTaken,
+		SCSum:     scSum,
+		Taken:     tage.Predicted,
+		Source:    SourceTAGE,
+	}
+
+	scTaken := scSum >= 0
+	magnitude := scSum
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+
+	switch {
+	case loopValid:
+		meta.Taken = loopTaken
+		meta.Source = SourceLoop
+	case scTaken != tage.Predicted && magnitude >= SCThreshold && tage.Confidence < 2:
+		meta.Taken = scTaken
+		meta.Source = SourceSC
+	}
+
+	return meta
+}
+
+// Confidence returns the output confidence for the final prediction.
+func (m *SCLPredictionMetadata) Confidence() uint8 {
+	switch m.Source {
+	case SourceLoop:
+		return 2
+	case SourceSC:
+		return 1
+	}
+	return m.TAGE.Confidence
+}
+
+// Predict returns the combined prediction without changing any state.
+func (p *TAGESCLPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence uint8) {
+	meta := p.Lookup(pc, ctx)
+	return meta.Taken, meta.Confidence()
+}
+
+// PredictSpeculative predicts and shifts the final prediction into the context's history.
+func (p *TAGESCLPredictor) PredictSpeculative(pc uint64, ctx uint8) SCLPredictionMetadata {
+	meta := p.Lookup(pc, ctx)
+
+	// Wire: history[ctx] <= {checkpoint[62:0], final_taken}
+	p.TAGE.Restore(meta.TAGE.Checkpoint, meta.Taken)
+	meta.TAGE.Speculative = true
+
+	return meta
+}
+
+// Update trains every component for a branch that was not predicted speculatively.
+func (p *TAGESCLPredictor) Update(pc uint64, ctx uint8, taken bool) {
+	p.Resolve(p.Lookup(pc, ctx), taken)
+}
+
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// Resolve trains every component with the resolved outcome of one branch.
+//
+// All history-indexed training (SC weights, TAGE allocation) uses the checkpointed
+// history in meta, so branches may resolve after younger branches were predicted.
+//
+// HISTORY:
+//   Speculative:     repaired only if the FINAL prediction was wrong
+//   Non-speculative: actual outcome shifted in
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+func (p *TAGESCLPredictor) Resolve(meta SCLPredictionMetadata, taken bool) {
+	tage := &meta.TAGE
+	ctx := tage.Ctx
+
+	p.SC.Update(tage.PC, ctx, tage.Checkpoint.History, tage.Predicted, taken, meta.SCSum)
+
+	tageMispredicted := tage.Predicted != taken
+	p.Loop.Update(tage.PC, ctx, taken, tageMispredicted)
+
+	if tageMispredicted {
+		p.TAGE.trainMispredict(tage, taken)
+		p.TAGE.tickAging()
+	} else {
+		p.TAGE.trainCorrect(tage, taken)
+	}
+
+	switch {
+	case !tage.Speculative:
+		p.TAGE.shiftHistory(ctx, taken)
+	case meta.Taken != taken:
+		p.TAGE.Restore(tage.Checkpoint, taken)
+	}
+
+	// Statistics
+	p.stats.Predictions++
+	if !tageMispredicted {
+		p.stats.TAGECorrect++
+	}
+	if meta.Taken == taken {
+		p.stats.FinalCorrect++
+	}
+	switch meta.Source {
+	case SourceLoop:
+		p.stats.LoopProvided++
+		if meta.Taken == taken {
+			p.stats.LoopCorrect++
+		}
+	case SourceSC:
+		p.stats.SCOverrides++
+		if meta.Taken == taken {
+			p.stats.SCOverridesCorrect++
+		}
+	}
+}
+
+// Reset clears all learned state in every component.
+func (p *TAGESCLPredictor) Reset() {
+	p.TAGE.Reset()
+	p.Loop.Reset()
+	p.SC.Reset()
+	p.stats = TAGESCLStats{}
+}
+
+// Stats returns component contribution counters.
+func (p *TAGESCLPredictor) Stats() TAGESCLStats {
+	return p.stats
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 // HARDWARE IMPLEMENTATION SUMMARY
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -2091,9 +3263,19 @@ func (p *TAGEPredictor) Stats() TAGEStats {
 //   ├── history_shifter (×8)              // Per-context shift register
 //   │   └── shift_or                      // {history[62:0], taken}
 //   │
-//   └── aging_fsm                         // Background maintenance
-//       ├── counter                       // Trigger every 1024 branches
-//       └── bitmap_scanner                // Iterate valid entries
+//   ├── aging_fsm                         // Background maintenance
+//   │   ├── counter                       // Trigger every 1024 branches
+//   │   └── bitmap_scanner                // Iterate valid entries
+//   │
+//   ├── loop_predictor                    // Fixed-trip-count loops
+//   │   ├── loop_table                    // 64 × 39-bit entries
+//   │   └── iter_compare                  // current_iter == past_iter
+//   │
+//   ├── statistical_corrector             // Overrides weak TAGE predictions
+//   │   ├── sc_banks (×4)                 // 256 × 6-bit signed weights
+//   │   └── adder_tree                    // Σ(2w+1) + threshold compare
+//   │
+//   └── final_mux                         // loop > SC > TAGE
 //
 // TIMING BUDGET (3.5GHz = 285ps cycle):
 //
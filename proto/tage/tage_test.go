@@ -3,6 +3,7 @@ package tage
 import (
 	"math"
 	"math/bits"
+	"slices"
 	"testing"
 )
 
@@ -116,8 +117,8 @@ import (
 // 12. LONGEST MATCH SELECTION TESTS
 //     CLZ-based selection, tag+context matching, conflict resolution
 //
-// 13. PROVIDER METADATA CACHING TESTS
-//     Predict→Update optimization, overwrite behavior
+// 13. PER-BRANCH METADATA AND SPECULATIVE HISTORY TESTS
+//     Metadata per prediction, speculative shift, checkpoint restore
 //
 // 14. CONTEXT MASKING TESTS
 //     Verification in all code paths (predict, update, allocation)
@@ -140,6 +141,15 @@ import (
 // 20. STATS TESTS
 //     Statistics collection and reporting
 //
+// 21. TAGE-SC-L TESTS
+//     Loop predictor, statistical corrector, final MUX, accuracy vs bare TAGE
+//
+// 22. CONFIGURABLE GEOMETRY TESTS
+//     TAGEConfig validation, default equivalence, non-default geometries
+//
+// 23. LONG HISTORY / FOLDED REGISTER TESTS
+//     Circular history buffer, folded register invariant, checkpoint/restore
+//
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -295,22 +305,25 @@ func TestInit_AgingEnabledByDefault(t *testing.T) {
 	}
 }
 
-func TestInit_LastPredictionCleared(t *testing.T) {
-	// WHAT: Cached prediction metadata starts cleared
-	// WHY: No prediction made yet, metadata invalid
-	// HARDWARE: Pipeline register cleared on reset
+func TestInit_LookupOnFreshPredictor(t *testing.T) {
+	// WHAT: First lookup on a fresh predictor comes from the base predictor
+	// WHY: No history entries yet, history register is zero
+	// HARDWARE: Metadata checkpoint = reset value of history register
 	//
-	// ProviderTable = -1 indicates no cached prediction.
-	// Update() will search tables instead of using cache.
+	// There is no cached "last prediction" register: every Lookup() returns
+	// its own metadata.
 
 	pred := NewTAGEPredictor()
+	meta := pred.Lookup(0x1000, 0)
 
-	if pred.LastPrediction.ProviderTable != -1 {
-		t.Errorf("LastPrediction.ProviderTable = %d, expected -1", pred.LastPrediction.ProviderTable)
+	if meta.ProviderTable != 0 {
+		t.Errorf("ProviderTable = %d, expected 0 (base)", meta.ProviderTable)
 	}
-
-	if pred.LastPrediction.ProviderEntry != nil {
-		t.Error("LastPrediction.ProviderEntry should be nil on init")
+	if meta.ProviderEntry == nil {
+		t.Error("ProviderEntry should point at the base entry")
+	}
+	if meta.Checkpoint.History != 0 || meta.Speculative {
+		t.Errorf("Checkpoint = %#x speculative=%v, expected 0/false", meta.Checkpoint.History, meta.Speculative)
 	}
 }
 
@@ -1397,34 +1410,39 @@ func TestOnMispredict_AggressiveAllocation(t *testing.T) {
 	}
 }
 
-func TestUpdate_ClearsMetadataCache(t *testing.T) {
-	// WHAT: Update() clears cached prediction metadata
-	// WHY: Metadata consumed, prevent stale cache use
-	// HARDWARE: Clear pipeline register after use
-	//
-	// After Update() consumes the cached metadata, it must be cleared.
-	// Otherwise, a later Update() might use stale data.
+func TestUpdate_LookupIsPure(t *testing.T) {
+	// WHAT: Predict()/Lookup() do not modify predictor state
+	// WHY: Per-branch metadata replaces the shared last-prediction register,
+	//      so a prediction for one branch cannot disturb another in flight
+	// HARDWARE: Predict path is pure combinational read
 
 	pred := NewTAGEPredictor()
 	pc := uint64(0xB000)
 	ctx := uint8(0)
 
-	// Predict (caches metadata)
-	pred.Predict(pc, ctx)
+	for i := 0; i < 20; i++ {
+		pred.OnMispredict(pc+uint64(i%3)*0x1000, ctx, i%2 == 0)
+	}
 
-	if pred.LastPC != pc {
-		t.Error("Predict should cache LastPC")
+	historyBefore := pred.History
+	entriesBefore := make([][]TAGEEntry, len(pred.Tables))
+	validBefore := make([][]uint64, len(pred.Tables))
+	for i := range pred.Tables {
+		entriesBefore[i] = slices.Clone(pred.Tables[i].Entries)
+		validBefore[i] = slices.Clone(pred.Tables[i].ValidBits)
 	}
 
-	// Update (consumes and clears metadata)
-	pred.Update(pc, ctx, true)
+	pred.Predict(pc, ctx)
+	pred.Lookup(pc+0x1000, ctx)
 
-	if pred.LastPC != 0 {
-		t.Error("Update should clear LastPC")
+	if pred.History != historyBefore {
+		t.Error("Predict/Lookup should not change history")
 	}
-
-	if pred.LastPrediction.ProviderEntry != nil {
-		t.Error("Update should clear ProviderEntry")
+	for i := range pred.Tables {
+		if !slices.Equal(pred.Tables[i].Entries, entriesBefore[i]) ||
+			!slices.Equal(pred.Tables[i].ValidBits, validBefore[i]) {
+			t.Errorf("Predict/Lookup should not change table %d", i)
+		}
 	}
 }
 
@@ -2547,31 +2565,29 @@ func TestReset_ClearsBranchCount(t *testing.T) {
 	}
 }
 
-func TestReset_ClearsMetadataCache(t *testing.T) {
-	// WHAT: Reset clears cached prediction metadata
-	// WHY: Prevent stale cache use after reset
-	// HARDWARE: Pipeline register cleared
-	//
-	// Cached metadata (LastPC, LastCtx, LastPrediction) must be cleared.
-	// Otherwise, Update() might use stale pointers.
+func TestReset_OutstandingMetadataHarmless(t *testing.T) {
+	// WHAT: Resolving metadata obtained before Reset() does not corrupt state
+	// WHY: Branches may still be in flight when the predictor is reset
+	// HARDWARE: Provider revalidated (valid + tag + context) at resolve time
 
 	pred := NewTAGEPredictor()
+	pc := uint64(0x1000)
 
-	// Make prediction to populate cache
-	pred.Predict(0x1000, 0)
-
-	if pred.LastPC == 0 && pred.LastPrediction.ProviderEntry == nil {
-		t.Skip("Metadata not cached after Predict")
+	for i := 0; i < 10; i++ {
+		pred.OnMispredict(pc, 0, i%2 == 0)
 	}
+	meta := pred.PredictSpeculative(pc, 0)
 
 	pred.Reset()
+	pred.Resolve(meta, !meta.Predicted)
 
-	if pred.LastPrediction.ProviderTable != -1 {
-		t.Errorf("LastPrediction.ProviderTable = %d, expected -1", pred.LastPrediction.ProviderTable)
-	}
-
-	if pred.LastPrediction.ProviderEntry != nil {
-		t.Error("LastPrediction.ProviderEntry should be nil after reset")
+	for tableNum := 1; tableNum < NumTables; tableNum++ {
+		for i := 0; i < EntriesPerTable; i++ {
+			if (pred.Tables[tableNum].ValidBits[i>>6]>>(i&63))&1 != 0 &&
+				pred.Tables[tableNum].Entries[i].Tag != hashTag(pc) {
+				t.Fatalf("Table %d entry %d valid with foreign tag after reset", tableNum, i)
+			}
+		}
 	}
 }
 
@@ -2611,13 +2627,13 @@ func TestMatch_LongestHistory(t *testing.T) {
 	pred.OnMispredict(pc, ctx, false)
 
 	// Predict - should use longest matching table
-	pred.Predict(pc, ctx)
+	meta := pred.Lookup(pc, ctx)
 
 	// Log which table was used
-	if pred.LastPrediction.ProviderTable >= 0 {
+	if meta.ProviderTable >= 0 {
 		t.Logf("Provider table: %d (history length: %d)",
-			pred.LastPrediction.ProviderTable,
-			pred.Tables[pred.LastPrediction.ProviderTable].HistoryLen)
+			meta.ProviderTable,
+			pred.Tables[meta.ProviderTable].HistoryLen)
 	}
 }
 
@@ -2707,10 +2723,11 @@ func TestMatch_MultipleHitsConflicting(t *testing.T) {
 	}
 
 	// Now predict - should use longest matching table
-	predicted, _ := pred.Predict(pc, ctx)
+	meta := pred.Lookup(pc, ctx)
+	predicted := meta.Predicted
 
-	if pred.LastPrediction.ProviderTable >= 0 {
-		providerTable := pred.LastPrediction.ProviderTable
+	if meta.ProviderTable >= 0 {
+		providerTable := meta.ProviderTable
 		t.Logf("Multiple tables hit, selected Table %d (history length: %d)",
 			providerTable, pred.Tables[providerTable].HistoryLen)
 		t.Logf("Final prediction: %v", predicted)
@@ -2727,139 +2744,327 @@ func TestMatch_MultipleHitsConflicting(t *testing.T) {
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
-// 13. PROVIDER METADATA CACHING TESTS
+// 13. PER-BRANCH METADATA AND SPECULATIVE HISTORY TESTS
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 //
-// Predict() caches provider metadata for use in Update().
-// Avoids redundant lookups - Predict() already found the provider entry.
+// Every prediction returns its own PredictionMetadata (provider + history checkpoint).
+// PredictSpeculative() advances history with the predicted outcome immediately;
+// Restore()/Resolve() repair it when the branch turns out mispredicted.
 //
-// Hardware: Pipeline register between predict and update stages
-// Timing: Part of predict path
+// Hardware: Metadata carried in branch queue; one history write port for repair
+// Timing: Speculative shift is part of predict path; repair is a single register write
 //
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
-func TestMetadata_CachedOnPredict(t *testing.T) {
-	// WHAT: Prediction caches provider metadata
-	// WHY: Avoid redundant lookups in Update()
-	// HARDWARE: Pipeline register captures prediction state
+func TestMetadata_ReturnedByLookup(t *testing.T) {
+	// WHAT: Lookup() returns PC, context, checkpoint and provider
+	// WHY: Everything Resolve() needs travels with the branch
+	// HARDWARE: Metadata bundle written into branch queue entry
 
 	pred := NewTAGEPredictor()
 	pc := uint64(0x90000)
-	ctx := uint8(0)
+	ctx := uint8(3)
+	pred.History[ctx] = 0b1101
 
-	// Train entry
-	for i := 0; i < 5; i++ {
-		pred.Update(pc, ctx, true)
+	meta := pred.Lookup(pc, ctx)
+
+	if meta.PC != pc || meta.Ctx != ctx {
+		t.Errorf("PC/Ctx = %#x/%d, expected %#x/%d", meta.PC, meta.Ctx, pc, ctx)
+	}
+	if cp := meta.Checkpoint; cp.Ctx != ctx || cp.History != 0b1101 || cp.Head != 0 || cp.Folded != nil {
+		t.Errorf("Checkpoint = %+v, expected ctx 3 history 0b1101", meta.Checkpoint)
+	}
+	if meta.ProviderEntry == nil {
+		t.Error("ProviderEntry should be set")
+	}
+	if meta.Speculative {
+		t.Error("Lookup() should not mark metadata speculative")
 	}
+}
 
-	// Predict
-	pred.Predict(pc, ctx)
+func TestMetadata_ContextClampedInMetadata(t *testing.T) {
+	// WHAT: Out-of-range context is clamped before being recorded
+	// WHY: Resolve/Restore must address the same history register Lookup read
+
+	pred := NewTAGEPredictor()
+	meta := pred.Lookup(0x1000, 200)
+
+	if meta.Ctx != 0 || meta.Checkpoint.Ctx != 0 {
+		t.Errorf("Ctx = %d, checkpoint ctx = %d, expected 0", meta.Ctx, meta.Checkpoint.Ctx)
+	}
+}
+
+func TestMetadata_MultipleInFlight(t *testing.T) {
+	// WHAT: Several predictions outstanding at once keep independent metadata
+	// WHY: Replaces the single LastPrediction register
+	// HARDWARE: One metadata slot per branch queue entry
 
-	// Metadata should be cached
-	if pred.LastPC != pc {
-		t.Error("LastPC not cached")
+	pred := NewTAGEPredictor()
+	ctx := uint8(0)
+
+	m1 := pred.PredictSpeculative(0xB0000, ctx)
+	m2 := pred.PredictSpeculative(0xC0000, ctx)
+	m3 := pred.PredictSpeculative(0xD0000, ctx)
+
+	if m1.PC != 0xB0000 || m2.PC != 0xC0000 || m3.PC != 0xD0000 {
+		t.Error("Each metadata should keep its own PC")
 	}
-	if pred.LastCtx != ctx {
-		t.Error("LastCtx not cached")
+	if m2.Checkpoint.History != (m1.Checkpoint.History<<1)|boolBit(m1.Predicted) {
+		t.Errorf("m2 checkpoint %#b should be m1 checkpoint shifted by m1 prediction", m2.Checkpoint.History)
 	}
-	if pred.LastPrediction.ProviderEntry == nil {
-		t.Error("ProviderEntry not cached")
+	if m3.Checkpoint.History != (m2.Checkpoint.History<<1)|boolBit(m2.Predicted) {
+		t.Errorf("m3 checkpoint %#b should be m2 checkpoint shifted by m2 prediction", m3.Checkpoint.History)
 	}
 }
 
-func TestMetadata_UsedByUpdate(t *testing.T) {
-	// WHAT: Update() uses cached metadata from Predict()
-	// WHY: Avoid redundant table search
-	// HARDWARE: Pipeline register read in update path
+func TestMetadata_ResolveUsesPredictTimeHistory(t *testing.T) {
+	// WHAT: Allocation on mispredict hashes with the branch's checkpoint history
+	// WHY: By resolve time, younger branches have already changed the history
+	// HARDWARE: Index recomputed from metadata.checkpoint, not history_regs[ctx]
 
 	pred := NewTAGEPredictor()
-	pc := uint64(0xA0000)
+	pc := uint64(0x1000000)
 	ctx := uint8(0)
+	pred.History[ctx] = 0b1010
 
-	// Train entry strongly
-	for i := 0; i < 10; i++ {
-		pred.OnMispredict(pc, ctx, true)
-		pred.Update(pc, ctx, true)
+	meta := pred.PredictSpeculative(pc, ctx)
+
+	// Younger branches advance history further
+	for i := 0; i < 5; i++ {
+		pred.PredictSpeculative(0x2000000+uint64(i)*0x1000000, ctx)
+	}
+
+	pred.Resolve(meta, !meta.Predicted)
+
+	// The new table 1 entry must be found by a lookup with the checkpoint history
+	pred.History[ctx] = meta.Checkpoint.History
+	again := pred.Lookup(pc, ctx)
+	if again.ProviderTable < 1 {
+		// Allocation lands near (not always at) the hash index; verify by scan
+		idx := hashIndex(pc, meta.Checkpoint.History, pred.Tables[1].HistoryLen, 1)
+		found := false
+		for off := -LRUSearchWidth / 2; off < LRUSearchWidth/2; off++ {
+			i := uint32(int32(idx)+int32(off)) & (EntriesPerTable - 1)
+			e := &pred.Tables[1].Entries[i]
+			if (pred.Tables[1].ValidBits[i>>6]>>(i&63))&1 != 0 && e.Tag == hashTag(pc) {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Allocation should be placed using the checkpoint history")
+		}
 	}
+}
 
-	// Predict (caches metadata)
-	pred.Predict(pc, ctx)
+func TestMetadata_StaleProviderNotTrained(t *testing.T) {
+	// WHAT: A provider entry reallocated to another branch is not trained
+	// WHY: Between predict and resolve, another branch may evict the provider
+	// HARDWARE: Tag + context recheck at update
+
+	pred := NewTAGEPredictor()
+	pc := uint64(0x1000000)
+	ctx := uint8(0)
 
-	// Capture table before update
-	tableBefore := pred.LastPrediction.ProviderTable
+	tag := hashTag(pc)
+	idx := hashIndex(pc, 0, pred.Tables[2].HistoryLen, 2)
+	pred.Tables[2].Entries[idx] = TAGEEntry{Tag: tag, Counter: 5}
+	pred.Tables[2].ValidBits[idx>>6] |= 1 << (idx & 63)
 
-	// Update (should use cached metadata)
-	pred.Update(pc, ctx, true)
+	meta := pred.Lookup(pc, ctx)
+	if meta.ProviderTable != 2 {
+		t.Fatalf("ProviderTable = %d, expected 2", meta.ProviderTable)
+	}
 
-	if tableBefore < 0 {
-		t.Skip("No history table entry created, test not applicable")
+	// Another branch takes over the slot
+	pred.Tables[2].Entries[idx] = TAGEEntry{Tag: tag ^ 1, Counter: 5}
+
+	pred.Resolve(meta, true)
+
+	if pred.Tables[2].Entries[idx].Counter != 5 || pred.Tables[2].Entries[idx].Useful {
+		t.Error("Replaced entry should not be trained by stale metadata")
 	}
+}
+
+func TestSpeculative_ShiftsPredictedOutcome(t *testing.T) {
+	// WHAT: PredictSpeculative() shifts the predicted bit into history
+	// WHY: Next prediction must see this branch's (predicted) outcome
+	// HARDWARE: history[ctx] <= {history[ctx][62:0], predicted}
 
-	t.Logf("Provider table %d used for update", tableBefore)
+	pred := NewTAGEPredictor()
+	ctx := uint8(1)
+	pred.History[ctx] = 0b110
+
+	meta := pred.PredictSpeculative(0x5000, ctx)
+
+	expected := uint64(0b110<<1) | boolBit(meta.Predicted)
+	if pred.History[ctx] != expected {
+		t.Errorf("History = %#b, expected %#b", pred.History[ctx], expected)
+	}
+	if !meta.Speculative {
+		t.Error("Metadata should be marked speculative")
+	}
+	if meta.Checkpoint.History != 0b110 {
+		t.Errorf("Checkpoint = %#b, expected 0b110", meta.Checkpoint.History)
+	}
 }
 
-func TestMetadata_OverwrittenOnSecondPredict(t *testing.T) {
-	// WHAT: Second Predict() overwrites cached metadata
-	// WHY: Cache holds only most recent prediction
-	// HARDWARE: Pipeline register updated each prediction
+func TestSpeculative_RestoreRepairsHistory(t *testing.T) {
+	// WHAT: Restore() rewinds to checkpoint and shifts in the actual outcome
+	// WHY: Younger speculative bits belong to squashed branches
+	// HARDWARE: history[ctx] <= {checkpoint[62:0], actual}
 	//
-	// If user calls Predict() twice before Update(),
-	// the second Predict() overwrites the first's metadata.
+	// Example from the SPECULATIVE HISTORY section:
+	//   B1 (T), B2 (T), B3 (N) predicted; B2 actually NOT TAKEN → H·T·N
+
+	pred := NewTAGEPredictor()
+	ctx := uint8(0)
+	H := uint64(0b1001)
+
+	cp1 := HistoryCheckpoint{Ctx: ctx, History: H}
+	pred.History[ctx] = H<<1 | 1 // B1 predicted T
+	cp2 := HistoryCheckpoint{Ctx: ctx, History: pred.History[ctx]}
+	pred.History[ctx] = pred.History[ctx]<<1 | 1 // B2 predicted T
+	pred.History[ctx] = pred.History[ctx] << 1   // B3 predicted N
+
+	pred.Restore(cp2, false)
+
+	expected := (H<<1|1)<<1 | 0
+	if pred.History[ctx] != expected {
+		t.Errorf("History = %#b, expected %#b", pred.History[ctx], expected)
+	}
+
+	pred.Restore(cp1, false)
+	if pred.History[ctx] != H<<1 {
+		t.Errorf("History = %#b, expected %#b", pred.History[ctx], H<<1)
+	}
+}
+
+func TestSpeculative_CorrectResolveLeavesHistory(t *testing.T) {
+	// WHAT: Resolving a correctly predicted speculative branch does not touch history
+	// WHY: Its bit is already in place; younger branches' bits must survive
 
 	pred := NewTAGEPredictor()
+	ctx := uint8(0)
+
+	m1 := pred.PredictSpeculative(0x6000, ctx)
+	pred.PredictSpeculative(0x7000, ctx)
+	historyBefore := pred.History[ctx]
+
+	pred.Resolve(m1, m1.Predicted)
+
+	if pred.History[ctx] != historyBefore {
+		t.Errorf("History = %#b, expected unchanged %#b", pred.History[ctx], historyBefore)
+	}
+}
+
+func TestSpeculative_MispredictResolveRestores(t *testing.T) {
+	// WHAT: Resolving a mispredicted speculative branch repairs history
+	// WHY: Resolve() is the one-call path for the backend
 
-	pc1 := uint64(0xB0000)
-	pc2 := uint64(0xC0000)
+	pred := NewTAGEPredictor()
 	ctx := uint8(0)
 
-	// First predict
-	pred.Predict(pc1, ctx)
-	firstPC := pred.LastPC
+	m1 := pred.PredictSpeculative(0x6000, ctx)
+	pred.PredictSpeculative(0x7000, ctx)
+	pred.PredictSpeculative(0x8000, ctx)
+
+	pred.Resolve(m1, !m1.Predicted)
 
-	if firstPC != pc1 {
-		t.Errorf("First Predict: LastPC = 0x%X, expected 0x%X", firstPC, pc1)
+	expected := (m1.Checkpoint.History << 1) | boolBit(!m1.Predicted)
+	if pred.History[ctx] != expected {
+		t.Errorf("History = %#b, expected %#b", pred.History[ctx], expected)
+	}
+	if pred.BranchCount != 1 {
+		t.Errorf("BranchCount = %d, expected 1 (mispredict path)", pred.BranchCount)
 	}
+}
+
+func TestSpeculative_NonSpeculativeResolveShifts(t *testing.T) {
+	// WHAT: Resolve() of Lookup() metadata shifts the actual outcome
+	// WHY: Same observable behavior as Update()/OnMispredict()
 
-	// Second predict
-	pred.Predict(pc2, ctx)
-	secondPC := pred.LastPC
+	pred := NewTAGEPredictor()
+	ctx := uint8(0)
+	pred.History[ctx] = 0b11
 
-	if secondPC != pc2 {
-		t.Errorf("Second Predict: LastPC = 0x%X, expected 0x%X", secondPC, pc2)
+	meta := pred.Lookup(0x6000, ctx)
+	pred.Resolve(meta, false)
+
+	if pred.History[ctx] != 0b110 {
+		t.Errorf("History = %#b, expected 0b110", pred.History[ctx])
 	}
+}
+
+func TestSpeculative_RestorePerContext(t *testing.T) {
+	// WHAT: Restore() only touches the checkpoint's context
+	// WHY: Context isolation - one context's mispredict cannot rewrite another's history
+
+	pred := NewTAGEPredictor()
+	pred.History[2] = 0xAAAA
+	pred.History[3] = 0x5555
+
+	meta := pred.PredictSpeculative(0x6000, 2)
+	pred.Restore(meta.Checkpoint, !meta.Predicted)
 
-	if secondPC == firstPC {
-		t.Error("Second Predict should overwrite cached metadata")
+	if pred.History[3] != 0x5555 {
+		t.Errorf("Context 3 history changed to %#x", pred.History[3])
 	}
 }
 
-func TestMetadata_FallbackSearchWhenStale(t *testing.T) {
-	// WHAT: Update() searches tables when cache is stale
-	// WHY: Cache may not match current PC/ctx
-	// HARDWARE: Fallback path when cache miss
-	//
-	// If Update() called with different PC than Predict(),
-	// cached metadata is stale. Must search tables.
+func TestSpeculative_PipelinedHistoryMatchesSequential(t *testing.T) {
+	// WHAT: With N branches in flight, committed history equals the actual outcome stream
+	// WHY: Speculation + repair must be invisible once everything resolves
+	// HARDWARE: Models a 4-deep branch queue with squash on mispredict
 
 	pred := NewTAGEPredictor()
 	ctx := uint8(0)
+	const depth = 4
 
-	// Train entry
-	pc1 := uint64(0xD0000)
-	for i := 0; i < 5; i++ {
-		pred.OnMispredict(pc1, ctx, true)
+	var expected uint64
+	var queue []PredictionMetadata
+	var outcomes []bool
+
+	outcome := func(i int) bool { return (i*2654435761)>>7&3 != 0 }
+
+	i := 0
+	for i < 2000 {
+		// Fetch up to depth branches
+		for len(queue) < depth {
+			queue = append(queue, pred.PredictSpeculative(0x10000+uint64(i%13)*0x1000, ctx))
+			outcomes = append(outcomes, outcome(i+len(outcomes)))
+		}
+
+		// Resolve oldest
+		meta, actual := queue[0], outcomes[0]
+		pred.Resolve(meta, actual)
+		expected = (expected << 1) | boolBit(actual)
+		i++
+
+		if meta.Predicted != actual {
+			// Squash younger branches: they will be refetched
+			queue, outcomes = queue[:0], outcomes[:0]
+		} else {
+			queue, outcomes = queue[1:], outcomes[1:]
+		}
 	}
 
-	// Predict different PC (caches wrong metadata)
-	pc2 := uint64(0xE0000)
-	pred.Predict(pc2, ctx)
+	// Committed history = checkpoint of the oldest branch still in flight
+	committed := pred.History[ctx]
+	if len(queue) > 0 {
+		committed = queue[0].Checkpoint.History
+	}
 
-	// Update original PC - cache is stale, should still work
-	// (Update searches tables as fallback)
-	pred.Update(pc1, ctx, true)
+	if committed != expected {
+		t.Errorf("Committed history = %#x, expected %#x", committed, expected)
+	}
+}
 
-	// Should not crash or corrupt state
-	t.Log("Fallback search verified (no crash)")
+// boolBit converts an outcome to its history bit.
+func boolBit(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -4034,155 +4239,1315 @@ func TestStats_AfterReset(t *testing.T) {
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
-// BENCHMARK TESTS
+// 21. TAGE-SC-L TESTS
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 //
-// Benchmarks measure performance of critical operations.
-// Used for optimization and regression testing.
+// The loop predictor and statistical corrector sit beside TAGE and override it when
+// they know better. These tests verify each component in isolation, the final
+// prediction MUX, context isolation, and accuracy against bare TAGE.
 //
-// Run with: go test -bench=. -benchmem
+// Hardware: 64-entry loop table + 4 × 256 SC weights + final MUX
 //
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
-func BenchmarkPredict(b *testing.B) {
-	pred := NewTAGEPredictor()
+// trainLoopTrips drives the loop predictor through full trips of a loop whose body
+// direction is taken. tageMispredicted is passed on the exit only, so the first
+// exit allocates the entry.
+func trainLoopTrips(l *LoopPredictor, pc uint64, ctx uint8, body int, trips int) {
+	for trip := 0; trip < trips; trip++ {
+		for i := 0; i < body; i++ {
+			l.Update(pc, ctx, true, false)
+		}
+		l.Update(pc, ctx, false, true)
+	}
+}
 
-	// Pre-train
-	for i := 0; i < 100; i++ {
-		pred.Update(uint64(i)*4, 0, i%2 == 0)
+func TestLoop_AllocatesOnlyOnTAGEMispredict(t *testing.T) {
+	// WHAT: Miss path allocates only when TAGE mispredicted
+	// WHY: The loop table is tiny - spend it only on branches TAGE gets wrong
+
+	var l LoopPredictor
+	pc := uint64(0x4000)
+
+	l.Update(pc, 0, true, false)
+	if l.ValidBits != 0 {
+		t.Error("Correct TAGE prediction should not allocate a loop entry")
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		pred.Predict(uint64(i)*4, uint8(i%8))
+	l.Update(pc, 0, false, true)
+	idx, tag := hashLoop(pc)
+	if (l.ValidBits>>idx)&1 == 0 {
+		t.Fatal("TAGE misprediction should allocate a loop entry")
+	}
+
+	entry := &l.Entries[idx]
+	if entry.Tag != tag || entry.Context != 0 {
+		t.Errorf("Entry tag/context = %#x/%d, expected %#x/0", entry.Tag, entry.Context, tag)
+	}
+	if entry.Dir != true {
+		t.Error("Mispredicted outcome is the exit, so body direction should be its inverse")
+	}
+	if entry.Age != LoopMaxAge || entry.Confidence != 0 {
+		t.Errorf("New entry age/conf = %d/%d, expected %d/0", entry.Age, entry.Confidence, LoopMaxAge)
 	}
 }
 
-func BenchmarkUpdate(b *testing.B) {
-	pred := NewTAGEPredictor()
+func TestLoop_LearnsTripCount(t *testing.T) {
+	// WHAT: Same trip count seen repeatedly → confident, exact exit prediction
+	// WHY: Core purpose of the loop predictor
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		pred.Update(uint64(i)*4, uint8(i%8), i%2 == 0)
+	var l LoopPredictor
+	pc := uint64(0x4100)
+	body := 9
+
+	trainLoopTrips(&l, pc, 0, body, 1+LoopMaxConfidence+1)
+
+	entry, hit := l.lookup(pc, 0)
+	if !hit {
+		t.Fatal("Loop entry should hit after training")
+	}
+	if entry.PastIter != uint16(body) {
+		t.Errorf("PastIter = %d, expected %d", entry.PastIter, body)
+	}
+	if entry.Confidence != LoopMaxConfidence {
+		t.Errorf("Confidence = %d, expected %d", entry.Confidence, LoopMaxConfidence)
+	}
+
+	// One full trip: body predicted taken, exit predicted not taken
+	for i := 0; i < body; i++ {
+		valid, taken := l.Predict(pc, 0)
+		if !valid || !taken {
+			t.Fatalf("Iteration %d: predicted valid=%v taken=%v, expected taken", i, valid, taken)
+		}
+		l.Update(pc, 0, true, false)
+	}
+	valid, taken := l.Predict(pc, 0)
+	if !valid || taken {
+		t.Errorf("Exit: predicted valid=%v taken=%v, expected not taken", valid, taken)
 	}
 }
 
-func BenchmarkOnMispredict(b *testing.B) {
-	pred := NewTAGEPredictor()
+func TestLoop_ConfidenceRequiredBeforeUse(t *testing.T) {
+	// WHAT: No prediction until LoopMaxConfidence matching trips
+	// WHY: One observed trip count is not evidence of a fixed-count loop
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		pred.OnMispredict(uint64(i)*4, uint8(i%8), i%2 == 0)
+	var l LoopPredictor
+	pc := uint64(0x4200)
+
+	trainLoopTrips(&l, pc, 0, 4, 2) // Allocate + first learned count
+	if valid, _ := l.Predict(pc, 0); valid {
+		t.Error("Loop prediction should not be used before confidence saturates")
 	}
 }
 
-func BenchmarkHashIndex(b *testing.B) {
-	pc := uint64(0x123456789ABCDEF0)
-	history := uint64(0xFEDCBA9876543210)
+func TestLoop_TripCountChangeResetsConfidence(t *testing.T) {
+	// WHAT: Running past the learned count, or exiting early, drops confidence
+	// WHY: Data-dependent loops must fall back to TAGE immediately
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		hashIndex(pc, history, 32, 4)
+	var l LoopPredictor
+	pc := uint64(0x4300)
+	trainLoopTrips(&l, pc, 0, 5, 6)
+
+	// Longer trip: confidence drops as soon as iteration 6 is seen
+	for i := 0; i < 6; i++ {
+		l.Update(pc, 0, true, false)
+	}
+	if valid, _ := l.Predict(pc, 0); valid {
+		t.Error("Running past learned trip count should drop confidence")
+	}
+	l.Update(pc, 0, false, false)
+
+	entry, _ := l.lookup(pc, 0)
+	if entry.PastIter != 6 || entry.Confidence != 0 {
+		t.Errorf("After new count: PastIter=%d Confidence=%d, expected 6/0", entry.PastIter, entry.Confidence)
 	}
 }
 
-func BenchmarkHashTag(b *testing.B) {
-	pc := uint64(0x123456789ABCDEF0)
+func TestLoop_DoubleExitFreesEntry(t *testing.T) {
+	// WHAT: Two exits in a row (zero body iterations) frees the entry
+	// WHY: The branch is not behaving like a loop - give the slot back
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		hashTag(pc)
+	var l LoopPredictor
+	pc := uint64(0x4400)
+
+	l.Update(pc, 0, false, true) // Allocate (Dir = taken)
+	l.Update(pc, 0, false, true) // Exit with CurrentIter == 0
+
+	if _, hit := l.lookup(pc, 0); hit {
+		t.Error("Entry should be freed after an empty trip")
 	}
 }
 
-func BenchmarkAgeAllEntries(b *testing.B) {
-	pred := NewTAGEPredictor()
+func TestLoop_IterationOverflowFreesEntry(t *testing.T) {
+	// WHAT: Trip counts above LoopMaxIter free the entry
+	// WHY: 10-bit counter cannot represent the loop
 
-	// Pre-fill tables
-	for i := 0; i < 1000; i++ {
-		pred.OnMispredict(uint64(i)*4, 0, i%2 == 0)
+	var l LoopPredictor
+	pc := uint64(0x4500)
+
+	l.Update(pc, 0, false, true)
+	for i := 0; i <= LoopMaxIter; i++ {
+		l.Update(pc, 0, true, false)
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		pred.AgeAllEntries()
+	if _, hit := l.lookup(pc, 0); hit {
+		t.Error("Entry should be freed after iteration counter overflow")
 	}
 }
 
-func BenchmarkFullCycle(b *testing.B) {
-	pred := NewTAGEPredictor()
+func TestLoop_AgeProtectsEntry(t *testing.T) {
+	// WHAT: An occupied slot decays by one age step per conflicting allocation
+	// WHY: Hot loops must not be thrashed by one-off mispredicting branches
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		pc := uint64(i) * uint64(0x1234)
-		ctx := uint8(i % 8)
+	var l LoopPredictor
+	pc := uint64(0x4600)
+	trainLoopTrips(&l, pc, 0, 3, 5)
 
-		pred.Predict(pc, ctx)
-		if i%5 == 0 {
-			pred.OnMispredict(pc, ctx, i%2 == 0)
-		} else {
-			pred.Update(pc, ctx, i%2 == 0)
+	idx, _ := hashLoop(pc)
+	age := l.Entries[idx].Age
+
+	// Different context, same slot: must wait for age to reach 0
+	for i := 0; i < int(age); i++ {
+		l.Update(pc, 5, false, true)
+		if _, hit := l.lookup(pc, 0); !hit {
+			t.Fatalf("Entry replaced after %d attempts, age was %d", i+1, age)
 		}
 	}
+	l.Update(pc, 5, false, true)
+	if _, hit := l.lookup(pc, 5); !hit {
+		t.Error("Slot should be reallocated once age reaches 0")
+	}
 }
 
-func BenchmarkReset(b *testing.B) {
-	// WHAT: Benchmark Reset() operation
-	// WHY: Reset should be fast (single cycle target)
-	// HARDWARE: Parallel clear of registers and bitmaps
-	//
-	// NOTE: We benchmark Reset() on a pre-filled predictor.
-	// After first Reset(), subsequent ones clear already-empty tables.
-	// This measures the worst-case (clearing full valid bitmaps).
+func TestLoop_ContextIsolation(t *testing.T) {
+	// WHAT: Loop entries only hit for their own context
+	// WHY: Spectre v2 - attacker must not steer victim's loop exits
+	// HARDWARE: Same tag+context comparator as TAGEEntry
 
-	pred := NewTAGEPredictor()
+	var l LoopPredictor
+	pc := uint64(0x4700)
+	trainLoopTrips(&l, pc, 3, 6, 6)
 
-	// Pre-fill once
-	for i := 0; i < 500; i++ {
-		pred.OnMispredict(uint64(i)*4, 0, true)
+	if valid, _ := l.Predict(pc, 3); !valid {
+		t.Fatal("Training context should get a loop prediction")
 	}
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		pred.Reset()
+	for ctx := uint8(0); ctx < NumContexts; ctx++ {
+		if ctx == 3 {
+			continue
+		}
+		if valid, _ := l.Predict(pc, ctx); valid {
+			t.Errorf("Context %d received context 3's loop prediction", ctx)
+		}
 	}
 }
 
-func BenchmarkStats(b *testing.B) {
-	// WHAT: Benchmark Stats() collection
-	// WHY: Stats is debug-only but shouldn't be too slow
-	// HARDWARE: Not synthesized, software only
+func TestSC_WeightsSaturate(t *testing.T) {
+	// WHAT: Weights clamp to [SCWeightMin, SCWeightMax]
+	// WHY: 6-bit signed counters in hardware
 
-	pred := NewTAGEPredictor()
+	sc := NewStatisticalCorrector()
+	pc := uint64(0x5000)
 
-	// Pre-fill
-	for i := 0; i < 500; i++ {
-		pred.OnMispredict(uint64(i)*4, 0, true)
+	for i := 0; i < 100; i++ {
+		sc.Update(pc, 0, 0, false, true, 0)
+	}
+	for i := 0; i < SCNumTables; i++ {
+		w := sc.Tables[i].Entries[scHashIndex(pc, 0, sc.Tables[i].HistoryLen, i, false)].Weight
+		if w != SCWeightMax {
+			t.Errorf("Table %d weight = %d, expected %d", i, w, SCWeightMax)
+		}
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = pred.Stats()
+	for i := 0; i < 200; i++ {
+		sc.Update(pc, 0, 0, false, false, 0)
+	}
+	for i := 0; i < SCNumTables; i++ {
+		w := sc.Tables[i].Entries[scHashIndex(pc, 0, sc.Tables[i].HistoryLen, i, false)].Weight
+		if w != SCWeightMin {
+			t.Errorf("Table %d weight = %d, expected %d", i, w, SCWeightMin)
+		}
 	}
 }
 
-func BenchmarkFindLRUVictim(b *testing.B) {
-	// WHAT: Benchmark LRU victim selection
-	// WHY: LRU is in allocation critical path
-	// HARDWARE: 8-way parallel comparators
+func TestSC_TrainingStopsWhenConfident(t *testing.T) {
+	// WHAT: Correct sum with |sum| ≥ SCThreshold leaves weights untouched
+	// WHY: Perceptron rule - avoids over-saturation and keeps weights adaptable
 
-	pred := NewTAGEPredictor()
+	sc := NewStatisticalCorrector()
+	pc := uint64(0x5100)
 
-	// Fill table 1
-	for i := 0; i < EntriesPerTable; i++ {
-		pred.Tables[1].ValidBits[i>>6] |= 1 << (i & 63)
-		pred.Tables[1].Entries[i].Age = uint8(i % 8)
-		pred.Tables[1].Entries[i].Useful = i%3 == 0
+	sc.Update(pc, 0, 0, true, true, SCThreshold)
+	for i := 0; i < SCNumTables; i++ {
+		w := sc.Tables[i].Entries[scHashIndex(pc, 0, sc.Tables[i].HistoryLen, i, true)].Weight
+		if w != 0 {
+			t.Errorf("Table %d weight = %d, expected untouched 0", i, w)
+		}
 	}
+}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		findLRUVictim(&pred.Tables[1], uint32(i%EntriesPerTable))
+func TestSC_ContextIsolation(t *testing.T) {
+	// WHAT: Weights owned by another context contribute 0 and are reclaimed on train
+	// WHY: Spectre v2 - attacker must not bias victim's corrections
+
+	sc := NewStatisticalCorrector()
+	pc := uint64(0x5200)
+	history := uint64(0b1011)
+
+	for i := 0; i < 50; i++ {
+		sc.Update(pc, 2, history, true, false, sc.Sum(pc, 2, history, true))
+	}
+	if sum := sc.Sum(pc, 2, history, true); sum >= 0 {
+		t.Fatalf("Owner context sum = %d, expected negative", sum)
+	}
+	if sum := sc.Sum(pc, 6, history, true); sum != 0 {
+		t.Errorf("Foreign context sum = %d, expected 0", sum)
+	}
+
+	// Victim trains the same entries: they are reset before use
+	sc.Update(pc, 6, history, true, true, 0)
+	if sum := sc.Sum(pc, 6, history, true); sum != SCNumTables*3 {
+		t.Errorf("Reclaimed sum = %d, expected %d (all weights 1)", sum, SCNumTables*3)
+	}
+}
+
+func TestSCL_LoopOverridesTAGE(t *testing.T) {
+	// WHAT: Confident loop entry provides the final prediction
+	// WHY: Loop predictor has highest priority in the final MUX
+
+	pred := NewTAGESCLPredictor()
+	pc := uint64(0x6000)
+
+	for i := 0; i < 200; i++ {
+		pred.Predict(pc, 0)
+		pred.Update(pc, 0, i%8 != 7)
+	}
+
+	if meta := pred.Lookup(pc, 0); meta.Source != SourceLoop {
+		t.Errorf("Source = %d, expected SourceLoop", meta.Source)
+	}
+}
+
+func TestSCL_SCOverridesOnlyWeakTAGE(t *testing.T) {
+	// WHAT: SC may only override TAGE predictions below high confidence
+	// WHY: Saturated TAGE entries are right far more often than SC
+
+	pred := NewTAGESCLPredictor()
+	pc := uint64(0x6100)
+
+	// Bias SC strongly toward not-taken for this PC/history/TAGE-taken
+	history := pred.TAGE.History[0]
+	for i := 0; i < 50; i++ {
+		pred.SC.Update(pc, 0, history, true, false, 0)
+	}
+
+	// Base predictor: neutral counter → predicts taken at confidence 0
+	meta := pred.Lookup(pc, 0)
+	if meta.Taken || meta.Source != SourceSC {
+		t.Errorf("Weak TAGE: taken=%v source=%d, expected SC override to not taken",
+			meta.Taken, meta.Source)
+	}
+
+	// Force a high-confidence TAGE hit in table 1
+	tag := hashTag(pc)
+	idx := hashIndex(pc, history, pred.TAGE.Tables[1].HistoryLen, 1)
+	pred.TAGE.Tables[1].Entries[idx] = TAGEEntry{Tag: tag, Counter: MaxCounter}
+	pred.TAGE.Tables[1].ValidBits[idx>>6] |= 1 << (idx & 63)
+
+	meta = pred.Lookup(pc, 0)
+	if !meta.Taken || meta.Source != SourceTAGE {
+		t.Errorf("Strong TAGE: taken=%v source=%d, expected TAGE taken",
+			meta.Taken, meta.Source)
+	}
+}
+
+func TestSCL_UpdateRoutesTAGETraining(t *testing.T) {
+	// WHAT: Update calls OnMispredict when TAGE itself was wrong
+	// WHY: Final prediction may be right while TAGE was wrong - TAGE must still learn
+
+	pred := NewTAGESCLPredictor()
+	pc := uint64(0x6200)
+
+	pred.Predict(pc, 0) // Base predictor says taken
+	pred.Update(pc, 0, false)
+
+	if pred.TAGE.BranchCount != 1 {
+		t.Errorf("BranchCount = %d, expected 1 (OnMispredict path)", pred.TAGE.BranchCount)
+	}
+	if pred.TAGE.History[0] != 0 {
+		t.Errorf("History = %b, expected 0 after not-taken", pred.TAGE.History[0])
+	}
+}
+
+func TestSCL_UpdateWithoutPredict(t *testing.T) {
+	// WHAT: Update without a preceding Predict still trains against a real prediction
+	// WHY: Warm-up and trace replay may skip explicit predictions
+
+	pred := NewTAGESCLPredictor()
+	for i := 0; i < 20; i++ {
+		pred.Update(0x6300, 0, true)
+	}
+
+	if pred.Stats().Predictions != 20 {
+		t.Errorf("Predictions = %d, expected 20", pred.Stats().Predictions)
+	}
+	if taken, _ := pred.Predict(0x6300, 0); !taken {
+		t.Error("Should predict taken after always-taken training")
+	}
+}
+
+func TestSCL_Reset(t *testing.T) {
+	// WHAT: Reset clears TAGE, loop, SC and statistics
+	// WHY: Same contract as TAGEPredictor.Reset
+
+	pred := NewTAGESCLPredictor()
+	for i := 0; i < 500; i++ {
+		pred.Predict(uint64(i%7)*0x40, 0)
+		pred.Update(uint64(i%7)*0x40, 0, i%5 != 0)
+	}
+
+	pred.Reset()
+
+	if pred.Loop.ValidBits != 0 {
+		t.Error("Loop table should be empty after reset")
+	}
+	for i := 0; i < SCNumTables; i++ {
+		for j := 0; j < SCEntriesPerTable; j++ {
+			if pred.SC.Tables[i].Entries[j] != (SCEntry{}) {
+				t.Fatalf("SC table %d entry %d not cleared", i, j)
+			}
+		}
+		if pred.SC.Tables[i].HistoryLen != SCHistoryLengths[i] {
+			t.Errorf("SC table %d history length lost on reset", i)
+		}
+	}
+	if pred.Stats() != (TAGESCLStats{}) {
+		t.Error("Stats should be cleared on reset")
+	}
+	if pred.TAGE.History[0] != 0 {
+		t.Error("TAGE history should be cleared on reset")
+	}
+}
+
+func TestSCL_PatternAccuracyComparison(t *testing.T) {
+	// WHAT: Compare bare TAGE against TAGE-SC-L on the pattern suite
+	// WHY: The extra components must pay for themselves
+	//
+	// Bare TAGE uses the full protocol (Update when right, OnMispredict when wrong)
+	// so the comparison is fair. First 1000 branches are warm-up.
+
+	patterns := []struct {
+		name    string
+		outcome func(i int) bool
+		mustWin bool // TAGE-SC-L must be strictly better
+	}{
+		{"always taken", func(i int) bool { return true }, false},
+		{"alternating", func(i int) bool { return i%2 == 0 }, true},
+		{"loop N=5", func(i int) bool { return i%6 != 5 }, true},
+		{"loop N=37", func(i int) bool { return i%38 != 37 }, true},
+		{"period-10 biased", func(i int) bool { return (i*7919)%10 < 8 }, true},
+	}
+
+	const warmup, total = 1000, 4000
+	pc := uint64(0x120000)
+
+	t.Log("Pattern           | TAGE    | TAGE-SC-L")
+	t.Log("------------------+---------+----------")
+
+	for _, pat := range patterns {
+		tage := NewTAGEPredictor()
+		scl := NewTAGESCLPredictor()
+		var tageCorrect, sclCorrect int
+
+		for i := 0; i < total; i++ {
+			expected := pat.outcome(i)
+
+			tageTaken, _ := tage.Predict(pc, 0)
+			if tageTaken == expected {
+				tage.Update(pc, 0, expected)
+			} else {
+				tage.OnMispredict(pc, 0, expected)
+			}
+
+			sclTaken, _ := scl.Predict(pc, 0)
+			scl.Update(pc, 0, expected)
+
+			if i >= warmup {
+				if tageTaken == expected {
+					tageCorrect++
+				}
+				if sclTaken == expected {
+					sclCorrect++
+				}
+			}
+		}
+
+		n := float64(total - warmup)
+		tageAcc := float64(tageCorrect) / n * 100
+		sclAcc := float64(sclCorrect) / n * 100
+		t.Logf("%-17s | %6.1f%% | %6.1f%%", pat.name, tageAcc, sclAcc)
+
+		if sclCorrect < tageCorrect {
+			t.Errorf("%s: TAGE-SC-L (%.1f%%) worse than TAGE (%.1f%%)", pat.name, sclAcc, tageAcc)
+		}
+		if pat.mustWin && sclCorrect <= tageCorrect {
+			t.Errorf("%s: TAGE-SC-L (%.1f%%) should beat TAGE (%.1f%%)", pat.name, sclAcc, tageAcc)
+		}
+		if pat.mustWin && sclAcc < 99 {
+			t.Errorf("%s: TAGE-SC-L accuracy %.1f%%, expected ≥99%%", pat.name, sclAcc)
+		}
+	}
+}
+
+func TestSCL_SpeculativeHistoryFollowsFinalPrediction(t *testing.T) {
+	// WHAT: Speculative history holds the FINAL prediction, not TAGE's
+	// WHY: Fetch follows the final prediction; history must match the fetched path
+
+	pred := NewTAGESCLPredictor()
+	pc := uint64(0x6500)
+
+	// SC strongly disagrees with the base predictor (which says taken)
+	for i := 0; i < 50; i++ {
+		pred.SC.Update(pc, 0, 0, true, false, 0)
+	}
+
+	meta := pred.PredictSpeculative(pc, 0)
+	if meta.Source != SourceSC || meta.Taken {
+		t.Fatalf("Expected SC override to not-taken, got source=%d taken=%v", meta.Source, meta.Taken)
+	}
+	if pred.TAGE.History[0] != 0 {
+		t.Errorf("History = %#b, expected 0 (final prediction not taken)", pred.TAGE.History[0])
+	}
+
+	// Final prediction wrong (TAGE was right): history repaired to taken
+	pred.Resolve(meta, true)
+	if pred.TAGE.History[0] != 1 {
+		t.Errorf("History after repair = %#b, expected 1", pred.TAGE.History[0])
+	}
+	if pred.TAGE.BranchCount != 0 {
+		t.Error("TAGE was right - should train through the correct path")
+	}
+}
+
+func TestSCL_PipelinedLoopAccuracy(t *testing.T) {
+	// WHAT: Loop pattern with branches of different PCs in flight
+	// WHY: Per-branch metadata lets predictions run ahead of resolution
+
+	pred := NewTAGESCLPredictor()
+	const depth = 3
+	var queue []SCLPredictionMetadata
+	var outcomes []bool
+	correct, total := 0, 0
+
+	// Outer branch 0x7000 always taken, inner 0x7100 loop of 6
+	outcome := func(i int) (uint64, bool) {
+		if i%2 == 0 {
+			return 0x7000, true
+		}
+		return 0x7100, (i/2)%7 != 6
+	}
+
+	i := 0
+	for i < 6000 {
+		for len(queue) < depth {
+			pc, actual := outcome(i + len(queue))
+			queue = append(queue, pred.PredictSpeculative(pc, 0))
+			outcomes = append(outcomes, actual)
+		}
+		meta, actual := queue[0], outcomes[0]
+		pred.Resolve(meta, actual)
+		if i >= 2000 {
+			total++
+			if meta.Taken == actual {
+				correct++
+			}
+		}
+		i++
+		if meta.Taken != actual {
+			queue, outcomes = queue[:0], outcomes[:0]
+		} else {
+			queue, outcomes = queue[1:], outcomes[1:]
+		}
+	}
+
+	acc := float64(correct) / float64(total) * 100
+	t.Logf("Pipelined (depth %d) accuracy: %.1f%%", depth, acc)
+	if acc < 90 {
+		t.Errorf("Pipelined accuracy %.1f%%, expected ≥90%%", acc)
+	}
+}
+
+func TestSCL_MixedContextsIsolated(t *testing.T) {
+	// WHAT: Same PC trained with different loops in two contexts
+	// WHY: Each context must learn its own trip count without interference
+
+	pred := NewTAGESCLPredictor()
+	pc := uint64(0x6400)
+	var correct [2]int
+
+	for i := 0; i < 3000; i++ {
+		for c, trip := range []int{4, 11} {
+			ctx := uint8(c * 5)
+			expected := i%(trip+1) != trip
+			taken, _ := pred.Predict(pc, ctx)
+			pred.Update(pc, ctx, expected)
+			if i >= 1000 && taken == expected {
+				correct[c]++
+			}
+		}
+	}
+
+	for c := range correct {
+		acc := float64(correct[c]) / 2000 * 100
+		if acc < 99 {
+			t.Errorf("Context %d accuracy %.1f%%, expected ≥99%%", c*5, acc)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 22. CONFIGURABLE GEOMETRY TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TAGEConfig lets experiments change table count, sizes, tag widths, history lengths,
+// counter width and aging interval without editing constants. The default config must
+// reproduce the constant-built predictor exactly; other geometries must stay in bounds.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runTAGEPattern trains pred on one branch with the given outcome pattern and returns
+// accuracy (%) over the second half of the run.
+func runTAGEPattern(pred *TAGEPredictor, pc uint64, n int, pattern func(int) bool) float64 {
+	correct, counted := 0, 0
+	for i := 0; i < n; i++ {
+		taken := pattern(i)
+		predicted, _ := pred.Predict(pc, 0)
+		if i >= n/2 {
+			counted++
+			if predicted == taken {
+				correct++
+			}
+		}
+		if predicted == taken {
+			pred.Update(pc, 0, taken)
+		} else {
+			pred.OnMispredict(pc, 0, taken)
+		}
+	}
+	return float64(correct) * 100 / float64(counted)
+}
+
+func TestConfig_DefaultMatchesConstants(t *testing.T) {
+	// WHAT: DefaultTAGEConfig() is the constant geometry
+	// WHY: NewTAGEPredictor() must keep its historical behavior
+
+	cfg := DefaultTAGEConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Default config invalid: %v", err)
+	}
+	if cfg.NumTables != NumTables || cfg.CounterWidth != CounterWidth || cfg.AgingInterval != AgingInterval {
+		t.Errorf("Default scalars mismatch: %+v", cfg)
+	}
+	for i := 0; i < NumTables; i++ {
+		if cfg.EntriesPerTable[i] != EntriesPerTable || cfg.HistoryLengths[i] != HistoryLengths[i] {
+			t.Errorf("Table %d: entries %d history %d", i, cfg.EntriesPerTable[i], cfg.HistoryLengths[i])
+		}
+		if i > 0 && cfg.TagWidths[i] != TagWidth {
+			t.Errorf("Table %d: tag width %d, expected %d", i, cfg.TagWidths[i], TagWidth)
+		}
+	}
+
+	pred := NewTAGEPredictor()
+	if pred.MaxCounter != MaxCounter || pred.neutralCounter() != NeutralCounter {
+		t.Errorf("Derived counters %d/%d, expected %d/%d",
+			pred.MaxCounter, pred.neutralCounter(), MaxCounter, NeutralCounter)
+	}
+	for i := range pred.Tables {
+		if len(pred.Tables[i].Entries) != EntriesPerTable || len(pred.Tables[i].ValidBits) != ValidBitmapWords {
+			t.Errorf("Table %d sized %d/%d", i, len(pred.Tables[i].Entries), len(pred.Tables[i].ValidBits))
+		}
+		if pred.Tables[i].IndexWidth != IndexWidth {
+			t.Errorf("Table %d index width %d", i, pred.Tables[i].IndexWidth)
+		}
+	}
+}
+
+func TestConfig_DefaultConfigIdenticalBehavior(t *testing.T) {
+	// WHAT: Predictor from NewTAGEPredictorWithConfig(default) tracks NewTAGEPredictor exactly
+	// WHY: Generalized hash/allocation paths must not perturb the default design
+
+	a := NewTAGEPredictor()
+	b, err := NewTAGEPredictorWithConfig(DefaultTAGEConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := uint64(0xC0FFEE)
+	for i := 0; i < 20000; i++ {
+		seed = seed*6364136223846793005 + 1442695040888963407
+		pc := 0x1000 + (seed>>40)%64*0x40
+		ctx := uint8(seed>>20) & 7
+		taken := (seed>>33)&3 != 0
+
+		pa, ca := a.Predict(pc, ctx)
+		pb, cb := b.Predict(pc, ctx)
+		if pa != pb || ca != cb {
+			t.Fatalf("Branch %d: predictions diverge (%v/%d vs %v/%d)", i, pa, ca, pb, cb)
+		}
+		if pa == taken {
+			a.Update(pc, ctx, taken)
+			b.Update(pc, ctx, taken)
+		} else {
+			a.OnMispredict(pc, ctx, taken)
+			b.OnMispredict(pc, ctx, taken)
+		}
+	}
+
+	for i := range a.Tables {
+		if !slices.Equal(a.Tables[i].Entries, b.Tables[i].Entries) ||
+			!slices.Equal(a.Tables[i].ValidBits, b.Tables[i].ValidBits) {
+			t.Errorf("Table %d state diverged", i)
+		}
+	}
+}
+
+func TestConfig_ValidateRejects(t *testing.T) {
+	// WHAT: Validate() rejects each class of bad geometry
+	// WHY: A bad config would index out of range or break longest-match ordering
+
+	cases := []struct {
+		name   string
+		mutate func(*TAGEConfig)
+	}{
+		{"one table", func(c *TAGEConfig) { c.NumTables = 1 }},
+		{"too many tables", func(c *TAGEConfig) { c.NumTables = MaxTables + 1 }},
+		{"entries length", func(c *TAGEConfig) { c.EntriesPerTable = c.EntriesPerTable[:3] }},
+		{"tag widths length", func(c *TAGEConfig) { c.TagWidths = append(c.TagWidths, 13) }},
+		{"history length count", func(c *TAGEConfig) { c.HistoryLengths = c.HistoryLengths[:7] }},
+		{"non power of two", func(c *TAGEConfig) { c.EntriesPerTable[3] = 1000 }},
+		{"smaller than LRU window", func(c *TAGEConfig) { c.EntriesPerTable[2] = LRUSearchWidth / 2 }},
+		{"zero tag width", func(c *TAGEConfig) { c.TagWidths[4] = 0 }},
+		{"wide tag", func(c *TAGEConfig) { c.TagWidths[4] = 17 }},
+		{"base history", func(c *TAGEConfig) { c.HistoryLengths[0] = 2 }},
+		{"non-increasing history", func(c *TAGEConfig) { c.HistoryLengths[5] = c.HistoryLengths[4] }},
+		{"history too long", func(c *TAGEConfig) { c.HistoryLengths[7] = HistoryWidth + 1 }},
+		{"counter too narrow", func(c *TAGEConfig) { c.CounterWidth = 1 }},
+		{"counter too wide", func(c *TAGEConfig) { c.CounterWidth = 8 }},
+		{"zero aging interval", func(c *TAGEConfig) { c.AgingInterval = 0 }},
+	}
+
+	for _, tc := range cases {
+		cfg := DefaultTAGEConfig()
+		tc.mutate(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted bad config", tc.name)
+		}
+		if pred, err := NewTAGEPredictorWithConfig(cfg); err == nil || pred != nil {
+			t.Errorf("%s: constructor should return (nil, error)", tc.name)
+		}
+	}
+}
+
+func TestConfig_CallerSlicesCopied(t *testing.T) {
+	// WHAT: Mutating the caller's config after construction has no effect
+	// WHY: Geometry is fixed at elaboration
+
+	cfg := DefaultTAGEConfig()
+	pred, err := NewTAGEPredictorWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.HistoryLengths[7] = 1
+	cfg.EntriesPerTable[1] = 1
+
+	if pred.Config.HistoryLengths[7] != HistoryLengths[7] || pred.Config.EntriesPerTable[1] != EntriesPerTable {
+		t.Error("Predictor config aliases caller's slices")
+	}
+}
+
+func TestConfig_SmallGeometry(t *testing.T) {
+	// WHAT: Small, mixed-size geometry with narrow tags and 2-bit counters stays in bounds
+	// WHY: Index, tag and counter widths must follow the config, not the constants
+
+	cfg := TAGEConfig{
+		NumTables:       4,
+		EntriesPerTable: []int{256, 64, 128, 32},
+		TagWidths:       []int{0, 5, 7, 9},
+		HistoryLengths:  []int{0, 3, 9, 20},
+		CounterWidth:    2,
+		AgingInterval:   16,
+	}
+	pred, err := NewTAGEPredictorWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pred.MaxCounter != 3 || pred.neutralCounter() != 2 {
+		t.Fatalf("2-bit counters: max %d neutral %d", pred.MaxCounter, pred.neutralCounter())
+	}
+	if pred.Tables[0].Entries[0].Counter != 2 {
+		t.Errorf("Base counter %d, expected neutral 2", pred.Tables[0].Entries[0].Counter)
+	}
+
+	seed := uint64(42)
+	for i := 0; i < 5000; i++ {
+		seed = seed*6364136223846793005 + 1442695040888963407
+		pc := seed >> 8
+		ctx := uint8(seed>>60) & 7
+		taken := seed>>63 == 1
+		if p, _ := pred.Predict(pc, ctx); p == taken {
+			pred.Update(pc, ctx, taken)
+		} else {
+			pred.OnMispredict(pc, ctx, taken)
+		}
+	}
+
+	stats := pred.Stats()
+	if len(stats.EntriesUsed) != 4 {
+		t.Fatalf("Stats sized %d, expected 4", len(stats.EntriesUsed))
+	}
+	for i, table := range pred.Tables {
+		if int(stats.EntriesUsed[i]) > len(table.Entries) {
+			t.Errorf("Table %d: %d entries used of %d", i, stats.EntriesUsed[i], len(table.Entries))
+		}
+		for _, e := range table.Entries {
+			if e.Counter > pred.MaxCounter {
+				t.Fatalf("Table %d: counter %d exceeds %d", i, e.Counter, pred.MaxCounter)
+			}
+			if i > 0 && int(e.Tag) >= 1<<cfg.TagWidths[i] {
+				t.Fatalf("Table %d: tag %#x wider than %d bits", i, e.Tag, cfg.TagWidths[i])
+			}
+		}
+	}
+	if stats.EntriesUsed[1] == 0 {
+		t.Errorf("Expected allocations in Table 1, got %v", stats.EntriesUsed)
+	}
+}
+
+func TestConfig_AllocationWindowPerWidth(t *testing.T) {
+	// WHAT: shouldAllocate accepts a non-empty band of weak counters at every legal width
+	// WHY: With 2-bit counters the [2, max-2] window was empty, so TAGE never grew past Table 1
+
+	cases := []struct {
+		width int
+		want  []bool // indexed by counter value
+	}{
+		{2, []bool{false, true, true, false}},
+		{3, []bool{false, false, true, true, true, true, false, false}},
+	}
+	for _, c := range cases {
+		maxCounter := uint8(1<<c.width - 1)
+		for counter, want := range c.want {
+			if got := shouldAllocate(uint8(counter), maxCounter); got != want {
+				t.Errorf("width %d counter %d: shouldAllocate = %v, expected %v", c.width, counter, got, want)
+			}
+		}
+	}
+	for width := 2; width <= 7; width++ {
+		maxCounter := uint8(1<<width - 1)
+		neutral := uint8(1 << (width - 1))
+		if !shouldAllocate(neutral, maxCounter) || !shouldAllocate(neutral-1, maxCounter) {
+			t.Errorf("width %d: weak counters %d/%d do not allocate", width, neutral-1, neutral)
+		}
+		if shouldAllocate(0, maxCounter) || shouldAllocate(maxCounter, maxCounter) {
+			t.Errorf("width %d: saturated counters allocate", width)
+		}
+	}
+}
+
+func TestConfig_NarrowCounterAllocatesLonger(t *testing.T) {
+	// WHAT: A weak 2-bit provider that mispredicts allocates into longer tables
+	// WHY: Validate accepts CounterWidth 2, so the allocation path must work there too
+
+	cfg := DefaultTAGEConfig()
+	cfg.CounterWidth = 2
+	pred, err := NewTAGEPredictorWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plant a weak-taken (3) provider in Table 1 at this branch's index
+	pc := uint64(0x4000)
+	meta := pred.Lookup(pc, 0)
+	idx := pred.tableIndex(pc, &meta.Checkpoint, 1)
+	pred.Tables[1].Entries[idx] = TAGEEntry{Tag: pred.tableTag(pc, &meta.Checkpoint, 1), Counter: 3, Taken: true}
+	pred.Tables[1].ValidBits[idx>>6] |= 1 << (idx & 63)
+
+	meta = pred.Lookup(pc, 0)
+	if meta.ProviderTable != 1 {
+		t.Fatalf("Provider table %d, expected 1", meta.ProviderTable)
+	}
+	pred.trainMispredict(&meta, false) // Provider drops to 2 (weak): must allocate to Table 2+
+	longer := uint32(0)
+	s := pred.Stats()
+	for i := 2; i < len(s.EntriesUsed); i++ {
+		longer += uint32(s.EntriesUsed[i])
+	}
+	if longer == 0 {
+		t.Errorf("No allocation beyond Table 1 with 2-bit counters: %v", s.EntriesUsed)
+	}
+}
+
+func TestConfig_AgingIntervalHonored(t *testing.T) {
+	// WHAT: Aging sweep runs every cfg.AgingInterval mispredictions
+	// WHY: Aging period is a tuning knob
+
+	cfg := DefaultTAGEConfig()
+	cfg.AgingInterval = 4
+	pred, err := NewTAGEPredictorWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pc := uint64(0x5000)
+	for i := 0; i < 3; i++ {
+		pred.OnMispredict(pc+uint64(i)*0x100000, 0, true)
+	}
+	if s := pred.Stats(); s.AverageAge[1] != 0 {
+		t.Fatalf("Aging ran early: Table 1 average age %.2f", s.AverageAge[1])
+	}
+
+	pred.OnMispredict(pc+3*0x100000, 0, true) // 4th mispredict triggers the sweep
+	if s := pred.Stats(); s.EntriesUsed[1] == 0 || s.AverageAge[1] != 1 {
+		t.Errorf("After 4 mispredicts: %d entries, average age %.2f, expected age 1",
+			s.EntriesUsed[1], s.AverageAge[1])
+	}
+}
+
+func TestConfig_GeometryAccuracy(t *testing.T) {
+	// WHAT: Larger and smaller geometries still learn simple patterns
+	// WHY: Sanity check that non-default configs are usable for experiments
+
+	large := TAGEConfig{
+		NumTables:       12,
+		EntriesPerTable: []int{4096, 2048, 2048, 2048, 2048, 2048, 2048, 2048, 1024, 1024, 1024, 1024},
+		TagWidths:       []int{0, 8, 9, 10, 11, 12, 12, 13, 13, 14, 15, 16},
+		HistoryLengths:  []int{0, 2, 4, 6, 9, 13, 18, 25, 32, 40, 50, 64},
+		CounterWidth:    4,
+		AgingInterval:   2048,
+	}
+	small := TAGEConfig{
+		NumTables:       4,
+		EntriesPerTable: []int{512, 256, 256, 256},
+		TagWidths:       []int{0, 9, 10, 11},
+		HistoryLengths:  []int{0, 5, 15, 44},
+		CounterWidth:    3,
+		AgingInterval:   512,
+	}
+
+	patterns := []struct {
+		name    string
+		pattern func(int) bool
+	}{
+		{"always taken", func(i int) bool { return true }},
+		{"loop37", func(i int) bool { return i%37 != 36 }},
+	}
+
+	for _, cfg := range []TAGEConfig{large, small} {
+		for _, p := range patterns {
+			pred, err := NewTAGEPredictorWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acc := runTAGEPattern(pred, 0x7000, 4000, p.pattern)
+			t.Logf("%d tables, %s: %.1f%%", cfg.NumTables, p.name, acc)
+			if acc < 95 {
+				t.Errorf("%d tables, %s: accuracy %.1f%%, expected ≥95%%", cfg.NumTables, p.name, acc)
+			}
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 23. LONG HISTORY / FOLDED REGISTER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// With FoldedHistory, outcomes live in a per-context circular buffer and each table keeps
+// incrementally updated folded registers. These tests check the fold invariant against a
+// direct computation, checkpoint/restore of the folded state, and that tables longer than
+// 64 bits actually see old outcomes.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// longHistoryConfig is an 8-table geometry reaching 640 bits of history.
+func longHistoryConfig() TAGEConfig {
+	return TAGEConfig{
+		NumTables:       8,
+		EntriesPerTable: []int{1024, 1024, 1024, 1024, 1024, 1024, 512, 512},
+		TagWidths:       []int{0, 9, 10, 11, 12, 13, 14, 15},
+		HistoryLengths:  []int{0, 5, 12, 27, 60, 130, 290, 640},
+		CounterWidth:    3,
+		AgingInterval:   AgingInterval,
+		FoldedHistory:   true,
+	}
+}
+
+// referenceFold computes XOR over age a < origLen of outcome[a] << (a % compLen),
+// where outcomes is oldest-first.
+func referenceFold(outcomes []bool, origLen int, compLen int) uint32 {
+	var v uint32
+	for a := 0; a < origLen && a < len(outcomes); a++ {
+		if outcomes[len(outcomes)-1-a] {
+			v ^= 1 << (a % compLen)
+		}
+	}
+	return v
+}
+
+func TestLongHistory_ConfigValidation(t *testing.T) {
+	// WHAT: Histories over 64 bits need FoldedHistory and must not exceed MaxHistoryLength
+
+	cfg := longHistoryConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("640-bit folded config rejected: %v", err)
+	}
+
+	cfg.FoldedHistory = false
+	if cfg.Validate() == nil {
+		t.Error("640-bit history without FoldedHistory should be rejected")
+	}
+
+	cfg = longHistoryConfig()
+	cfg.HistoryLengths[7] = MaxHistoryLength + 1
+	if cfg.Validate() == nil {
+		t.Error("History beyond MaxHistoryLength should be rejected")
+	}
+}
+
+func TestLongHistory_FoldMatchesDirectComputation(t *testing.T) {
+	// WHAT: Incremental folded registers equal the fold of the raw history window
+	// WHY: This invariant is what makes the 3-XOR update equivalent to a full hash
+
+	pred, err := NewTAGEPredictorWithConfig(longHistoryConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := uint8(3)
+
+	var outcomes []bool
+	seed := uint64(99)
+	for i := 0; i < 3000; i++ { // Wraps the buffer (2048 bits) at least once
+		seed = seed*6364136223846793005 + 1442695040888963407
+		taken := seed>>63 == 1
+		outcomes = append(outcomes, taken)
+		pred.Update(0x4000+(seed>>50), ctx, taken)
+
+		if i%97 != 0 && i != 2999 {
+			continue
+		}
+		for tn := 1; tn < len(pred.Tables); tn++ {
+			table := &pred.Tables[tn]
+			f := pred.Folded[ctx][tn]
+			w0, w1 := tagFoldWidths(table.TagWidth)
+			if want := referenceFold(outcomes, table.HistoryLen, table.IndexWidth); f.Index != want {
+				t.Fatalf("Branch %d table %d: index fold %#x, expected %#x", i, tn, f.Index, want)
+			}
+			if want := referenceFold(outcomes, table.HistoryLen, w0); uint32(f.Tag0) != want {
+				t.Fatalf("Branch %d table %d: tag0 fold %#x, expected %#x", i, tn, f.Tag0, want)
+			}
+			if want := referenceFold(outcomes, table.HistoryLen, w1); uint32(f.Tag1) != want {
+				t.Fatalf("Branch %d table %d: tag1 fold %#x, expected %#x", i, tn, f.Tag1, want)
+			}
+		}
+	}
+
+	// 64-bit register still tracks the newest outcomes
+	var want uint64
+	for _, o := range outcomes[len(outcomes)-64:] {
+		want = want<<1 | boolBit(o)
+	}
+	if pred.History[ctx] != want {
+		t.Errorf("64-bit History %#x, expected %#x", pred.History[ctx], want)
+	}
+}
+
+func TestLongHistory_DistantOutcomeChangesIndex(t *testing.T) {
+	// WHAT: An outcome 500 branches ago changes the 640-bit table's index/tag only
+	// WHY: This is the correlation reach the 64-bit register cannot provide
+
+	build := func(flipAt int) *TAGEPredictor {
+		pred, err := NewTAGEPredictorWithConfig(longHistoryConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 700; i++ {
+			pred.Update(0x1000+uint64(i%16)*0x40, 0, i%3 == 0 != (i == flipAt))
+		}
+		return pred
+	}
+
+	a := build(-1)
+	b := build(700 - 500) // Outcome at age ~500 differs
+
+	ma := a.Lookup(0x8000, 0)
+	mb := b.Lookup(0x8000, 0)
+	if ma.Checkpoint.History != mb.Checkpoint.History {
+		t.Fatal("64-bit histories should be identical")
+	}
+	for tn := 1; tn < len(a.Tables); tn++ {
+		ia := a.tableIndex(0x8000, &ma.Checkpoint, tn)
+		ib := b.tableIndex(0x8000, &mb.Checkpoint, tn)
+		ta := a.tableTag(0x8000, &ma.Checkpoint, tn)
+		tb := b.tableTag(0x8000, &mb.Checkpoint, tn)
+		sees := a.Tables[tn].HistoryLen > 500
+		if (ia != ib || ta != tb) != sees {
+			t.Errorf("Table %d (len %d): index %d/%d tag %#x/%#x, expected differ=%v",
+				tn, a.Tables[tn].HistoryLen, ia, ib, ta, tb, sees)
+		}
+	}
+}
+
+func TestLongHistory_RestoreRepairsFoldedState(t *testing.T) {
+	// WHAT: Restore rewinds buffer head and folded registers, then shifts the actual outcome
+	// WHY: Wrong-path branches must leave no trace in long history
+
+	spec, _ := NewTAGEPredictorWithConfig(longHistoryConfig())
+	ref, _ := NewTAGEPredictorWithConfig(longHistoryConfig())
+	ctx := uint8(1)
+
+	for i := 0; i < 200; i++ {
+		taken := i%5 != 0
+		spec.Update(0x2000+uint64(i%7)*0x40, ctx, taken)
+		ref.Update(0x2000+uint64(i%7)*0x40, ctx, taken)
+	}
+
+	m := spec.PredictSpeculative(0x3000, ctx)
+	for i := 0; i < 20; i++ { // Wrong-path branches
+		spec.PredictSpeculative(0x3100+uint64(i)*0x40, ctx)
+	}
+	spec.Restore(m.Checkpoint, !m.Predicted)
+	ref.Update(0x3000, ctx, !m.Predicted)
+
+	if spec.History[ctx] != ref.History[ctx] {
+		t.Errorf("History %#x, expected %#x", spec.History[ctx], ref.History[ctx])
+	}
+	if spec.LongHistory[ctx].Head != ref.LongHistory[ctx].Head {
+		t.Errorf("Head %d, expected %d", spec.LongHistory[ctx].Head, ref.LongHistory[ctx].Head)
+	}
+	if spec.Folded[ctx] != ref.Folded[ctx] {
+		t.Error("Folded registers differ from sequential reference after restore")
+	}
+	for age := 0; age < 640; age++ {
+		if spec.LongHistory[ctx].bit(age) != ref.LongHistory[ctx].bit(age) {
+			t.Fatalf("Buffer differs at age %d", age)
+		}
+	}
+}
+
+func TestLongHistory_ContextsIndependent(t *testing.T) {
+	// WHAT: Each context has its own buffer and folded registers
+
+	pred, _ := NewTAGEPredictorWithConfig(longHistoryConfig())
+	for i := 0; i < 100; i++ {
+		pred.Update(0x1000, 2, true)
+	}
+	if pred.LongHistory[5].Head != 0 || pred.Folded[5] != ([MaxTables]FoldedHistory{}) {
+		t.Error("Context 5 history changed by context 2 updates")
+	}
+	if pred.LongHistory[2].Head != 100 {
+		t.Errorf("Context 2 head %d, expected 100", pred.LongHistory[2].Head)
+	}
+
+	pred.Reset()
+	if pred.LongHistory[2].Head != 0 || pred.Folded[2] != ([MaxTables]FoldedHistory{}) {
+		t.Error("Reset should clear long history and folded registers")
+	}
+}
+
+func TestLongHistory_CheckpointSizedToTables(t *testing.T) {
+	// WHAT: A folded checkpoint holds one register set per configured table and owns its copy
+	// WHY: Every in-flight branch carries a checkpoint; MaxTables-wide copies waste metadata
+
+	pred, err := NewTAGEPredictorWithConfig(longHistoryConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		pred.Update(0x2000+uint64(i)*4, 1, i%3 == 0)
+	}
+	meta := pred.Lookup(0x3000, 1)
+	cp := meta.Checkpoint
+	if len(cp.Folded) != len(pred.Tables) {
+		t.Fatalf("Checkpoint holds %d folded sets, expected %d", len(cp.Folded), len(pred.Tables))
+	}
+	for tn := range cp.Folded {
+		if cp.Folded[tn] != pred.Folded[1][tn] {
+			t.Errorf("Table %d: checkpoint %+v, live %+v", tn, cp.Folded[tn], pred.Folded[1][tn])
+		}
+	}
+
+	want := append([]FoldedHistory(nil), cp.Folded...)
+	pred.Update(0x3000, 1, true) // Advances the live registers only
+	for tn := range cp.Folded {
+		if cp.Folded[tn] != want[tn] {
+			t.Fatalf("Table %d: checkpoint aliased the live folded registers", tn)
+		}
+	}
+}
+
+func TestLongHistory_DefaultCheckpointUnchanged(t *testing.T) {
+	// WHAT: Without FoldedHistory the checkpoint carries only Ctx and History
+	// WHY: The default predictor allocates no long-history state
+
+	pred := NewTAGEPredictor()
+	pred.Update(0x1000, 0, true)
+	meta := pred.Lookup(0x1000, 0)
+	if cp := meta.Checkpoint; cp.Ctx != 0 || cp.History != 1 || cp.Head != 0 || cp.Folded != nil {
+		t.Errorf("Checkpoint %+v carries long-history state", meta.Checkpoint)
+	}
+	if pred.LongHistory[0].Bits != nil {
+		t.Error("Default predictor should not allocate history buffers")
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// BENCHMARK TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Benchmarks measure performance of critical operations.
+// Used for optimization and regression testing.
+//
+// Run with: go test -bench=. -benchmem
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func BenchmarkPredict(b *testing.B) {
+	pred := NewTAGEPredictor()
+
+	// Pre-train
+	for i := 0; i < 100; i++ {
+		pred.Update(uint64(i)*4, 0, i%2 == 0)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pred.Predict(uint64(i)*4, uint8(i%8))
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	pred := NewTAGEPredictor()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pred.Update(uint64(i)*4, uint8(i%8), i%2 == 0)
+	}
+}
+
+func BenchmarkOnMispredict(b *testing.B) {
+	pred := NewTAGEPredictor()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pred.OnMispredict(uint64(i)*4, uint8(i%8), i%2 == 0)
+	}
+}
+
+func BenchmarkHashIndex(b *testing.B) {
+	pc := uint64(0x123456789ABCDEF0)
+	history := uint64(0xFEDCBA9876543210)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashIndex(pc, history, 32, 4)
+	}
+}
+
+func BenchmarkHashTag(b *testing.B) {
+	pc := uint64(0x123456789ABCDEF0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashTag(pc)
+	}
+}
+
+func BenchmarkAgeAllEntries(b *testing.B) {
+	pred := NewTAGEPredictor()
+
+	// Pre-fill tables
+	for i := 0; i < 1000; i++ {
+		pred.OnMispredict(uint64(i)*4, 0, i%2 == 0)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pred.AgeAllEntries()
+	}
+}
+
+func BenchmarkFullCycle(b *testing.B) {
+	pred := NewTAGEPredictor()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pc := uint64(i) * uint64(0x1234)
+		ctx := uint8(i % 8)
+
+		pred.Predict(pc, ctx)
+		if i%5 == 0 {
+			pred.OnMispredict(pc, ctx, i%2 == 0)
+		} else {
+			pred.Update(pc, ctx, i%2 == 0)
+		}
+	}
+}
+
+func BenchmarkReset(b *testing.B) {
+	// WHAT: Benchmark Reset() operation
+	// WHY: Reset should be fast (single cycle target)
+	// HARDWARE: Parallel clear of registers and bitmaps
+	//
+	// NOTE: We benchmark Reset() on a pre-filled predictor.
+	// After first Reset(), subsequent ones clear already-empty tables.
+	// This measures the worst-case (clearing full valid bitmaps).
+
+	pred := NewTAGEPredictor()
+
+	// Pre-fill once
+	for i := 0; i < 500; i++ {
+		pred.OnMispredict(uint64(i)*4, 0, true)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pred.Reset()
+	}
+}
+
+func BenchmarkStats(b *testing.B) {
+	// WHAT: Benchmark Stats() collection
+	// WHY: Stats is debug-only but shouldn't be too slow
+	// HARDWARE: Not synthesized, software only
+
+	pred := NewTAGEPredictor()
+
+	// Pre-fill
+	for i := 0; i < 500; i++ {
+		pred.OnMispredict(uint64(i)*4, 0, true)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = pred.Stats()
+	}
+}
+
+func BenchmarkFindLRUVictim(b *testing.B) {
+	// WHAT: Benchmark LRU victim selection
+	// WHY: LRU is in allocation critical path
+	// HARDWARE: 8-way parallel comparators
+
+	pred := NewTAGEPredictor()
+
+	// Fill table 1
+	for i := 0; i < EntriesPerTable; i++ {
+		pred.Tables[1].ValidBits[i>>6] |= 1 << (i & 63)
+		pred.Tables[1].Entries[i].Age = uint8(i % 8)
+		pred.Tables[1].Entries[i].Useful = i%3 == 0
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		findLRUVictim(&pred.Tables[1], uint32(i%EntriesPerTable))
 	}
 }
 
@@ -4254,3 +5619,25 @@ func BenchmarkHistoryShift(b *testing.B) {
 		b.Log("History saturated")
 	}
 }
+
+func BenchmarkTAGESCLFullCycle(b *testing.B) {
+	pred := NewTAGESCLPredictor()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pc := uint64(i&63) * 0x40
+		pred.Predict(pc, uint8(i%8))
+		pred.Update(pc, uint8(i%8), i%7 != 0)
+	}
+}
+
+func BenchmarkFoldedHistoryFullCycle(b *testing.B) {
+	pred, _ := NewTAGEPredictorWithConfig(longHistoryConfig())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pc := uint64(i&63) * 0x40
+		pred.Predict(pc, uint8(i%8))
+		pred.Update(pc, uint8(i%8), i%7 != 0)
+	}
+}